@@ -0,0 +1,179 @@
+package aitelemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+const (
+	otlpScopeName     = "github.com/Azure/azure-container-networking/aitelemetry"
+	otlpEventNameKey  = "event.name"
+	otlpResourceIDKey = "ResourceID"
+	otlpContextKey    = "Context"
+)
+
+// otlpHandle is a TelemetryHandle that exports logs, events and metrics to an OpenTelemetry collector over
+// OTLP/HTTP. Events are exported as log records with an event.name
+// attribute. The VM metadata that the appinsights handle adds to each item is left to the collector's resource
+// processors.
+type otlpHandle struct {
+	appName    string
+	appVersion string
+	exporter   *otlpExporter
+}
+
+// NewOTLPTelemetry creates a telemetry handle that exports to the OTLP/HTTP endpoint of an OpenTelemetry
+// collector, such as http://localhost:4318. The batch interval of the config sets how often the queued
+// telemetry is exported.
+func NewOTLPTelemetry(endpoint string, aiConfig AIConfig) (TelemetryHandle, error) {
+	debugMode = aiConfig.DebugMode
+
+	setAIConfigDefaults(&aiConfig)
+
+	hostname, _ := os.Hostname()
+	exporter, err := newOTLPExporter(endpoint, map[string]string{
+		"service.name":    aiConfig.AppName,
+		"service.version": aiConfig.AppVersion,
+		"host.name":       hostname,
+		"os.type":         runtime.GOOS,
+	}, &commonpb.InstrumentationScope{Name: otlpScopeName, Version: aiConfig.AppVersion},
+		time.Duration(aiConfig.BatchInterval)*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	return &otlpHandle{
+		appName:    aiConfig.AppName,
+		appVersion: aiConfig.AppVersion,
+		exporter:   exporter,
+	}, nil
+}
+
+// TrackLog queues the report as a log record.
+func (th *otlpHandle) TrackLog(report Report) {
+	attrs := map[string]string{}
+	for key, value := range report.CustomDimensions {
+		attrs[key] = value
+	}
+	attrs[hostNameKey], _ = os.Hostname()
+	attrs[appNameStr] = th.appName
+	attrs[versionStr] = th.version(report.AppVersion)
+	if report.Context != "" {
+		attrs[otlpContextKey] = report.Context
+	}
+
+	th.exporter.export(&logspb.LogRecord{
+		TimeUnixNano:   uint64(time.Now().UnixNano()),
+		SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+		SeverityText:   "Warning",
+		Body:           otlpString(report.Message),
+		Attributes:     otlpAttributes(attrs),
+	})
+}
+
+// TrackEvent queues the event as a log record with an event.name attribute.
+func (th *otlpHandle) TrackEvent(event Event) {
+	attrs := map[string]string{}
+	for key, value := range event.Properties {
+		attrs[key] = value
+	}
+	attrs[otlpEventNameKey] = event.EventName
+	attrs[osStr] = runtime.GOOS
+	attrs[appNameStr] = th.appName
+	attrs[versionStr] = th.appVersion
+	if event.ResourceID != "" {
+		attrs[otlpResourceIDKey] = event.ResourceID
+	}
+
+	th.exporter.export(&logspb.LogRecord{
+		TimeUnixNano:   uint64(time.Now().UnixNano()),
+		SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+		SeverityText:   "Information",
+		Body:           otlpString(event.EventName),
+		Attributes:     otlpAttributes(attrs),
+	})
+}
+
+// TrackMetric queues the metric as a gauge.
+func (th *otlpHandle) TrackMetric(metric Metric) {
+	attrs := map[string]string{}
+	for key, value := range metric.CustomDimensions {
+		attrs[key] = value
+	}
+	attrs[osStr] = runtime.GOOS
+	attrs[versionStr] = th.version(metric.AppVersion)
+
+	m := &metricspb.Metric{
+		Name: metric.Name,
+		Data: &metricspb.Metric_Gauge{
+			Gauge: &metricspb.Gauge{
+				DataPoints: []*metricspb.NumberDataPoint{
+					{
+						TimeUnixNano: uint64(time.Now().UnixNano()),
+						Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: metric.Value},
+						Attributes:   otlpAttributes(attrs),
+					},
+				},
+			},
+		},
+	}
+
+	th.exporter.exportMetric(m)
+}
+
+// Close exports the queued telemetry, waiting up to timeout seconds, and stops the export loop.
+func (th *otlpHandle) Close(timeout int) {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	if err := th.exporter.close(ctx); err != nil {
+		debugLog("[otlp] failed to export telemetry on close: %v", err)
+	}
+}
+
+// Flush exports the queued telemetry.
+func (th *otlpHandle) Flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(defaultTimeout)*time.Second)
+	defer cancel()
+	if err := th.exporter.flush(ctx); err != nil {
+		debugLog("[otlp] %v", err)
+	}
+}
+
+// version returns the app version, which is empty when CNS runs as the telemetry service for CNI.
+func (th *otlpHandle) version(reported string) string {
+	if th.appVersion == "" {
+		return reported
+	}
+	return th.appVersion
+}
+
+func otlpString(s string) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}}
+}
+
+// otlpAttributes converts the properties to attributes, sorted by key.
+func otlpAttributes(props map[string]string) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, &commonpb.KeyValue{Key: key, Value: otlpString(props[key])})
+	}
+	return attrs
+}
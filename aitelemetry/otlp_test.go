@@ -0,0 +1,119 @@
+package aitelemetry
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// otlpReceiver is an in-process OTLP/HTTP receiver that records what it is sent.
+type otlpReceiver struct {
+	mu      sync.Mutex
+	logs    []*logspb.LogRecord
+	metrics []*metricspb.Metric
+	service string
+}
+
+func (r *otlpReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil || req.Header.Get("Content-Type") != otlpContentType {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch req.URL.Path {
+	case otlpLogsPath:
+		var data logspb.LogsData
+		if err := proto.Unmarshal(body, &data); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, rl := range data.ResourceLogs {
+			r.service = attribute(rl.Resource.Attributes, "service.name")
+			for _, sl := range rl.ScopeLogs {
+				r.logs = append(r.logs, sl.LogRecords...)
+			}
+		}
+	case otlpMetricsPath:
+		var data metricspb.MetricsData
+		if err := proto.Unmarshal(body, &data); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, rm := range data.ResourceMetrics {
+			for _, sm := range rm.ScopeMetrics {
+				r.metrics = append(r.metrics, sm.Metrics...)
+			}
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func attribute(attrs []*commonpb.KeyValue, key string) string {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value.GetStringValue()
+		}
+	}
+	return ""
+}
+
+func TestOTLPTelemetry(t *testing.T) {
+	receiver := &otlpReceiver{}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	th, err := NewOTLPTelemetry(srv.URL, AIConfig{AppName: "testapp", AppVersion: "v1.0.0", BatchInterval: 3600})
+	require.NoError(t, err)
+
+	th.TrackLog(Report{
+		Message:          "test log",
+		Context:          "pod1",
+		CustomDimensions: map[string]string{"Operation": "ADD"},
+	})
+	th.TrackEvent(Event{EventName: "testevent", ResourceID: "rid", Properties: map[string]string{"key": "value"}})
+	th.TrackMetric(Metric{Name: "testmetric", Value: 1.5, CustomDimensions: map[string]string{"dim": "x"}})
+
+	// nothing is exported before the batch interval
+	receiver.mu.Lock()
+	require.Empty(t, receiver.logs)
+	receiver.mu.Unlock()
+
+	th.Close(5)
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	require.Equal(t, "testapp", receiver.service)
+	require.Len(t, receiver.logs, 2)
+	require.Equal(t, "test log", receiver.logs[0].Body.GetStringValue())
+	require.Equal(t, "pod1", attribute(receiver.logs[0].Attributes, otlpContextKey))
+	require.Equal(t, "ADD", attribute(receiver.logs[0].Attributes, "Operation"))
+	require.Equal(t, "v1.0.0", attribute(receiver.logs[0].Attributes, versionStr))
+	require.Equal(t, "testevent", attribute(receiver.logs[1].Attributes, otlpEventNameKey))
+	require.Equal(t, "rid", attribute(receiver.logs[1].Attributes, otlpResourceIDKey))
+	require.Equal(t, "value", attribute(receiver.logs[1].Attributes, "key"))
+
+	require.Len(t, receiver.metrics, 1)
+	require.Equal(t, "testmetric", receiver.metrics[0].Name)
+	dp := receiver.metrics[0].GetGauge().DataPoints[0]
+	require.InDelta(t, 1.5, dp.GetAsDouble(), 0)
+	require.Equal(t, "x", attribute(dp.Attributes, "dim"))
+}
+
+func TestOTLPTelemetryInvalidEndpoint(t *testing.T) {
+	_, err := NewOTLPTelemetry("localhost", AIConfig{})
+	require.Error(t, err)
+}
@@ -0,0 +1,227 @@
+package aitelemetry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	otlpLogsPath    = "/v1/logs"
+	otlpMetricsPath = "/v1/metrics"
+	otlpContentType = "application/x-protobuf"
+
+	otlpDefaultBatchInterval = 15 * time.Second
+	otlpMaxBatchSize         = 512
+	otlpMaxQueueSize         = 8192
+	otlpRequestTimeout       = 10 * time.Second
+)
+
+var errInvalidOTLPEndpoint = errors.New("invalid OTLP endpoint")
+
+// otlpExporter batches log records and metrics and exports them to an OpenTelemetry collector over OTLP/HTTP.
+// Exports run in the background, so queueing never waits on the collector. Telemetry that fails to export, or
+// that does not fit in the queue, is dropped.
+type otlpExporter struct {
+	batchInterval time.Duration
+	logsURL       string
+	metricsURL    string
+	resource      *resourcepb.Resource
+	scope         *commonpb.InstrumentationScope
+	client        *http.Client
+
+	mu      sync.Mutex
+	records []*logspb.LogRecord
+	metrics []*metricspb.Metric
+
+	// exportMu serializes exports so that telemetry is sent in order.
+	exportMu sync.Mutex
+
+	full    chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// newOTLPExporter creates an exporter for the collector endpoint, such as http://localhost:4318, and starts its
+// export loop. close must be called to stop it.
+func newOTLPExporter(endpoint string, resource map[string]string, scope *commonpb.InstrumentationScope,
+	batchInterval time.Duration,
+) (*otlpExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%w %q", errInvalidOTLPEndpoint, endpoint)
+	}
+	if batchInterval <= 0 {
+		batchInterval = otlpDefaultBatchInterval
+	}
+
+	base := strings.TrimSuffix(u.String(), "/")
+	e := &otlpExporter{
+		batchInterval: batchInterval,
+		logsURL:       base + otlpLogsPath,
+		metricsURL:    base + otlpMetricsPath,
+		resource:      &resourcepb.Resource{Attributes: otlpAttributes(resource)},
+		scope:         scope,
+		client:        &http.Client{Timeout: otlpRequestTimeout},
+		full:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	go e.run()
+	return e, nil
+}
+
+// export queues a log record. A full batch is exported by the export loop.
+func (e *otlpExporter) export(record *logspb.LogRecord) {
+	e.mu.Lock()
+	if len(e.records) < otlpMaxQueueSize {
+		e.records = append(e.records, record)
+	}
+	full := len(e.records) >= otlpMaxBatchSize
+	e.mu.Unlock()
+
+	if full {
+		e.signalFull()
+	}
+}
+
+// exportMetric queues a metric. A full batch is exported by the export loop.
+func (e *otlpExporter) exportMetric(metric *metricspb.Metric) {
+	e.mu.Lock()
+	if len(e.metrics) < otlpMaxQueueSize {
+		e.metrics = append(e.metrics, metric)
+	}
+	full := len(e.metrics) >= otlpMaxBatchSize
+	e.mu.Unlock()
+
+	if full {
+		e.signalFull()
+	}
+}
+
+// signalFull wakes the export loop without waiting for it.
+func (e *otlpExporter) signalFull() {
+	select {
+	case e.full <- struct{}{}:
+	default:
+	}
+}
+
+// flush exports the queued log records and metrics.
+func (e *otlpExporter) flush(ctx context.Context) error {
+	e.exportMu.Lock()
+	defer e.exportMu.Unlock()
+
+	e.mu.Lock()
+	records, metrics := e.records, e.metrics
+	e.records, e.metrics = nil, nil
+	e.mu.Unlock()
+
+	var errs []string
+	if len(records) > 0 {
+		// LogsData is wire compatible with the ExportLogsServiceRequest of the collector
+		data := &logspb.LogsData{
+			ResourceLogs: []*logspb.ResourceLogs{
+				{
+					Resource:  e.resource,
+					ScopeLogs: []*logspb.ScopeLogs{{Scope: e.scope, LogRecords: records}},
+				},
+			},
+		}
+		if err := e.post(ctx, e.logsURL, data); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to export %d log records: %v", len(records), err))
+		}
+	}
+
+	if len(metrics) > 0 {
+		// MetricsData is wire compatible with the ExportMetricsServiceRequest of the collector
+		data := &metricspb.MetricsData{
+			ResourceMetrics: []*metricspb.ResourceMetrics{
+				{
+					Resource:     e.resource,
+					ScopeMetrics: []*metricspb.ScopeMetrics{{Scope: e.scope, Metrics: metrics}},
+				},
+			},
+		}
+		if err := e.post(ctx, e.metricsURL, data); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to export %d metrics: %v", len(metrics), err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (e *otlpExporter) post(ctx context.Context, endpoint string, msg proto.Message) error {
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", otlpContentType)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// close stops the export loop and exports the queued telemetry, waiting until the context is done.
+func (e *otlpExporter) close(ctx context.Context) error {
+	e.once.Do(func() {
+		close(e.stop)
+	})
+
+	select {
+	case <-e.stopped:
+	case <-ctx.Done():
+		return fmt.Errorf("exporter did not stop: %w", ctx.Err())
+	}
+	return e.flush(ctx)
+}
+
+func (e *otlpExporter) run() {
+	defer close(e.stopped)
+
+	ticker := time.NewTicker(e.batchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.full:
+		case <-e.stop:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), otlpRequestTimeout)
+		_ = e.flush(ctx)
+		cancel()
+	}
+}
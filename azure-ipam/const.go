@@ -8,6 +8,8 @@ const (
 	pluginName    = "azure-ipam"
 	cnsBaseURL    = "" // fallback to default http://localhost:10090
 	cnsReqTimeout = 15 * time.Second
+	// socketDialTimeout bounds the check of whether CNS accepts connections on a Unix socket.
+	socketDialTimeout = time.Second
 )
 
 // plugin specific error codes
@@ -2,9 +2,11 @@ package main
 
 import (
 	"log"
+	"net"
 	"os"
 
 	"github.com/Azure/azure-container-networking/azure-ipam/logger"
+	"github.com/Azure/azure-container-networking/cns"
 	cnsclient "github.com/Azure/azure-container-networking/cns/client"
 	"github.com/containernetworking/cni/pkg/skel"
 	"github.com/containernetworking/cni/pkg/version"
@@ -36,10 +38,11 @@ func executePlugin() error {
 	defer cleanup()
 
 	// Create CNS client
-	client, err := cnsclient.New(cnsBaseURL, cnsReqTimeout)
+	client, closeClient, err := newCNSClient()
 	if err != nil {
 		return errors.Wrapf(err, "failed to initialize CNS client")
 	}
+	defer closeClient()
 
 	// Create IPAM plugin
 	plugin, err := NewPlugin(pluginLogger, client, os.Stdout)
@@ -57,3 +60,40 @@ func executePlugin() error {
 
 	return nil
 }
+
+// listening reports whether CNS accepts connections on the Unix socket. A socket left behind by a CNS that
+// stopped, or that no longer serves it, refuses connections, so the socket file existing is not enough.
+func listening(socket string) bool {
+	conn, err := net.DialTimeout("unix", socket, socketDialTimeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// cnsURL returns the CNS Unix socket if CNS serves one, so that CNS can check the credentials of the plugin, and
+// the CNS TCP endpoint otherwise.
+func cnsURL() string {
+	if listening(cns.DefaultUnixSocketPath) {
+		return cns.DefaultUnixSocketPath
+	}
+	return cnsBaseURL
+}
+
+// newCNSClient returns a gRPC CNS client if CNS serves its gRPC API, and an HTTP CNS client otherwise, with the
+// func that closes it.
+func newCNSClient() (cnsClient, func(), error) {
+	if listening(cns.DefaultGRPCSocketPath) {
+		client, err := cnsclient.NewGRPC(cns.DefaultGRPCSocketPath, cnsReqTimeout)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to create gRPC CNS client")
+		}
+		return client, func() { _ = client.Close() }, nil
+	}
+	client, err := cnsclient.New(cnsURL(), cnsReqTimeout)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create CNS client")
+	}
+	return client, func() {}, nil
+}
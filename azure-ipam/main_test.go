@@ -0,0 +1,26 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListening(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "cns.sock")
+	require.False(t, listening(socket), "no socket")
+
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	require.True(t, listening(socket), "served socket")
+
+	// a socket left behind by a stopped server refuses connections
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, l.Close())
+	_, err = os.Stat(socket)
+	require.NoError(t, err)
+	require.False(t, listening(socket), "stale socket")
+}
@@ -36,3 +36,13 @@ func (a *AzureCNIState) PrintResult() error {
 
 	return nil
 }
+
+// TransparentVlanReconcileReport lists the orphaned transparent vlan resources found by the CNI, which were removed
+// unless it was a dry run.
+type TransparentVlanReconcileReport struct {
+	Namespaces []string
+	VlanLinks  []string
+	Veths      []string
+	Rules      []string
+	Errors     []string
+}
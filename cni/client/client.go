@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -47,6 +48,31 @@ func (c *client) GetEndpointState() (*api.AzureCNIState, error) {
 	return state, nil
 }
 
+// ReconcileTransparentVlan runs the CNI to remove the orphaned transparent vlan resources of the node, or only to
+// report them on a dry run. The CNI reaches CNS as its network configuration says, as on an ADD, or at the default
+// URL of CNS if the network configuration is empty.
+func (c *client) ReconcileTransparentVlan(netconf []byte, dryRun bool) (*api.TransparentVlanReconcileReport, error) {
+	cmd := c.exec.Command(platform.CNIBinaryPath)
+	cmd.SetDir(CNIExecDir)
+	cmd.SetStdin(bytes.NewReader(netconf))
+	envs := append(os.Environ(),
+		fmt.Sprintf("%s=%s", cni.Cmd, cni.CmdReconcileTransparentVlan),
+		fmt.Sprintf("%s=%t", cni.ReconcileDryRun, dryRun))
+	cmd.SetEnv(envs)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Azure CNI bin with err: [%w], output: [%s]", err, string(output))
+	}
+
+	report := &api.TransparentVlanReconcileReport{}
+	if err := json.Unmarshal(output, report); err != nil {
+		return nil, fmt.Errorf("failed to decode response from Azure CNI when reconciling transparent vlan: [%w], response from CNI: [%s]", err, string(output))
+	}
+
+	return report, nil
+}
+
 func (c *client) GetVersion() (*semver.Version, error) {
 	cmd := c.exec.Command(platform.CNIBinaryPath, "-v")
 	cmd.SetDir(CNIExecDir)
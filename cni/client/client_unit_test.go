@@ -49,3 +49,20 @@ func TestGetVersion(t *testing.T) {
 
 	require.Equal(t, expectedVersion, version)
 }
+
+func TestReconcileTransparentVlan(t *testing.T) {
+	calls := []testutils.TestCmd{
+		{Cmd: []string{"/opt/cni/bin/azure-vnet"}, Stdout: `{"Namespaces":["az_ns_3"],"VlanLinks":["eth0_5"],"Veths":null,"Rules":null,"Errors":["failed to delete veth azv9999 in namespace az_ns_1"]}`},
+	}
+
+	fakeexec := testutils.GetFakeExecWithScripts(calls)
+
+	c := New(fakeexec)
+	report, err := c.ReconcileTransparentVlan([]byte(`{"name":"azure","type":"azure-vnet","cnsurl":"/var/run/azure-cns/cns.sock"}`), true)
+	require.NoError(t, err)
+	require.Equal(t, &api.TransparentVlanReconcileReport{
+		Namespaces: []string{"az_ns_3"},
+		VlanLinks:  []string{"eth0_5"},
+		Errors:     []string{"failed to delete veth azv9999 in namespace az_ns_1"},
+	}, report)
+}
@@ -24,6 +24,11 @@ const (
 	// nonstandard CNI spec command, used to dump CNI state to stdout
 	CmdGetEndpointsState = "GET_ENDPOINT_STATE"
 
+	// nonstandard CNI spec command, used to remove orphaned transparent vlan resources and report them to stdout
+	CmdReconcileTransparentVlan = "RECONCILE_TRANSPARENT_VLAN"
+	// ReconcileDryRun is set to true to only report the orphans found by CmdReconcileTransparentVlan.
+	ReconcileDryRun = "CNI_RECONCILE_DRY_RUN"
+
 	// CNI errors.
 	ErrRuntime = 100
 
@@ -0,0 +1,344 @@
+// Package conflist renders the Azure CNI conflist of every supported scenario from typed options, so that the
+// conflists packaged with the CNI, generated by CNS and written at install time by dropgz and acncli are the same.
+package conflist
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"runtime"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cni/util"
+	cniTypes "github.com/containernetworking/cni/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// Scenario is a supported deployment of the CNI.
+type Scenario string
+
+const (
+	// Vnet assigns pod IPs from the VNET with the azure-vnet-ipam plugin.
+	Vnet Scenario = "vnet"
+	// Swift assigns pod IPs from the VNET with CNS.
+	Swift Scenario = "swift"
+	// Overlay assigns pod IPs from the overlay with CNS, for both the ipv4 and dualstack overlay.
+	Overlay Scenario = "overlay"
+	// V4Overlay assigns ipv4 pod IPs from the overlay with CNS.
+	V4Overlay Scenario = "v4overlay"
+	// DualStackOverlay assigns ipv4 and ipv6 pod IPs from the overlay with CNS.
+	DualStackOverlay Scenario = "dualstack-overlay"
+	// Multitenancy attaches pods to the network containers of their tenant in bridge mode.
+	Multitenancy Scenario = "multitenancy"
+	// MultitenancyTransparentVlan attaches pods to the network containers of their tenant in transparent-vlan mode.
+	MultitenancyTransparentVlan Scenario = "multitenancy-transparent-vlan"
+	// Baremetal is the Windows baremetal scenario.
+	Baremetal Scenario = "baremetal"
+	// Cilium chains the Cilium CNI with azure-ipam.
+	Cilium Scenario = "cilium"
+)
+
+const (
+	linux   = "linux"
+	windows = "windows"
+
+	cniVersion    = "0.3.0"
+	networkName   = "azure"
+	azureVnetType = "azure-vnet"
+	portmapType   = "portmap"
+
+	modeBridge          = "bridge"
+	modeTransparent     = "transparent"
+	modeTransparentVlan = "transparent-vlan"
+	bridgeName          = "azure0"
+
+	azureVnetIPAM = "azure-vnet-ipam"
+	azureCNS      = "azure-cns"
+
+	nodeLocalDNSIP = "169.254.20.10"
+	hnsTimeout     = 120
+
+	ciliumCNIVersion = "0.3.1"
+	ciliumName       = "cilium"
+	ciliumType       = "cilium-cni"
+	ciliumLogFile    = "/var/log/cilium-cni.log"
+	ciliumIPAM       = "azure-ipam"
+)
+
+var (
+	ErrUnsupportedOS       = errors.New("unsupported os")
+	ErrUnsupportedScenario = errors.New("unsupported scenario")
+	ErrInvalidConflist     = errors.New("invalid conflist")
+)
+
+// scenarios lists the scenarios supported on each OS.
+var scenarios = map[string][]Scenario{
+	linux:   {Vnet, Swift, Overlay, V4Overlay, DualStackOverlay, Multitenancy, MultitenancyTransparentVlan, Cilium},
+	windows: {Vnet, Swift, Overlay, V4Overlay, DualStackOverlay, Multitenancy, MultitenancyTransparentVlan, Baremetal},
+}
+
+// Scenarios returns the scenarios supported on the OS.
+func Scenarios(goos string) []Scenario {
+	return scenarios[goos]
+}
+
+// Options of the rendered conflist.
+type Options struct {
+	Scenario Scenario
+	// OS the conflist is rendered for, linux or windows. Defaults to the OS of the running binary.
+	OS string
+	// Name of the network. Defaults to azure, or cilium in the Cilium scenario.
+	Name string
+	// Mode overrides the datapath mode of the scenario, such as bridge or transparent.
+	Mode string
+	// MTU of the pod interfaces. The plugin picks the MTU when it is zero.
+	MTU int
+	// CNSURL is the URL or Unix socket path of CNS in the scenarios that call CNS. The plugin uses its default
+	// when it is empty.
+	CNSURL string
+	// EnableExactMatchForPodName overrides the default of the multitenancy scenarios when set.
+	EnableExactMatchForPodName *bool
+}
+
+// netConfList represents the containernetworking/cni/pkg/types.NetConfList
+type netConfList struct {
+	CNIVersion string `json:"cniVersion,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Plugins    []any  `json:"plugins,omitempty"`
+}
+
+// azureVnet is the config of the azure-vnet plugin. The capabilities are read by the container runtime, not by
+// the plugin, so they are not part of cni.NetworkConfig.
+type azureVnet struct {
+	cni.NetworkConfig
+	Capabilities map[string]bool `json:"capabilities,omitempty"`
+}
+
+// portmap is the config of the upstream portmap plugin.
+type portmap struct {
+	Type         string          `json:"type"`
+	Capabilities map[string]bool `json:"capabilities"`
+	SNAT         bool            `json:"snat"`
+}
+
+// ciliumNetConf is the Cilium specific containernetworking/cni/pkg/types.NetConf
+type ciliumNetConf struct {
+	CNIVersion   string          `json:"cniVersion,omitempty"`
+	Name         string          `json:"name,omitempty"`
+	Type         string          `json:"type,omitempty"`
+	Capabilities map[string]bool `json:"capabilities,omitempty"`
+	IPAM         ciliumIPAMConf  `json:"ipam,omitempty"`
+	EnableDebug  bool            `json:"enable-debug"`
+	LogFile      string          `json:"log-file"`
+	MTU          int             `json:"mtu,omitempty"`
+
+	RawPrevResult map[string]interface{} `json:"prevResult,omitempty"`
+}
+
+type ciliumIPAMConf struct {
+	Type string `json:"type,omitempty"`
+}
+
+// windowsEndpointPolicies are the HNS endpoint policies of the Windows scenarios.
+var windowsEndpointPolicies = []cni.KVPair{
+	{
+		Name:  "EndpointPolicy",
+		Value: json.RawMessage(`{"Type":"OutBoundNAT","ExceptionList":["10.240.0.0/16","10.0.0.0/8"]}`),
+	},
+	{
+		Name:  "EndpointPolicy",
+		Value: json.RawMessage(`{"Type":"ROUTE","DestinationPrefix":"10.0.0.0/8","NeedEncap":true}`),
+	},
+}
+
+// Render writes the validated conflist of the scenario to the writer.
+func Render(w io.Writer, opts *Options) error {
+	b, err := Marshal(opts)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(b); err != nil {
+		return errors.Wrap(err, "error writing conflist")
+	}
+	return nil
+}
+
+// Marshal returns the conflist of the scenario, indented with tabs. The conflist is validated before it is
+// returned.
+func Marshal(opts *Options) ([]byte, error) {
+	conflist, err := build(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(conflist); err != nil {
+		return nil, errors.Wrap(err, "error encoding conflist to json")
+	}
+
+	if err := Validate(buf.Bytes()); err != nil {
+		return nil, errors.Wrapf(err, "rendered an invalid conflist for scenario %s", opts.Scenario)
+	}
+	return buf.Bytes(), nil
+}
+
+func build(opts *Options) (*netConfList, error) {
+	goos := opts.OS
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if _, ok := scenarios[goos]; !ok {
+		return nil, errors.Wrapf(ErrUnsupportedOS, "%q", goos)
+	}
+	if !supported(goos, opts.Scenario) {
+		return nil, errors.Wrapf(ErrUnsupportedScenario, "%q on %s", opts.Scenario, goos)
+	}
+
+	if opts.Scenario == Cilium {
+		return cilium(opts), nil
+	}
+
+	conf := base(goos)
+	switch opts.Scenario {
+	case Vnet:
+		conf.IPAM.Type = azureVnetIPAM
+	case Swift:
+		conf.IPAM.Type = azureCNS
+		conf.ExecutionMode = string(util.V4Swift)
+		if goos == windows {
+			conf.WindowsSettings.HnsTimeoutDurationInSeconds = hnsTimeout
+		}
+	case Overlay:
+		conf.IPAM = cni.IPAM{Type: azureCNS, Mode: string(util.Overlay)}
+	case V4Overlay:
+		conf.IPAM = cni.IPAM{Type: azureCNS, Mode: string(util.V4Overlay)}
+		conf.ExecutionMode = string(util.V4Swift)
+	case DualStackOverlay:
+		conf.IPAM = cni.IPAM{Type: azureCNS, Mode: string(util.DualStackOverlay)}
+	case Multitenancy, MultitenancyTransparentVlan:
+		multitenancy(goos, conf)
+		if opts.Scenario == MultitenancyTransparentVlan {
+			conf.Mode = modeTransparentVlan
+		}
+	case Baremetal:
+		conf.Capabilities = map[string]bool{"portMappings": true}
+		conf.EnableSnatOnHost = true
+		conf.EnableExactMatchForPodName = true
+		conf.ExecutionMode = string(util.Baremetal)
+		conf.IPAM.Type = azureVnetIPAM
+	}
+
+	// no bridge in transparent mode
+	switch opts.Mode {
+	case "":
+	case modeTransparent:
+		conf.Mode = opts.Mode
+		conf.Bridge = ""
+	case modeBridge:
+		conf.Mode = opts.Mode
+		conf.Bridge = bridgeName
+	default:
+		conf.Mode = opts.Mode
+	}
+
+	conf.MTU = opts.MTU
+	if conf.MultiTenancy || conf.IPAM.Type == azureCNS {
+		conf.CNSUrl = opts.CNSURL
+	}
+	if conf.MultiTenancy {
+		if opts.EnableExactMatchForPodName != nil {
+			conf.EnableExactMatchForPodName = *opts.EnableExactMatchForPodName
+		}
+	}
+
+	conflist := &netConfList{
+		CNIVersion: cniVersion,
+		Name:       name(opts, networkName),
+		Plugins:    []any{conf},
+	}
+	if goos == linux {
+		conflist.Plugins = append(conflist.Plugins, portmap{
+			Type:         portmapType,
+			Capabilities: map[string]bool{"portMappings": true},
+			SNAT:         true,
+		})
+	}
+	return conflist, nil
+}
+
+func supported(goos string, scenario Scenario) bool {
+	for _, s := range scenarios[goos] {
+		if s == scenario {
+			return true
+		}
+	}
+	return false
+}
+
+func name(opts *Options, defaultName string) string {
+	if opts.Name != "" {
+		return opts.Name
+	}
+	return defaultName
+}
+
+// base returns the azure-vnet config shared by the scenarios of the OS.
+func base(goos string) *azureVnet {
+	if goos == windows {
+		return &azureVnet{
+			NetworkConfig: cni.NetworkConfig{
+				Type:   azureVnetType,
+				Mode:   modeBridge,
+				Bridge: bridgeName,
+				DNS: cniTypes.DNS{
+					Nameservers: []string{"10.0.0.10", "168.63.129.16"},
+					Search:      []string{"svc.cluster.local"},
+				},
+				AdditionalArgs: windowsEndpointPolicies,
+			},
+			Capabilities: map[string]bool{"portMappings": true, "dns": true},
+		}
+	}
+	return &azureVnet{
+		NetworkConfig: cni.NetworkConfig{
+			Type:              azureVnetType,
+			Mode:              modeTransparent,
+			IPsToRouteViaHost: []string{nodeLocalDNSIP},
+		},
+	}
+}
+
+func multitenancy(goos string, conf *azureVnet) {
+	conf.Mode = modeBridge
+	conf.Bridge = bridgeName
+	conf.MultiTenancy = true
+	conf.EnableSnatOnHost = true
+	conf.IPAM.Type = azureCNS
+	if goos == windows {
+		conf.Capabilities = map[string]bool{"portMappings": true}
+		conf.EnableExactMatchForPodName = true
+		conf.WindowsSettings.HnsTimeoutDurationInSeconds = hnsTimeout
+		return
+	}
+	conf.IPsToRouteViaHost = nil
+}
+
+func cilium(opts *Options) *netConfList {
+	return &netConfList{
+		CNIVersion: ciliumCNIVersion,
+		Name:       name(opts, ciliumName),
+		Plugins: []any{
+			ciliumNetConf{
+				Type:        ciliumType,
+				LogFile:     ciliumLogFile,
+				EnableDebug: true,
+				MTU:         opts.MTU,
+				IPAM: ciliumIPAMConf{
+					Type: ciliumIPAM,
+				},
+			},
+		},
+	}
+}
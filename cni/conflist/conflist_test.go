@@ -0,0 +1,239 @@
+package conflist
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the conflist templates of dropgz from the rendered conflists")
+
+// typedConfList is a conflist with the plugin configs decoded by type, so that conflists can be compared
+// regardless of formatting, key order and the case of keys.
+type typedConfList struct {
+	CNIVersion string
+	Name       string
+	Plugins    []any
+}
+
+func decode(t *testing.T, b []byte) typedConfList {
+	t.Helper()
+	var raw rawConfList
+	require.NoError(t, json.Unmarshal(b, &raw))
+
+	conflist := typedConfList{CNIVersion: raw.CNIVersion, Name: raw.Name}
+	for _, p := range raw.Plugins {
+		var plugin pluginType
+		require.NoError(t, json.Unmarshal(p, &plugin))
+		switch plugin.Type {
+		case azureVnetType:
+			var conf azureVnet
+			require.NoError(t, json.Unmarshal(p, &conf))
+			conflist.Plugins = append(conflist.Plugins, conf)
+		case portmapType:
+			var conf portmap
+			require.NoError(t, json.Unmarshal(p, &conf))
+			conflist.Plugins = append(conflist.Plugins, conf)
+		case ciliumType:
+			var conf ciliumNetConf
+			require.NoError(t, json.Unmarshal(p, &conf))
+			conflist.Plugins = append(conflist.Plugins, conf)
+		default:
+			t.Fatalf("unexpected plugin type %q", plugin.Type)
+		}
+	}
+	return conflist
+}
+
+// rawJSON compares raw JSON values by their decoded value.
+var rawJSON = cmp.Transformer("rawJSON", func(m json.RawMessage) any {
+	var v any
+	_ = json.Unmarshal(m, &v)
+	return v
+})
+
+// TestRenderPackagedConflists keeps the conflists packaged with the CNI in sync with the rendered ones.
+func TestRenderPackagedConflists(t *testing.T) {
+	tests := []struct {
+		file string
+		opts Options
+	}{
+		{file: "azure-linux.conflist", opts: Options{Scenario: Vnet, OS: linux}},
+		{file: "azure-linux-swift.conflist", opts: Options{Scenario: Swift, OS: linux}},
+		{file: "azure-linux-swift-overlay.conflist", opts: Options{Scenario: Overlay, OS: linux}},
+		{file: "azure-linux-swift-overlay-dualstack.conflist", opts: Options{Scenario: Overlay, OS: linux}},
+		{file: "azure-linux-multitenancy.conflist", opts: Options{Scenario: Multitenancy, OS: linux}},
+		{file: "azure-linux-multitenancy-transparent-vlan.conflist", opts: Options{Scenario: MultitenancyTransparentVlan, OS: linux}},
+		{file: "azure-windows.conflist", opts: Options{Scenario: Vnet, OS: windows}},
+		{file: "azure-windows-swift.conflist", opts: Options{Scenario: Swift, OS: windows}},
+		{file: "azure-windows-swift-overlay.conflist", opts: Options{Scenario: Overlay, OS: windows}},
+		{file: "azure-windows-swift-overlay-dualstack.conflist", opts: Options{Scenario: Overlay, OS: windows}},
+		{file: "azure-windows-multitenancy.conflist", opts: Options{Scenario: Multitenancy, OS: windows}},
+		{file: "azure-windows-multitenancy-transparent-vlan.conflist", opts: Options{Scenario: MultitenancyTransparentVlan, OS: windows}},
+		{file: "azure-windows-baremetal.conflist", opts: Options{Scenario: Baremetal, OS: windows}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.file, func(t *testing.T) {
+			packaged, err := os.ReadFile(filepath.Join("..", tt.file))
+			require.NoError(t, err)
+			require.NoError(t, Validate(packaged))
+
+			var buf bytes.Buffer
+			require.NoError(t, Render(&buf, &tt.opts))
+
+			diff := cmp.Diff(decode(t, packaged), decode(t, buf.Bytes()), cmpopts.EquateEmpty(), rawJSON)
+			require.Empty(t, diff, "rendered conflist differs from %s (-packaged +rendered)", tt.file)
+		})
+	}
+}
+
+// TestRenderDropgzTemplates keeps the conflist templates that dropgz deploys, which are checked in so that dropgz
+// does not depend on this module, in sync with the rendered ones. Run it with -update to rewrite them.
+func TestRenderDropgzTemplates(t *testing.T) {
+	for goos, supported := range scenarios {
+		for _, scenario := range supported {
+			goos, scenario := goos, scenario
+			t.Run(goos+"/"+string(scenario), func(t *testing.T) {
+				rendered, err := Marshal(&Options{Scenario: scenario, OS: goos})
+				require.NoError(t, err)
+
+				file := filepath.Join("..", "..", "dropgz", "pkg", "conflist", "templates", goos, string(scenario)+".conflist")
+				if *update {
+					require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+					require.NoError(t, os.WriteFile(file, rendered, 0o644)) //nolint:gosec // conflists are world readable
+					return
+				}
+				template, err := os.ReadFile(file)
+				require.NoError(t, err)
+				require.Equal(t, string(rendered), string(template), "%s is out of date, run this test with -update", file)
+			})
+		}
+	}
+}
+
+func TestRenderOptions(t *testing.T) {
+	exactMatch := true
+	b, err := Marshal(&Options{
+		Scenario:                   Multitenancy,
+		OS:                         linux,
+		Name:                       "tenant",
+		Mode:                       modeTransparent,
+		MTU:                        1500,
+		CNSURL:                     "http://localhost:10090",
+		EnableExactMatchForPodName: &exactMatch,
+	})
+	require.NoError(t, err)
+
+	conflist := decode(t, b)
+	require.Equal(t, "tenant", conflist.Name)
+	conf, ok := conflist.Plugins[0].(azureVnet)
+	require.True(t, ok)
+	require.Equal(t, modeTransparent, conf.Mode)
+	require.Empty(t, conf.Bridge)
+	require.Equal(t, 1500, conf.MTU)
+	require.Equal(t, "http://localhost:10090", conf.CNSUrl)
+	require.True(t, conf.EnableExactMatchForPodName)
+
+	// the CNS URL is ignored in the scenarios that do not call CNS
+	b, err = Marshal(&Options{Scenario: Vnet, OS: linux, Mode: modeBridge, CNSURL: "http://localhost:10090"})
+	require.NoError(t, err)
+	conf, ok = decode(t, b).Plugins[0].(azureVnet)
+	require.True(t, ok)
+	require.Equal(t, bridgeName, conf.Bridge)
+	require.Empty(t, conf.CNSUrl)
+
+	b, err = Marshal(&Options{Scenario: Overlay, OS: linux, CNSURL: "unix:///var/run/azure-cns/cns.sock"})
+	require.NoError(t, err)
+	conf, ok = decode(t, b).Plugins[0].(azureVnet)
+	require.True(t, ok)
+	require.Equal(t, "unix:///var/run/azure-cns/cns.sock", conf.CNSUrl)
+}
+
+func TestRenderUnsupported(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr error
+	}{
+		{name: "unknown os", opts: Options{Scenario: Vnet, OS: "darwin"}, wantErr: ErrUnsupportedOS},
+		{name: "unknown scenario", opts: Options{Scenario: "unknown", OS: linux}, wantErr: ErrUnsupportedScenario},
+		{name: "cilium on windows", opts: Options{Scenario: Cilium, OS: windows}, wantErr: ErrUnsupportedScenario},
+		{name: "baremetal on linux", opts: Options{Scenario: Baremetal, OS: linux}, wantErr: ErrUnsupportedScenario},
+		{name: "unknown mode", opts: Options{Scenario: Vnet, OS: linux, Mode: "l2"}, wantErr: ErrInvalidConflist},
+		{name: "negative mtu", opts: Options{Scenario: Cilium, OS: linux, MTU: -1}, wantErr: ErrInvalidConflist},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := Render(&bytes.Buffer{}, &tt.opts)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		conflist string
+	}{
+		{name: "not json", conflist: `{`},
+		{name: "no name", conflist: `{"cniVersion":"0.3.0","plugins":[{"type":"portmap"}]}`},
+		{name: "no version", conflist: `{"name":"azure","plugins":[{"type":"portmap"}]}`},
+		{name: "no plugins", conflist: `{"cniVersion":"0.3.0","name":"azure","plugins":[]}`},
+		{name: "no plugin type", conflist: `{"cniVersion":"0.3.0","name":"azure","plugins":[{}]}`},
+		{
+			name:     "unknown mode",
+			conflist: `{"cniVersion":"0.3.0","name":"azure","plugins":[{"type":"azure-vnet","mode":"l2","ipam":{"type":"azure-cns"}}]}`,
+		},
+		{
+			name:     "unknown ipam",
+			conflist: `{"cniVersion":"0.3.0","name":"azure","plugins":[{"type":"azure-vnet","mode":"bridge","ipam":{"type":"host-local"}}]}`,
+		},
+		{
+			name:     "overlay without cns",
+			conflist: `{"cniVersion":"0.3.0","name":"azure","plugins":[{"type":"azure-vnet","mode":"transparent","ipam":{"type":"azure-vnet-ipam","mode":"overlay"}}]}`,
+		},
+		{
+			name:     "swift without cns",
+			conflist: `{"cniVersion":"0.3.0","name":"azure","plugins":[{"type":"azure-vnet","mode":"transparent","executionMode":"v4swift","ipam":{"type":"azure-vnet-ipam"}}]}`,
+		},
+		{
+			name:     "multitenancy without cns",
+			conflist: `{"cniVersion":"0.3.0","name":"azure","plugins":[{"type":"azure-vnet","mode":"bridge","multiTenancy":true,"ipam":{"type":"azure-vnet-ipam"}}]}`,
+		},
+		{
+			name:     "mistyped field",
+			conflist: `{"cniVersion":"0.3.0","name":"azure","plugins":[{"type":"azure-vnet","mode":"bridge","mtu":"1500","ipam":{"type":"azure-cns"}}]}`,
+		},
+		{
+			name:     "cilium without ipam",
+			conflist: `{"cniVersion":"0.3.1","name":"cilium","plugins":[{"type":"cilium-cni"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, Validate([]byte(tt.conflist)), ErrInvalidConflist)
+		})
+	}
+}
+
+func TestScenariosRender(t *testing.T) {
+	for _, goos := range []string{linux, windows} {
+		for _, scenario := range Scenarios(goos) {
+			_, err := Marshal(&Options{Scenario: scenario, OS: goos})
+			require.NoError(t, err, "%s on %s", scenario, goos)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package conflist
+
+import (
+	"encoding/json"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cni/util"
+	"github.com/pkg/errors"
+)
+
+// rawConfList is a conflist with the plugin configs left undecoded.
+type rawConfList struct {
+	CNIVersion string            `json:"cniVersion"`
+	Name       string            `json:"name"`
+	Plugins    []json.RawMessage `json:"plugins"`
+}
+
+type pluginType struct {
+	Type string `json:"type"`
+}
+
+// Validate checks that the conflist is well formed and that its azure-vnet plugin config is parsed by the CNI
+// into a supported combination of mode, IPAM and execution mode.
+func Validate(b []byte) error {
+	var conflist rawConfList
+	if err := json.Unmarshal(b, &conflist); err != nil {
+		return errors.Wrap(ErrInvalidConflist, err.Error())
+	}
+	if conflist.Name == "" {
+		return errors.Wrap(ErrInvalidConflist, "name is empty")
+	}
+	if conflist.CNIVersion == "" {
+		return errors.Wrap(ErrInvalidConflist, "cniVersion is empty")
+	}
+	if len(conflist.Plugins) == 0 {
+		return errors.Wrap(ErrInvalidConflist, "no plugins")
+	}
+
+	for i, raw := range conflist.Plugins {
+		var plugin pluginType
+		if err := json.Unmarshal(raw, &plugin); err != nil {
+			return errors.Wrapf(ErrInvalidConflist, "plugin %d: %s", i, err)
+		}
+		var err error
+		switch plugin.Type {
+		case "":
+			err = errors.Wrap(ErrInvalidConflist, "type is empty")
+		case azureVnetType:
+			err = validateAzureVnet(raw)
+		case ciliumType:
+			err = validateCilium(raw)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "plugin %d", i)
+		}
+	}
+	return nil
+}
+
+func validateAzureVnet(b []byte) error {
+	nwCfg, err := cni.ParseNetworkConfig(b)
+	if err != nil {
+		return errors.Wrap(ErrInvalidConflist, err.Error())
+	}
+
+	switch nwCfg.Mode {
+	case modeBridge, modeTransparent, modeTransparentVlan:
+	default:
+		return errors.Wrapf(ErrInvalidConflist, "unsupported mode %q", nwCfg.Mode)
+	}
+
+	switch nwCfg.IPAM.Type {
+	case azureVnetIPAM, azureCNS:
+	default:
+		return errors.Wrapf(ErrInvalidConflist, "unsupported ipam type %q", nwCfg.IPAM.Type)
+	}
+
+	switch util.IpamMode(nwCfg.IPAM.Mode) {
+	case "":
+	case util.V4Overlay, util.DualStackOverlay, util.Overlay:
+		if nwCfg.IPAM.Type != azureCNS {
+			return errors.Wrapf(ErrInvalidConflist, "ipam mode %q requires ipam type %s", nwCfg.IPAM.Mode, azureCNS)
+		}
+	default:
+		return errors.Wrapf(ErrInvalidConflist, "unsupported ipam mode %q", nwCfg.IPAM.Mode)
+	}
+
+	switch util.ExecutionMode(nwCfg.ExecutionMode) {
+	case "", util.Default, util.Baremetal:
+	case util.V4Swift:
+		if nwCfg.IPAM.Type != azureCNS {
+			return errors.Wrapf(ErrInvalidConflist, "execution mode %q requires ipam type %s", nwCfg.ExecutionMode, azureCNS)
+		}
+	default:
+		return errors.Wrapf(ErrInvalidConflist, "unsupported execution mode %q", nwCfg.ExecutionMode)
+	}
+
+	if nwCfg.MultiTenancy && nwCfg.IPAM.Type != azureCNS {
+		return errors.Wrapf(ErrInvalidConflist, "multitenancy requires ipam type %s", azureCNS)
+	}
+	if nwCfg.MTU < 0 {
+		return errors.Wrapf(ErrInvalidConflist, "negative mtu %d", nwCfg.MTU)
+	}
+	return nil
+}
+
+func validateCilium(b []byte) error {
+	var conf ciliumNetConf
+	if err := json.Unmarshal(b, &conf); err != nil {
+		return errors.Wrap(ErrInvalidConflist, err.Error())
+	}
+	if conf.IPAM.Type == "" {
+		return errors.Wrap(ErrInvalidConflist, "ipam type is empty")
+	}
+	if conf.MTU < 0 {
+		return errors.Wrapf(ErrInvalidConflist, "negative mtu %d", conf.MTU)
+	}
+	return nil
+}
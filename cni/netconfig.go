@@ -50,35 +50,47 @@ type IPAM struct {
 	QueryInterval string `json:"queryInterval,omitempty"`
 }
 
+// InterfaceConfig describes a secondary interface to create in the pod network namespace
+// in addition to the one named by the CNI_IFNAME argument.
+type InterfaceConfig struct {
+	IfName string `json:"ifName"`
+	Master string `json:"master,omitempty"`
+	IPAM   IPAM   `json:"ipam,omitempty"`
+}
+
 // NetworkConfig represents Azure CNI plugin network configuration.
 type NetworkConfig struct {
-	CNIVersion                    string          `json:"cniVersion,omitempty"`
-	Name                          string          `json:"name,omitempty"`
-	Type                          string          `json:"type,omitempty"`
-	Mode                          string          `json:"mode,omitempty"`
-	Master                        string          `json:"master,omitempty"`
-	AdapterName                   string          `json:"adapterName,omitempty"`
-	Bridge                        string          `json:"bridge,omitempty"`
-	LogLevel                      string          `json:"logLevel,omitempty"`
-	LogTarget                     string          `json:"logTarget,omitempty"`
-	InfraVnetAddressSpace         string          `json:"infraVnetAddressSpace,omitempty"`
-	IPV6Mode                      string          `json:"ipv6Mode,omitempty"`
-	ServiceCidrs                  string          `json:"serviceCidrs,omitempty"`
-	VnetCidrs                     string          `json:"vnetCidrs,omitempty"`
-	PodNamespaceForDualNetwork    []string        `json:"podNamespaceForDualNetwork,omitempty"`
-	IPsToRouteViaHost             []string        `json:"ipsToRouteViaHost,omitempty"`
-	MultiTenancy                  bool            `json:"multiTenancy,omitempty"`
-	EnableSnatOnHost              bool            `json:"enableSnatOnHost,omitempty"`
-	EnableExactMatchForPodName    bool            `json:"enableExactMatchForPodName,omitempty"`
-	DisableHairpinOnHostInterface bool            `json:"disableHairpinOnHostInterface,omitempty"`
-	DisableIPTableLock            bool            `json:"disableIPTableLock,omitempty"`
-	CNSUrl                        string          `json:"cnsurl,omitempty"`
-	ExecutionMode                 string          `json:"executionMode,omitempty"`
-	IPAM                          IPAM            `json:"ipam,omitempty"`
-	DNS                           cniTypes.DNS    `json:"dns,omitempty"`
-	RuntimeConfig                 RuntimeConfig   `json:"runtimeConfig,omitempty"`
-	WindowsSettings               WindowsSettings `json:"windowsSettings,omitempty"`
-	AdditionalArgs                []KVPair        `json:"AdditionalArgs,omitempty"`
+	CNIVersion                    string            `json:"cniVersion,omitempty"`
+	Name                          string            `json:"name,omitempty"`
+	Type                          string            `json:"type,omitempty"`
+	Mode                          string            `json:"mode,omitempty"`
+	Master                        string            `json:"master,omitempty"`
+	AdapterName                   string            `json:"adapterName,omitempty"`
+	Bridge                        string            `json:"bridge,omitempty"`
+	LogLevel                      string            `json:"logLevel,omitempty"`
+	LogTarget                     string            `json:"logTarget,omitempty"`
+	InfraVnetAddressSpace         string            `json:"infraVnetAddressSpace,omitempty"`
+	IPV6Mode                      string            `json:"ipv6Mode,omitempty"`
+	ServiceCidrs                  string            `json:"serviceCidrs,omitempty"`
+	VnetCidrs                     string            `json:"vnetCidrs,omitempty"`
+	PodNamespaceForDualNetwork    []string          `json:"podNamespaceForDualNetwork,omitempty"`
+	IPsToRouteViaHost             []string          `json:"ipsToRouteViaHost,omitempty"`
+	MultiTenancy                  bool              `json:"multiTenancy,omitempty"`
+	EnableSnatOnHost              bool              `json:"enableSnatOnHost,omitempty"`
+	EnableExactMatchForPodName    bool              `json:"enableExactMatchForPodName,omitempty"`
+	DisableHairpinOnHostInterface bool              `json:"disableHairpinOnHostInterface,omitempty"`
+	DisableIPTableLock            bool              `json:"disableIPTableLock,omitempty"`
+	CNSUrl                        string            `json:"cnsurl,omitempty"`
+	ExecutionMode                 string            `json:"executionMode,omitempty"`
+	IPAM                          IPAM              `json:"ipam,omitempty"`
+	DNS                           cniTypes.DNS      `json:"dns,omitempty"`
+	RuntimeConfig                 RuntimeConfig     `json:"runtimeConfig,omitempty"`
+	WindowsSettings               WindowsSettings   `json:"windowsSettings,omitempty"`
+	AdditionalArgs                []KVPair          `json:"AdditionalArgs,omitempty"`
+	SecondaryInterfaces           []InterfaceConfig `json:"secondaryInterfaces,omitempty"`
+	MTU                           int               `json:"mtu,omitempty"`
+	// TracingEndpoint is the OTLP/HTTP endpoint of an OpenTelemetry collector that CNI spans are exported to.
+	TracingEndpoint string `json:"tracingEndpoint,omitempty"`
 }
 
 type WindowsSettings struct {
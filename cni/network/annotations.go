@@ -0,0 +1,203 @@
+package network
+
+import (
+	"encoding/json"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/network"
+	cniTypes "github.com/containernetworking/cni/pkg/types"
+	cniTypesCurr "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxPodRoutes is the maximum number of routes a pod can add through annotations.
+	maxPodRoutes = 32
+	// maxDNSSearches is the maximum number of search domains the resolver honors.
+	maxDNSSearches = 6
+)
+
+var (
+	errInvalidPodAnnotation = errors.New("invalid pod network annotation")
+	dnsNameRegex            = regexp.MustCompile(`^[a-zA-Z0-9]([-a-zA-Z0-9]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([-a-zA-Z0-9]{0,61}[a-zA-Z0-9])?)*\.?$`)
+)
+
+// podRouteAnnotation is a single route in the routes pod annotation.
+type podRouteAnnotation struct {
+	Dst string `json:"dst"`
+	Gw  string `json:"gw,omitempty"`
+}
+
+// podNetworkConfig is the per pod network configuration read from the pod annotations.
+type podNetworkConfig struct {
+	Routes      []network.RouteInfo
+	DNSServers  []string
+	DNSSearches []string
+	DNSOptions  []string
+}
+
+// parsePodNetworkAnnotations validates the pod network annotations passed by CNS and returns
+// the configuration they describe. It returns nil if there are no pod network annotations.
+func parsePodNetworkAnnotations(annotations map[string]string) (*podNetworkConfig, error) {
+	if len(cns.FilterPodNetworkAnnotations(annotations)) == 0 {
+		return nil, nil
+	}
+
+	cfg := &podNetworkConfig{}
+
+	if routes, ok := annotations[cns.PodRoutesAnnotation]; ok {
+		var podRoutes []podRouteAnnotation
+		if err := json.Unmarshal([]byte(routes), &podRoutes); err != nil {
+			return nil, errors.Wrapf(errInvalidPodAnnotation, "%s: %v", cns.PodRoutesAnnotation, err)
+		}
+		if len(podRoutes) > maxPodRoutes {
+			return nil, errors.Wrapf(errInvalidPodAnnotation, "%s: %d routes exceeds the limit of %d", cns.PodRoutesAnnotation, len(podRoutes), maxPodRoutes)
+		}
+		for _, r := range podRoutes {
+			route, err := parsePodRoute(r)
+			if err != nil {
+				return nil, errors.Wrap(err, cns.PodRoutesAnnotation)
+			}
+			cfg.Routes = append(cfg.Routes, route)
+		}
+	}
+
+	if servers, ok := annotations[cns.PodDNSServersAnnotation]; ok {
+		for _, server := range splitAnnotationList(servers) {
+			if net.ParseIP(server) == nil {
+				return nil, errors.Wrapf(errInvalidPodAnnotation, "%s: %q is not an IP address", cns.PodDNSServersAnnotation, server)
+			}
+			cfg.DNSServers = append(cfg.DNSServers, server)
+		}
+	}
+
+	if searches, ok := annotations[cns.PodDNSSearchesAnnotation]; ok {
+		for _, search := range splitAnnotationList(searches) {
+			if len(search) > 253 || !dnsNameRegex.MatchString(search) { //nolint:gomnd // max DNS name length
+				return nil, errors.Wrapf(errInvalidPodAnnotation, "%s: %q is not a valid domain", cns.PodDNSSearchesAnnotation, search)
+			}
+			cfg.DNSSearches = append(cfg.DNSSearches, search)
+		}
+		if len(cfg.DNSSearches) > maxDNSSearches {
+			return nil, errors.Wrapf(errInvalidPodAnnotation, "%s: %d search domains exceeds the limit of %d", cns.PodDNSSearchesAnnotation, len(cfg.DNSSearches), maxDNSSearches)
+		}
+	}
+
+	if options, ok := annotations[cns.PodDNSOptionsAnnotation]; ok {
+		cfg.DNSOptions = splitAnnotationList(options)
+	}
+
+	return cfg, nil
+}
+
+func parsePodRoute(r podRouteAnnotation) (network.RouteInfo, error) {
+	_, dst, err := net.ParseCIDR(r.Dst)
+	if err != nil {
+		return network.RouteInfo{}, errors.Wrapf(errInvalidPodAnnotation, "route destination %q: %v", r.Dst, err)
+	}
+	if ones, _ := dst.Mask.Size(); ones == 0 {
+		// the default route is owned by the IPAM result
+		return network.RouteInfo{}, errors.Wrapf(errInvalidPodAnnotation, "route destination %q overrides the default route", r.Dst)
+	}
+
+	route := network.RouteInfo{Dst: *dst}
+	if r.Gw != "" {
+		if route.Gw = net.ParseIP(r.Gw); route.Gw == nil {
+			return network.RouteInfo{}, errors.Wrapf(errInvalidPodAnnotation, "route gateway %q is not an IP address", r.Gw)
+		}
+		if (route.Gw.To4() == nil) != (dst.IP.To4() == nil) {
+			return network.RouteInfo{}, errors.Wrapf(errInvalidPodAnnotation, "route gateway %q and destination %q are of different families", r.Gw, r.Dst)
+		}
+	}
+
+	return route, nil
+}
+
+func splitAnnotationList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// applyPodNetworkConfig merges the per pod configuration into the endpoint. Pod routes replace
+// routes to the same destination, DNS servers replace the configured ones and search domains
+// are appended to the DNS suffix list.
+func applyPodNetworkConfig(cfg *podNetworkConfig, epInfo *network.EndpointInfo) {
+	if cfg == nil {
+		return
+	}
+
+	epInfo.Routes = mergeRoutes(epInfo.Routes, cfg.Routes)
+
+	if len(cfg.DNSServers) > 0 {
+		epInfo.DNS.Servers = cfg.DNSServers
+	}
+
+	if len(cfg.DNSSearches) > 0 {
+		var suffixes []string
+		if epInfo.DNS.Suffix != "" {
+			suffixes = append(suffixes, epInfo.DNS.Suffix)
+		}
+		epInfo.DNS.Suffix = strings.Join(append(suffixes, cfg.DNSSearches...), ",")
+	}
+
+	epInfo.DNS.Options = append(epInfo.DNS.Options, cfg.DNSOptions...)
+}
+
+// applyPodNetworkConfigToResult merges the per pod configuration into the CNI result the same way
+// applyPodNetworkConfig does for the endpoint, so that the runtime sees the routes and DNS the pod got.
+func applyPodNetworkConfigToResult(cfg *podNetworkConfig, result *cniTypesCurr.Result) {
+	if cfg == nil || result == nil {
+		return
+	}
+
+	if len(cfg.Routes) > 0 {
+		overridden := make(map[string]struct{}, len(cfg.Routes))
+		for i := range cfg.Routes {
+			overridden[cfg.Routes[i].Dst.String()] = struct{}{}
+		}
+		routes := make([]*cniTypes.Route, 0, len(result.Routes)+len(cfg.Routes))
+		for _, route := range result.Routes {
+			if _, ok := overridden[route.Dst.String()]; !ok {
+				routes = append(routes, route)
+			}
+		}
+		for i := range cfg.Routes {
+			routes = append(routes, &cniTypes.Route{Dst: cfg.Routes[i].Dst, GW: cfg.Routes[i].Gw})
+		}
+		result.Routes = routes
+	}
+
+	if len(cfg.DNSServers) > 0 {
+		result.DNS.Nameservers = cfg.DNSServers
+	}
+	result.DNS.Search = append(result.DNS.Search, cfg.DNSSearches...)
+	result.DNS.Options = append(result.DNS.Options, cfg.DNSOptions...)
+}
+
+// mergeRoutes returns the routes with the overrides applied, keyed by destination.
+func mergeRoutes(routes, overrides []network.RouteInfo) []network.RouteInfo {
+	if len(overrides) == 0 {
+		return routes
+	}
+
+	byDst := make(map[string]int, len(overrides))
+	for i := range overrides {
+		byDst[overrides[i].Dst.String()] = i
+	}
+
+	merged := make([]network.RouteInfo, 0, len(routes)+len(overrides))
+	for i := range routes {
+		if _, ok := byDst[routes[i].Dst.String()]; !ok {
+			merged = append(merged, routes[i])
+		}
+	}
+	return append(merged, overrides...)
+}
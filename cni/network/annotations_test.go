@@ -0,0 +1,158 @@
+package network
+
+import (
+	"net"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/network"
+	cniTypes "github.com/containernetworking/cni/pkg/types"
+	cniTypesCurr "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseCIDR(s string) net.IPNet {
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return *ipNet
+}
+
+func TestParsePodNetworkAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        *podNetworkConfig
+		wantErr     bool
+	}{
+		{
+			name: "no pod network annotations",
+			annotations: map[string]string{
+				"other.io/annotation": "value",
+			},
+		},
+		{
+			name: "routes and dns",
+			annotations: map[string]string{
+				cns.PodRoutesAnnotation:      `[{"dst":"10.1.0.0/16","gw":"10.0.0.1"},{"dst":"fd00:1::/64"}]`,
+				cns.PodDNSServersAnnotation:  "10.0.0.10, 10.0.0.11",
+				cns.PodDNSSearchesAnnotation: "svc.example.com,example.com",
+				cns.PodDNSOptionsAnnotation:  "ndots:2",
+			},
+			want: &podNetworkConfig{
+				Routes: []network.RouteInfo{
+					{Dst: mustParseCIDR("10.1.0.0/16"), Gw: net.ParseIP("10.0.0.1")},
+					{Dst: mustParseCIDR("fd00:1::/64")},
+				},
+				DNSServers:  []string{"10.0.0.10", "10.0.0.11"},
+				DNSSearches: []string{"svc.example.com", "example.com"},
+				DNSOptions:  []string{"ndots:2"},
+			},
+		},
+		{
+			name:        "malformed routes",
+			annotations: map[string]string{cns.PodRoutesAnnotation: `{"dst":"10.1.0.0/16"}`},
+			wantErr:     true,
+		},
+		{
+			name:        "default route",
+			annotations: map[string]string{cns.PodRoutesAnnotation: `[{"dst":"0.0.0.0/0","gw":"10.0.0.1"}]`},
+			wantErr:     true,
+		},
+		{
+			name:        "gateway of other family",
+			annotations: map[string]string{cns.PodRoutesAnnotation: `[{"dst":"10.1.0.0/16","gw":"fd00::1"}]`},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid dns server",
+			annotations: map[string]string{cns.PodDNSServersAnnotation: "dns.example.com"},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid search domain",
+			annotations: map[string]string{cns.PodDNSSearchesAnnotation: "bad_domain!"},
+			wantErr:     true,
+		},
+		{
+			name:        "too many search domains",
+			annotations: map[string]string{cns.PodDNSSearchesAnnotation: "a.com,b.com,c.com,d.com,e.com,f.com,g.com"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePodNetworkAnnotations(tt.annotations)
+			if tt.wantErr {
+				require.ErrorIs(t, err, errInvalidPodAnnotation)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestApplyPodNetworkConfig(t *testing.T) {
+	epInfo := &network.EndpointInfo{
+		Routes: []network.RouteInfo{
+			{Dst: *getCIDRNotationForAddress("0.0.0.0/0"), Gw: net.ParseIP("10.0.0.1")},
+			{Dst: *getCIDRNotationForAddress("10.1.0.0/16"), Gw: net.ParseIP("10.0.0.1")},
+		},
+		DNS: network.DNSInfo{
+			Suffix:  "cluster.local",
+			Servers: []string{"168.63.129.16"},
+		},
+	}
+
+	applyPodNetworkConfig(&podNetworkConfig{
+		Routes: []network.RouteInfo{
+			{Dst: *getCIDRNotationForAddress("10.1.0.0/16"), Gw: net.ParseIP("10.0.0.254")},
+			{Dst: *getCIDRNotationForAddress("10.2.0.0/16"), Gw: net.ParseIP("10.0.0.254")},
+		},
+		DNSServers:  []string{"10.0.0.10"},
+		DNSSearches: []string{"example.com"},
+	}, epInfo)
+
+	require.Len(t, epInfo.Routes, 3)
+	require.Equal(t, "0.0.0.0/0", epInfo.Routes[0].Dst.String())
+	require.Equal(t, "10.1.0.0/16", epInfo.Routes[1].Dst.String())
+	require.Equal(t, "10.0.0.254", epInfo.Routes[1].Gw.String())
+	require.Equal(t, "10.2.0.0/16", epInfo.Routes[2].Dst.String())
+	require.Equal(t, []string{"10.0.0.10"}, epInfo.DNS.Servers)
+	require.Equal(t, "cluster.local,example.com", epInfo.DNS.Suffix)
+
+	// a nil config leaves the endpoint untouched
+	applyPodNetworkConfig(nil, epInfo)
+	require.Len(t, epInfo.Routes, 3)
+}
+
+func TestApplyPodNetworkConfigToResult(t *testing.T) {
+	result := &cniTypesCurr.Result{
+		Routes: []*cniTypes.Route{
+			{Dst: *getCIDRNotationForAddress("0.0.0.0/0"), GW: net.ParseIP("10.0.0.1")},
+			{Dst: *getCIDRNotationForAddress("10.1.0.0/16"), GW: net.ParseIP("10.0.0.1")},
+		},
+		DNS: cniTypes.DNS{Nameservers: []string{"168.63.129.16"}, Search: []string{"cluster.local"}},
+	}
+
+	applyPodNetworkConfigToResult(&podNetworkConfig{
+		Routes: []network.RouteInfo{
+			{Dst: *getCIDRNotationForAddress("10.1.0.0/16"), Gw: net.ParseIP("10.0.0.254")},
+		},
+		DNSServers:  []string{"10.0.0.10"},
+		DNSSearches: []string{"example.com"},
+		DNSOptions:  []string{"ndots:2"},
+	}, result)
+
+	require.Len(t, result.Routes, 2)
+	require.Equal(t, "0.0.0.0/0", result.Routes[0].Dst.String())
+	require.Equal(t, "10.1.0.0/16", result.Routes[1].Dst.String())
+	require.Equal(t, "10.0.0.254", result.Routes[1].GW.String())
+	require.Equal(t, []string{"10.0.0.10"}, result.DNS.Nameservers)
+	require.Equal(t, []string{"cluster.local", "example.com"}, result.DNS.Search)
+	require.Equal(t, []string{"ndots:2"}, result.DNS.Options)
+}
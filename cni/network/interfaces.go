@@ -0,0 +1,404 @@
+package network
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cni/log"
+	"github.com/Azure/azure-container-networking/network"
+	"github.com/Azure/azure-container-networking/store"
+	cniSkel "github.com/containernetworking/cni/pkg/skel"
+	cniTypesCurr "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var (
+	errSecondaryIfName     = errors.New("invalid secondary interface name")
+	errSecondaryIPAMType   = errors.New("unsupported IPAM type for secondary interface")
+	errTooManyNCsForIfaces = errors.New("more network containers than configured interfaces")
+)
+
+// validateSecondaryInterfaces checks that every secondary interface in the netconf has a unique name
+// that does not collide with the primary interface. Secondary interfaces that get their addresses from
+// CNS are expressed as additional network containers in multitenancy mode, so azure-cns is rejected here.
+func validateSecondaryInterfaces(nwCfg *cni.NetworkConfig, primaryIfName string) error {
+	seen := map[string]struct{}{primaryIfName: {}}
+	for _, ifCfg := range nwCfg.SecondaryInterfaces {
+		if ifCfg.IfName == "" {
+			return errors.Wrap(errSecondaryIfName, "ifName must be set")
+		}
+		if _, ok := seen[ifCfg.IfName]; ok {
+			return errors.Wrapf(errSecondaryIfName, "%s is used by more than one interface", ifCfg.IfName)
+		}
+		seen[ifCfg.IfName] = struct{}{}
+
+		if !nwCfg.MultiTenancy && (ifCfg.IPAM.Type == "" || ifCfg.IPAM.Type == network.AzureCNS) {
+			return errors.Wrapf(errSecondaryIPAMType, "%q for interface %s", ifCfg.IPAM.Type, ifCfg.IfName)
+		}
+	}
+
+	return nil
+}
+
+// assignSecondaryInterfaceNames names the interfaces of the network containers returned by CNS in multitenancy
+// mode. The first network container is the primary interface, every following one takes the name of the
+// matching entry in the netconf's secondary interfaces.
+func assignSecondaryInterfaceNames(nwCfg *cni.NetworkConfig, ipamAddResults []IPAMAddResult) error {
+	if len(ipamAddResults)-1 > len(nwCfg.SecondaryInterfaces) {
+		return errors.Wrapf(errTooManyNCsForIfaces, "received %d network containers for %d secondary interfaces",
+			len(ipamAddResults), len(nwCfg.SecondaryInterfaces))
+	}
+
+	for i := 1; i < len(ipamAddResults); i++ {
+		ifName := nwCfg.SecondaryInterfaces[i-1].IfName
+		ipamAddResults[i].ifName = ifName
+		if ipamAddResults[i].ipv4Result != nil {
+			ipamAddResults[i].ipv4Result.Interfaces = []*cniTypesCurr.Interface{{Name: ifName}}
+		}
+	}
+
+	return nil
+}
+
+// argsForInterface returns the CNI args to use for the endpoint of the given interface.
+func argsForInterface(args *cniSkel.CmdArgs, ifName string) *cniSkel.CmdArgs {
+	if ifName == "" || ifName == args.IfName {
+		return args
+	}
+
+	ifArgs := *args
+	ifArgs.IfName = ifName
+	return &ifArgs
+}
+
+// secondaryNetworkConfig returns the netconf used to allocate addresses and create the network for a
+// secondary interface. Each secondary interface lives in its own network so that it can be attached to
+// a different master interface.
+func secondaryNetworkConfig(nwCfg *cni.NetworkConfig, ifCfg cni.InterfaceConfig) *cni.NetworkConfig {
+	secCfg := *nwCfg
+	secCfg.Name = fmt.Sprintf("%s-%s", nwCfg.Name, ifCfg.IfName)
+	secCfg.IPAM = ifCfg.IPAM
+	secCfg.Bridge = ""
+	secCfg.IPV6Mode = ""
+	secCfg.SecondaryInterfaces = nil
+	if ifCfg.Master != "" {
+		secCfg.Master = ifCfg.Master
+	}
+
+	return &secCfg
+}
+
+// getSecondaryIPAMInvoker returns the IPAM invoker for a secondary interface.
+func (plugin *NetPlugin) getSecondaryIPAMInvoker(nwCfg *cni.NetworkConfig, ifName string, nwInfo *network.NetworkInfo) IPAMInvoker {
+	if invoker, ok := plugin.secondaryIpamInvokers[ifName]; ok {
+		return invoker
+	}
+
+	var invoker IPAMInvoker
+	switch nwCfg.IPAM.Type {
+	case azureVnetIpam, ipamV6:
+		invoker = NewAzureIpamInvoker(plugin, nwInfo)
+	default:
+		invoker = NewDelegatedIPAMInvoker(plugin)
+	}
+
+	if plugin.secondaryIpamInvokers == nil {
+		plugin.secondaryIpamInvokers = make(map[string]IPAMInvoker)
+	}
+	plugin.secondaryIpamInvokers[ifName] = invoker
+
+	return invoker
+}
+
+// secondaryInterfacesKey is the key of the secondary interfaces of the containers in the CNI state store.
+const secondaryInterfacesKey = "SecondaryInterfaces"
+
+// secondaryInterface is the record of a secondary interface created for a container, so that DEL removes
+// the interfaces that ADD created even if the netconf changed in between. The IPAM of the network container
+// interfaces of multitenancy mode is empty, their addresses are released with the primary interface.
+type secondaryInterface struct {
+	Interface  cni.InterfaceConfig
+	NetworkID  string
+	EndpointID string
+}
+
+// readSecondaryInterfaces returns the recorded secondary interfaces of the containers, by container ID.
+func (plugin *NetPlugin) readSecondaryInterfaces() (map[string][]secondaryInterface, error) {
+	records := make(map[string][]secondaryInterface)
+	if plugin.Store == nil {
+		return records, nil
+	}
+
+	err := plugin.Store.Read(secondaryInterfacesKey, &records)
+	if err != nil && !errors.Is(err, store.ErrKeyNotFound) && !errors.Is(err, store.ErrStoreEmpty) {
+		return nil, errors.Wrap(err, "failed to read secondary interfaces")
+	}
+	if records == nil {
+		records = make(map[string][]secondaryInterface)
+	}
+
+	return records, nil
+}
+
+// writeSecondaryInterfaces records the secondary interfaces of a container, or drops its record if it has none.
+func (plugin *NetPlugin) writeSecondaryInterfaces(containerID string, ifaces []secondaryInterface) error {
+	if plugin.Store == nil {
+		return nil
+	}
+
+	records, err := plugin.readSecondaryInterfaces()
+	if err != nil {
+		return err
+	}
+	if len(ifaces) == 0 {
+		delete(records, containerID)
+	} else {
+		records[containerID] = ifaces
+	}
+
+	return errors.Wrap(plugin.Store.Write(secondaryInterfacesKey, records), "failed to write secondary interfaces")
+}
+
+// resultFromEndpoint returns the result of a secondary interface that already exists.
+func resultFromEndpoint(ifName string, epInfo *network.EndpointInfo) IPAMAddResult {
+	result := IPAMAddResult{ifName: ifName, ipv4Result: &cniTypesCurr.Result{}}
+	for i := range epInfo.IPAddresses {
+		result.ipv4Result.IPs = append(result.ipv4Result.IPs, &cniTypesCurr.IPConfig{Address: epInfo.IPAddresses[i]})
+	}
+
+	return result
+}
+
+// addSecondaryInterface allocates addresses for a secondary interface from its own IPAM and creates
+// its network and endpoint. If the endpoint already exists from a previous ADD of the container, its
+// addresses are returned instead.
+func (plugin *NetPlugin) addSecondaryInterface(
+	args *cniSkel.CmdArgs,
+	nwCfg *cni.NetworkConfig,
+	ifCfg cni.InterfaceConfig,
+	k8sPodName, k8sNamespace string,
+) (IPAMAddResult, secondaryInterface, error) {
+	var (
+		ipamAddResult IPAMAddResult
+		err           error
+	)
+
+	secCfg := secondaryNetworkConfig(nwCfg, ifCfg)
+	secArgs := argsForInterface(args, ifCfg.IfName)
+	networkID := secCfg.Name
+	endpointID := GetEndpointID(secArgs)
+	iface := secondaryInterface{Interface: ifCfg, NetworkID: networkID, EndpointID: endpointID}
+	options := make(map[string]any)
+
+	nwInfo, nwInfoErr := plugin.nm.GetNetworkInfo(networkID)
+	if nwInfoErr == nil {
+		options = nwInfo.Options
+		if epInfo, epErr := plugin.nm.GetEndpointInfo(networkID, endpointID); epErr == nil {
+			log.Logger.Info("[cni-net] Found endpoint for secondary interface",
+				zap.String("ifName", ifCfg.IfName), zap.String("endpoint", endpointID))
+			return resultFromEndpoint(ifCfg.IfName, epInfo), iface, nil
+		}
+	}
+
+	invoker := plugin.getSecondaryIPAMInvoker(secCfg, ifCfg.IfName, &nwInfo)
+	ipamAddConfig := IPAMAddConfig{nwCfg: secCfg, args: secArgs, options: options}
+	if ipamAddResult, err = invoker.Add(ipamAddConfig); err != nil {
+		return ipamAddResult, iface, fmt.Errorf("IPAM Invoker Add failed for interface %s with error: %w", ifCfg.IfName, err)
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, result := range []*cniTypesCurr.Result{ipamAddResult.ipv4Result, ipamAddResult.ipv6Result} {
+			if result == nil || len(result.IPs) == 0 {
+				continue
+			}
+			if er := invoker.Delete(&result.IPs[0].Address, secCfg, secArgs, options); er != nil {
+				log.Logger.Error("Failed to cleanup ip allocation on failure",
+					zap.String("ifName", ifCfg.IfName), zap.Error(er))
+			}
+		}
+	}()
+
+	if nwInfoErr != nil {
+		log.Logger.Info("[cni-net] Creating network", zap.String("networkID", networkID))
+		if nwInfo, err = plugin.createNetworkInternal(networkID, nil, ipamAddConfig, ipamAddResult); err != nil {
+			log.Logger.Error("Create network failed", zap.String("ifName", ifCfg.IfName), zap.Error(err))
+			return ipamAddResult, iface, err
+		}
+	}
+
+	createEndpointInternalOpt := createEndpointInternalOpt{
+		nwCfg:        secCfg,
+		result:       ipamAddResult.ipv4Result,
+		resultV6:     ipamAddResult.ipv6Result,
+		args:         secArgs,
+		nwInfo:       &nwInfo,
+		endpointID:   endpointID,
+		k8sPodName:   k8sPodName,
+		k8sNamespace: k8sNamespace,
+	}
+	if _, err = plugin.createEndpointInternal(&createEndpointInternalOpt); err != nil {
+		log.Logger.Error("Endpoint creation failed", zap.String("ifName", ifCfg.IfName), zap.Error(err))
+		return ipamAddResult, iface, err
+	}
+
+	ipamAddResult.ifName = ifCfg.IfName
+
+	return ipamAddResult, iface, nil
+}
+
+// rollbackSecondaryInterfaces deletes the secondary interfaces created by a failed ADD.
+func (plugin *NetPlugin) rollbackSecondaryInterfaces(args *cniSkel.CmdArgs, nwCfg *cni.NetworkConfig, ifaces []secondaryInterface) {
+	for i := len(ifaces) - 1; i >= 0; i-- {
+		if err := plugin.deleteSecondaryInterface(args, nwCfg, ifaces[i]); err != nil {
+			log.Logger.Error("Failed to cleanup secondary interface on failure",
+				zap.String("ifName", ifaces[i].Interface.IfName), zap.Error(err))
+			_ = plugin.writeSecondaryInterfaces(args.ContainerID, ifaces[:i+1])
+			return
+		}
+	}
+
+	if err := plugin.writeSecondaryInterfaces(args.ContainerID, nil); err != nil {
+		log.Logger.Error("Failed to drop the record of the secondary interfaces", zap.Error(err))
+	}
+}
+
+// secondaryInterfacesFromNetConf returns the secondary interfaces of the netconf, for containers that were
+// added before their secondary interfaces were recorded.
+func (plugin *NetPlugin) secondaryInterfacesFromNetConf(args *cniSkel.CmdArgs, nwCfg *cni.NetworkConfig) []secondaryInterface {
+	ifaces := make([]secondaryInterface, 0, len(nwCfg.SecondaryInterfaces))
+	for _, ifCfg := range nwCfg.SecondaryInterfaces {
+		iface := secondaryInterface{
+			Interface:  ifCfg,
+			NetworkID:  secondaryNetworkConfig(nwCfg, ifCfg).Name,
+			EndpointID: GetEndpointID(argsForInterface(args, ifCfg.IfName)),
+		}
+		if nwCfg.MultiTenancy {
+			// network container interfaces share the network of the primary interface
+			networkID, err := plugin.getNetworkName(args.Netns, nil, nwCfg)
+			if err != nil {
+				log.Logger.Info("[cni-net] Network for secondary interfaces not found", zap.Error(err))
+				return nil
+			}
+			iface.NetworkID = networkID
+			iface.Interface.IPAM = cni.IPAM{}
+		}
+		ifaces = append(ifaces, iface)
+	}
+
+	return ifaces
+}
+
+// deleteSecondaryInterfaces deletes the recorded secondary interfaces of the container in the reverse order
+// of ADD and releases their addresses. Interfaces that were never created are skipped.
+func (plugin *NetPlugin) deleteSecondaryInterfaces(args *cniSkel.CmdArgs, nwCfg *cni.NetworkConfig) error {
+	records, err := plugin.readSecondaryInterfaces()
+	if err != nil {
+		return err
+	}
+
+	ifaces, recorded := records[args.ContainerID]
+	if !recorded {
+		ifaces = plugin.secondaryInterfacesFromNetConf(args, nwCfg)
+	}
+
+	for i := len(ifaces) - 1; i >= 0; i-- {
+		if err = plugin.deleteSecondaryInterface(args, nwCfg, ifaces[i]); err != nil {
+			if recorded {
+				// keep the record of the interfaces that are left for the retry of DEL
+				_ = plugin.writeSecondaryInterfaces(args.ContainerID, ifaces[:i+1])
+			}
+			return err
+		}
+	}
+
+	if !recorded {
+		return nil
+	}
+	return plugin.writeSecondaryInterfaces(args.ContainerID, nil)
+}
+
+// deleteSecondaryInterface deletes the endpoint of a secondary interface and releases its addresses.
+func (plugin *NetPlugin) deleteSecondaryInterface(args *cniSkel.CmdArgs, nwCfg *cni.NetworkConfig, iface secondaryInterface) error {
+	ifName := iface.Interface.IfName
+	nwInfo, err := plugin.nm.GetNetworkInfo(iface.NetworkID)
+	if err != nil {
+		log.Logger.Info("[cni-net] Network for secondary interface not found",
+			zap.String("ifName", ifName), zap.String("network", iface.NetworkID))
+		return nil
+	}
+
+	epInfo, err := plugin.nm.GetEndpointInfo(iface.NetworkID, iface.EndpointID)
+	if err != nil {
+		log.Logger.Info("[cni-net] Endpoint for secondary interface not found",
+			zap.String("ifName", ifName), zap.String("endpoint", iface.EndpointID))
+		return nil
+	}
+
+	log.Logger.Info("Deleting secondary endpoint", zap.String("endpointID", iface.EndpointID))
+	if err = plugin.nm.DeleteEndpoint(iface.NetworkID, iface.EndpointID); err != nil {
+		return fmt.Errorf("failed to delete endpoint %s: %w", iface.EndpointID, err)
+	}
+
+	if iface.Interface.IPAM.Type == "" {
+		return nil
+	}
+
+	secCfg := secondaryNetworkConfig(nwCfg, iface.Interface)
+	secCfg.Name = iface.NetworkID
+	secArgs := argsForInterface(args, ifName)
+	invoker := plugin.getSecondaryIPAMInvoker(secCfg, ifName, &nwInfo)
+	for i := range epInfo.IPAddresses {
+		log.Logger.Info("Release ip", zap.String("ip", epInfo.IPAddresses[i].IP.String()), zap.String("ifName", ifName))
+		if err = invoker.Delete(&epInfo.IPAddresses[i], secCfg, secArgs, nwInfo.Options); err != nil {
+			return fmt.Errorf("failed to release address: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// hasSecondaryInterfaces returns true if any of the results is for a secondary interface.
+func hasSecondaryInterfaces(results []IPAMAddResult) bool {
+	for i := range results {
+		if results[i].ifName != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// combineInterfaceResults merges the per-interface results of a multi-interface pod into one CNI result that
+// lists every interface, with each IP pointing at the index of its interface. DNS is taken from the primary.
+func combineInterfaceResults(primaryIfName string, results []IPAMAddResult) *cniTypesCurr.Result {
+	combined := &cniTypesCurr.Result{}
+
+	for i := range results {
+		ifName := results[i].ifName
+		if ifName == "" {
+			ifName = primaryIfName
+		}
+		combined.Interfaces = append(combined.Interfaces, &cniTypesCurr.Interface{Name: ifName})
+
+		if i == 0 && results[i].ipv4Result != nil {
+			combined.DNS = results[i].ipv4Result.DNS
+		}
+
+		for _, result := range []*cniTypesCurr.Result{results[i].ipv4Result, results[i].ipv6Result} {
+			if result == nil {
+				continue
+			}
+			for _, ipConfig := range result.IPs {
+				ip := *ipConfig
+				ip.Interface = cniTypesCurr.Int(i)
+				combined.IPs = append(combined.IPs, &ip)
+			}
+			combined.Routes = append(combined.Routes, result.Routes...)
+		}
+	}
+
+	return combined
+}
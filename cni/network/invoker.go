@@ -31,4 +31,11 @@ type IPAMAddResult struct {
 	ipv6Result       *cniTypesCurr.Result
 	ncResponse       *cns.GetNetworkContainerResponse
 	hostSubnetPrefix net.IPNet
+	// ifName is the name of the pod interface the result is for. It is only set for secondary
+	// interfaces; the primary interface is named by the CNI args.
+	ifName string
+	// podAnnotations are the pod network annotations passed through by CNS.
+	podAnnotations map[string]string
+	// mtu is the interface MTU of the network container, 0 if CNS did not set one.
+	mtu int
 }
@@ -33,6 +33,8 @@ type CNSIPAMInvoker struct {
 	cnsClient     cnsclient
 	executionMode util.ExecutionMode
 	ipamMode      util.IpamMode
+	// ctx carries the trace of the CNI command to CNS.
+	ctx context.Context
 }
 
 type IPResultInfo struct {
@@ -45,16 +47,25 @@ type IPResultInfo struct {
 	hostGateway        string
 }
 
-func NewCNSInvoker(podName, namespace string, cnsClient cnsclient, executionMode util.ExecutionMode, ipamMode util.IpamMode) *CNSIPAMInvoker {
+func NewCNSInvoker(ctx context.Context, podName, namespace string, cnsClient cnsclient, executionMode util.ExecutionMode, ipamMode util.IpamMode) *CNSIPAMInvoker {
 	return &CNSIPAMInvoker{
 		podName:       podName,
 		podNamespace:  namespace,
 		cnsClient:     cnsClient,
 		executionMode: executionMode,
 		ipamMode:      ipamMode,
+		ctx:           ctx,
 	}
 }
 
+// requestContext returns the context of requests to CNS.
+func (invoker *CNSIPAMInvoker) requestContext() context.Context {
+	if invoker.ctx == nil {
+		return context.TODO()
+	}
+	return invoker.ctx
+}
+
 // Add uses the requestipconfig API in cns, and returns ipv4 and a nil ipv6 as CNS doesn't support IPv6 yet
 func (invoker *CNSIPAMInvoker) Add(addConfig IPAMAddConfig) (IPAMAddResult, error) {
 	// Parse Pod arguments.
@@ -82,7 +93,7 @@ func (invoker *CNSIPAMInvoker) Add(addConfig IPAMAddConfig) (IPAMAddResult, erro
 	log.Logger.Info("Requesting IP for pod using ipconfig",
 		zap.Any("pod", podInfo),
 		zap.Any("ipconfig", ipconfigs))
-	response, err := invoker.cnsClient.RequestIPs(context.TODO(), ipconfigs)
+	response, err := invoker.cnsClient.RequestIPs(invoker.requestContext(), ipconfigs)
 	if err != nil {
 		if cnscli.IsUnsupportedAPI(err) {
 			// If RequestIPs is not supported by CNS, use RequestIPAddress API
@@ -94,7 +105,7 @@ func (invoker *CNSIPAMInvoker) Add(addConfig IPAMAddConfig) (IPAMAddResult, erro
 				InfraContainerID:    addConfig.args.ContainerID,
 			}
 
-			res, errRequestIP := invoker.cnsClient.RequestIPAddress(context.TODO(), ipconfig)
+			res, errRequestIP := invoker.cnsClient.RequestIPAddress(invoker.requestContext(), ipconfig)
 			if errRequestIP != nil {
 				// if the old API fails as well then we just return the error
 				log.Logger.Error("Failed to request IP address from CNS using RequestIPAddress",
@@ -116,7 +127,7 @@ func (invoker *CNSIPAMInvoker) Add(addConfig IPAMAddConfig) (IPAMAddResult, erro
 		}
 	}
 
-	addResult := IPAMAddResult{}
+	addResult := IPAMAddResult{podAnnotations: response.PodAnnotations}
 
 	for i := 0; i < len(response.PodIPInfo); i++ {
 		info := IPResultInfo{
@@ -129,6 +140,10 @@ func (invoker *CNSIPAMInvoker) Add(addConfig IPAMAddConfig) (IPAMAddResult, erro
 			hostGateway:        response.PodIPInfo[i].HostPrimaryIPInfo.Gateway,
 		}
 
+		if response.PodIPInfo[i].MTU > 0 {
+			addResult.mtu = response.PodIPInfo[i].MTU
+		}
+
 		// set the NC Primary IP in options
 		// SNATIPKey is not set for ipv6
 		if net.ParseIP(info.ncPrimaryIP).To4() != nil {
@@ -306,7 +321,7 @@ func (invoker *CNSIPAMInvoker) Delete(address *net.IPNet, nwCfg *cni.NetworkConf
 		log.Logger.Info("CNS invoker called with empty IP address")
 	}
 
-	if err := invoker.cnsClient.ReleaseIPs(context.TODO(), ipConfigs); err != nil {
+	if err := invoker.cnsClient.ReleaseIPs(invoker.requestContext(), ipConfigs); err != nil {
 		if cnscli.IsUnsupportedAPI(err) {
 			// If ReleaseIPs is not supported by CNS, use ReleaseIPAddress API
 			log.Logger.Error("ReleaseIPs not supported by CNS. Invoking ReleaseIPAddress API",
@@ -318,7 +333,7 @@ func (invoker *CNSIPAMInvoker) Delete(address *net.IPNet, nwCfg *cni.NetworkConf
 				InfraContainerID:    args.ContainerID,
 			}
 
-			if err = invoker.cnsClient.ReleaseIPAddress(context.TODO(), ipConfig); err != nil {
+			if err = invoker.cnsClient.ReleaseIPAddress(invoker.requestContext(), ipConfig); err != nil {
 				// if the old API fails as well then we just return the error
 				log.Logger.Error("Failed to release IP address from CNS using ReleaseIPAddress ",
 					zap.String("infracontainerid", ipConfigs.InfraContainerID),
@@ -0,0 +1,125 @@
+package network
+
+import (
+	"net"
+	"runtime/debug"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cni/log"
+	cniSkel "github.com/containernetworking/cni/pkg/skel"
+	cniTypesCurr "github.com/containernetworking/cni/pkg/types/100"
+	"go.uber.org/zap"
+)
+
+const (
+	// azureVnetIpam is the name of the azure-vnet-ipam plugin binary.
+	azureVnetIpam = "azure-vnet-ipam"
+)
+
+// DelegatedIPAMInvoker calls an arbitrary CNI IPAM plugin (host-local, whereabouts, ...) through
+// the CNI delegation mechanism. The plugin binary is the one named by IPAM.Type in the netconf.
+// Unlike the azure-vnet-ipam invoker, a single delegated call returns both the IPv4 and the IPv6
+// addresses, which are split into the per-family results expected by the caller.
+type DelegatedIPAMInvoker struct {
+	plugin delegatePlugin
+}
+
+// NewDelegatedIPAMInvoker creates a delegated IPAM invoker for the current CNI action.
+func NewDelegatedIPAMInvoker(plugin delegatePlugin) *DelegatedIPAMInvoker {
+	return &DelegatedIPAMInvoker{
+		plugin: plugin,
+	}
+}
+
+func (invoker *DelegatedIPAMInvoker) Add(addConfig IPAMAddConfig) (IPAMAddResult, error) {
+	addResult := IPAMAddResult{}
+
+	if addConfig.nwCfg == nil {
+		return addResult, invoker.plugin.Errorf("nil nwCfg passed to CNI ADD, stack: %+v", string(debug.Stack()))
+	}
+
+	result, err := invoker.plugin.DelegateAdd(addConfig.nwCfg.IPAM.Type, addConfig.nwCfg)
+	if err != nil {
+		return addResult, invoker.plugin.Errorf("Failed to allocate address from %s: %v", addConfig.nwCfg.IPAM.Type, err)
+	}
+
+	addResult.ipv4Result, addResult.ipv6Result = splitResultByFamily(result)
+
+	if addResult.ipv4Result == nil {
+		// the endpoint setup requires an IPv4 address, so release whatever the plugin handed out
+		if er := invoker.Delete(nil, addConfig.nwCfg, addConfig.args, addConfig.options); er != nil {
+			log.Logger.Error("Failed to release addresses after IPAM returned no IPv4 address", zap.Error(er))
+		}
+		return IPAMAddResult{}, invoker.plugin.Errorf("IPAM plugin %s returned no IPv4 address: %+v", addConfig.nwCfg.IPAM.Type, result)
+	}
+
+	addResult.hostSubnetPrefix = addResult.ipv4Result.IPs[0].Address
+
+	return addResult, nil
+}
+
+// Delete releases the addresses held by the container. CNI IPAM plugins release by container ID rather than
+// by address, so the address is only used for logging and a repeated call for the second family is a no-op
+// per the CNI spec.
+func (invoker *DelegatedIPAMInvoker) Delete(address *net.IPNet, nwCfg *cni.NetworkConfig, _ *cniSkel.CmdArgs, _ map[string]interface{}) error {
+	if nwCfg == nil {
+		return invoker.plugin.Errorf("nil nwCfg passed to CNI DEL, stack: %+v", string(debug.Stack()))
+	}
+
+	if address != nil {
+		nwCfg.IPAM.Address = address.IP.String()
+	}
+
+	log.Logger.Info("Releasing address through delegated IPAM",
+		zap.String("plugin", nwCfg.IPAM.Type),
+		zap.String("address", nwCfg.IPAM.Address))
+
+	if err := invoker.plugin.DelegateDel(nwCfg.IPAM.Type, nwCfg); err != nil {
+		return invoker.plugin.Errorf("Failed to release address from %s: %v", nwCfg.IPAM.Type, err)
+	}
+
+	return nil
+}
+
+// splitResultByFamily splits a dual-stack CNI result into an IPv4 and an IPv6 result. Routes are assigned
+// by the family of their destination and DNS settings are kept on the IPv4 result, which is the one used
+// for network DNS configuration. Either return value is nil if the result has no address of that family.
+func splitResultByFamily(result *cniTypesCurr.Result) (ipv4Result, ipv6Result *cniTypesCurr.Result) {
+	if result == nil {
+		return nil, nil
+	}
+
+	v4 := &cniTypesCurr.Result{CNIVersion: result.CNIVersion, DNS: result.DNS}
+	v6 := &cniTypesCurr.Result{CNIVersion: result.CNIVersion}
+
+	for _, ipConfig := range result.IPs {
+		if ipConfig == nil {
+			continue
+		}
+		if ipConfig.Address.IP.To4() != nil {
+			v4.IPs = append(v4.IPs, ipConfig)
+		} else {
+			v6.IPs = append(v6.IPs, ipConfig)
+		}
+	}
+
+	for _, route := range result.Routes {
+		if route == nil {
+			continue
+		}
+		if route.Dst.IP.To4() != nil {
+			v4.Routes = append(v4.Routes, route)
+		} else {
+			v6.Routes = append(v6.Routes, route)
+		}
+	}
+
+	if len(v4.IPs) > 0 {
+		ipv4Result = v4
+	}
+	if len(v6.IPs) > 0 {
+		ipv6Result = v6
+	}
+
+	return ipv4Result, ipv6Result
+}
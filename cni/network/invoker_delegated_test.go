@@ -0,0 +1,159 @@
+package network
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cni"
+	cniTypes "github.com/containernetworking/cni/pkg/types"
+	cniTypesCurr "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/stretchr/testify/require"
+)
+
+func getDualStackResult(ipv4, ipv6 string) []*cniTypesCurr.Result {
+	res := &cniTypesCurr.Result{
+		CNIVersion: "1.0.0",
+		DNS:        cniTypes.DNS{Nameservers: []string{"10.0.0.10"}},
+	}
+	if ipv4 != "" {
+		res.IPs = append(res.IPs, &cniTypesCurr.IPConfig{Address: *getCIDRNotationForAddress(ipv4)})
+		res.Routes = append(res.Routes, &cniTypes.Route{Dst: *getCIDRNotationForAddress("0.0.0.0/0")})
+	}
+	if ipv6 != "" {
+		res.IPs = append(res.IPs, &cniTypesCurr.IPConfig{Address: *getCIDRNotationForAddress(ipv6)})
+		res.Routes = append(res.Routes, &cniTypes.Route{Dst: *getCIDRNotationForAddress("::/0")})
+	}
+	return []*cniTypesCurr.Result{res}
+}
+
+func TestDelegatedIPAMInvoker_Add(t *testing.T) {
+	tests := []struct {
+		name       string
+		plugin     delegatePlugin
+		nwCfg      *cni.NetworkConfig
+		wantV4     string
+		wantV6     string
+		wantRoutes int
+		wantErr    bool
+	}{
+		{
+			name: "ipv4 only",
+			plugin: &mockDelegatePlugin{
+				add: add{resultsIPv4: getDualStackResult("10.0.0.5/24", "")},
+			},
+			nwCfg:      &cni.NetworkConfig{IPAM: cni.IPAM{Type: "host-local"}},
+			wantV4:     "10.0.0.5/24",
+			wantRoutes: 1,
+		},
+		{
+			name: "dual stack result is split by family",
+			plugin: &mockDelegatePlugin{
+				add: add{resultsIPv4: getDualStackResult("10.0.0.5/24", "2001:db8::5/64")},
+			},
+			nwCfg:      &cni.NetworkConfig{IPAM: cni.IPAM{Type: "host-local"}},
+			wantV4:     "10.0.0.5/24",
+			wantV6:     "2001:db8::5/64",
+			wantRoutes: 1,
+		},
+		{
+			name: "ipv6 only result is rejected",
+			plugin: &mockDelegatePlugin{
+				add: add{resultsIPv4: getDualStackResult("", "2001:db8::5/64")},
+			},
+			nwCfg:   &cni.NetworkConfig{IPAM: cni.IPAM{Type: "host-local"}},
+			wantErr: true,
+		},
+		{
+			name: "delegate add fails",
+			plugin: &mockDelegatePlugin{
+				add: add{errv4: errors.New("no addresses")}, //nolint:goerr113
+			},
+			nwCfg:   &cni.NetworkConfig{IPAM: cni.IPAM{Type: "whereabouts"}},
+			wantErr: true,
+		},
+		{
+			name:    "nil nwCfg",
+			plugin:  &mockDelegatePlugin{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			invoker := NewDelegatedIPAMInvoker(tt.plugin)
+			res, err := invoker.Add(IPAMAddConfig{nwCfg: tt.nwCfg})
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.NotNil(t, res.ipv4Result)
+			require.Equal(t, tt.wantV4, res.ipv4Result.IPs[0].Address.String())
+			require.Len(t, res.ipv4Result.Routes, tt.wantRoutes)
+			require.Equal(t, []string{"10.0.0.10"}, res.ipv4Result.DNS.Nameservers)
+			require.Equal(t, tt.wantV4, res.hostSubnetPrefix.String())
+
+			if tt.wantV6 == "" {
+				require.Nil(t, res.ipv6Result)
+				return
+			}
+			require.NotNil(t, res.ipv6Result)
+			require.Equal(t, tt.wantV6, res.ipv6Result.IPs[0].Address.String())
+			require.Len(t, res.ipv6Result.Routes, tt.wantRoutes)
+		})
+	}
+}
+
+func TestDelegatedIPAMInvoker_Delete(t *testing.T) {
+	tests := []struct {
+		name    string
+		plugin  delegatePlugin
+		address *net.IPNet
+		nwCfg   *cni.NetworkConfig
+		wantErr bool
+	}{
+		{
+			name:    "delete with address",
+			plugin:  &mockDelegatePlugin{},
+			address: getCIDRNotationForAddress("10.0.0.5/24"),
+			nwCfg:   &cni.NetworkConfig{IPAM: cni.IPAM{Type: "host-local"}},
+		},
+		{
+			name:   "delete without address",
+			plugin: &mockDelegatePlugin{},
+			nwCfg:  &cni.NetworkConfig{IPAM: cni.IPAM{Type: "host-local"}},
+		},
+		{
+			name: "delegate del fails",
+			plugin: &mockDelegatePlugin{
+				del: del{err: errors.New("release failed")}, //nolint:goerr113
+			},
+			nwCfg:   &cni.NetworkConfig{IPAM: cni.IPAM{Type: "host-local"}},
+			wantErr: true,
+		},
+		{
+			name:    "nil nwCfg",
+			plugin:  &mockDelegatePlugin{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			invoker := NewDelegatedIPAMInvoker(tt.plugin)
+			err := invoker.Delete(tt.address, tt.nwCfg, nil, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.address != nil {
+				require.Equal(t, tt.address.IP.String(), tt.nwCfg.IPAM.Address)
+			}
+		})
+	}
+}
@@ -229,6 +229,8 @@ func (m *Multitenancy) GetAllNetworkContainers(
 		ipamResults[i].ncResponse = &ncResponses[i]
 		ipamResults[i].hostSubnetPrefix = hostSubnetPrefixes[i]
 		ipamResults[i].ipv4Result = convertToCniResult(ipamResults[i].ncResponse, ifName)
+		ipamResults[i].podAnnotations = ncResponses[i].PodAnnotations
+		ipamResults[i].mtu = ncResponses[i].MTU
 	}
 
 	return ipamResults, err
@@ -77,6 +77,9 @@ type NetPlugin struct {
 	tb                 *telemetry.TelemetryBuffer
 	nnsClient          NnsClient
 	multitenancyClient MultitenancyClient
+
+	// secondaryIpamInvokers holds the IPAM invokers of secondary interfaces, keyed by interface name.
+	secondaryIpamInvokers map[string]IPAMInvoker
 }
 
 type PolicyArgs struct {
@@ -326,6 +329,7 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 	var (
 		ipamAddResult    IPAMAddResult
 		ipamAddResults   []IPAMAddResult
+		interfaceResults []IPAMAddResult
 		azIpamResult     *cniTypesCurr.Result
 		enableInfraVnet  bool
 		enableSnatForDNS bool
@@ -352,6 +356,9 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 		return err
 	}
 
+	ctx, endSpan := plugin.startSpan(nwCfg, "cni.Add", args)
+	defer func() { endSpan(err) }()
+
 	iptables.DisableIPTableLock = nwCfg.DisableIPTableLock
 	plugin.setCNIReportDetails(nwCfg, CNI_ADD, "")
 
@@ -367,17 +374,22 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 		telemetry.SendCNIMetric(&cniMetric, plugin.tb)
 
 		// Add Interfaces to result.
-		if ipamAddResult.ipv4Result == nil {
-			ipamAddResult.ipv4Result = &cniTypesCurr.Result{}
-		}
+		if hasSecondaryInterfaces(interfaceResults) {
+			// list every interface of a multi-interface pod in the result
+			ipamAddResult.ipv4Result = combineInterfaceResults(args.IfName, interfaceResults)
+		} else {
+			if ipamAddResult.ipv4Result == nil {
+				ipamAddResult.ipv4Result = &cniTypesCurr.Result{}
+			}
 
-		iface := &cniTypesCurr.Interface{
-			Name: args.IfName,
-		}
-		ipamAddResult.ipv4Result.Interfaces = append(ipamAddResult.ipv4Result.Interfaces, iface)
+			iface := &cniTypesCurr.Interface{
+				Name: args.IfName,
+			}
+			ipamAddResult.ipv4Result.Interfaces = append(ipamAddResult.ipv4Result.Interfaces, iface)
 
-		if ipamAddResult.ipv6Result != nil {
-			ipamAddResult.ipv4Result.IPs = append(ipamAddResult.ipv4Result.IPs, ipamAddResult.ipv6Result.IPs...)
+			if ipamAddResult.ipv6Result != nil {
+				ipamAddResult.ipv4Result.IPs = append(ipamAddResult.ipv4Result.IPs, ipamAddResult.ipv6Result.IPs...)
+			}
 		}
 
 		addSnatInterface(nwCfg, ipamAddResult.ipv4Result)
@@ -422,6 +434,10 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 		return plugin.Errorf(errMsg)
 	}
 
+	if err = validateSecondaryInterfaces(nwCfg, k8sIfName); err != nil {
+		return plugin.Errorf("Invalid secondary interfaces in network configuration: %v", err)
+	}
+
 	platformInit(nwCfg)
 	if nwCfg.ExecutionMode == string(util.Baremetal) {
 		var res *nnscontracts.ConfigureContainerNetworkingResponse
@@ -460,7 +476,7 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 			return fmt.Errorf("%w", err)
 		}
 
-		ipamAddResults, err = plugin.multitenancyClient.GetAllNetworkContainers(context.TODO(), nwCfg, k8sPodName, k8sNamespace, args.IfName)
+		ipamAddResults, err = plugin.multitenancyClient.GetAllNetworkContainers(ctx, nwCfg, k8sPodName, k8sNamespace, args.IfName)
 		if err != nil {
 			err = fmt.Errorf("GetAllNetworkContainers failed for podname %s namespace %s. error: %w", k8sPodName, k8sNamespace, err)
 			log.Logger.Error("GetAllNetworkContainers failed",
@@ -471,10 +487,13 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 		}
 
 		if len(ipamAddResults) > 1 && !plugin.isDualNicFeatureSupported(args.Netns) {
-			errMsg := fmt.Sprintf("received multiple NC results %+v from CNS while dualnic feature is not supported", ipamAddResults)
-			log.Logger.Error("received multiple NC results from CNS while dualnic feature is not supported",
-				zap.Any("results", ipamAddResult))
-			return plugin.Errorf(errMsg)
+			// without dual nic support, additional NCs become secondary interfaces named by the netconf
+			if err = assignSecondaryInterfaceNames(nwCfg, ipamAddResults); err != nil {
+				errMsg := fmt.Sprintf("received multiple NC results %+v from CNS while dualnic feature is not supported: %v", ipamAddResults, err)
+				log.Logger.Error("received multiple NC results from CNS while dualnic feature is not supported",
+					zap.Any("results", ipamAddResult))
+				return plugin.Errorf(errMsg)
+			}
 		}
 	} else {
 		// TODO: refactor this code for simplification
@@ -483,15 +502,28 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 		ipamAddResults = append(ipamAddResults, ipamAddResult)
 	}
 
+	// record the secondary interfaces of the container for DEL, and delete them if ADD fails
+	var secondaries []secondaryInterface
+	recordSecondary := func(iface secondaryInterface) error {
+		secondaries = append(secondaries, iface)
+		return plugin.writeSecondaryInterfaces(args.ContainerID, secondaries)
+	}
+	defer func() {
+		if err != nil && len(secondaries) > 0 {
+			plugin.rollbackSecondaryInterfaces(args, nwCfg, secondaries)
+		}
+	}()
+
 	// iterate ipamAddResults and program the endpoint
 	for i := 0; i < len(ipamAddResults); i++ {
 		var networkID string
 		ipamAddResult = ipamAddResults[i]
+		ifArgs := argsForInterface(args, ipamAddResult.ifName)
 
 		options := make(map[string]any)
 		networkID, err = plugin.getNetworkName(args.Netns, &ipamAddResult, nwCfg)
 
-		endpointID := GetEndpointID(args)
+		endpointID := GetEndpointID(ifArgs)
 		policies := cni.GetPoliciesFromNwCfg(nwCfg.AdditionalArgs)
 
 		// Check whether the network already exists.
@@ -517,18 +549,28 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 
 			if resultSecondAdd != nil {
 				ipamAddResult.ipv4Result = resultSecondAdd
-				return nil
+				if ipamAddResult.ifName != "" {
+					iface := secondaryInterface{Interface: cni.InterfaceConfig{IfName: ipamAddResult.ifName}, NetworkID: networkID, EndpointID: endpointID}
+					if err = recordSecondary(iface); err != nil {
+						return err
+					}
+				}
+				interfaceResults = append(interfaceResults, ipamAddResult)
+				continue
 			}
 		}
 
-		// Initialize azureipam/cns ipam
+		// Initialize azureipam/cns/delegated ipam
 		if plugin.ipamInvoker == nil {
 			switch nwCfg.IPAM.Type {
 			case network.AzureCNS:
-				plugin.ipamInvoker = NewCNSInvoker(k8sPodName, k8sNamespace, cnsClient, util.ExecutionMode(nwCfg.ExecutionMode), util.IpamMode(nwCfg.IPAM.Mode))
+				plugin.ipamInvoker = NewCNSInvoker(ctx, k8sPodName, k8sNamespace, cnsClient, util.ExecutionMode(nwCfg.ExecutionMode), util.IpamMode(nwCfg.IPAM.Mode))
 
-			default:
+			case azureVnetIpam, ipamV6:
 				plugin.ipamInvoker = NewAzureIpamInvoker(plugin, &nwInfo)
+
+			default:
+				plugin.ipamInvoker = NewDelegatedIPAMInvoker(plugin)
 			}
 		}
 
@@ -571,7 +613,7 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 			result:           ipamAddResult.ipv4Result,
 			resultV6:         ipamAddResult.ipv6Result,
 			azIpamResult:     azIpamResult,
-			args:             args,
+			args:             ifArgs,
 			nwInfo:           &nwInfo,
 			policies:         policies,
 			endpointID:       endpointID,
@@ -580,6 +622,8 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 			enableInfraVnet:  enableInfraVnet,
 			enableSnatForDNS: enableSnatForDNS,
 			natInfo:          natInfo,
+			podAnnotations:   ipamAddResult.podAnnotations,
+			mtu:              ipamAddResult.mtu,
 		}
 
 		var epInfo network.EndpointInfo
@@ -589,8 +633,38 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 			return err
 		}
 
+		if ipamAddResult.ifName != "" {
+			// the addresses of network container interfaces are released with the primary interface
+			iface := secondaryInterface{Interface: cni.InterfaceConfig{IfName: ipamAddResult.ifName}, NetworkID: networkID, EndpointID: endpointID}
+			if err = recordSecondary(iface); err != nil {
+				return err
+			}
+		}
+
 		sendEvent(plugin, fmt.Sprintf("CNI ADD succeeded : IP:%+v, VlanID: %v, podname %v, namespace %v numendpoints:%d",
 			ipamAddResult.ipv4Result.IPs, epInfo.Data[network.VlanIDKey], k8sPodName, k8sNamespace, plugin.nm.GetNumberOfEndpoints("", nwCfg.Name)))
+		interfaceResults = append(interfaceResults, ipamAddResult)
+	}
+
+	if !nwCfg.MultiTenancy {
+		// secondary interfaces get their addresses from their own IPAM and live in their own network
+		for _, ifCfg := range nwCfg.SecondaryInterfaces {
+			var (
+				secondaryResult IPAMAddResult
+				iface           secondaryInterface
+			)
+			secondaryResult, iface, err = plugin.addSecondaryInterface(args, nwCfg, ifCfg, k8sPodName, k8sNamespace)
+			if err != nil {
+				return err
+			}
+			if err = recordSecondary(iface); err != nil {
+				return err
+			}
+
+			sendEvent(plugin, fmt.Sprintf("CNI ADD succeeded for secondary interface %s: IP:%+v, podname %v, namespace %v",
+				ifCfg.IfName, secondaryResult.ipv4Result.IPs, k8sPodName, k8sNamespace))
+			interfaceResults = append(interfaceResults, secondaryResult)
+		}
 	}
 
 	return nil
@@ -664,6 +738,7 @@ func (plugin *NetPlugin) createNetworkInternal(
 		IPAMType:                      ipamAddConfig.nwCfg.IPAM.Type,
 		ServiceCidrs:                  ipamAddConfig.nwCfg.ServiceCidrs,
 		IsIPv6Enabled:                 ipamAddResult.ipv6Result != nil,
+		MTU:                           ipamAddConfig.nwCfg.MTU,
 	}
 
 	if err = addSubnetToNetworkInfo(ipamAddResult, &nwInfo); err != nil {
@@ -733,11 +808,19 @@ type createEndpointInternalOpt struct {
 	enableInfraVnet  bool
 	enableSnatForDNS bool
 	natInfo          []policy.NATInfo
+	podAnnotations   map[string]string
+	mtu              int
 }
 
 func (plugin *NetPlugin) createEndpointInternal(opt *createEndpointInternalOpt) (network.EndpointInfo, error) {
 	epInfo := network.EndpointInfo{}
 
+	podNetworkCfg, err := parsePodNetworkAnnotations(opt.podAnnotations)
+	if err != nil {
+		err = plugin.Errorf("Failed to parse pod network annotations: %v", err)
+		return epInfo, err
+	}
+
 	epDNSInfo, err := getEndpointDNSSettings(opt.nwCfg, opt.result, opt.k8sNamespace)
 	if err != nil {
 		err = plugin.Errorf("Failed to getEndpointDNSSettings: %v", err)
@@ -784,6 +867,7 @@ func (plugin *NetPlugin) createEndpointInternal(opt *createEndpointInternalOpt)
 		VnetCidrs:          opt.nwCfg.VnetCidrs,
 		ServiceCidrs:       opt.nwCfg.ServiceCidrs,
 		NATInfo:            opt.natInfo,
+		MTU:                opt.mtu,
 	}
 
 	isIPv6Enabled := opt.resultV6 != nil
@@ -816,6 +900,10 @@ func (plugin *NetPlugin) createEndpointInternal(opt *createEndpointInternalOpt)
 		plugin.multitenancyClient.SetupRoutingForMultitenancy(opt.nwCfg, opt.cnsNetworkConfig, opt.azIpamResult, &epInfo, opt.result)
 	}
 
+	// Apply per pod routes and DNS settings on top of the ones from the netconf and IPAM.
+	applyPodNetworkConfig(podNetworkCfg, &epInfo)
+	applyPodNetworkConfigToResult(podNetworkCfg, opt.result)
+
 	setEndpointOptions(opt.cnsNetworkConfig, &epInfo, vethName)
 
 	cnsclient, err := cnscli.New(opt.nwCfg.CNSUrl, defaultRequestTimeout)
@@ -968,6 +1056,9 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 		return err
 	}
 
+	ctx, endSpan := plugin.startSpan(nwCfg, "cni.Delete", args)
+	defer func() { endSpan(err) }()
+
 	// Parse Pod arguments.
 	if k8sPodName, k8sNamespace, err = plugin.getPodInfo(args.Args); err != nil {
 		log.Logger.Error("[cni-net] Failed to get POD info", zap.Error(err))
@@ -1008,15 +1099,26 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 	if plugin.ipamInvoker == nil {
 		switch nwCfg.IPAM.Type {
 		case network.AzureCNS:
-			cnsClient, cnsErr := cnscli.New("", defaultRequestTimeout)
+			cnsClient, cnsErr := cnscli.New(nwCfg.CNSUrl, defaultRequestTimeout)
 			if cnsErr != nil {
 				log.Logger.Error("[cni-net] failed to create cns client", zap.Error(cnsErr))
 				return errors.Wrap(cnsErr, "failed to create cns client")
 			}
-			plugin.ipamInvoker = NewCNSInvoker(k8sPodName, k8sNamespace, cnsClient, util.ExecutionMode(nwCfg.ExecutionMode), util.IpamMode(nwCfg.IPAM.Mode))
+			plugin.ipamInvoker = NewCNSInvoker(ctx, k8sPodName, k8sNamespace, cnsClient, util.ExecutionMode(nwCfg.ExecutionMode), util.IpamMode(nwCfg.IPAM.Mode))
 
-		default:
+		case azureVnetIpam, ipamV6:
 			plugin.ipamInvoker = NewAzureIpamInvoker(plugin, &nwInfo)
+
+		default:
+			plugin.ipamInvoker = NewDelegatedIPAMInvoker(plugin)
+		}
+	}
+
+	// Delete the secondary interfaces first, in the reverse order of ADD. In multitenancy mode they
+	// only exist when the NCs could not be attached through the dual nic feature.
+	if !nwCfg.MultiTenancy || !plugin.isDualNicFeatureSupported(args.Netns) {
+		if err = plugin.deleteSecondaryInterfaces(args, nwCfg); err != nil {
+			return plugin.RetriableError(fmt.Errorf("failed to delete secondary interfaces: %w", err))
 		}
 	}
 
@@ -1292,6 +1394,17 @@ func (plugin *NetPlugin) Update(args *cniSkel.CmdArgs) error {
 		log.Logger.Info("Successfully added route from cnetAddressspace to targetEpInfo", zap.Any("subnet", ipRouteSubnet))
 	}
 
+	podNetworkCfg, err := parsePodNetworkAnnotations(targetNetworkConfig.PodAnnotations)
+	if err != nil {
+		return plugin.Errorf("Failed to parse pod network annotations: %v", err)
+	}
+	if podNetworkCfg != nil {
+		for i := range podNetworkCfg.Routes {
+			podNetworkCfg.Routes[i].DevName = existingEpInfo.IfName
+		}
+		targetEpInfo.Routes = mergeRoutes(targetEpInfo.Routes, podNetworkCfg.Routes)
+	}
+
 	log.Logger.Info("Finished collecting new routes in targetEpInfo", zap.Any("route", targetEpInfo.Routes))
 	log.Logger.Info("Now saving existing infravnetaddress space if needed.")
 	for _, ns := range nwCfg.PodNamespaceForDualNetwork {
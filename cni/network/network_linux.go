@@ -1,17 +1,22 @@
 package network
 
 import (
+	"context"
 	"net"
 	"strconv"
 
 	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cni/api"
 	"github.com/Azure/azure-container-networking/cni/log"
 	"github.com/Azure/azure-container-networking/cns"
+	cnscli "github.com/Azure/azure-container-networking/cns/client"
+	"github.com/Azure/azure-container-networking/netlink"
 	"github.com/Azure/azure-container-networking/network"
 	"github.com/Azure/azure-container-networking/network/policy"
 	cniSkel "github.com/containernetworking/cni/pkg/skel"
 	cniTypes "github.com/containernetworking/cni/pkg/types"
 	cniTypesCurr "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/pkg/errors"
 )
 
 const (
@@ -55,6 +60,7 @@ func setNetworkOptions(cnsNwConfig *cns.GetNetworkContainerResponse, nwInfo *net
 		log.Logger.Info("Setting Network Options")
 		vlanMap := make(map[string]interface{})
 		vlanMap[network.VlanIDKey] = strconv.Itoa(cnsNwConfig.MultiTenancyInfo.ID)
+		vlanMap[network.EncapTypeKey] = cnsNwConfig.MultiTenancyInfo.EncapType
 		vlanMap[network.SnatBridgeIPKey] = cnsNwConfig.LocalIPConfiguration.GatewayIPAddress + "/" + strconv.Itoa(int(cnsNwConfig.LocalIPConfiguration.IPSubnet.PrefixLength))
 		nwInfo.Options[dockerNetworkOption] = vlanMap
 	}
@@ -150,3 +156,49 @@ func (plugin *NetPlugin) isDualNicFeatureSupported(netNs string) bool {
 func getOverlayGateway(_ *net.IPNet) (net.IP, error) {
 	return net.ParseIP("169.254.1.1"), nil
 }
+
+// ReconcileTransparentVlan removes the transparent vlan namespaces, links and rules that are used neither by an
+// endpoint in the CNI state nor by a network container in CNS, or only reports them on a dry run. CNS is reached as
+// the network configuration says, as on an ADD. The plugin must be started, and so hold the CNI lock.
+func (plugin *NetPlugin) ReconcileTransparentVlan(ctx context.Context, nwCfg *cni.NetworkConfig, dryRun bool) (*api.TransparentVlanReconcileReport, error) {
+	cnsClient, err := cnscli.New(nwCfg.CNSUrl, defaultRequestTimeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cns client")
+	}
+
+	ncs := network.NCVlanListerFunc(func(ctx context.Context) ([]int, error) {
+		resp, err := cnsClient.GetAllNCsFromCns(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get network containers")
+		}
+		vlans := make([]int, 0, len(resp.NetworkContainers))
+		for i := range resp.NetworkContainers {
+			if vlanID := resp.NetworkContainers[i].MultiTenancyInfo.ID; vlanID != 0 {
+				vlans = append(vlans, vlanID)
+			}
+		}
+		return vlans, nil
+	})
+
+	reconciler, err := network.NewTransparentVlanReconciler(plugin.nm, ncs, "", netlink.NewNetlink())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create transparent vlan reconciler")
+	}
+
+	report, err := reconciler.Reconcile(ctx, dryRun)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reconcile transparent vlan resources")
+	}
+
+	out := &api.TransparentVlanReconcileReport{
+		Namespaces: report.Namespaces,
+		VlanLinks:  report.VlanLinks,
+		Veths:      report.Veths,
+		Rules:      report.Rules,
+	}
+	for _, e := range report.Errors {
+		out.Errors = append(out.Errors, e.Error())
+	}
+
+	return out, nil
+}
@@ -15,8 +15,11 @@ import (
 	"github.com/Azure/azure-container-networking/network/networkutils"
 	"github.com/Azure/azure-container-networking/network/policy"
 	"github.com/Azure/azure-container-networking/nns"
+	"github.com/Azure/azure-container-networking/store"
 	"github.com/Azure/azure-container-networking/telemetry"
 	cniSkel "github.com/containernetworking/cni/pkg/skel"
+	cniTypes "github.com/containernetworking/cni/pkg/types"
+	cniTypesCurr "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -74,6 +77,7 @@ func GetTestResources() *NetPlugin {
 	config := &common.PluginConfig{}
 	grpcClient := &nns.MockGrpcClient{}
 	plugin, _ := NewPlugin(pluginName, config, grpcClient, &Multitenancy{})
+	plugin.Store = store.NewMockStore("")
 	plugin.report = &telemetry.CNIReport{}
 	mockNetworkManager := acnnetwork.NewMockNetworkmanager()
 	plugin.nm = mockNetworkManager
@@ -500,6 +504,166 @@ func TestAddDualStack(t *testing.T) {
 	args.StdinData = nwCfg.Serialize()
 }
 
+// Test add and delete of a pod with secondary interfaces
+func TestPluginSecondaryInterfaces(t *testing.T) {
+	secondaryNwCfg := nwCfg
+	secondaryNwCfg.SecondaryInterfaces = []cni.InterfaceConfig{
+		{
+			IfName: "eth1",
+			Master: eth0IfName,
+			IPAM:   cni.IPAM{Type: "host-local"},
+		},
+	}
+	twoSecondariesNwCfg := secondaryNwCfg
+	twoSecondariesNwCfg.SecondaryInterfaces = append([]cni.InterfaceConfig{}, secondaryNwCfg.SecondaryInterfaces...)
+	twoSecondariesNwCfg.SecondaryInterfaces = append(twoSecondariesNwCfg.SecondaryInterfaces,
+		cni.InterfaceConfig{IfName: "eth2", Master: eth0IfName, IPAM: cni.IPAM{Type: "host-local"}})
+
+	tests := []struct {
+		name        string
+		nwCfg       cni.NetworkConfig
+		delNwCfg    *cni.NetworkConfig
+		consecutive bool
+		failIfName  string
+		wantErr     bool
+	}{
+		{
+			name:  "secondary interface happy path",
+			nwCfg: secondaryNwCfg,
+		},
+		{
+			name:     "secondary interface deleted after the netconf changed",
+			nwCfg:    secondaryNwCfg,
+			delNwCfg: &nwCfg,
+		},
+		{
+			name:        "secondary interface of consecutive add",
+			nwCfg:       secondaryNwCfg,
+			consecutive: true,
+		},
+		{
+			name:       "earlier secondary interfaces cleaned up when a later one fails",
+			nwCfg:      twoSecondariesNwCfg,
+			failIfName: "eth2",
+			wantErr:    true,
+		},
+		{
+			name: "secondary interface with duplicate name",
+			nwCfg: func() cni.NetworkConfig {
+				cfg := secondaryNwCfg
+				cfg.SecondaryInterfaces = []cni.InterfaceConfig{{IfName: eth0IfName, IPAM: cni.IPAM{Type: "host-local"}}}
+				return cfg
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "secondary interface from cns",
+			nwCfg: func() cni.NetworkConfig {
+				cfg := secondaryNwCfg
+				cfg.SecondaryInterfaces = []cni.InterfaceConfig{{IfName: "eth1", IPAM: cni.IPAM{Type: acnnetwork.AzureCNS}}}
+				return cfg
+			}(),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := GetTestResources()
+			plugin.secondaryIpamInvokers = map[string]IPAMInvoker{
+				"eth1": NewMockIpamInvoker(false, false, false),
+				"eth2": NewMockIpamInvoker(false, tt.failIfName == "eth2", false),
+			}
+			cmdArgs := &cniSkel.CmdArgs{
+				StdinData:   tt.nwCfg.Serialize(),
+				ContainerID: "test-container",
+				Netns:       "test-container",
+				Args:        fmt.Sprintf("K8S_POD_NAME=%v;K8S_POD_NAMESPACE=%v", "test-pod", "test-pod-ns"),
+				IfName:      eth0IfName,
+			}
+			secondaryEndpointID := GetEndpointID(argsForInterface(cmdArgs, "eth1"))
+
+			err := plugin.Add(cmdArgs)
+			if tt.wantErr {
+				require.Error(t, err)
+				if tt.failIfName != "" {
+					// eth1 was created before eth2 failed
+					_, err = plugin.nm.GetNetworkInfo(tt.nwCfg.Name + "-eth1")
+					require.NoError(t, err)
+					endpoints, _ := plugin.nm.GetAllEndpoints(tt.nwCfg.Name + "-eth1")
+					require.Empty(t, endpoints)
+					records, err := plugin.readSecondaryInterfaces()
+					require.NoError(t, err)
+					require.NotContains(t, records, cmdArgs.ContainerID)
+				}
+				return
+			}
+			require.NoError(t, err)
+			if tt.consecutive {
+				require.NoError(t, plugin.Add(cmdArgs))
+			}
+
+			_, err = plugin.nm.GetNetworkInfo(tt.nwCfg.Name + "-eth1")
+			require.NoError(t, err)
+			endpoints, _ := plugin.nm.GetAllEndpoints(tt.nwCfg.Name)
+			require.Len(t, endpoints, 1)
+			require.NotContains(t, endpoints, secondaryEndpointID)
+			endpoints, _ = plugin.nm.GetAllEndpoints(tt.nwCfg.Name + "-eth1")
+			require.Len(t, endpoints, 1)
+			require.Contains(t, endpoints, secondaryEndpointID)
+
+			if tt.delNwCfg != nil {
+				cmdArgs.StdinData = tt.delNwCfg.Serialize()
+			}
+			err = plugin.Delete(cmdArgs)
+			require.NoError(t, err)
+			endpoints, _ = plugin.nm.GetAllEndpoints(tt.nwCfg.Name)
+			require.Empty(t, endpoints)
+			endpoints, _ = plugin.nm.GetAllEndpoints(tt.nwCfg.Name + "-eth1")
+			require.Empty(t, endpoints)
+			records, err := plugin.readSecondaryInterfaces()
+			require.NoError(t, err)
+			require.NotContains(t, records, cmdArgs.ContainerID)
+		})
+	}
+}
+
+func TestCombineInterfaceResults(t *testing.T) {
+	primary := IPAMAddResult{
+		ipv4Result: &cniTypesCurr.Result{
+			IPs: []*cniTypesCurr.IPConfig{{Address: *getCIDRNotationForAddress("10.0.0.5/24")}},
+			DNS: cniTypes.DNS{Nameservers: []string{"10.0.0.10"}},
+		},
+		ipv6Result: &cniTypesCurr.Result{
+			IPs: []*cniTypesCurr.IPConfig{{Address: *getCIDRNotationForAddress("fd00::5/64")}},
+		},
+	}
+	secondary := IPAMAddResult{
+		ifName: "eth1",
+		ipv4Result: &cniTypesCurr.Result{
+			IPs:    []*cniTypesCurr.IPConfig{{Address: *getCIDRNotationForAddress("192.168.0.5/24")}},
+			Routes: []*cniTypes.Route{{Dst: *getCIDRNotationForAddress("192.168.100.0/24")}},
+		},
+	}
+
+	require.False(t, hasSecondaryInterfaces([]IPAMAddResult{primary}))
+	require.True(t, hasSecondaryInterfaces([]IPAMAddResult{primary, secondary}))
+
+	res := combineInterfaceResults(eth0IfName, []IPAMAddResult{primary, secondary})
+	require.Len(t, res.Interfaces, 2)
+	require.Equal(t, eth0IfName, res.Interfaces[0].Name)
+	require.Equal(t, "eth1", res.Interfaces[1].Name)
+	require.Len(t, res.IPs, 3)
+	require.Equal(t, 0, *res.IPs[0].Interface)
+	require.Equal(t, 0, *res.IPs[1].Interface)
+	require.Equal(t, 1, *res.IPs[2].Interface)
+	require.Len(t, res.Routes, 1)
+	require.Equal(t, []string{"10.0.0.10"}, res.DNS.Nameservers)
+	// the per-interface results are not modified
+	require.Nil(t, secondary.ipv4Result.IPs[0].Interface)
+}
+
 // Test CNI Get call
 func TestPluginGet(t *testing.T) {
 	plugin, _ := cni.NewPlugin("name", "0.3.0")
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -11,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cni/api"
 	"github.com/Azure/azure-container-networking/cni/log"
 	"github.com/Azure/azure-container-networking/cni/util"
 	"github.com/Azure/azure-container-networking/cns"
@@ -27,6 +29,8 @@ import (
 	"golang.org/x/sys/windows/registry"
 )
 
+var errTransparentVlanUnsupported = errors.New("transparent vlan is not supported on windows")
+
 var (
 	snatConfigFileName = filepath.FromSlash(os.Getenv("TEMP")) + "\\snatConfig"
 	// windows build for version 1903
@@ -474,3 +478,8 @@ func getOverlayGateway(podsubnet *net.IPNet) (net.IP, error) {
 
 	return ncgw, nil
 }
+
+// ReconcileTransparentVlan is not supported on Windows.
+func (plugin *NetPlugin) ReconcileTransparentVlan(context.Context, *cni.NetworkConfig, bool) (*api.TransparentVlanReconcileReport, error) {
+	return nil, errTransparentVlanUnsupported
+}
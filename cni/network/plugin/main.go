@@ -103,6 +103,19 @@ func getCmdArgsFromEnv() (string, *skel.CmdArgs, error) {
 	return cmd, cmdArgs, nil
 }
 
+// readReconcileNetworkConfig reads the network configuration of a reconcile, which is empty if the caller has none.
+func readReconcileNetworkConfig(r io.Reader) (*cni.NetworkConfig, error) {
+	stdinData, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading from stdin")
+	}
+	if len(stdinData) == 0 {
+		return &cni.NetworkConfig{}, nil
+	}
+	nwCfg, err := cni.ParseNetworkConfig(stdinData)
+	return nwCfg, errors.Wrap(err, "error parsing network config")
+}
+
 func handleIfCniUpdate(update func(*skel.CmdArgs) error) (bool, error) {
 	isupdate := true
 
@@ -194,9 +207,10 @@ func rootExecute() error {
 			printCNIError(fmt.Sprintf("Failed to initialize key-value store of network plugin: %v", err))
 
 			tb = telemetry.NewTelemetryBuffer()
+			// reports are spooled to disk if the telemetry service is unavailable
+			tb.SetSpool(telemetry.NewDefaultSpool())
 			if tberr := tb.Connect(); tberr != nil {
-				zaplog.Logger.Error("Cannot connect to telemetry service", zap.Error(tberr))
-				return errors.Wrap(err, "lock acquire error")
+				zaplog.Logger.Error("Cannot connect to telemetry service, spooling reports", zap.Error(tberr))
 			}
 
 			reportPluginError(reportManager, tb, err)
@@ -231,6 +245,7 @@ func rootExecute() error {
 		// Start telemetry process if not already started. This should be done inside lock, otherwise multiple process
 		// end up creating/killing telemetry process results in undesired state.
 		tb = telemetry.NewTelemetryBuffer()
+		tb.SetSpool(telemetry.NewDefaultSpool())
 		tb.ConnectToTelemetryService(telemetryNumRetries, telemetryWaitTimeInMilliseconds)
 		defer tb.Close()
 
@@ -262,6 +277,27 @@ func rootExecute() error {
 
 			return errors.Wrap(err, "Get cni state printresult error")
 		}
+
+		// used to remove orphaned transparent vlan resources
+		if cniCmd == cni.CmdReconcileTransparentVlan {
+			zaplog.Logger.Info("Reconciling transparent vlan resources")
+			// the network configuration is read from stdin as on an ADD, and is empty if the caller has none.
+			var nwCfg *cni.NetworkConfig
+			nwCfg, err = readReconcileNetworkConfig(os.Stdin)
+			if err != nil {
+				zaplog.Logger.Error("Failed to read the network configuration", zap.Error(err))
+				return errors.Wrap(err, "Reconcile transparent vlan error")
+			}
+			var report *api.TransparentVlanReconcileReport
+			report, err = netPlugin.ReconcileTransparentVlan(context.Background(), nwCfg, os.Getenv(cni.ReconcileDryRun) == "true")
+			if err != nil {
+				zaplog.Logger.Error("Failed to reconcile transparent vlan resources", zap.Error(err))
+				return errors.Wrap(err, "Reconcile transparent vlan error")
+			}
+
+			err = json.NewEncoder(os.Stdout).Encode(report)
+			return errors.Wrap(err, "Reconcile transparent vlan print result error")
+		}
 	}
 
 	handled, _ := handleIfCniUpdate(netPlugin.Update)
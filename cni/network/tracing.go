@@ -0,0 +1,51 @@
+package network
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cni/log"
+	"github.com/Azure/azure-container-networking/tracing"
+	cniSkel "github.com/containernetworking/cni/pkg/skel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// tracingShutdownTimeout bounds how long a CNI command waits to export its spans.
+const tracingShutdownTimeout = 2 * time.Second
+
+// startSpan starts the span of a CNI command, whose trace context is sent to CNS with the IPAM requests.
+// The spans are exported to the tracing endpoint of the network config if it is set. The returned func
+// ends the span with the error of the command and exports it.
+func (plugin *NetPlugin) startSpan(nwCfg *cni.NetworkConfig, name string, args *cniSkel.CmdArgs) (context.Context, func(error)) {
+	var exporter sdktrace.SpanExporter
+	if nwCfg.TracingEndpoint != "" {
+		otlp, err := tracing.NewOTLPExporter(nwCfg.TracingEndpoint)
+		if err != nil {
+			log.Logger.Error("[cni-net] Failed to create span exporter", zap.Error(err))
+		} else {
+			exporter = otlp
+		}
+	}
+	shutdown := tracing.Init(plugin.Name, exporter)
+
+	ctx, span := tracing.Start(context.Background(), name, trace.WithAttributes(
+		attribute.String("cni.container_id", args.ContainerID),
+		attribute.String("cni.netns", args.Netns),
+		attribute.String("cni.ifname", args.IfName),
+	))
+	log.Logger.Info("[cni-net] Started trace", zap.String("command", name), zap.String("traceID", tracing.TraceID(ctx)))
+
+	return ctx, func(err error) {
+		tracing.End(span, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			log.Logger.Error("[cni-net] Failed to export spans", zap.Error(err))
+		}
+	}
+}
@@ -181,10 +181,16 @@ func main() {
 		GetEnvRetryWaitTimeInSecs:    config.GetEnvRetryWaitTimeInSecs,
 	}
 
-	if telemetry.CreateAITelemetryHandle(aiConfig, config.DisableAll, config.DisableTrace, config.DisableMetric) != nil {
+	if config.OTLPEndpoint != "" {
+		if err = telemetry.CreateOTLPTelemetryHandle(config.OTLPEndpoint, aiConfig, config.DisableAll, config.DisableMetric, config.DisableTrace); err != nil {
+			log.Logger.Error("[Telemetry] OTLP Handle creation error", zap.Error(err))
+		}
+	} else if telemetry.CreateAITelemetryHandle(aiConfig, config.DisableAll, config.DisableTrace, config.DisableMetric) != nil {
 		log.Logger.Error("[Telemetry] AI Handle creation error", zap.Error(err))
 	}
 	log.Logger.Info("[Telemetry] Report to host interval", zap.Duration("seconds", config.ReportToHostIntervalInSeconds))
+	// send the reports CNI spooled while the service was unavailable
+	go tb.DrainSpool(telemetry.NewDefaultSpool())
 	tb.PushData(context.Background())
 	telemetry.CloseAITelemetryHandle()
 
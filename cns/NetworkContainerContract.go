@@ -1,6 +1,7 @@
 package cns
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -33,6 +34,12 @@ const (
 	PathDebugIPAddresses                     = "/debug/ipaddresses"
 	PathDebugPodContext                      = "/debug/podcontext"
 	PathDebugRestData                        = "/debug/restdata"
+	PathDebugNetworkContainers               = "/debug/networkcontainers"
+	PathDebugEndpoints                       = "/debug/endpoints"
+	PathDebugReconcile                       = "/debug/reconcile"
+	PathDebugWatch                           = "/debug/watch"
+	PathDebugSnapshot                        = "/debug/snapshot"
+	PathOpenAPI                              = "/openapi.json"
 	NumberOfCPUCores                         = NumberOfCPUCoresPath
 	NMAgentSupportedAPIs                     = NmAgentSupportedApisPath
 )
@@ -75,6 +82,8 @@ const (
 	Managed        = "Managed"
 	CRD            = "CRD"
 	MultiTenantCRD = "MultiTenantCRD"
+	// LocalIPAM runs the IPAM of CNS on node-local IP pools, without DNC or the NodeNetworkConfig.
+	LocalIPAM = "LocalIPAM"
 )
 
 // CreateNetworkContainerRequest specifies request to create a network container or network isolation boundary.
@@ -95,6 +104,7 @@ type CreateNetworkContainerRequest struct {
 	AllowHostToNCCommunication bool
 	AllowNCToHostCommunication bool
 	EndpointPolicies           []NetworkContainerRequestPolicies
+	MTU                        int `json:",omitempty"`
 }
 
 // CreateNetworkContainerRequest implements fmt.Stringer for logging
@@ -141,6 +151,53 @@ func (f PodInfoByIPProviderFunc) PodInfoByIP() (map[string]PodInfo, error) {
 	return f()
 }
 
+// Pod annotations that azure-vnet reads to customize the network of a single pod.
+// CNS passes them through to CNI in its IP and NC responses.
+const (
+	// PodNetworkAnnotationPrefix is the prefix shared by all pod network annotations.
+	PodNetworkAnnotationPrefix = "networking.azure.com/"
+	// PodRoutesAnnotation holds a JSON list of extra routes, e.g. [{"dst":"10.1.0.0/16","gw":"10.0.0.1"}].
+	PodRoutesAnnotation = PodNetworkAnnotationPrefix + "routes"
+	// PodDNSServersAnnotation holds a comma separated list of DNS server IPs.
+	PodDNSServersAnnotation = PodNetworkAnnotationPrefix + "dns-servers"
+	// PodDNSSearchesAnnotation holds a comma separated list of DNS search domains.
+	PodDNSSearchesAnnotation = PodNetworkAnnotationPrefix + "dns-searches"
+	// PodDNSOptionsAnnotation holds a comma separated list of resolver options.
+	PodDNSOptionsAnnotation = PodNetworkAnnotationPrefix + "dns-options"
+)
+
+// PodAnnotationsProvider to be implemented by sources which can look up
+// the annotations of a Pod by name and namespace.
+type PodAnnotationsProvider interface {
+	PodAnnotations(ctx context.Context, podName, podNamespace string) (map[string]string, error)
+}
+
+var _ PodAnnotationsProvider = (PodAnnotationsProviderFunc)(nil)
+
+// PodAnnotationsProviderFunc functional type which implements PodAnnotationsProvider.
+type PodAnnotationsProviderFunc func(ctx context.Context, podName, podNamespace string) (map[string]string, error)
+
+// PodAnnotations implements PodAnnotationsProvider on PodAnnotationsProviderFunc.
+func (f PodAnnotationsProviderFunc) PodAnnotations(ctx context.Context, podName, podNamespace string) (map[string]string, error) {
+	return f(ctx, podName, podNamespace)
+}
+
+// FilterPodNetworkAnnotations returns the pod network annotations from the passed annotations,
+// or nil if there are none.
+func FilterPodNetworkAnnotations(annotations map[string]string) map[string]string {
+	var filtered map[string]string
+	for k, v := range annotations {
+		if !strings.HasPrefix(k, PodNetworkAnnotationPrefix) {
+			continue
+		}
+		if filtered == nil {
+			filtered = map[string]string{}
+		}
+		filtered[k] = v
+	}
+	return filtered
+}
+
 var GlobalPodInfoScheme podInfoScheme
 
 // podInfoScheme indicates which schema should be used when generating
@@ -398,12 +455,15 @@ type GetNetworkContainerResponse struct {
 	Response                   Response
 	AllowHostToNCCommunication bool
 	AllowNCToHostCommunication bool
+	PodAnnotations             map[string]string `json:",omitempty"`
+	MTU                        int               `json:",omitempty"`
 }
 
 type PodIpInfo struct {
 	PodIPConfig                     IPSubnet
 	NetworkContainerPrimaryIPConfig IPConfiguration
 	HostPrimaryIPInfo               HostIPInfo
+	MTU                             int `json:",omitempty"`
 }
 
 type HostIPInfo struct {
@@ -437,8 +497,9 @@ type IPConfigResponse struct {
 
 // IPConfigsResponse is used in CNS IPAM mode to return a slice of IP configs as a response to CNI ADD
 type IPConfigsResponse struct {
-	PodIPInfo []PodIpInfo `json:"podIPInfo"`
-	Response  Response    `json:"response"`
+	PodIPInfo      []PodIpInfo       `json:"podIPInfo"`
+	Response       Response          `json:"response"`
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
 }
 
 // GetIPAddressesRequest is used in CNS IPAM mode to get the states of IPConfigs
@@ -465,6 +526,26 @@ type GetPodContextResponse struct {
 	Response   Response
 }
 
+// NetworkContainerDebugInfo is the state of an NC in CNS, used in CNS Client debug mode.
+type NetworkContainerDebugInfo struct {
+	ID   string
+	Type string
+	// Version is the version of the NC that CNS received.
+	Version string
+	// HostVersion is the version of the NC that is programmed on the host.
+	HostVersion      string
+	PrimaryIP        string
+	SecondaryIPCount int
+	// ProgrammingState is the state of the programming of the version of the NC on the host.
+	ProgrammingState types.NCState `json:",omitempty"`
+}
+
+// GetNetworkContainersDebugResponse is used in CNS Client debug mode to get the NCs in CNS with their versions.
+type GetNetworkContainersDebugResponse struct {
+	NetworkContainers []NetworkContainerDebugInfo
+	Response          Response
+}
+
 // IPAddressState Only used in the GetIPConfig API to return IPs that match a filter
 type IPAddressState struct {
 	IPAddress string
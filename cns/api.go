@@ -36,12 +36,18 @@ const (
 	V2Prefix                      = "/v0.2"
 )
 
+// DefaultUnixSocketPath is the Unix socket that CNS serves the routes called by the CNI on, if enabled.
+const DefaultUnixSocketPath = "/var/run/azure-cns/cns.sock"
+
+// DefaultGRPCSocketPath is the Unix socket that CNS serves its gRPC IPAM API on, if enabled.
+const DefaultGRPCSocketPath = "/var/run/azure-cns/cns-grpc.sock"
+
 // HTTPService describes the min API interface that every service should have.
 type HTTPService interface {
 	common.ServiceAPI
 	SendNCSnapShotPeriodically(context.Context, int)
 	SetNodeOrchestrator(*SetOrchestratorTypeRequest)
-	SyncNodeStatus(string, string, string, json.RawMessage) (types.ResponseCode, string)
+	SyncNodeStatus(context.Context, string, string, string, json.RawMessage) (types.ResponseCode, string)
 	GetPendingProgramIPConfigs() []IPConfigurationStatus
 	GetAvailableIPConfigs() []IPConfigurationStatus
 	GetAssignedIPConfigs() []IPConfigurationStatus
@@ -145,6 +151,11 @@ func (i *IPConfigurationStatus) UnmarshalJSON(b []byte) error {
 			return errors.Wrap(err, "failed to unmarshal key IPAddress to string")
 		}
 	}
+	if s, ok := m["LastStateTransition"]; ok {
+		if err := json.Unmarshal(s, &(i.LastStateTransition)); err != nil {
+			return errors.Wrap(err, "failed to unmarshal key LastStateTransition to time")
+		}
+	}
 	if s, ok := m["state"]; ok {
 		if err := json.Unmarshal(s, &(i.state)); err != nil {
 			return errors.Wrap(err, "failed to unmarshal key state to IPConfigState")
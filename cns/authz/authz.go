@@ -0,0 +1,177 @@
+// Package authz authorizes the callers of the CNS REST API. Callers are identified by the subject of their
+// client certificate or by the user ID of their Unix socket peer, and each route belongs to a group, such as
+// the routes called by the CNI, that rules allow identities to call.
+package authz
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	acn "github.com/Azure/azure-container-networking/common"
+	"github.com/pkg/errors"
+)
+
+// RouteGroup is a group of CNS routes that are authorized together.
+type RouteGroup string
+
+const (
+	// CNI is the group of routes called by the CNI plugin on pod add and delete.
+	CNI RouteGroup = "cni"
+	// DNC is the group of routes called by the control plane to manage the network containers of the node.
+	DNC RouteGroup = "dnc"
+	// Debug is the group of debug and pprof routes.
+	Debug RouteGroup = "debug"
+	// Read is the group of read-only routes that describe the API, such as its OpenAPI document.
+	Read RouteGroup = "read"
+
+	debugPrefix = "/debug/"
+)
+
+var ErrUnknownRouteGroup = errors.New("unknown route group")
+
+// routeGroups maps the paths of the CNS API, without version prefix, to their group.
+var routeGroups = map[string]RouteGroup{
+	cns.GetNetworkContainerByOrchestratorContext: CNI,
+	cns.GetAllNetworkContainers:                  CNI,
+	cns.GetInterfaceForContainer:                 CNI,
+	cns.AttachContainerToNetwork:                 CNI,
+	cns.DetachContainerFromNetwork:               CNI,
+	cns.CreateHostNCApipaEndpointPath:            CNI,
+	cns.DeleteHostNCApipaEndpointPath:            CNI,
+	cns.RequestIPConfig:                          CNI,
+	cns.RequestIPConfigs:                         CNI,
+	cns.ReleaseIPConfig:                          CNI,
+	cns.ReleaseIPConfigs:                         CNI,
+	cns.GetHomeAz:                                CNI,
+
+	cns.SetEnvironmentPath:             DNC,
+	cns.CreateNetworkPath:              DNC,
+	cns.DeleteNetworkPath:              DNC,
+	cns.ReserveIPAddressPath:           DNC,
+	cns.ReleaseIPAddressPath:           DNC,
+	cns.GetHostLocalIPPath:             DNC,
+	cns.GetIPAddressUtilizationPath:    DNC,
+	cns.GetUnhealthyIPAddressesPath:    DNC,
+	cns.CreateOrUpdateNetworkContainer: DNC,
+	cns.DeleteNetworkContainer:         DNC,
+	cns.PublishNetworkContainer:        DNC,
+	cns.UnpublishNetworkContainer:      DNC,
+	cns.SetOrchestratorType:            DNC,
+	cns.CreateHnsNetworkPath:           DNC,
+	cns.DeleteHnsNetworkPath:           DNC,
+	cns.NumberOfCPUCoresPath:           DNC,
+	cns.NmAgentSupportedApisPath:       DNC,
+	cns.NetworkContainersURLPath:       DNC,
+
+	cns.PathOpenAPI: Read,
+}
+
+// groupOf returns the group of the route, and false if the route is not part of the API.
+func groupOf(path string) (RouteGroup, bool) {
+	for _, prefix := range []string{cns.V1Prefix, cns.V2Prefix} {
+		if trimmed := strings.TrimPrefix(path, prefix); trimmed != path && strings.HasPrefix(trimmed, "/") {
+			path = trimmed
+			break
+		}
+	}
+	if group, ok := routeGroups[path]; ok {
+		return group, true
+	}
+	if strings.HasPrefix(path, debugPrefix) {
+		return Debug, true
+	}
+	return "", false
+}
+
+// Rule allows the identities it matches to call the routes of its groups.
+type Rule struct {
+	// Subjects are the common names of client certificates.
+	Subjects []string
+	// UIDs are the user IDs of Unix socket peers.
+	UIDs []uint32
+	// Anonymous matches callers that can not be identified, such as over plain HTTP.
+	Anonymous bool
+	Groups    []RouteGroup
+}
+
+func (r *Rule) matches(id acn.Identity) bool {
+	if r.Anonymous && id.Anonymous() {
+		return true
+	}
+	for _, subject := range r.Subjects {
+		if id.Subject != "" && subject == id.Subject {
+			return true
+		}
+	}
+	for _, uid := range r.UIDs {
+		if id.HasUID && uid == id.UID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) allows(group RouteGroup) bool {
+	for _, g := range r.Groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorizer allows a request if any rule that matches the identity of the caller allows the group of the
+// route. Requests for routes outside of the API are denied.
+type Authorizer struct {
+	rules []Rule
+}
+
+// New validates the rules and returns their Authorizer.
+func New(rules []Rule) (*Authorizer, error) {
+	for i := range rules {
+		for _, group := range rules[i].Groups {
+			switch group {
+			case CNI, DNC, Debug, Read:
+			default:
+				return nil, errors.Wrapf(ErrUnknownRouteGroup, "rule %d: %q", i, group)
+			}
+		}
+	}
+	return &Authorizer{rules: rules}, nil
+}
+
+// Allowed reports whether the identity may call the route at path.
+func (a *Authorizer) Allowed(id acn.Identity, path string) bool {
+	group, ok := groupOf(path)
+	if !ok {
+		return false
+	}
+	return a.AllowedGroup(id, group)
+}
+
+// AllowedGroup reports whether the identity may call the routes of the group, such as the CNS gRPC API, which
+// is not served on the paths of the REST API.
+func (a *Authorizer) AllowedGroup(id acn.Identity, group RouteGroup) bool {
+	for i := range a.rules {
+		if a.rules[i].matches(id) && a.rules[i].allows(group) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware denies the requests that are not allowed with 403 Forbidden and audit logs them.
+func (a *Authorizer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := acn.CallerIdentity(r)
+		if !a.Allowed(id, r.URL.Path) {
+			group, _ := groupOf(r.URL.Path)
+			logger.Printf("[Azure CNS] [audit] denied %s %s (group %q) to %s from %s", r.Method, r.URL.Path, group, id, r.RemoteAddr)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,52 @@
+package authz
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	acn "github.com/Azure/azure-container-networking/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareUnixSocketPeer(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "cns.sock")
+	// a socket left behind by a previous process is replaced
+	require.NoError(t, os.WriteFile(socket, nil, 0o600))
+	listener, err := acn.NewListener(&url.URL{Scheme: "unix", Path: socket})
+	require.NoError(t, err)
+
+	uid := uint32(os.Getuid())
+	a, err := New([]Rule{{UIDs: []uint32{uid}, Groups: []RouteGroup{CNI}}})
+	require.NoError(t, err)
+	listener.Use(a.Middleware)
+	listener.AddHandler(cns.RequestIPConfigs, ok)
+	listener.AddHandler(cns.CreateOrUpdateNetworkContainer, ok)
+
+	errChan := make(chan error, 1)
+	require.NoError(t, listener.Start(errChan))
+	defer listener.Stop()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	call := func(path string) int {
+		resp, err := client.Post("http://cns"+path, "application/json", http.NoBody)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, call(cns.RequestIPConfigs))
+	require.Equal(t, http.StatusForbidden, call(cns.CreateOrUpdateNetworkContainer))
+}
@@ -0,0 +1,176 @@
+package authz
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/restserver"
+	acn "github.com/Azure/azure-container-networking/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logDir, err := os.MkdirTemp("", "cns-authz-")
+	if err != nil {
+		panic(err)
+	}
+	logger.InitLogger("azure-cns.log", 0, 0, logDir+"/")
+	code := m.Run()
+	os.RemoveAll(logDir)
+	os.Exit(code)
+}
+
+var testRules = []Rule{
+	{Subjects: []string{"dnc"}, Groups: []RouteGroup{DNC}},
+	{UIDs: []uint32{0}, Groups: []RouteGroup{CNI, Debug}},
+	{Anonymous: true, Groups: []RouteGroup{Debug, Read}},
+}
+
+func TestAllowed(t *testing.T) {
+	a, err := New(testRules)
+	require.NoError(t, err)
+
+	root := acn.Identity{UID: 0, HasUID: true}
+	dnc := acn.Identity{Subject: "dnc"}
+	anonymous := acn.Identity{}
+
+	tests := []struct {
+		name string
+		id   acn.Identity
+		path string
+		want bool
+	}{
+		{name: "cni route by cni", id: root, path: cns.RequestIPConfigs, want: true},
+		{name: "versioned cni route by cni", id: root, path: cns.V2Prefix + cns.GetNetworkContainerByOrchestratorContext, want: true},
+		{name: "dnc route by cni", id: root, path: cns.CreateOrUpdateNetworkContainer, want: false},
+		{name: "dnc route by dnc", id: dnc, path: cns.CreateOrUpdateNetworkContainer, want: true},
+		{name: "versioned dnc route by dnc", id: dnc, path: cns.V1Prefix + cns.SetOrchestratorType, want: true},
+		{name: "cni route by dnc", id: dnc, path: cns.ReleaseIPConfigs, want: false},
+		{name: "debug route by anonymous", id: anonymous, path: cns.PathDebugIPAddresses, want: true},
+		{name: "pprof by anonymous", id: anonymous, path: "/debug/pprof/heap", want: true},
+		{name: "cni route by anonymous", id: anonymous, path: cns.RequestIPConfigs, want: false},
+		{name: "openapi by anonymous", id: anonymous, path: cns.PathOpenAPI, want: true},
+		{name: "openapi by dnc", id: dnc, path: cns.PathOpenAPI, want: false},
+		{name: "unknown subject", id: acn.Identity{Subject: "cni"}, path: cns.RequestIPConfigs, want: false},
+		{name: "unknown uid", id: acn.Identity{UID: 1000, HasUID: true}, path: cns.RequestIPConfigs, want: false},
+		{name: "unmapped route", id: root, path: "/network/unknown", want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, a.Allowed(tt.id, tt.path))
+		})
+	}
+}
+
+// TestRouteGroupsCoverRoutes checks that every route that CNS serves belongs to a group, so that no route is
+// denied to every caller once rules are configured.
+func TestRouteGroupsCoverRoutes(t *testing.T) {
+	paths := []string{cns.PathOpenAPI}
+	for i := range restserver.Routes {
+		paths = append(paths, restserver.Routes[i].Path)
+	}
+	for _, path := range paths {
+		_, ok := groupOf(path)
+		require.True(t, ok, "route %s has no group", path)
+	}
+}
+
+func TestNewUnknownRouteGroup(t *testing.T) {
+	_, err := New([]Rule{{Anonymous: true, Groups: []RouteGroup{"admin"}}})
+	require.ErrorIs(t, err, ErrUnknownRouteGroup)
+}
+
+func ok(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestMiddleware(t *testing.T) {
+	a, err := New(testRules)
+	require.NoError(t, err)
+	h := a.Middleware(http.HandlerFunc(ok))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, cns.PathDebugRestData, http.NoBody))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, cns.RequestIPConfigs, http.NoBody))
+	require.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+// newCertificate creates a certificate with the common name, signed by the parent, or self-signed if the
+// parent is nil.
+func newCertificate(t *testing.T, cn string, parent *tls.Certificate) *tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, any(key)
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestMiddlewareClientCertificate(t *testing.T) {
+	ca := newCertificate(t, "ca", nil)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.Leaf)
+
+	a, err := New(testRules)
+	require.NoError(t, err)
+	srv := httptest.NewUnstartedServer(a.Middleware(http.HandlerFunc(ok)))
+	srv.TLS = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientCAs:  clientCAs,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	call := func(cn, path string) int {
+		transport := srv.Client().Transport.(*http.Transport).Clone()
+		transport.TLSClientConfig.Certificates = []tls.Certificate{*newCertificate(t, cn, ca)}
+		client := &http.Client{Transport: transport}
+
+		resp, err := client.Post(srv.URL+path, "application/json", http.NoBody)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, call("dnc", cns.CreateOrUpdateNetworkContainer))
+	require.Equal(t, http.StatusForbidden, call("dnc", cns.RequestIPConfigs))
+	require.Equal(t, http.StatusForbidden, call("other", cns.CreateOrUpdateNetworkContainer))
+}
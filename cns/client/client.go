@@ -4,49 +4,63 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/restserver"
 	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/pkg/errors"
 )
 
 const (
 	contentTypeJSON = "application/json"
 	defaultBaseURL  = "http://localhost:10090"
+	// socketBaseURL is the base URL of the routes when CNS is called on a Unix socket.
+	socketBaseURL = "http://localhost"
+	socketScheme  = "unix://"
 	// DefaultTimeout default timeout duration for CNS Client.
 	DefaultTimeout    = 5 * time.Second
 	headerContentType = "Content-Type"
 )
 
-var clientPaths = []string{
-	cns.GetNetworkContainerByOrchestratorContext,
-	cns.GetAllNetworkContainers,
-	cns.CreateHostNCApipaEndpointPath,
-	cns.DeleteHostNCApipaEndpointPath,
-	cns.RequestIPConfig,
-	cns.RequestIPConfigs,
-	cns.ReleaseIPConfig,
-	cns.ReleaseIPConfigs,
-	cns.PathDebugIPAddresses,
-	cns.PathDebugPodContext,
-	cns.PathDebugRestData,
-	cns.UnpublishNetworkContainer,
-	cns.PublishNetworkContainer,
-	cns.CreateOrUpdateNetworkContainer,
-	cns.SetOrchestratorType,
-	cns.NumberOfCPUCores,
-	cns.NMAgentSupportedAPIs,
-	cns.DeleteNetworkContainer,
-	cns.NetworkContainersURLPath,
-	cns.GetHomeAz,
+// ErrUndeclaredOperation is returned when the client calls an operation that the CNS API does not declare, or with
+// request or response types other than the declared ones.
+var ErrUndeclaredOperation = errors.New("operation is not declared by the CNS API")
+
+// operation is an operation of the CNS API with the path of its route.
+type operation struct {
+	restserver.Operation
+	path string
 }
 
+// operations are the operations declared by the CNS API by ID. The client calls them with their declared method
+// on the path of their route.
+var operations = func() map[string]operation {
+	ops := map[string]operation{}
+	for i := range restserver.Routes {
+		for _, op := range restserver.Routes[i].Operations {
+			ops[op.ID] = operation{Operation: op, path: restserver.Routes[i].Path}
+		}
+	}
+	return ops
+}()
+
+// clientPaths are the paths of the routes declared by the CNS API, which the client calls with their declared methods.
+var clientPaths = func() []string {
+	paths := make([]string, 0, len(restserver.Routes))
+	for i := range restserver.Routes {
+		paths = append(paths, restserver.Routes[i].Path)
+	}
+	return paths
+}()
+
 type do interface {
 	Do(*http.Request) (*http.Response, error)
 }
@@ -54,15 +68,24 @@ type do interface {
 // Client specifies a client to connect to Ipam Plugin.
 type Client struct {
 	client do
+	// stream is the client of the watches, which last until their context is done and so are not timed out.
+	stream do
 	routes map[string]url.URL
 }
 
 // New returns a new CNS client configured with the passed URL and timeout.
+// The base URL may also be the path of the CNS Unix socket, as an absolute path or as a unix:// URL.
 func New(baseURL string, requestTimeout time.Duration) (*Client, error) {
 	if baseURL == "" {
 		baseURL = defaultBaseURL
 	}
 
+	var transport http.RoundTripper
+	if socket, ok := socketPath(baseURL); ok {
+		transport = socketTransport(socket)
+		baseURL = socketBaseURL
+	}
+
 	routes, err := buildRoutes(baseURL, clientPaths)
 	if err != nil {
 		return nil, err
@@ -70,12 +93,37 @@ func New(baseURL string, requestTimeout time.Duration) (*Client, error) {
 
 	return &Client{
 		client: &http.Client{
-			Timeout: requestTimeout,
+			Timeout:   requestTimeout,
+			Transport: tracing.NewTransport(transport),
+		},
+		stream: &http.Client{
+			Transport: tracing.NewTransport(transport),
 		},
 		routes: routes,
 	}, nil
 }
 
+// socketPath returns the path of the Unix socket named by the base URL, if any.
+func socketPath(baseURL string) (string, bool) {
+	if strings.HasPrefix(baseURL, socketScheme) {
+		return strings.TrimPrefix(baseURL, socketScheme), true
+	}
+	if strings.HasPrefix(baseURL, "/") {
+		return baseURL, true
+	}
+	return "", false
+}
+
+// socketTransport returns a transport that dials the Unix socket for every request.
+func socketTransport(socket string) *http.Transport {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}
+}
+
 func buildRoutes(baseURL string, paths []string) (map[string]url.URL, error) {
 	base, err := url.Parse(baseURL)
 	if err != nil {
@@ -96,383 +144,168 @@ func buildRoutes(baseURL string, paths []string) (map[string]url.URL, error) {
 	return routes, nil
 }
 
-// GetAllNetworkContainers Request to get network container configs.
-func (c *Client) GetAllNetworkContainers(ctx context.Context, orchestratorContext []byte) ([]cns.GetNetworkContainerResponse, error) {
-	payload := cns.GetNetworkContainerRequest{
-		OrchestratorContext: orchestratorContext,
+// newRequest builds the request of the operation declared by the CNS API with the ID.
+func (c *Client) newRequest(ctx context.Context, id string, body io.Reader) (*http.Request, error) {
+	op, ok := operations[id]
+	if !ok {
+		return nil, errors.Wrap(ErrUndeclaredOperation, id)
 	}
+	u := c.routes[op.path]
+	req, err := http.NewRequestWithContext(ctx, op.Method, u.String(), body)
+	return req, errors.Wrap(err, "failed to build request")
+}
 
-	var body bytes.Buffer
-	if err := json.NewEncoder(&body).Encode(payload); err != nil {
-		return nil, &CNSClientError{
-			Code: types.UnexpectedError,
-			Err:  err,
-		}
+// responseStatus is the status of a CNS response, which is either a cns.Response or has one as its Response.
+type responseStatus struct {
+	cns.Response
+	Wrapped *cns.Response `json:"Response"`
+}
+
+// call calls the operation declared by the CNS API with the ID, with the request as its JSON body, or without a
+// body if the request is nil, and returns its decoded response. The request and response must be of the declared
+// types. A 404 is returned as an UnsupportedAPI CNSClientError, as it is how a CNS that predates the operation
+// responds, and a non-zero return code of the response as a CNSClientError.
+func call[T any](ctx context.Context, c *Client, id string, request any) (*T, error) {
+	op, ok := operations[id]
+	if !ok {
+		return nil, errors.Wrap(ErrUndeclaredOperation, id)
+	}
+	if responseType := reflect.TypeOf((*T)(nil)).Elem(); op.Response != responseType {
+		return nil, errors.Wrapf(ErrUndeclaredOperation, "%s responds with %v, not %v", id, op.Response, responseType)
+	}
+	if requestType := reflect.TypeOf(request); op.Request != requestType {
+		return nil, errors.Wrapf(ErrUndeclaredOperation, "%s takes %v, not %v", id, op.Request, requestType)
 	}
 
-	u := c.routes[cns.GetAllNetworkContainers]
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
+	body := io.Reader(http.NoBody)
+	if request != nil {
+		b, err := json.Marshal(request)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encode %v", op.Request)
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := c.newRequest(ctx, id, body)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to build request")
+		return nil, err
+	}
+	if request != nil {
+		req.Header.Set(headerContentType, contentTypeJSON)
 	}
-	req.Header.Set(headerContentType, contentTypeJSON)
 	res, err := c.client.Do(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "http request failed")
 	}
 	defer res.Body.Close()
 
-	//  investigate 404 orchestratorContext which is invalid and make sure this is addressed
-	if res.StatusCode == http.StatusNotFound {
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
 		return nil, &CNSClientError{
 			Code: types.UnsupportedAPI,
 			Err:  errors.Errorf("Unsupported API"),
 		}
-	}
-
-	if res.StatusCode != http.StatusOK {
-		return nil, &CNSClientError{
-			Code: types.UnexpectedError,
-			Err:  errors.Errorf("http response %d", res.StatusCode),
+	default:
+		return nil, &FailedHTTPRequest{
+			Code: res.StatusCode,
 		}
 	}
 
-	var resp cns.GetAllNetworkContainersResponse
-	err = json.NewDecoder(res.Body).Decode(&resp)
+	b, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, &CNSClientError{
-			Code: types.UnexpectedError,
-			Err:  err,
-		}
+		return nil, errors.Wrap(err, "failed to read response")
 	}
-
-	if resp.Response.ReturnCode != 0 {
-		return nil, &CNSClientError{
-			Code: resp.Response.ReturnCode,
-			Err:  errors.New(resp.Response.Message),
-		}
+	var out T
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode %v", op.Response)
 	}
-
-	return resp.NetworkContainers, nil
-}
-
-// GetNetworkContainer Request to get network container config.
-func (c *Client) GetNetworkContainer(ctx context.Context, orchestratorContext []byte) (*cns.GetNetworkContainerResponse, error) {
-	payload := cns.GetNetworkContainerRequest{
-		OrchestratorContext: orchestratorContext,
+	var status responseStatus
+	if err := json.Unmarshal(b, &status); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode %v", op.Response)
 	}
-
-	var body bytes.Buffer
-	if err := json.NewEncoder(&body).Encode(payload); err != nil {
-		return nil, &CNSClientError{
-			Code: types.UnexpectedError,
-			Err:  err,
-		}
+	if status.Wrapped != nil {
+		status.Response = *status.Wrapped
 	}
-
-	u := c.routes[cns.GetNetworkContainerByOrchestratorContext]
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to build request")
-	}
-	req.Header.Set(headerContentType, contentTypeJSON)
-	res, err := c.client.Do(req)
-	if err != nil {
-		return nil, errors.Wrap(err, "http request failed")
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
+	if status.ReturnCode != types.Success {
 		return nil, &CNSClientError{
-			Code: types.UnexpectedError,
-			Err:  errors.Errorf("http response %d", res.StatusCode),
+			Code: status.ReturnCode,
+			Err:  errors.New(status.Message),
 		}
 	}
+	return &out, nil
+}
 
-	var resp cns.GetNetworkContainerResponse
-	err = json.NewDecoder(res.Body).Decode(&resp)
+// GetAllNetworkContainers Request to get network container configs.
+func (c *Client) GetAllNetworkContainers(ctx context.Context, orchestratorContext []byte) ([]cns.GetNetworkContainerResponse, error) {
+	resp, err := call[cns.GetAllNetworkContainersResponse](ctx, c, "GetAllNetworkContainers", cns.GetNetworkContainerRequest{
+		OrchestratorContext: orchestratorContext,
+	})
 	if err != nil {
-		return nil, &CNSClientError{
-			Code: types.UnexpectedError,
-			Err:  err,
-		}
-	}
-
-	if resp.Response.ReturnCode != 0 {
-		return nil, &CNSClientError{
-			Code: resp.Response.ReturnCode,
-			Err:  errors.New(resp.Response.Message),
-		}
+		return nil, err
 	}
+	return resp.NetworkContainers, nil
+}
 
-	return &resp, nil
+// GetNetworkContainer Request to get network container config.
+func (c *Client) GetNetworkContainer(ctx context.Context, orchestratorContext []byte) (*cns.GetNetworkContainerResponse, error) {
+	return call[cns.GetNetworkContainerResponse](ctx, c, "GetNetworkContainerByOrchestratorContext", cns.GetNetworkContainerRequest{
+		OrchestratorContext: orchestratorContext,
+	})
 }
 
 // CreateHostNCApipaEndpoint creates an endpoint in APIPA network for host container connectivity.
 func (c *Client) CreateHostNCApipaEndpoint(ctx context.Context, networkContainerID string) (string, error) {
-	payload := cns.CreateHostNCApipaEndpointRequest{
+	resp, err := call[cns.CreateHostNCApipaEndpointResponse](ctx, c, "CreateHostNCApipaEndpoint", cns.CreateHostNCApipaEndpointRequest{
 		NetworkContainerID: networkContainerID,
-	}
-
-	var body bytes.Buffer
-	if err := json.NewEncoder(&body).Encode(payload); err != nil {
-		return "", errors.Wrap(err, "failed to encode CreateCNSHostNCApipaEndpointRequest")
-	}
-
-	u := c.routes[cns.CreateHostNCApipaEndpointPath]
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
-	if err != nil {
-		return "", errors.Wrap(err, "failed to build request")
-	}
-	req.Header.Set(headerContentType, contentTypeJSON)
-	res, err := c.client.Do(req)
+	})
 	if err != nil {
-		return "", errors.Wrap(err, "http request failed")
+		return "", err
 	}
-
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		return "", errors.Errorf("http response %d", res.StatusCode)
-	}
-
-	var resp cns.CreateHostNCApipaEndpointResponse
-
-	if err = json.NewDecoder(res.Body).Decode(&resp); err != nil {
-		return "", errors.Wrap(err, "failed to decode CreateHostNCApipaEndpointResponse")
-	}
-
-	if resp.Response.ReturnCode != 0 {
-		return "", errors.New(resp.Response.Message)
-	}
-
 	return resp.EndpointID, nil
 }
 
 // DeleteHostNCApipaEndpoint deletes the endpoint in APIPA network created for host container connectivity.
 func (c *Client) DeleteHostNCApipaEndpoint(ctx context.Context, networkContainerID string) error {
-	payload := cns.DeleteHostNCApipaEndpointRequest{
+	_, err := call[cns.DeleteHostNCApipaEndpointResponse](ctx, c, "DeleteHostNCApipaEndpoint", cns.DeleteHostNCApipaEndpointRequest{
 		NetworkContainerID: networkContainerID,
-	}
-
-	var body bytes.Buffer
-	if err := json.NewEncoder(&body).Encode(payload); err != nil {
-		return errors.Wrap(err, "failed to encode DeleteHostNCApipaEndpointRequest")
-	}
-
-	u := c.routes[cns.DeleteHostNCApipaEndpointPath]
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
-	if err != nil {
-		return errors.Wrap(err, "failed to build request")
-	}
-	req.Header.Set(headerContentType, contentTypeJSON)
-	res, err := c.client.Do(req)
-	if err != nil {
-		return errors.Wrap(err, "http request failed")
-	}
-
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		return errors.Errorf("http response %d", res.StatusCode)
-	}
-
-	var resp cns.DeleteHostNCApipaEndpointResponse
-
-	err = json.NewDecoder(res.Body).Decode(&resp)
-	if err != nil {
-		return errors.Wrap(err, "failed to decode DeleteHostNCApipaEndpointResponse")
-	}
-
-	if resp.Response.ReturnCode != 0 {
-		return errors.New(resp.Response.Message)
-	}
-
-	return nil
+	})
+	return err
 }
 
 // RequestIPAddress calls the requestIPAddress in CNS
 func (c *Client) RequestIPAddress(ctx context.Context, ipconfig cns.IPConfigRequest) (*cns.IPConfigResponse, error) {
-	var err error
-	defer func() {
-		if err != nil {
-			if e := c.ReleaseIPAddress(ctx, ipconfig); e != nil {
-				err = errors.Wrap(e, err.Error())
-			}
-		}
-	}()
-
-	var body bytes.Buffer
-	err = json.NewEncoder(&body).Encode(ipconfig)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to encode IPConfigRequest")
-	}
-
-	u := c.routes[cns.RequestIPConfig]
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to build request")
-	}
-	req.Header.Set(headerContentType, contentTypeJSON)
-	res, err := c.client.Do(req)
-	if err != nil {
-		return nil, errors.Wrap(err, "http request failed")
-	}
-
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		return nil, errors.Errorf("http response %d", res.StatusCode)
-	}
-
-	var response cns.IPConfigResponse
-	err = json.NewDecoder(res.Body).Decode(&response)
+	resp, err := call[cns.IPConfigResponse](ctx, c, "RequestIPConfig", ipconfig)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to decode IPConfigResponse")
-	}
-
-	if response.Response.ReturnCode != 0 {
-		return nil, errors.New(response.Response.Message)
+		if e := c.ReleaseIPAddress(ctx, ipconfig); e != nil {
+			err = errors.Wrap(e, err.Error())
+		}
+		return nil, err
 	}
-
-	return &response, nil
+	return resp, nil
 }
 
 // ReleaseIPAddress calls releaseIPAddress on CNS, ipaddress ex: (10.0.0.1)
 func (c *Client) ReleaseIPAddress(ctx context.Context, ipconfig cns.IPConfigRequest) error {
-	var body bytes.Buffer
-	err := json.NewEncoder(&body).Encode(ipconfig)
-	if err != nil {
-		return errors.Wrap(err, "failed to encode IPConfigRequest")
-	}
-
-	u := c.routes[cns.ReleaseIPConfig]
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
-	if err != nil {
-		return errors.Wrap(err, "failed to build request")
-	}
-	req.Header.Set(headerContentType, contentTypeJSON)
-	res, err := c.client.Do(req)
-	if err != nil {
-		return errors.Wrap(err, "http request failed")
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		return errors.Errorf("http response %d", res.StatusCode)
-	}
-
-	var resp cns.Response
-
-	err = json.NewDecoder(res.Body).Decode(&resp)
-	if err != nil {
-		return errors.Wrap(err, "failed to decode Response")
-	}
-
-	if resp.ReturnCode != 0 {
-		return errors.New(resp.Message)
-	}
-
-	return nil
+	_, err := call[cns.Response](ctx, c, "ReleaseIPConfig", ipconfig)
+	return err
 }
 
 // RequestIPs calls the RequestIPConfigs in CNS
 func (c *Client) RequestIPs(ctx context.Context, ipconfig cns.IPConfigsRequest) (*cns.IPConfigsResponse, error) {
-	var err error
-	defer func() {
-		if err != nil {
-			if e := c.ReleaseIPs(ctx, ipconfig); e != nil {
-				err = errors.Wrap(e, err.Error())
-			}
-		}
-	}()
-
-	var body bytes.Buffer
-	err = json.NewEncoder(&body).Encode(ipconfig)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to encode IPConfigsRequest")
-	}
-
-	u := c.routes[cns.RequestIPConfigs]
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to build request")
-	}
-	req.Header.Set(headerContentType, contentTypeJSON)
-	res, err := c.client.Do(req)
+	resp, err := call[cns.IPConfigsResponse](ctx, c, "RequestIPConfigs", ipconfig)
 	if err != nil {
-		return nil, errors.Wrap(err, "http request failed")
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode == http.StatusNotFound {
-		return nil, &CNSClientError{
-			Code: types.UnsupportedAPI,
-			Err:  errors.Errorf("Unsupported API"),
+		if e := c.ReleaseIPs(ctx, ipconfig); e != nil {
+			err = errors.Wrap(e, err.Error())
 		}
+		return nil, err
 	}
-
-	if res.StatusCode != http.StatusOK {
-		return nil, errors.Errorf("http response %d", res.StatusCode)
-	}
-
-	var response cns.IPConfigsResponse
-	err = json.NewDecoder(res.Body).Decode(&response)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to decode IPConfigsResponse")
-	}
-
-	if response.Response.ReturnCode != 0 {
-		return nil, errors.New(response.Response.Message)
-	}
-
-	return &response, nil
+	return resp, nil
 }
 
 // ReleaseIPs calls releaseIPs on which releases the IPs on the pod
 func (c *Client) ReleaseIPs(ctx context.Context, ipconfig cns.IPConfigsRequest) error {
-	var body bytes.Buffer
-	err := json.NewEncoder(&body).Encode(ipconfig)
-	if err != nil {
-		return errors.Wrap(err, "failed to encode IPConfigsRequest")
-	}
-
-	u := c.routes[cns.ReleaseIPConfigs]
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
-	if err != nil {
-		return errors.Wrap(err, "failed to build request")
-	}
-	req.Header.Set(headerContentType, contentTypeJSON)
-	res, err := c.client.Do(req)
-	if err != nil {
-		return errors.Wrap(err, "http request failed")
-	}
-	defer res.Body.Close()
-
-	// if we get a 404 error
-	if res.StatusCode == http.StatusNotFound {
-		return &CNSClientError{
-			Code: types.UnsupportedAPI,
-			Err:  errors.Errorf("Unsupported API"),
-		}
-	}
-
-	if res.StatusCode != http.StatusOK {
-		return errors.Errorf("http response %d", res.StatusCode)
-	}
-
-	var resp cns.Response
-
-	err = json.NewDecoder(res.Body).Decode(&resp)
-	if err != nil {
-		return errors.Wrap(err, "failed to decode Response")
-	}
-
-	if resp.ReturnCode != 0 {
-		return errors.New(resp.Message)
-	}
-
-	return nil
+	_, err := call[cns.Response](ctx, c, "ReleaseIPConfigs", ipconfig)
+	return err
 }
 
 // GetIPAddressesMatchingStates takes a variadic number of string parameters, to get all IP Addresses matching a number of states
@@ -482,141 +315,77 @@ func (c *Client) GetIPAddressesMatchingStates(ctx context.Context, stateFilter .
 		return nil, nil
 	}
 
-	payload := cns.GetIPAddressesRequest{
+	resp, err := call[cns.GetIPAddressStatusResponse](ctx, c, "GetIPAddresses", cns.GetIPAddressesRequest{
 		IPConfigStateFilter: stateFilter,
-	}
-
-	var body bytes.Buffer
-	if err := json.NewEncoder(&body).Encode(payload); err != nil {
-		return nil, errors.Wrap(err, "failed to encode GetIPAddressesRequest")
-	}
-
-	u := c.routes[cns.PathDebugIPAddresses]
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to build request")
-	}
-	req.Header.Set(headerContentType, contentTypeJSON)
-	res, err := c.client.Do(req)
-	if err != nil {
-		return nil, errors.Wrap(err, "http request failed")
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		return nil, errors.Errorf("http response %d", res.StatusCode)
-	}
-
-	var resp cns.GetIPAddressStatusResponse
-	err = json.NewDecoder(res.Body).Decode(&resp)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to decode GetIPAddressStatusResponse")
-	}
-
-	if resp.Response.ReturnCode != 0 {
-		return nil, errors.New(resp.Response.Message)
+		return nil, err
 	}
-
 	return resp.IPConfigurationStatus, nil
 }
 
 // GetPodOrchestratorContext calls GetPodIpOrchestratorContext API on CNS
 func (c *Client) GetPodOrchestratorContext(ctx context.Context) (map[string][]string, error) {
-	u := c.routes[cns.PathDebugPodContext]
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to build request")
-	}
-	res, err := c.client.Do(req)
+	resp, err := call[cns.GetPodContextResponse](ctx, c, "GetPodContext", nil)
 	if err != nil {
-		return nil, errors.Wrap(err, "http request failed")
+		return nil, err
 	}
-	defer res.Body.Close()
+	return resp.PodContext, nil
+}
 
-	if res.StatusCode != http.StatusOK {
-		return nil, errors.Errorf("http response %d", res.StatusCode)
-	}
+// GetHTTPServiceData gets all public in-memory struct details for debugging purpose
+func (c *Client) GetHTTPServiceData(ctx context.Context) (*restserver.GetHTTPServiceDataResponse, error) {
+	return call[restserver.GetHTTPServiceDataResponse](ctx, c, "GetRestData", nil)
+}
 
-	var resp cns.GetPodContextResponse
-	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
-		return nil, errors.Wrap(err, "failed to decode GetPodContextResponse")
+// GetNetworkContainersDebug gets the NCs in CNS with their versions for debugging purpose
+func (c *Client) GetNetworkContainersDebug(ctx context.Context) ([]cns.NetworkContainerDebugInfo, error) {
+	resp, err := call[cns.GetNetworkContainersDebugResponse](ctx, c, "GetNetworkContainersDebug", nil)
+	if err != nil {
+		return nil, err
 	}
+	return resp.NetworkContainers, nil
+}
 
-	if resp.Response.ReturnCode != 0 {
-		return nil, errors.New(resp.Response.Message)
+// GetEndpointState gets the endpoint state of CNS, keyed by container ID, for debugging purpose
+func (c *Client) GetEndpointState(ctx context.Context) (map[string]*restserver.EndpointInfo, error) {
+	resp, err := call[restserver.GetEndpointStateResponse](ctx, c, "GetEndpointState", nil)
+	if err != nil {
+		return nil, err
 	}
+	return resp.EndpointState, nil
+}
 
-	return resp.PodContext, nil
+// TriggerReconcile makes CNS enqueue a reconcile of the NodeNetworkConfig of its node
+func (c *Client) TriggerReconcile(ctx context.Context) error {
+	_, err := call[cns.Response](ctx, c, "Reconcile", nil)
+	return err
 }
 
-// GetHTTPServiceData gets all public in-memory struct details for debugging purpose
-func (c *Client) GetHTTPServiceData(ctx context.Context) (*restserver.GetHTTPServiceDataResponse, error) {
-	u := c.routes[cns.PathDebugRestData]
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+// GetSnapshot writes a snapshot of the state of CNS to w, as the gzipped tar archive that restserver.ReadSnapshot
+// reads.
+func (c *Client) GetSnapshot(ctx context.Context, w io.Writer) error {
+	req, err := c.newRequest(ctx, "GetSnapshot", http.NoBody)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to build request")
+		return err
 	}
 	res, err := c.client.Do(req)
 	if err != nil {
-		return nil, errors.Wrap(err, "http request failed")
+		return errors.Wrap(err, "http request failed")
 	}
 	defer res.Body.Close()
-	b, err := io.ReadAll(res.Body)
-	s := string(b)
-	if err != nil {
-		return nil, errors.Wrap(err, fmt.Sprintf("failed to read body %s", s))
-	}
 
 	if res.StatusCode != http.StatusOK {
-		return nil, errors.Errorf("http response %d", res.StatusCode)
-	}
-	var resp restserver.GetHTTPServiceDataResponse
-	err = json.NewDecoder(bytes.NewReader(b)).Decode(&resp)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to decode GetHTTPServiceDataResponse")
-	}
-
-	if resp.Response.ReturnCode != 0 {
-		return nil, errors.New(resp.Response.Message)
+		return errors.Errorf("http response %d", res.StatusCode)
 	}
-
-	return &resp, nil
+	_, err = io.Copy(w, res.Body)
+	return errors.Wrap(err, "failed to read snapshot")
 }
 
 // NumOfCPUCores returns the number of CPU cores available on the host that
 // CNS is running on.
 func (c *Client) NumOfCPUCores(ctx context.Context) (*cns.NumOfCPUCoresResponse, error) {
-	// build the request
-	u := c.routes[cns.NumberOfCPUCores]
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
-	if err != nil {
-		return nil, errors.Wrap(err, "building http request")
-	}
-
-	// submit the request
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, errors.Wrap(err, "sending HTTP request")
-	}
-	defer resp.Body.Close()
-
-	// decode the response
-	var out cns.NumOfCPUCoresResponse
-	err = json.NewDecoder(resp.Body).Decode(&out)
-	if err != nil {
-		return nil, errors.Wrap(err, "decoding response as JSON")
-	}
-
-	// if the return code is non-zero, something went wrong and it should be
-	// surfaced to the caller
-	if out.Response.ReturnCode != 0 {
-		return nil, &CNSClientError{
-			Code: out.Response.ReturnCode,
-			Err:  errors.New(out.Response.Message),
-		}
-	}
-
-	return &out, nil
+	return call[cns.NumOfCPUCoresResponse](ctx, c, "GetNumberOfCPUCores", nil)
 }
 
 // DeleteNetworkContainer destroys the requested network container matching the
@@ -628,44 +397,10 @@ func (c *Client) DeleteNetworkContainer(ctx context.Context, ncID string) error
 		return errors.New("no network container ID provided")
 	}
 
-	// build the request
-	dncr := cns.DeleteNetworkContainerRequest{
+	_, err := call[cns.DeleteNetworkContainerResponse](ctx, c, "DeleteNetworkContainer", cns.DeleteNetworkContainerRequest{
 		NetworkContainerid: ncID,
-	}
-	body, err := json.Marshal(dncr)
-	if err != nil {
-		return errors.Wrap(err, "encoding request body")
-	}
-	u := c.routes[cns.DeleteNetworkContainer]
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
-	if err != nil {
-		return errors.Wrap(err, "building HTTP request")
-	}
-
-	// submit the request
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return errors.Wrap(err, "sending HTTP request")
-	}
-	defer resp.Body.Close()
-
-	// decode the response
-	var out cns.DeleteNetworkContainerResponse
-	err = json.NewDecoder(resp.Body).Decode(&out)
-	if err != nil {
-		return errors.Wrap(err, "decoding response as JSON")
-	}
-
-	// if a non-zero response code was received from CNS, it means something went
-	// wrong and it should be surfaced to the caller as an error
-	if out.Response.ReturnCode != 0 {
-		return errors.New(out.Response.Message)
-	}
-
-	// otherwise the response isn't terribly useful in a successful case, so it
-	// doesn't make sense to provide it to callers. The absence of an error is
-	// sufficient to communicate success.
-	return nil
+	})
+	return err
 }
 
 // SetOrchestratorType sets the orchestrator type for a given node
@@ -684,41 +419,8 @@ func (c *Client) SetOrchestratorType(ctx context.Context, sotr cns.SetOrchestrat
 		return errors.New("request missing field NodeID")
 	}
 
-	// build the HTTP request using the supplied request body
-	// submit the request
-	body, err := json.Marshal(sotr)
-	if err != nil {
-		return errors.Wrap(err, "encoding request body")
-	}
-	u := c.routes[cns.SetOrchestratorType]
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
-	if err != nil {
-		return errors.Wrap(err, "building HTTP request")
-	}
-
-	// send the request
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return errors.Wrap(err, "sending HTTP request")
-	}
-	defer resp.Body.Close()
-
-	// decode the response
-	var out cns.Response
-	err = json.NewDecoder(resp.Body).Decode(&out)
-	if err != nil {
-		return errors.Wrap(err, "decoding JSON response")
-	}
-
-	// if there was a non-zero response code, this is an error that
-	// should be communicated back to the caller...
-	if out.ReturnCode != 0 {
-		return errors.New(out.Message)
-	}
-
-	// ...otherwise it's a success and returning nil is sufficient to
-	// communicate that
-	return nil
+	_, err := call[cns.Response](ctx, c, "SetOrchestratorType", sotr)
+	return err
 }
 
 // CreateNetworkContainer will create the provided network container, or update
@@ -733,40 +435,8 @@ func (c *Client) CreateNetworkContainer(ctx context.Context, cncr cns.CreateNetw
 		return errors.New("empty request provided")
 	}
 
-	// build the request using the supplied struct and the client's internal
-	// routes
-	body, err := json.Marshal(cncr)
-	if err != nil {
-		return errors.Wrap(err, "encoding request as JSON")
-	}
-	u := c.routes[cns.CreateOrUpdateNetworkContainer]
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
-	if err != nil {
-		return errors.Wrap(err, "building HTTP request")
-	}
-
-	// send the request
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return errors.Wrap(err, "sending HTTP request")
-	}
-	defer resp.Body.Close()
-
-	// decode the response
-	var out cns.Response
-	err = json.NewDecoder(resp.Body).Decode(&out)
-	if err != nil {
-		return errors.Wrap(err, "decoding JSON response")
-	}
-
-	// if there was a non-zero response code, this is an error that
-	// should be communicated back to the caller...
-	if out.ReturnCode != 0 {
-		return errors.New(out.Message)
-	}
-
-	// ...otherwise the request was successful so
-	return nil
+	_, err := call[cns.CreateNetworkContainerResponse](ctx, c, "CreateOrUpdateNetworkContainer", cncr)
+	return err
 }
 
 // PublishNetworkContainer publishes the provided network container via the
@@ -782,39 +452,8 @@ func (c *Client) PublishNetworkContainer(ctx context.Context, pncr cns.PublishNe
 		return errors.New("network container id missing from request")
 	}
 
-	// Now that the request is valid it can be packaged as an HTTP request:
-	body, err := json.Marshal(pncr)
-	if err != nil {
-		return errors.Wrap(err, "encoding request body as json")
-	}
-	u := c.routes[cns.PublishNetworkContainer]
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
-	if err != nil {
-		return errors.Wrap(err, "building HTTP request")
-	}
-
-	// send the HTTP request
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return errors.Wrap(err, "sending HTTP request")
-	}
-	defer resp.Body.Close()
-
-	// decode the response to see if it was successful
-	var out cns.PublishNetworkContainerResponse
-	err = json.NewDecoder(resp.Body).Decode(&out)
-	if err != nil {
-		return errors.Wrap(err, "decoding JSON response")
-	}
-
-	// if there was a non-zero response code, this is an error that
-	// should be communicated back to the caller...
-	if out.Response.ReturnCode != 0 {
-		return errors.New(out.Response.Message)
-	}
-
-	// ...otherwise the request was successful so
-	return nil
+	_, err := call[cns.PublishNetworkContainerResponse](ctx, c, "PublishNetworkContainer", pncr)
+	return err
 }
 
 // UnpublishNC unpublishes the network container via the NMAgent running
@@ -828,184 +467,39 @@ func (c *Client) UnpublishNC(ctx context.Context, uncr cns.UnpublishNetworkConta
 		return errors.New("request missing network container id")
 	}
 
-	// Now that the request is valid it can be packaged as an HTTP request:
-	body, err := json.Marshal(uncr)
-	if err != nil {
-		return errors.Wrap(err, "encoding request body as json")
-	}
-	u := c.routes[cns.UnpublishNetworkContainer]
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
-	if err != nil {
-		return errors.Wrap(err, "building HTTP request")
-	}
-
-	// send the HTTP request
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return errors.Wrap(err, "sending HTTP request")
-	}
-	defer resp.Body.Close()
-
-	// decode the response to see if it was successful
-	var out cns.UnpublishNetworkContainerResponse
-	err = json.NewDecoder(resp.Body).Decode(&out)
-	if err != nil {
-		return errors.Wrap(err, "decoding JSON response")
-	}
-
-	// if there was a non-zero response code, this is an error that
-	// should be communicated back to the caller...
-	if out.Response.ReturnCode != 0 {
-		return errors.New(out.Response.Message)
-	}
-
-	// ...otherwise the request was successful so
-	return nil
+	_, err := call[cns.UnpublishNetworkContainerResponse](ctx, c, "UnpublishNetworkContainer", uncr)
+	return err
 }
 
 // NMAgentSupportedAPIs returns the supported API names from NMAgent. This can
 // be used, for example, to detect whether the node is capable for GRE
-// allocations.
+// allocations. It is a POST, as declared by the CNS API: the client used to send
+// a GET, which the handler has always rejected.
 func (c *Client) NMAgentSupportedAPIs(ctx context.Context) (*cns.NmAgentSupportedApisResponse, error) {
-	// build the request
-	reqBody := &cns.NmAgentSupportedApisRequest{
+	return call[cns.NmAgentSupportedApisResponse](ctx, c, "GetNmAgentSupportedApis", cns.NmAgentSupportedApisRequest{
 		// the IP used below is that of the Wireserver
 		GetNmAgentSupportedApisURL: "http://168.63.129.16/machine/plugins/?comp=nmagent&type=GetSupportedApis",
-	}
-
-	body, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, errors.Wrap(err, "encoding request body")
-	}
-
-	u := c.routes[cns.NMAgentSupportedAPIs]
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), bytes.NewReader(body))
-	if err != nil {
-		return nil, errors.Wrap(err, "building http request")
-	}
-
-	// submit the request
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, errors.Wrap(err, "sending http request")
-	}
-	defer resp.Body.Close()
-
-	if code := resp.StatusCode; code != http.StatusOK {
-		return nil, &FailedHTTPRequest{
-			Code: code,
-		}
-	}
-
-	// decode response
-	var out cns.NmAgentSupportedApisResponse
-	err = json.NewDecoder(resp.Body).Decode(&out)
-	if err != nil {
-		return nil, errors.Wrap(err, "decoding response body")
-	}
-
-	// if there was a non-zero status code, that indicates an error and should be
-	// communicated as such
-	if out.Response.ReturnCode != 0 {
-		return nil, &CNSClientError{
-			Code: out.Response.ReturnCode,
-			Err:  errors.New(out.Response.Message),
-		}
-	}
-
-	return &out, nil
+	})
 }
 
 func (c *Client) GetAllNCsFromCns(ctx context.Context) (cns.GetAllNetworkContainersResponse, error) {
-	// Build the request
-	urlPath := c.routes[cns.NetworkContainersURLPath]
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlPath.String(), http.NoBody)
-	if err != nil {
-		return cns.GetAllNetworkContainersResponse{}, errors.Wrap(err, "building HTTP request")
-	}
-
-	// Submit the request
-	resp, err := c.client.Do(req)
+	resp, err := call[cns.GetAllNetworkContainersResponse](ctx, c, "GetNetworkContainers", nil)
 	if err != nil {
-		return cns.GetAllNetworkContainersResponse{}, errors.Wrap(err, "sending HTTP request")
-	}
-	defer resp.Body.Close()
-
-	// Decode the response
-	var response cns.GetAllNetworkContainersResponse
-	err = json.NewDecoder(resp.Body).Decode(&response)
-	if err != nil || response.Response.ReturnCode != types.Success {
-		return cns.GetAllNetworkContainersResponse{}, errors.Wrap(err, "decoding GetAllNetworkContainersResponse as JSON")
+		return cns.GetAllNetworkContainersResponse{}, err
 	}
-
-	return response, nil
+	return *resp, nil
 }
 
 func (c *Client) PostAllNetworkContainers(ctx context.Context, createNcRequest cns.PostNetworkContainersRequest) error {
-	if createNcRequest.CreateNetworkContainerRequests == nil || len(createNcRequest.CreateNetworkContainerRequests) == 0 {
+	if len(createNcRequest.CreateNetworkContainerRequests) == 0 {
 		return errors.New("empty request provided")
 	}
 
-	// Build the request
-	var body bytes.Buffer
-	err := json.NewEncoder(&body).Encode(createNcRequest)
-	if err != nil {
-		return errors.Wrap(err, "building HTTP request")
-	}
-	urlPath := c.routes[cns.NetworkContainersURLPath]
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, urlPath.String(), &body)
-	if err != nil {
-		return errors.Wrap(err, "building HTTP request")
-	}
-
-	// Submit the request
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return errors.Wrap(err, "sending HTTP request")
-	}
-	defer resp.Body.Close()
-
-	// Decode the response
-	var response cns.PostNetworkContainersResponse
-	err = json.NewDecoder(resp.Body).Decode(&response)
-	if err != nil || response.Response.ReturnCode != types.Success {
-		return errors.Wrap(err, "decoding PostNetworkContainersResponse as JSON")
-	}
-
-	return nil
+	_, err := call[cns.PostNetworkContainersResponse](ctx, c, "PostNetworkContainers", createNcRequest)
+	return err
 }
 
 // GetHomeAz gets home AZ of host
 func (c *Client) GetHomeAz(ctx context.Context) (*cns.GetHomeAzResponse, error) {
-	// build the request
-	u := c.routes[cns.GetHomeAz]
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
-	if err != nil {
-		return nil, errors.Wrap(err, "building http request")
-	}
-
-	// submit the request
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, errors.Wrap(err, "sending HTTP request")
-	}
-	defer resp.Body.Close()
-
-	// decode the response
-	var getHomeAzResponse cns.GetHomeAzResponse
-	err = json.NewDecoder(resp.Body).Decode(&getHomeAzResponse)
-	if err != nil {
-		return nil, errors.Wrap(err, "decoding response as JSON")
-	}
-
-	// if the return code is non-zero, something went wrong and it should be
-	// surfaced to the caller
-	if getHomeAzResponse.Response.ReturnCode != 0 {
-		return nil, &CNSClientError{
-			Code: getHomeAzResponse.Response.ReturnCode,
-			Err:  errors.New(getHomeAzResponse.Response.Message),
-		}
-	}
-
-	return &getHomeAzResponse, nil
+	return call[cns.GetHomeAzResponse](ctx, c, "GetHomeAz", nil)
 }
@@ -9,8 +9,11 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
 	"sort"
 	"strconv"
 	"testing"
@@ -24,6 +27,7 @@ import (
 	"github.com/Azure/azure-container-networking/cns/types"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
 	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -58,8 +62,13 @@ func (m *mockdo) Do(req *http.Request) (*http.Response, error) {
 	byteArray, _ := json.Marshal(m.objToReturn)
 	body := io.NopCloser(bytes.NewReader(byteArray))
 
+	// like a server, the mock responds with 200 unless it is given another status code
+	statusCode := m.httpStatusCodeToReturn
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
 	return &http.Response{
-		StatusCode: m.httpStatusCodeToReturn,
+		StatusCode: statusCode,
 		Body:       body,
 	}, m.errToReturn
 }
@@ -382,6 +391,40 @@ func TestCNSClientDebugAPI(t *testing.T) {
 	assert.EqualValues(t, 50, testIpamPoolMonitor.CachedNNC.Status.Scaler.RequestThresholdPercent, "IPAMPoolMonitor cached NNC Status is not reflecting the initial set values")
 	assert.Len(t, testIpamPoolMonitor.CachedNNC.Status.NetworkContainers, 1, "Expected only one Network Container in the list")
 
+	ncs, err := cnsClient.GetNetworkContainersDebug(context.TODO())
+	assert.NoError(t, err, "Get network containers failed")
+	assert.Contains(t, ncs, cns.NetworkContainerDebugInfo{
+		ID:               "testNcId1",
+		Type:             string(cns.Docker),
+		Version:          "-1",
+		HostVersion:      "-1",
+		PrimaryIP:        "10.0.0.5",
+		SecondaryIPCount: 1,
+		ProgrammingState: types.NCRequested,
+	})
+
+	endpoints, err := cnsClient.GetEndpointState(context.TODO())
+	assert.NoError(t, err, "Get endpoint state failed")
+	assert.NotNil(t, endpoints)
+
+	// reconcile is not available until CNS reconciles a NodeNetworkConfig
+	assert.Error(t, cnsClient.TriggerReconcile(context.TODO()))
+	reconciled := false
+	svc.TriggerReconcile = func(context.Context) error {
+		reconciled = true
+		return nil
+	}
+	defer func() { svc.TriggerReconcile = nil }()
+	assert.NoError(t, cnsClient.TriggerReconcile(context.TODO()))
+	assert.True(t, reconciled)
+
+	var archive bytes.Buffer
+	require.NoError(t, cnsClient.GetSnapshot(context.TODO(), &archive))
+	snapshot, err := restserver.ReadSnapshot(&archive)
+	require.NoError(t, err)
+	assert.Equal(t, inmemory.HTTPRestServiceData.PodIPIDByPodInterfaceKey, snapshot.PodIPIDByPodInterfaceKey)
+	assert.Len(t, snapshot.PodIPConfigState, len(podConfig))
+
 	t.Logf("In-memory Data: ")
 	for i := range inmemory.HTTPRestServiceData.PodIPIDByPodInterfaceKey {
 		t.Logf("PodIPIDByOrchestratorContext: %+v", inmemory.HTTPRestServiceData.PodIPIDByPodInterfaceKey[i])
@@ -409,7 +452,11 @@ func TestNew(t *testing.T) {
 			want: &Client{
 				routes: emptyRoutes,
 				client: &http.Client{
-					Timeout: 0,
+					Timeout:   0,
+					Transport: tracing.NewTransport(nil),
+				},
+				stream: &http.Client{
+					Transport: tracing.NewTransport(nil),
 				},
 			},
 			wantErr: false,
@@ -420,7 +467,11 @@ func TestNew(t *testing.T) {
 			want: &Client{
 				routes: fqdnRoutes,
 				client: &http.Client{
-					Timeout: 0,
+					Timeout:   0,
+					Transport: tracing.NewTransport(nil),
+				},
+				stream: &http.Client{
+					Transport: tracing.NewTransport(nil),
 				},
 			},
 			wantErr: false,
@@ -431,7 +482,11 @@ func TestNew(t *testing.T) {
 			want: &Client{
 				routes: fqdnWithPortRoutes,
 				client: &http.Client{
-					Timeout: 0,
+					Timeout:   0,
+					Transport: tracing.NewTransport(nil),
+				},
+				stream: &http.Client{
+					Transport: tracing.NewTransport(nil),
 				},
 			},
 			wantErr: false,
@@ -457,6 +512,28 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNewUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "cns.sock")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(cns.GetHomeAz, func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(cns.GetHomeAzResponse{HomeAzResponse: cns.HomeAzResponse{HomeAz: 1}})
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: time.Second}
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Close()
+
+	for _, baseURL := range []string{socket, "unix://" + socket} {
+		c, err := New(baseURL, time.Second)
+		require.NoError(t, err)
+		resp, err := c.GetHomeAz(context.Background())
+		require.NoError(t, err, baseURL)
+		assert.Equal(t, uint(1), resp.HomeAzResponse.HomeAz)
+	}
+}
+
 func TestBuildRoutes(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -2765,3 +2842,137 @@ func TestGetHomeAz(t *testing.T) {
 		})
 	}
 }
+
+// declaredAPI is a fake CNS that serves the operations declared by the CNS API: it rejects the undeclared methods
+// and the request bodies that do not decode to the declared type, and responds with the zero declared response.
+func declaredAPI(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, ok := restserver.LookupOperation(r.URL.Path, r.Method)
+		if !ok {
+			t.Errorf("%s %s is not declared", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if op.Request != nil {
+			dec := json.NewDecoder(r.Body)
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(reflect.New(op.Request).Interface()); err != nil {
+				t.Errorf("%s %s: request does not decode to %s: %v", r.Method, r.URL.Path, op.Request, err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		_ = json.NewEncoder(w).Encode(reflect.New(op.Response).Interface())
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientCallsDeclaredOperations(t *testing.T) {
+	srv := declaredAPI(t)
+	c, err := New(srv.URL, time.Second)
+	require.NoError(t, err)
+
+	orchestratorContext, _ := json.Marshal(cns.KubernetesPodInfo{PodName: "pod", PodNamespace: "default"})
+	ipconfigs := cns.IPConfigsRequest{PodInterfaceID: "pod-eth0", InfraContainerID: "infra", OrchestratorContext: orchestratorContext}
+	ipconfig := cns.IPConfigRequest{PodInterfaceID: "pod-eth0", InfraContainerID: "infra", OrchestratorContext: orchestratorContext}
+
+	calls := map[string]func(context.Context) error{
+		"GetAllNetworkContainers": func(ctx context.Context) error {
+			_, err := c.GetAllNetworkContainers(ctx, orchestratorContext)
+			return err
+		},
+		"GetNetworkContainer": func(ctx context.Context) error {
+			_, err := c.GetNetworkContainer(ctx, orchestratorContext)
+			return err
+		},
+		"CreateHostNCApipaEndpoint": func(ctx context.Context) error {
+			_, err := c.CreateHostNCApipaEndpoint(ctx, "nc")
+			return err
+		},
+		"DeleteHostNCApipaEndpoint": func(ctx context.Context) error { return c.DeleteHostNCApipaEndpoint(ctx, "nc") },
+		"RequestIPAddress": func(ctx context.Context) error {
+			_, err := c.RequestIPAddress(ctx, ipconfig)
+			return err
+		},
+		"ReleaseIPAddress": func(ctx context.Context) error { return c.ReleaseIPAddress(ctx, ipconfig) },
+		"RequestIPs": func(ctx context.Context) error {
+			_, err := c.RequestIPs(ctx, ipconfigs)
+			return err
+		},
+		"ReleaseIPs": func(ctx context.Context) error { return c.ReleaseIPs(ctx, ipconfigs) },
+		"GetIPAddressesMatchingStates": func(ctx context.Context) error {
+			_, err := c.GetIPAddressesMatchingStates(ctx, types.Assigned)
+			return err
+		},
+		"GetPodOrchestratorContext": func(ctx context.Context) error {
+			_, err := c.GetPodOrchestratorContext(ctx)
+			return err
+		},
+		"GetHTTPServiceData": func(ctx context.Context) error {
+			_, err := c.GetHTTPServiceData(ctx)
+			return err
+		},
+		"GetNetworkContainersDebug": func(ctx context.Context) error {
+			_, err := c.GetNetworkContainersDebug(ctx)
+			return err
+		},
+		"GetEndpointState": func(ctx context.Context) error {
+			_, err := c.GetEndpointState(ctx)
+			return err
+		},
+		"TriggerReconcile": c.TriggerReconcile,
+		"GetSnapshot":      func(ctx context.Context) error { return c.GetSnapshot(ctx, io.Discard) },
+		"NumOfCPUCores": func(ctx context.Context) error {
+			_, err := c.NumOfCPUCores(ctx)
+			return err
+		},
+		"DeleteNetworkContainer": func(ctx context.Context) error { return c.DeleteNetworkContainer(ctx, "nc") },
+		"SetOrchestratorType": func(ctx context.Context) error {
+			return c.SetOrchestratorType(ctx, cns.SetOrchestratorTypeRequest{OrchestratorType: cns.KubernetesCRD, DncPartitionKey: "key", NodeID: "node"})
+		},
+		"CreateNetworkContainer": func(ctx context.Context) error {
+			return c.CreateNetworkContainer(ctx, cns.CreateNetworkContainerRequest{NetworkContainerid: "nc"})
+		},
+		"PublishNetworkContainer": func(ctx context.Context) error {
+			return c.PublishNetworkContainer(ctx, cns.PublishNetworkContainerRequest{NetworkContainerID: "nc"})
+		},
+		"UnpublishNC": func(ctx context.Context) error {
+			return c.UnpublishNC(ctx, cns.UnpublishNetworkContainerRequest{NetworkContainerID: "nc"})
+		},
+		"NMAgentSupportedAPIs": func(ctx context.Context) error {
+			_, err := c.NMAgentSupportedAPIs(ctx)
+			return err
+		},
+		"GetAllNCsFromCns": func(ctx context.Context) error {
+			_, err := c.GetAllNCsFromCns(ctx)
+			return err
+		},
+		"PostAllNetworkContainers": func(ctx context.Context) error {
+			return c.PostAllNetworkContainers(ctx, cns.PostNetworkContainersRequest{
+				CreateNetworkContainerRequests: []cns.CreateNetworkContainerRequest{{NetworkContainerid: "nc"}},
+			})
+		},
+		"GetHomeAz": func(ctx context.Context) error {
+			_, err := c.GetHomeAz(ctx)
+			return err
+		},
+	}
+	for name, call := range calls {
+		assert.NoError(t, call(context.Background()), name)
+	}
+}
+
+func TestClientRejectsUndeclaredOperation(t *testing.T) {
+	c, err := New("", time.Second)
+	require.NoError(t, err)
+	_, err = c.newRequest(context.Background(), "DeleteHomeAz", http.NoBody)
+	require.ErrorIs(t, err, ErrUndeclaredOperation)
+
+	// an operation is only called with its declared types
+	_, err = call[cns.Response](context.Background(), c, "GetHomeAz", nil)
+	require.ErrorIs(t, err, ErrUndeclaredOperation)
+	_, err = call[cns.GetHomeAzResponse](context.Background(), c, "GetHomeAz", cns.Response{})
+	require.ErrorIs(t, err, ErrUndeclaredOperation)
+}
@@ -0,0 +1,278 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/protos"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCClient calls the IPAM API of CNS over gRPC, which CNS serves on a Unix socket if it is enabled. It has the
+// IPAM methods of Client, so that its callers can opt in to it, and keeps its connection open between calls.
+type GRPCClient struct {
+	conn           *grpc.ClientConn
+	ipam           protos.IPAMClient
+	requestTimeout time.Duration
+}
+
+// NewGRPC returns a new gRPC CNS client of the Unix socket, or of the default CNS gRPC socket if it is empty. The
+// connection is made on the first call, and the options can override how it is made.
+func NewGRPC(socket string, requestTimeout time.Duration, opts ...grpc.DialOption) (*GRPCClient, error) {
+	if socket == "" {
+		socket = cns.DefaultGRPCSocketPath
+	}
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	conn, err := grpc.Dial(socketScheme+socket, opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to dial %s", socket)
+	}
+	return &GRPCClient{
+		conn:           conn,
+		ipam:           protos.NewIPAMClient(conn),
+		requestTimeout: requestTimeout,
+	}, nil
+}
+
+// Close closes the connection of the client.
+func (c *GRPCClient) Close() error {
+	return errors.Wrap(c.conn.Close(), "failed to close grpc connection")
+}
+
+func (c *GRPCClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.requestTimeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.requestTimeout)
+}
+
+// grpcError wraps the error of a call, as a CNSClientError with UnsupportedAPI if CNS does not serve the method.
+func grpcError(err error, method string) error {
+	if status.Code(err) == codes.Unimplemented {
+		return &CNSClientError{
+			Code: types.UnsupportedAPI,
+			Err:  errors.Errorf("Unsupported API"),
+		}
+	}
+	return errors.Wrapf(err, "grpc %s failed", method)
+}
+
+// RequestIPs calls RequestIPs on CNS, and releases the IPs of the pod if it fails.
+func (c *GRPCClient) RequestIPs(ctx context.Context, ipconfig cns.IPConfigsRequest) (*cns.IPConfigsResponse, error) {
+	var err error
+	defer func() {
+		if err != nil {
+			if e := c.ReleaseIPs(ctx, ipconfig); e != nil {
+				err = errors.Wrap(e, err.Error())
+			}
+		}
+	}()
+
+	callCtx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	resp, err := c.ipam.RequestIPs(callCtx, ipConfigsRequestToProto(ipconfig))
+	if err != nil {
+		return nil, grpcError(err, "RequestIPs")
+	}
+
+	if resp.GetResponse().GetReturnCode() != 0 {
+		err = errors.New(resp.GetResponse().GetMessage())
+		return nil, err
+	}
+
+	return ipConfigsResponseFromProto(resp), nil
+}
+
+// ReleaseIPs calls ReleaseIPs on CNS, which releases the IPs of the pod.
+func (c *GRPCClient) ReleaseIPs(ctx context.Context, ipconfig cns.IPConfigsRequest) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	resp, err := c.ipam.ReleaseIPs(ctx, ipConfigsRequestToProto(ipconfig))
+	if err != nil {
+		return grpcError(err, "ReleaseIPs")
+	}
+
+	if resp.GetReturnCode() != 0 {
+		return errors.New(resp.GetMessage())
+	}
+
+	return nil
+}
+
+// RequestIPAddress requests one IP for the pod, for the callers of the single IP HTTP API, such as azure-ipam.
+func (c *GRPCClient) RequestIPAddress(ctx context.Context, ipconfig cns.IPConfigRequest) (*cns.IPConfigResponse, error) {
+	req := ipConfigsRequestFromIPConfigRequest(ipconfig)
+	resp, err := c.RequestIPs(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// like the single IP HTTP API, exactly one IP is expected.
+	if len(resp.PodIPInfo) != 1 {
+		err = errors.Errorf("request returned incorrect number of IPs. Expected 1 and returned %d", len(resp.PodIPInfo))
+		if e := c.ReleaseIPs(ctx, req); e != nil {
+			err = errors.Wrap(e, err.Error())
+		}
+		return nil, err
+	}
+
+	return &cns.IPConfigResponse{
+		PodIpInfo: resp.PodIPInfo[0],
+		Response:  resp.Response,
+	}, nil
+}
+
+// ReleaseIPAddress releases the IP of the pod, for the callers of the single IP HTTP API, such as azure-ipam.
+func (c *GRPCClient) ReleaseIPAddress(ctx context.Context, ipconfig cns.IPConfigRequest) error {
+	return c.ReleaseIPs(ctx, ipConfigsRequestFromIPConfigRequest(ipconfig))
+}
+
+// GetIPAddressesMatchingStates returns the IPs in any of the states, or nothing if there are no states, like the
+// method of Client.
+func (c *GRPCClient) GetIPAddressesMatchingStates(ctx context.Context, stateFilter ...types.IPState) ([]cns.IPConfigurationStatus, error) {
+	if len(stateFilter) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	resp, err := c.ipam.GetIPs(ctx, &protos.GetIPsRequest{States: ipStatesToProto(stateFilter)})
+	if err != nil {
+		return nil, grpcError(err, "GetIPs")
+	}
+
+	if resp.GetResponse().GetReturnCode() != 0 {
+		return nil, errors.New(resp.GetResponse().GetMessage())
+	}
+
+	ips := make([]cns.IPConfigurationStatus, 0, len(resp.GetIps()))
+	for _, ip := range resp.GetIps() {
+		ips = append(ips, ipConfigurationStatusFromProto(ip))
+	}
+	return ips, nil
+}
+
+// IPStateEvent is an IP received from an IPStateWatch, with the state that it transitioned from, which is empty
+// for the IPs that are received when the watch starts.
+type IPStateEvent struct {
+	IP            cns.IPConfigurationStatus
+	PreviousState types.IPState
+}
+
+// IPStateWatch receives the IPs and their state transitions that CNS streams to WatchIPStates.
+type IPStateWatch struct {
+	stream protos.IPAM_WatchIPsClient
+}
+
+// Recv blocks until the next IP is received. The watch ends with an error, which is ResourceExhausted if the
+// watch fell behind the transitions, after which it can be started again.
+func (w *IPStateWatch) Recv() (IPStateEvent, error) {
+	event, err := w.stream.Recv()
+	if err != nil {
+		return IPStateEvent{}, errors.Wrap(err, "failed to receive ip state")
+	}
+	return IPStateEvent{
+		IP:            ipConfigurationStatusFromProto(event.GetIp()),
+		PreviousState: types.IPState(event.GetPreviousState()),
+	}, nil
+}
+
+// WatchIPStates watches the IPs in any of the states, or every IP if there are no states, until the context is
+// done. The watch receives the IPs first, and then their transitions from or to any of the states. The request
+// timeout of the client does not apply to the watch.
+func (c *GRPCClient) WatchIPStates(ctx context.Context, stateFilter ...types.IPState) (*IPStateWatch, error) {
+	stream, err := c.ipam.WatchIPs(ctx, &protos.GetIPsRequest{States: ipStatesToProto(stateFilter)})
+	if err != nil {
+		return nil, grpcError(err, "WatchIPs")
+	}
+	return &IPStateWatch{stream: stream}, nil
+}
+
+// ipConfigsRequestFromIPConfigRequest does not set the desired IPs if the desired IP is empty, like CNS does for
+// the single IP HTTP API.
+func ipConfigsRequestFromIPConfigRequest(ipconfig cns.IPConfigRequest) cns.IPConfigsRequest {
+	req := cns.IPConfigsRequest{
+		PodInterfaceID:      ipconfig.PodInterfaceID,
+		InfraContainerID:    ipconfig.InfraContainerID,
+		OrchestratorContext: ipconfig.OrchestratorContext,
+		Ifname:              ipconfig.Ifname,
+	}
+	if ipconfig.DesiredIPAddress != "" {
+		req.DesiredIPAddresses = []string{ipconfig.DesiredIPAddress}
+	}
+	return req
+}
+
+func ipConfigsRequestToProto(in cns.IPConfigsRequest) *protos.IPConfigsRequest {
+	return &protos.IPConfigsRequest{
+		DesiredIpAddresses:  in.DesiredIPAddresses,
+		PodInterfaceId:      in.PodInterfaceID,
+		InfraContainerId:    in.InfraContainerID,
+		OrchestratorContext: in.OrchestratorContext,
+		Ifname:              in.Ifname,
+	}
+}
+
+func ipStatesToProto(in []types.IPState) []string {
+	states := make([]string, 0, len(in))
+	for _, state := range in {
+		states = append(states, string(state))
+	}
+	return states
+}
+
+func ipSubnetFromProto(in *protos.IPSubnet) cns.IPSubnet {
+	return cns.IPSubnet{IPAddress: in.GetIpAddress(), PrefixLength: uint8(in.GetPrefixLength())}
+}
+
+func ipConfigsResponseFromProto(in *protos.IPConfigsResponse) *cns.IPConfigsResponse {
+	resp := &cns.IPConfigsResponse{
+		Response: cns.Response{
+			ReturnCode: types.ResponseCode(in.GetResponse().GetReturnCode()),
+			Message:    in.GetResponse().GetMessage(),
+		},
+		PodIPInfo:      make([]cns.PodIpInfo, 0, len(in.GetPodIpInfo())),
+		PodAnnotations: in.GetPodAnnotations(),
+	}
+	for _, info := range in.GetPodIpInfo() {
+		resp.PodIPInfo = append(resp.PodIPInfo, cns.PodIpInfo{
+			PodIPConfig: ipSubnetFromProto(info.GetPodIpConfig()),
+			NetworkContainerPrimaryIPConfig: cns.IPConfiguration{
+				IPSubnet:         ipSubnetFromProto(info.GetNetworkContainerPrimaryIpConfig().GetIpSubnet()),
+				DNSServers:       info.GetNetworkContainerPrimaryIpConfig().GetDnsServers(),
+				GatewayIPAddress: info.GetNetworkContainerPrimaryIpConfig().GetGatewayIpAddress(),
+			},
+			HostPrimaryIPInfo: cns.HostIPInfo{
+				Gateway:   info.GetHostPrimaryIpInfo().GetGateway(),
+				PrimaryIP: info.GetHostPrimaryIpInfo().GetPrimaryIp(),
+				Subnet:    info.GetHostPrimaryIpInfo().GetSubnet(),
+			},
+			MTU: int(info.GetMtu()),
+		})
+	}
+	return resp
+}
+
+func ipConfigurationStatusFromProto(in *protos.IPConfigurationStatus) cns.IPConfigurationStatus {
+	ip := cns.IPConfigurationStatus{
+		ID:        in.GetId(),
+		IPAddress: in.GetIpAddress(),
+		NCID:      in.GetNcId(),
+	}
+	if pod := in.GetPodInfo(); pod != nil {
+		ip.PodInfo = cns.NewPodInfo(pod.GetInfraContainerId(), pod.GetInterfaceId(), pod.GetName(), pod.GetNamespace())
+	}
+	// setting the state also sets the transition time, which is replaced by the one of CNS.
+	ip.SetState(types.IPState(in.GetState()))
+	ip.LastStateTransition = time.Time{}
+	if t := in.GetLastStateTransition(); t != nil {
+		ip.LastStateTransition = t.AsTime()
+	}
+	return ip
+}
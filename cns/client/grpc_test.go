@@ -0,0 +1,219 @@
+package client
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/protos"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeIPAM is a gRPC IPAM server that responds with the responses it is given and records the calls it receives.
+type fakeIPAM struct {
+	protos.UnimplementedIPAMServer
+	sync.Mutex
+	requestResp *protos.IPConfigsResponse
+	ips         []*protos.IPConfigurationStatus
+	events      []*protos.IPStateEvent
+	requests    []*protos.IPConfigsRequest
+	releases    []*protos.IPConfigsRequest
+	states      []string
+}
+
+func (f *fakeIPAM) RequestIPs(_ context.Context, in *protos.IPConfigsRequest) (*protos.IPConfigsResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.requests = append(f.requests, in)
+	return f.requestResp, nil
+}
+
+func (f *fakeIPAM) ReleaseIPs(_ context.Context, in *protos.IPConfigsRequest) (*protos.Response, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.releases = append(f.releases, in)
+	return &protos.Response{}, nil
+}
+
+func (f *fakeIPAM) GetIPs(_ context.Context, in *protos.GetIPsRequest) (*protos.GetIPsResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.states = in.GetStates()
+	return &protos.GetIPsResponse{Response: &protos.Response{}, Ips: f.ips}, nil
+}
+
+func (f *fakeIPAM) WatchIPs(in *protos.GetIPsRequest, stream protos.IPAM_WatchIPsServer) error {
+	f.Lock()
+	f.states = in.GetStates()
+	events := f.events
+	f.Unlock()
+	for _, event := range events {
+		if err := stream.Send(event); err != nil {
+			return err
+		}
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+// newGRPCTestClient serves the IPAM server on an in-memory connection and returns a client of it.
+func newGRPCTestClient(t *testing.T, srv protos.IPAMServer) *GRPCClient {
+	t.Helper()
+	l := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	protos.RegisterIPAMServer(s, srv)
+	go func() {
+		_ = s.Serve(l)
+	}()
+	t.Cleanup(s.Stop)
+
+	c, err := NewGRPC("", time.Second, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return l.DialContext(ctx)
+	}))
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+var grpcPodIPInfo = &protos.PodIPInfo{
+	PodIpConfig: &protos.IPSubnet{IpAddress: "10.0.0.5", PrefixLength: 24},
+	NetworkContainerPrimaryIpConfig: &protos.IPConfiguration{
+		IpSubnet:         &protos.IPSubnet{IpAddress: "10.0.0.4", PrefixLength: 24},
+		DnsServers:       []string{"168.63.129.16"},
+		GatewayIpAddress: "10.0.0.1",
+	},
+	HostPrimaryIpInfo: &protos.HostIPInfo{Gateway: "10.224.0.1", PrimaryIp: "10.224.0.4", Subnet: "10.224.0.0/16"},
+	Mtu:               1500,
+}
+
+func TestGRPCRequestIPAddress(t *testing.T) {
+	f := &fakeIPAM{requestResp: &protos.IPConfigsResponse{Response: &protos.Response{}, PodIpInfo: []*protos.PodIPInfo{grpcPodIPInfo}}}
+	c := newGRPCTestClient(t, f)
+
+	resp, err := c.RequestIPAddress(context.Background(), cns.IPConfigRequest{
+		DesiredIPAddress:    "10.0.0.5",
+		PodInterfaceID:      "pod-eth0",
+		InfraContainerID:    "infra",
+		OrchestratorContext: []byte(`{"PodName":"pod","PodNamespace":"default"}`),
+		Ifname:              "eth0",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &cns.IPConfigResponse{
+		PodIpInfo: cns.PodIpInfo{
+			PodIPConfig: cns.IPSubnet{IPAddress: "10.0.0.5", PrefixLength: 24},
+			NetworkContainerPrimaryIPConfig: cns.IPConfiguration{
+				IPSubnet:         cns.IPSubnet{IPAddress: "10.0.0.4", PrefixLength: 24},
+				DNSServers:       []string{"168.63.129.16"},
+				GatewayIPAddress: "10.0.0.1",
+			},
+			HostPrimaryIPInfo: cns.HostIPInfo{Gateway: "10.224.0.1", PrimaryIP: "10.224.0.4", Subnet: "10.224.0.0/16"},
+			MTU:               1500,
+		},
+	}, resp)
+
+	require.Len(t, f.requests, 1)
+	assert.True(t, proto.Equal(&protos.IPConfigsRequest{
+		DesiredIpAddresses:  []string{"10.0.0.5"},
+		PodInterfaceId:      "pod-eth0",
+		InfraContainerId:    "infra",
+		OrchestratorContext: []byte(`{"PodName":"pod","PodNamespace":"default"}`),
+		Ifname:              "eth0",
+	}, f.requests[0]), f.requests[0])
+	assert.Empty(t, f.releases)
+}
+
+func TestGRPCRequestIPsReleasesOnFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *protos.IPConfigsResponse
+	}{
+		{
+			name: "cns return code",
+			resp: &protos.IPConfigsResponse{Response: &protos.Response{ReturnCode: int32(types.FailedToAllocateIPConfig), Message: "no ips"}},
+		},
+		{
+			name: "more than one ip",
+			resp: &protos.IPConfigsResponse{Response: &protos.Response{}, PodIpInfo: []*protos.PodIPInfo{grpcPodIPInfo, grpcPodIPInfo}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeIPAM{requestResp: tt.resp}
+			c := newGRPCTestClient(t, f)
+			_, err := c.RequestIPAddress(context.Background(), cns.IPConfigRequest{PodInterfaceID: "pod-eth0", InfraContainerID: "infra"})
+			require.Error(t, err)
+			require.NotEmpty(t, f.releases)
+			assert.Equal(t, "infra", f.releases[0].GetInfraContainerId())
+		})
+	}
+}
+
+func TestGRPCGetIPAddressesMatchingStates(t *testing.T) {
+	transition := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	f := &fakeIPAM{ips: []*protos.IPConfigurationStatus{
+		{
+			Id: "id1", IpAddress: "10.0.0.5", NcId: "nc1", State: string(types.Assigned),
+			LastStateTransition: timestamppb.New(transition),
+			PodInfo:             &protos.PodInfo{Name: "pod", Namespace: "default", InterfaceId: "pod-eth0", InfraContainerId: "infra"},
+		},
+		{Id: "id2", IpAddress: "10.0.0.6", NcId: "nc1", State: string(types.PendingRelease)},
+	}}
+	c := newGRPCTestClient(t, f)
+
+	ips, err := c.GetIPAddressesMatchingStates(context.Background(), types.Assigned, types.PendingRelease)
+	require.NoError(t, err)
+	assert.Equal(t, []string{string(types.Assigned), string(types.PendingRelease)}, f.states)
+	require.Len(t, ips, 2)
+	assert.Equal(t, types.Assigned, ips[0].GetState())
+	assert.Equal(t, transition, ips[0].LastStateTransition)
+	assert.True(t, cns.NewPodInfo("infra", "pod-eth0", "pod", "default").Equals(ips[0].PodInfo))
+	assert.Equal(t, types.PendingRelease, ips[1].GetState())
+	assert.Nil(t, ips[1].PodInfo)
+
+	// like over HTTP, no states match no IPs.
+	ips, err = c.GetIPAddressesMatchingStates(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, ips)
+}
+
+func TestGRPCWatchIPStates(t *testing.T) {
+	f := &fakeIPAM{events: []*protos.IPStateEvent{
+		{Ip: &protos.IPConfigurationStatus{Id: "id1", State: string(types.Available)}},
+		{Ip: &protos.IPConfigurationStatus{Id: "id1", State: string(types.Assigned)}, PreviousState: string(types.Available)},
+	}}
+	c := newGRPCTestClient(t, f)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w, err := c.WatchIPStates(ctx, types.Assigned)
+	require.NoError(t, err)
+	event, err := w.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, types.Available, event.IP.GetState())
+	assert.Empty(t, event.PreviousState)
+	event, err = w.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, types.Assigned, event.IP.GetState())
+	assert.Equal(t, types.Available, event.PreviousState)
+	assert.Equal(t, []string{string(types.Assigned)}, f.states)
+
+	cancel()
+	_, err = w.Recv()
+	require.Error(t, err)
+}
+
+func TestGRPCUnsupportedAPI(t *testing.T) {
+	c := newGRPCTestClient(t, &protos.UnimplementedIPAMServer{})
+	err := c.ReleaseIPs(context.Background(), cns.IPConfigsRequest{})
+	assert.True(t, IsUnsupportedAPI(err), err)
+}
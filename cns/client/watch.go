@@ -0,0 +1,125 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/pkg/errors"
+)
+
+// maxWatchEventSize is the size of the largest event that a Watch receives, such as a pool spec with many IPs.
+const maxWatchEventSize = 1 << 20
+
+var (
+	// ErrWatchRevisionGone is returned when a watch is resumed after a revision whose events CNS does not have
+	// anymore, such as a revision of a CNS process that restarted. The watcher must get the state of CNS again, and
+	// start a new watch.
+	ErrWatchRevisionGone = errors.New("the events after the watch revision are gone")
+	// ErrWatchOverflow is returned when the watch fell behind the events of CNS and was ended. The watch can be
+	// resumed after its Revision.
+	ErrWatchOverflow = errors.New("the watch fell behind the events of CNS")
+)
+
+// Watch receives the events that CNS streams to a watch.
+type Watch struct {
+	body     io.ReadCloser
+	scanner  *bufio.Scanner
+	revision cns.WatchRevision
+}
+
+// Watch watches the events of any of the types, or of every type, after the revision, or after the current
+// revision of CNS if it is empty, until the context is done or the Watch is closed. The request timeout of the client
+// does not apply to the watch.
+func (c *Client) Watch(ctx context.Context, revision cns.WatchRevision, eventTypes ...cns.WatchEventType) (*Watch, error) {
+	req, err := c.newRequest(ctx, "Watch", http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	if revision != "" {
+		query.Set("revision", string(revision))
+	}
+	if len(eventTypes) > 0 {
+		types := make([]string, 0, len(eventTypes))
+		for _, t := range eventTypes {
+			types = append(types, string(t))
+		}
+		query.Set("types", strings.Join(types, ","))
+	}
+	req.URL.RawQuery = query.Encode()
+	req.Header.Set("Accept", "text/event-stream")
+
+	stream := c.stream
+	if stream == nil {
+		stream = c.client
+	}
+	res, err := stream.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "http request failed")
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, maxWatchEventSize))
+		if res.StatusCode == http.StatusGone {
+			return nil, errors.Wrap(ErrWatchRevisionGone, strings.TrimSpace(string(msg)))
+		}
+		return nil, errors.Errorf("http response %d: %s", res.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	w := &Watch{body: res.Body, scanner: bufio.NewScanner(res.Body), revision: revision}
+	w.scanner.Buffer(nil, maxWatchEventSize)
+	if revision == "" {
+		w.revision = cns.WatchRevision(res.Header.Get(cns.WatchRevisionHeader))
+	}
+	return w, nil
+}
+
+// Revision returns the revision of the last event received, or of CNS when the watch started if no event was
+// received, which a new watch can be resumed after.
+func (w *Watch) Revision() cns.WatchRevision {
+	return w.revision
+}
+
+// Recv blocks until the next event is received. The watch ends with ErrWatchOverflow if it fell behind the events
+// of CNS, or with io.EOF if CNS ended it.
+func (w *Watch) Recv() (cns.WatchEvent, error) {
+	var data []byte
+	for w.scanner.Scan() {
+		line := w.scanner.Bytes()
+		switch {
+		case len(line) == 0:
+			// an empty line ends an event, or a comment that is sent to keep the watch open.
+			if data == nil {
+				continue
+			}
+			var event cns.WatchEvent
+			if err := json.Unmarshal(data, &event); err != nil {
+				return cns.WatchEvent{}, errors.Wrap(err, "failed to decode watch event")
+			}
+			if event.Type == cns.WatchOverflow {
+				return event, ErrWatchOverflow
+			}
+			w.revision = event.Revision
+			return event, nil
+		case bytes.HasPrefix(line, []byte("data:")):
+			data = append(data, bytes.TrimSpace(line[len("data:"):])...)
+		}
+	}
+	if err := w.scanner.Err(); err != nil {
+		return cns.WatchEvent{}, errors.Wrap(err, "failed to receive watch event")
+	}
+	return cns.WatchEvent{}, io.EOF
+}
+
+// Close ends the watch.
+func (w *Watch) Close() error {
+	return errors.Wrap(w.body.Close(), "failed to close watch")
+}
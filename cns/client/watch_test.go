@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Header().Set(cns.WatchRevisionHeader, "e1.7")
+		fmt.Fprint(w, ": heartbeat\n\n")
+		fmt.Fprint(w, "id: e1.8\nevent: NCCreated\ndata: {\"Revision\":\"e1.8\",\"Type\":\"NCCreated\",\"NC\":{\"ID\":\"nc1\"}}\n\n")
+		fmt.Fprint(w, "event: Overflow\ndata: {\"Type\":\"Overflow\"}\n\n")
+	}))
+	defer srv.Close()
+	c, err := New(srv.URL, time.Second)
+	require.NoError(t, err)
+
+	// a new watch is at the revision of CNS when it started.
+	w, err := c.Watch(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, query)
+	assert.Equal(t, cns.WatchRevision("e1.7"), w.Revision())
+	require.NoError(t, w.Close())
+
+	// a resumed watch is at its revision until it receives the events after it.
+	w, err = c.Watch(context.Background(), "e1.5", cns.WatchNCCreated, cns.WatchNCDeleted)
+	require.NoError(t, err)
+	defer w.Close()
+	assert.Equal(t, "revision=e1.5&types=NCCreated%2CNCDeleted", query)
+	assert.Equal(t, cns.WatchRevision("e1.5"), w.Revision())
+
+	event, err := w.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, cns.WatchNCCreated, event.Type)
+	assert.Equal(t, "nc1", event.NC.ID)
+	assert.Equal(t, cns.WatchRevision("e1.8"), w.Revision())
+
+	_, err = w.Recv()
+	require.ErrorIs(t, err, ErrWatchOverflow)
+	_, err = w.Recv()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestWatchRevisionGone(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "revision e0.3 is not of epoch e1", http.StatusGone)
+	}))
+	defer srv.Close()
+	c, err := New(srv.URL, time.Second)
+	require.NoError(t, err)
+
+	_, err = c.Watch(context.Background(), "e0.3")
+	require.ErrorIs(t, err, ErrWatchRevisionGone)
+}
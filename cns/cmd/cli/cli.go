@@ -1,15 +1,18 @@
+// Package cli is the CNS CLI, which inspects and administers the CNS of the node over the CNS API.
 package cli
 
 import (
 	"context"
 	"fmt"
 	"os"
-	"sort"
 	"strings"
+	"time"
 
-	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/client"
 	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
 )
 
 const (
@@ -20,82 +23,89 @@ const (
 	getPodCmdArg    = "getPodContexts"
 )
 
-func HandleCNSClientCommands(ctx context.Context, cmd string, arg string) error {
-	cnsIPAddress := os.Getenv(envCNSIPAddress)
-	cnsPort := os.Getenv(envCNSPort)
+// options are the flags shared by the commands.
+type options struct {
+	url     string
+	timeout time.Duration
+	output  string
+	// getNNC gets the NodeNetworkConfig of the node, and is replaced in tests.
+	getNNC func(context.Context, *options) (*v1alpha.NodeNetworkConfig, error)
+	// node is the name of the node of the NodeNetworkConfig.
+	node string
+}
 
-	cnsClient, err := client.New("http://"+cnsIPAddress+":"+cnsPort, client.DefaultTimeout)
+func (o *options) client() (*client.Client, error) {
+	c, err := client.New(o.url, o.timeout)
 	if err != nil {
-		return err
-	}
-
-	switch {
-	case strings.EqualFold(getCmdArg, cmd):
-		return getCmd(ctx, cnsClient, arg)
-	case strings.EqualFold(getPodCmdArg, cmd):
-		return getPodCmd(ctx, cnsClient)
-	case strings.EqualFold(getInMemoryData, cmd):
-		return getInMemory(ctx, cnsClient)
-	default:
-		return fmt.Errorf("No debug cmd supplied, options are: %v", getCmdArg)
+		return nil, errors.Wrap(err, "failed to create cns client")
 	}
+	return c, nil
 }
 
-func getCmd(ctx context.Context, client *client.Client, arg string) error {
-	var states []types.IPState
-
-	switch types.IPState(arg) {
-	case types.Available:
-		states = append(states, types.Available)
-	case types.Assigned:
-		states = append(states, types.Assigned)
-	case types.PendingProgramming:
-		states = append(states, types.PendingProgramming)
-	case types.PendingRelease:
-		states = append(states, types.PendingRelease)
-	default:
-		states = append(states, types.Assigned, types.Available, types.PendingProgramming, types.PendingRelease)
-	}
+// NewCommand returns the root command of the CNS CLI.
+func NewCommand() *cobra.Command {
+	return newCommand(&options{getNNC: getNNC})
+}
 
-	addr, err := client.GetIPAddressesMatchingStates(ctx, states...)
-	if err != nil {
-		return err
+func newCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "cnscli",
+		Short:        "Inspect and administer the Azure CNS of the node",
+		SilenceUsage: true,
+		CompletionOptions: cobra.CompletionOptions{
+			DisableDefaultCmd: true,
+		},
+		PersistentPreRunE: func(*cobra.Command, []string) error {
+			return validateOutput(o.output)
+		},
 	}
+	cmd.PersistentFlags().StringVar(&o.url, "cns-url", defaultURL(), "URL of the CNS API, or the path of the CNS Unix socket")
+	cmd.PersistentFlags().DurationVar(&o.timeout, "timeout", client.DefaultTimeout, "timeout of the requests to CNS")
+	cmd.PersistentFlags().StringVarP(&o.output, "output", "o", outputTable, "output format, one of table, json or yaml")
 
-	printIPAddresses(addr)
-	return nil
+	cmd.AddCommand(
+		newIPsCmd(o),
+		newReleaseCmd(o),
+		newPoolCmd(o),
+		newNCsCmd(o),
+		newEndpointsCmd(o),
+		newPodContextsCmd(o),
+		newRestDataCmd(o),
+		newReconcileCmd(o),
+		newSnapshotCmd(o),
+		newDiffCmd(o),
+	)
+	return cmd
 }
 
-// Sort the addresses based on IP, then write to stdout
-func printIPAddresses(addrSlice []cns.IPConfigurationStatus) {
-	sort.Slice(addrSlice, func(i, j int) bool {
-		return addrSlice[i].IPAddress < addrSlice[j].IPAddress
-	})
-
-	for _, addr := range addrSlice {
-		fmt.Println(addr.String())
+// defaultURL is the CNS URL of the environment of the legacy debug commands, or the default URL of the client.
+func defaultURL() string {
+	ip, port := os.Getenv(envCNSIPAddress), os.Getenv(envCNSPort)
+	if ip == "" || port == "" {
+		return ""
 	}
+	return "http://" + ip + ":" + port
 }
 
-func getPodCmd(ctx context.Context, client *client.Client) error {
-	resp, err := client.GetPodOrchestratorContext(ctx)
-	if err != nil {
-		return err
-	}
-	i := 1
-	for orchContext, podID := range resp {
-		fmt.Printf("%d %s : %s\n", i, orchContext, podID)
-		i++
+// HandleCNSClientCommands runs the legacy debug commands of the CNS binary with the CLI.
+func HandleCNSClientCommands(ctx context.Context, cmd, arg string) error {
+	var args []string
+	switch {
+	case strings.EqualFold(getCmdArg, cmd):
+		args = []string{"ips"}
+		switch types.IPState(arg) {
+		case types.Available, types.Assigned, types.PendingProgramming, types.PendingRelease:
+			args = append(args, "--state", arg)
+		}
+	case strings.EqualFold(getPodCmdArg, cmd):
+		args = []string{"podcontexts"}
+	case strings.EqualFold(getInMemoryData, cmd):
+		args = []string{"restdata"}
+	default:
+		return fmt.Errorf("No debug cmd supplied, options are: %v", getCmdArg)
 	}
-	return nil
-}
 
-func getInMemory(ctx context.Context, client *client.Client) error {
-	data, err := client.GetHTTPServiceData(ctx)
-	if err != nil {
-		return err
-	}
-	fmt.Printf("PodIPIDByOrchestratorContext: %v\nPodIPConfigState: %v\nIPAMPoolMonitor: %v\n",
-		data.HTTPRestServiceData.PodIPIDByPodInterfaceKey, data.HTTPRestServiceData.PodIPConfigState, data.HTTPRestServiceData.IPAMPoolMonitor)
-	return nil
+	root := NewCommand()
+	root.SetArgs(args)
+	return errors.Wrap(root.ExecuteContext(ctx), "failed to run cns cli")
 }
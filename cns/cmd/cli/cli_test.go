@@ -0,0 +1,333 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/common"
+	"github.com/Azure/azure-container-networking/cns/fakes"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/restserver"
+	"github.com/Azure/azure-container-networking/cns/types"
+	acn "github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/Azure/azure-container-networking/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+// recorded is a fake CNS that replays the responses recorded in testdata/recorded, which are named by the path
+// of their route, and records the requests it receives.
+type recorded struct {
+	sync.Mutex
+	requests []string
+	bodies   map[string][]byte
+}
+
+func newRecorded(t *testing.T) (*httptest.Server, *recorded) {
+	t.Helper()
+	r := &recorded{bodies: map[string][]byte{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.Lock()
+		r.requests = append(r.requests, req.Method+" "+req.URL.Path)
+		r.bodies[req.URL.Path] = body
+		r.Unlock()
+
+		name := strings.ReplaceAll(strings.TrimPrefix(req.URL.Path, "/"), "/", "_") + ".json"
+		b, err := os.ReadFile(filepath.Join("testdata", "recorded", name))
+		if err != nil {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, r
+}
+
+func (r *recorded) called(request string) bool {
+	r.Lock()
+	defer r.Unlock()
+	for _, req := range r.requests {
+		if req == request {
+			return true
+		}
+	}
+	return false
+}
+
+func recordedNNC(context.Context, *options) (*v1alpha.NodeNetworkConfig, error) {
+	b, err := os.ReadFile(filepath.Join("testdata", "nnc.json"))
+	if err != nil {
+		return nil, err
+	}
+	nnc := &v1alpha.NodeNetworkConfig{}
+	return nnc, json.Unmarshal(b, nnc)
+}
+
+func run(t *testing.T, srv *httptest.Server, stdin string, args ...string) (string, error) {
+	t.Helper()
+	cmd := newCommand(&options{getNNC: recordedNNC})
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(io.Discard)
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetArgs(append([]string{"--cns-url", srv.URL}, args...))
+	err := cmd.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+func TestIPs(t *testing.T) {
+	srv, _ := newRecorded(t)
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "all", args: []string{"ips"}, want: []string{"10.0.0.5", "10.0.0.6", "10.0.0.7", "10.1.0.5"}},
+		{name: "by pod name", args: []string{"ips", "--pod", "pod-b"}, want: []string{"10.1.0.5"}},
+		{name: "by pod namespace and name", args: []string{"ips", "--pod", "default/pod-a"}, want: []string{"10.0.0.5"}},
+		{name: "by pod in other namespace", args: []string{"ips", "--pod", "kube-system/pod-a"}, want: []string{}},
+		{name: "by nc", args: []string{"ips", "--nc", "nc2"}, want: []string{"10.1.0.5"}},
+		{name: "unknown state", args: []string{"ips", "--state", "Stuck"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := run(t, srv, "", append(tt.args, "-o", "json")...)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrUnknownIPState)
+				return
+			}
+			require.NoError(t, err)
+			var ips []cns.IPConfigurationStatus
+			require.NoError(t, json.Unmarshal([]byte(out), &ips))
+			got := []string{}
+			for i := range ips {
+				got = append(got, ips[i].IPAddress)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIPsStates(t *testing.T) {
+	srv, rec := newRecorded(t)
+	_, err := run(t, srv, "", "ips", "--state", "Available,pendingrelease")
+	require.NoError(t, err)
+
+	var req cns.GetIPAddressesRequest
+	require.NoError(t, json.Unmarshal(rec.bodies[cns.PathDebugIPAddresses], &req))
+	assert.Equal(t, []types.IPState{types.Available, types.PendingRelease}, req.IPConfigStateFilter)
+}
+
+func TestIPsTable(t *testing.T) {
+	srv, _ := newRecorded(t)
+	out, err := run(t, srv, "", "ips", "--pod", "default/pod-a")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, []string{"IP", "STATE", "NC", "POD", "ID", "LAST", "TRANSITION"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"10.0.0.5", "Assigned", "nc1", "default/pod-a", "a1b2c3d4-0000-0000-0000-000000000005", "2023-05-01T10:00:00Z"},
+		strings.Fields(lines[1]))
+}
+
+func TestRelease(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		stdin       string
+		wantRelease bool
+		wantErr     error
+	}{
+		{name: "confirmed", args: []string{"release", "10.0.0.5"}, stdin: "y\n", wantRelease: true},
+		{name: "not confirmed", args: []string{"release", "10.0.0.5"}, stdin: "n\n"},
+		{name: "no answer", args: []string{"release", "10.0.0.5"}},
+		{name: "without confirmation", args: []string{"release", "10.0.0.5", "--yes"}, wantRelease: true},
+		{name: "not assigned", args: []string{"release", "10.0.0.7", "--yes"}, wantErr: ErrIPNotAssigned},
+		{name: "unknown ip", args: []string{"release", "10.9.9.9", "--yes"}, wantErr: ErrIPNotFound},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			srv, rec := newRecorded(t)
+			_, err := run(t, srv, tt.stdin, tt.args...)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantRelease, rec.called(http.MethodPost+" "+cns.ReleaseIPConfigs))
+			if !tt.wantRelease {
+				return
+			}
+			var req cns.IPConfigsRequest
+			require.NoError(t, json.Unmarshal(rec.bodies[cns.ReleaseIPConfigs], &req))
+			assert.Equal(t, "infra-a", req.InfraContainerID)
+			assert.Equal(t, "infra-a-eth0", req.PodInterfaceID)
+			podInfo, err := cns.UnmarshalPodInfo(req.OrchestratorContext)
+			require.NoError(t, err)
+			assert.Equal(t, "pod-a", podInfo.Name())
+			assert.Equal(t, "default", podInfo.Namespace())
+		})
+	}
+}
+
+func TestInspect(t *testing.T) {
+	srv, _ := newRecorded(t)
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "ncs", args: []string{"ncs"}, want: []string{"nc1", "Docker", "10.0.0.4"}},
+		{name: "ncs yaml", args: []string{"ncs", "-o", "yaml"}, want: []string{"- HostVersion: \"2\"", "ID: nc1"}},
+		{name: "pool", args: []string{"pool"}, want: []string{"MIN FREE", "15", "16"}},
+		{name: "endpoints", args: []string{"endpoints"}, want: []string{"infra-a", "default/pod-a", "eth0", "10.0.0.5/24"}},
+		{name: "podcontexts", args: []string{"podcontexts"}, want: []string{"infra-a-eth0", "a1b2c3d4-0000-0000-0000-000000000005"}},
+		{name: "restdata", args: []string{"restdata"}, want: []string{`"MinimumFreeIps": 5`}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := run(t, srv, "", tt.args...)
+			require.NoError(t, err)
+			for _, want := range tt.want {
+				assert.Contains(t, out, want)
+			}
+		})
+	}
+}
+
+func TestUnknownOutput(t *testing.T) {
+	srv, _ := newRecorded(t)
+	_, err := run(t, srv, "", "ncs", "-o", "xml")
+	require.ErrorIs(t, err, ErrUnknownOutput)
+}
+
+func TestReconcile(t *testing.T) {
+	srv, rec := newRecorded(t)
+	out, err := run(t, srv, "", "reconcile")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Enqueued a reconcile")
+	assert.True(t, rec.called(http.MethodPost+" "+cns.PathDebugReconcile))
+}
+
+func TestDiff(t *testing.T) {
+	srv, _ := newRecorded(t)
+	out, err := run(t, srv, "", "diff", "--node", "node", "-o", "yaml")
+	require.NoError(t, err)
+
+	var got []difference
+	require.NoError(t, yaml.Unmarshal([]byte(out), &got))
+	assert.Equal(t, []difference{
+		{Field: "ipNotInUse/a1b2c3d4-0000-0000-0000-000000000007", NNC: "false", CNS: "true"},
+		{Field: "ipNotInUse/a1b2c3d4-0000-0000-0000-000000000008", NNC: "true", CNS: "false"},
+		{NC: "nc1", Field: "version", NNC: "3", CNS: "2"},
+		{NC: "nc2", Field: "present", NNC: "false", CNS: "true"},
+		{NC: "nc3", Field: "present", NNC: "true", CNS: "false"},
+	}, got)
+}
+
+func TestDiffRequiresNode(t *testing.T) {
+	srv, _ := newRecorded(t)
+	_, err := run(t, srv, "", "diff", "--node", "")
+	require.Error(t, err)
+}
+
+// newSnapshot returns the snapshot archive of a CNS with an NC of two Available IPs.
+func newSnapshot(t *testing.T) []byte {
+	t.Helper()
+	if logger.Log == nil {
+		logger.InitLogger("", 0, 0, "")
+	}
+	service, err := restserver.NewHTTPRestService(&common.ServiceConfig{}, &fakes.WireserverClientFake{}, &fakes.WireserverProxyFake{},
+		&fakes.NMAgentClientFake{}, store.NewMockStore(""), nil, nil)
+	require.NoError(t, err)
+	service.Listener = &acn.Listener{}
+	service.SetNodeOrchestrator(&cns.SetOrchestratorTypeRequest{OrchestratorType: cns.KubernetesCRD, NodeID: "node"})
+	code := service.CreateOrUpdateNetworkContainerInternal(&cns.CreateNetworkContainerRequest{
+		NetworkContainerid:   "nc1",
+		NetworkContainerType: string(cns.Docker),
+		Version:              "-1",
+		AuthorizationToken:   "secret",
+		IPConfiguration:      cns.IPConfiguration{IPSubnet: cns.IPSubnet{IPAddress: "10.0.0.4", PrefixLength: 24}},
+		SecondaryIPConfigs: map[string]cns.SecondaryIPConfig{
+			"a1b2c3d4-0000-0000-0000-000000000005": {IPAddress: "10.0.0.5", NCVersion: -1},
+			"a1b2c3d4-0000-0000-0000-000000000006": {IPAddress: "10.0.0.6", NCVersion: -1},
+		},
+	})
+	require.Equal(t, types.Success, code)
+
+	snapshot, err := service.Snapshot(context.Background())
+	require.NoError(t, err)
+	b := &bytes.Buffer{}
+	require.NoError(t, snapshot.Write(b))
+	return b.Bytes()
+}
+
+func TestSnapshotExport(t *testing.T) {
+	archive := newSnapshot(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != cns.PathDebugSnapshot {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write(archive)
+	}))
+	t.Cleanup(srv.Close)
+
+	file := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	out, err := run(t, srv, "", "snapshot", "export", "-f", file)
+	require.NoError(t, err)
+	assert.Contains(t, out, file)
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, archive, b)
+
+	// the snapshot is not written over an existing file.
+	_, err = run(t, srv, "", "snapshot", "export", "-f", file)
+	require.Error(t, err)
+
+	out, err = run(t, srv, "", "snapshot", "export", "-f", "-")
+	require.NoError(t, err)
+	assert.Equal(t, archive, []byte(out))
+}
+
+func TestSnapshotImport(t *testing.T) {
+	srv, rec := newRecorded(t)
+	archive := newSnapshot(t)
+
+	out, err := run(t, srv, string(archive), "snapshot", "import", "--dry-run", "-", "-o", "json")
+	require.NoError(t, err)
+	var imported importedSnapshot
+	require.NoError(t, json.Unmarshal([]byte(out), &imported))
+	assert.Equal(t, restserver.SnapshotVersion, imported.Manifest.Version)
+	require.Len(t, imported.NetworkContainers, 1)
+	assert.Equal(t, "nc1", imported.NetworkContainers[0].ID)
+	assert.Equal(t, map[types.IPState]int{types.Available: 2}, imported.NetworkContainers[0].IPs)
+	// the snapshot is imported offline.
+	assert.Empty(t, rec.requests)
+
+	out, err = run(t, srv, string(archive), "snapshot", "import", "--dry-run", "-")
+	require.NoError(t, err)
+	assert.Contains(t, out, "PENDING PROGRAMMING")
+	assert.Contains(t, out, "nc1")
+
+	_, err = run(t, srv, string(archive), "snapshot", "import", "-")
+	require.ErrorIs(t, err, ErrImportNotDryRun)
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/configuration"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const nncNamespace = "kube-system"
+
+// difference is a field of an NC, or of the IP pool if the NC is empty, whose value in CNS differs from the
+// NodeNetworkConfig.
+type difference struct {
+	NC    string `json:"nc,omitempty"`
+	Field string `json:"field"`
+	NNC   string `json:"nnc"`
+	CNS   string `json:"cns"`
+}
+
+// getNNC gets the NodeNetworkConfig of the node from the apiserver of the kubeconfig of the environment.
+func getNNC(ctx context.Context, o *options) (*v1alpha.NodeNetworkConfig, error) {
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get kubeconfig")
+	}
+	scheme := runtime.NewScheme()
+	if err = v1alpha.AddToScheme(scheme); err != nil {
+		return nil, errors.Wrap(err, "failed to add nodenetworkconfig/v1alpha to scheme")
+	}
+	cli, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create kubernetes client")
+	}
+	nnc, err := nodenetworkconfig.NewClient(cli).Get(ctx, k8stypes.NamespacedName{Namespace: nncNamespace, Name: o.node})
+	return nnc, errors.Wrap(err, "failed to get nnc")
+}
+
+func newDiffCmd(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show the differences between the state of CNS and the NodeNetworkConfig of the node",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if o.node == "" {
+				return errors.Wrap(configuration.ErrNodeNameUnset, "--node is required")
+			}
+			nnc, err := o.getNNC(cmd.Context(), o)
+			if err != nil {
+				return err
+			}
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			ncs, err := c.GetNetworkContainersDebug(cmd.Context())
+			if err != nil {
+				return errors.Wrap(err, "failed to get ncs")
+			}
+			ips, err := c.GetIPAddressesMatchingStates(cmd.Context(), allStates...)
+			if err != nil {
+				return errors.Wrap(err, "failed to get ips")
+			}
+
+			diffs := diff(nnc, ncs, ips)
+			return o.print(cmd.OutOrStdout(), diffs, func() *table {
+				t := &table{header: []string{"NC", "FIELD", "NNC", "CNS"}}
+				for i := range diffs {
+					t.add(diffs[i].NC, diffs[i].Field, diffs[i].NNC, diffs[i].CNS)
+				}
+				return t
+			})
+		},
+	}
+	cmd.Flags().StringVar(&o.node, "node", os.Getenv(configuration.EnvNodeName), "name of the node of the NodeNetworkConfig")
+	return cmd
+}
+
+// diff compares the NCs and IPs of CNS to the NodeNetworkConfig. The NCs that CNS reported as skipped, because
+// they were made for another node, are not compared.
+func diff(nnc *v1alpha.NodeNetworkConfig, ncs []cns.NetworkContainerDebugInfo, ips []cns.IPConfigurationStatus) []difference {
+	diffs := []difference{}
+
+	skipped := map[string]bool{}
+	if nnc.Status.CNS != nil {
+		for _, nc := range nnc.Status.CNS.NetworkContainers {
+			skipped[nc.ID] = nc.Result == v1alpha.NCSkipped
+		}
+	}
+	cnsNCs := map[string]cns.NetworkContainerDebugInfo{}
+	for i := range ncs {
+		cnsNCs[ncs[i].ID] = ncs[i]
+	}
+
+	nncNCs := map[string]bool{}
+	for i := range nnc.Status.NetworkContainers {
+		nc := nnc.Status.NetworkContainers[i]
+		nncNCs[nc.ID] = true
+		if skipped[nc.ID] {
+			continue
+		}
+		cnsNC, ok := cnsNCs[nc.ID]
+		if !ok {
+			diffs = append(diffs, difference{NC: nc.ID, Field: "present", NNC: "true", CNS: "false"})
+			continue
+		}
+		if version := strconv.FormatInt(nc.Version, 10); version != cnsNC.Version {
+			diffs = append(diffs, difference{NC: nc.ID, Field: "version", NNC: version, CNS: cnsNC.Version})
+		}
+		// the secondary IPs of static NCs are carved out of their prefix by CNS.
+		if nc.AssignmentMode != v1alpha.Static && len(nc.IPAssignments) != cnsNC.SecondaryIPCount {
+			diffs = append(diffs, difference{
+				NC: nc.ID, Field: "ipCount", NNC: strconv.Itoa(len(nc.IPAssignments)), CNS: strconv.Itoa(cnsNC.SecondaryIPCount),
+			})
+		}
+	}
+	for i := range ncs {
+		if !nncNCs[ncs[i].ID] {
+			diffs = append(diffs, difference{NC: ncs[i].ID, Field: "present", NNC: "false", CNS: "true"})
+		}
+	}
+
+	// the IPs that CNS releases are in the spec until the NNC is reconciled.
+	pendingRelease := map[string]bool{}
+	for i := range ips {
+		if ips[i].GetState() == types.PendingRelease {
+			pendingRelease[ips[i].ID] = true
+		}
+	}
+	notInUse := map[string]bool{}
+	for _, id := range nnc.Spec.IPsNotInUse {
+		notInUse[id] = true
+		if !pendingRelease[id] {
+			diffs = append(diffs, difference{Field: "ipNotInUse/" + id, NNC: "true", CNS: "false"})
+		}
+	}
+	for id := range pendingRelease {
+		if !notInUse[id] {
+			diffs = append(diffs, difference{Field: "ipNotInUse/" + id, NNC: "false", CNS: "true"})
+		}
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].NC != diffs[j].NC {
+			return diffs[i].NC < diffs[j].NC
+		}
+		return diffs[i].Field < diffs[j].Field
+	})
+	return diffs
+}
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ErrUnknownIPState = errors.New("unknown IP state")
+	ErrIPNotFound     = errors.New("IP not found in CNS")
+	ErrIPNotAssigned  = errors.New("IP is not assigned to a pod")
+)
+
+var allStates = []types.IPState{types.Assigned, types.Available, types.PendingProgramming, types.PendingRelease}
+
+// ipFilter filters the IPs of CNS by pod and NC.
+type ipFilter struct {
+	pod string
+	nc  string
+}
+
+func (f *ipFilter) matches(ip *cns.IPConfigurationStatus) bool {
+	if f.nc != "" && ip.NCID != f.nc {
+		return false
+	}
+	if f.pod == "" {
+		return true
+	}
+	if ip.PodInfo == nil {
+		return false
+	}
+	if namespace, name, ok := strings.Cut(f.pod, "/"); ok {
+		return ip.PodInfo.Namespace() == namespace && ip.PodInfo.Name() == name
+	}
+	return ip.PodInfo.Name() == f.pod
+}
+
+func parseStates(states []string) ([]types.IPState, error) {
+	if len(states) == 0 {
+		return allStates, nil
+	}
+	parsed := make([]types.IPState, 0, len(states))
+	for _, state := range states {
+		found := false
+		for _, s := range allStates {
+			if strings.EqualFold(state, string(s)) {
+				parsed = append(parsed, s)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.Wrapf(ErrUnknownIPState, "%q, options are %v", state, allStates)
+		}
+	}
+	return parsed, nil
+}
+
+func podName(podInfo cns.PodInfo) string {
+	if podInfo == nil {
+		return ""
+	}
+	return podInfo.Namespace() + "/" + podInfo.Name()
+}
+
+func newIPsCmd(o *options) *cobra.Command {
+	var (
+		states []string
+		filter ipFilter
+	)
+	cmd := &cobra.Command{
+		Use:   "ips",
+		Short: "List the IPs of CNS, filtered by state, pod or NC",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ipStates, err := parseStates(states)
+			if err != nil {
+				return err
+			}
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			all, err := c.GetIPAddressesMatchingStates(cmd.Context(), ipStates...)
+			if err != nil {
+				return errors.Wrap(err, "failed to get ips")
+			}
+			ips := []cns.IPConfigurationStatus{}
+			for i := range all {
+				if filter.matches(&all[i]) {
+					ips = append(ips, all[i])
+				}
+			}
+			sort.Slice(ips, func(i, j int) bool {
+				return ips[i].IPAddress < ips[j].IPAddress
+			})
+
+			return o.print(cmd.OutOrStdout(), ips, func() *table {
+				t := &table{header: []string{"IP", "STATE", "NC", "POD", "ID", "LAST TRANSITION"}}
+				for i := range ips {
+					t.add(ips[i].IPAddress, string(ips[i].GetState()), ips[i].NCID, podName(ips[i].PodInfo), ips[i].ID,
+						ips[i].LastStateTransition.Format(time.RFC3339))
+				}
+				return t
+			})
+		},
+	}
+	cmd.Flags().StringSliceVar(&states, "state", nil, fmt.Sprintf("states of the IPs, any of %v", allStates))
+	cmd.Flags().StringVar(&filter.pod, "pod", "", "name, or namespace/name, of the pod of the IPs")
+	cmd.Flags().StringVar(&filter.nc, "nc", "", "ID of the NC of the IPs")
+	return cmd
+}
+
+func newReleaseCmd(o *options) *cobra.Command {
+	var yes bool
+	cmd := &cobra.Command{
+		Use:   "release IP",
+		Short: "Release an IP that is stuck assigned to a pod, with all other IPs of the pod",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			ips, err := c.GetIPAddressesMatchingStates(cmd.Context(), allStates...)
+			if err != nil {
+				return errors.Wrap(err, "failed to get ips")
+			}
+			var ip *cns.IPConfigurationStatus
+			for i := range ips {
+				if ips[i].IPAddress == args[0] {
+					ip = &ips[i]
+					break
+				}
+			}
+			if ip == nil {
+				return errors.Wrap(ErrIPNotFound, args[0])
+			}
+			if ip.GetState() != types.Assigned || ip.PodInfo == nil {
+				return errors.Wrapf(ErrIPNotAssigned, "%s is %s", ip.IPAddress, ip.GetState())
+			}
+
+			if !yes {
+				fmt.Fprintf(cmd.OutOrStdout(), "Release IP %s and all other IPs of pod %s? [y/N]: ", ip.IPAddress, podName(ip.PodInfo))
+				answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+				if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+					fmt.Fprintln(cmd.OutOrStdout(), "Aborted.")
+					return nil
+				}
+			}
+
+			orchestratorContext, err := ip.PodInfo.OrchestratorContext()
+			if err != nil {
+				return errors.Wrap(err, "failed to get orchestrator context of pod")
+			}
+			err = c.ReleaseIPs(cmd.Context(), cns.IPConfigsRequest{
+				PodInterfaceID:      ip.PodInfo.InterfaceID(),
+				InfraContainerID:    ip.PodInfo.InfraContainerID(),
+				OrchestratorContext: orchestratorContext,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "failed to release ips of pod %s", podName(ip.PodInfo))
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Released the IPs of pod %s.\n", podName(ip.PodInfo))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "release without confirmation")
+	return cmd
+}
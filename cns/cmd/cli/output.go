@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var ErrUnknownOutput = errors.New("unknown output format")
+
+func validateOutput(output string) error {
+	switch output {
+	case outputTable, outputJSON, outputYAML:
+		return nil
+	default:
+		return errors.Wrapf(ErrUnknownOutput, "%q, options are %s, %s and %s", output, outputTable, outputJSON, outputYAML)
+	}
+}
+
+// table is the table output of a command.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (t *table) add(row ...string) {
+	t.rows = append(t.rows, row)
+}
+
+// print writes v as JSON or YAML, or writes the table built by toTable.
+func (o *options) print(w io.Writer, v any, toTable func() *table) error {
+	switch o.output {
+	case outputJSON:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal json")
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return errors.Wrap(err, "failed to write output")
+	case outputYAML:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal yaml")
+		}
+		_, err = w.Write(b)
+		return errors.Wrap(err, "failed to write output")
+	default:
+		return toTable().write(w)
+	}
+}
+
+func (t *table) write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0) //nolint:gomnd // padding between columns
+	fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return errors.Wrap(tw.Flush(), "failed to write table")
+}
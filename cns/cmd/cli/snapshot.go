@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/restserver"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// stdio is the file name of stdin or stdout.
+const stdio = "-"
+
+var ErrImportNotDryRun = errors.New("snapshots can only be imported with --dry-run")
+
+func newSnapshotCmd(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Export or import snapshots of the state of CNS, with the secrets redacted",
+	}
+	cmd.AddCommand(newSnapshotExportCmd(o), newSnapshotImportCmd(o))
+	return cmd
+}
+
+func newSnapshotExportCmd(o *options) *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write a snapshot of the state of CNS to a gzipped tar archive, such as for a support bundle",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			if file == stdio {
+				return errors.Wrap(c.GetSnapshot(cmd.Context(), cmd.OutOrStdout()), "failed to get snapshot")
+			}
+			if file == "" {
+				file = restserver.SnapshotFileName(time.Now())
+			}
+			f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+			if err != nil {
+				return errors.Wrap(err, "failed to create snapshot file")
+			}
+			err = c.GetSnapshot(cmd.Context(), f)
+			if closeErr := f.Close(); err == nil {
+				err = errors.Wrap(closeErr, "failed to close snapshot file")
+			}
+			if err != nil {
+				_ = os.Remove(file)
+				return errors.Wrap(err, "failed to get snapshot")
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote the snapshot of CNS to %s.\n", file)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "file to write the snapshot to, or - for stdout (default cns-snapshot-<time>.tar.gz)")
+	return cmd
+}
+
+// snapshotNC is an NC of an imported snapshot, with the number of its IPs in each state.
+type snapshotNC struct {
+	cns.NetworkContainerDebugInfo
+	IPs map[types.IPState]int
+}
+
+// importedSnapshot is the state of CNS loaded from a snapshot.
+type importedSnapshot struct {
+	Manifest          restserver.SnapshotManifest
+	NetworkContainers []snapshotNC
+	Endpoints         int
+}
+
+func newSnapshotImportCmd(o *options) *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Load a snapshot of the state of CNS into an offline CNS, and show its NCs and IPs",
+		Long: "Load a snapshot of the state of CNS into an offline CNS, which does not serve the CNS API or change the " +
+			"state of the node, and show the NCs of the snapshot with the number of their IPs in each state. The " +
+			"file can be - for stdin. The offline CNS logs to stderr.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !dryRun {
+				return ErrImportNotDryRun
+			}
+			var r io.Reader = cmd.InOrStdin()
+			if args[0] != stdio {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return errors.Wrap(err, "failed to open snapshot file")
+				}
+				defer f.Close()
+				r = f
+			}
+			snapshot, err := restserver.ReadSnapshot(r)
+			if err != nil {
+				return errors.Wrap(err, "failed to read snapshot")
+			}
+			for _, e := range snapshot.Manifest.Errors {
+				fmt.Fprintf(cmd.ErrOrStderr(), "warning: the snapshot is incomplete: %s\n", e)
+			}
+			if logger.Log == nil {
+				// the offline CNS logs to stderr.
+				logger.InitLogger("cnscli", log.LevelInfo, log.TargetStderr, "")
+			}
+			service, err := restserver.NewHTTPRestServiceFromSnapshot(snapshot)
+			if err != nil {
+				return errors.Wrap(err, "failed to import snapshot")
+			}
+
+			imported := importSummary(snapshot.Manifest, service)
+			return o.print(cmd.OutOrStdout(), imported, func() *table {
+				t := &table{header: []string{"NC", "VERSION", "HOST VERSION", "PROGRAMMING", "AVAILABLE", "ASSIGNED", "PENDING RELEASE", "PENDING PROGRAMMING"}}
+				for i := range imported.NetworkContainers {
+					nc := &imported.NetworkContainers[i]
+					t.add(nc.ID, nc.Version, nc.HostVersion, string(nc.ProgrammingState), strconv.Itoa(nc.IPs[types.Available]),
+						strconv.Itoa(nc.IPs[types.Assigned]), strconv.Itoa(nc.IPs[types.PendingRelease]), strconv.Itoa(nc.IPs[types.PendingProgramming]))
+				}
+				return t
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "load the snapshot into an offline CNS instead of into the CNS of the node")
+	return cmd
+}
+
+// importSummary counts the IPs of the NCs of the service imported from a snapshot by their state.
+func importSummary(manifest restserver.SnapshotManifest, service *restserver.HTTPRestService) importedSnapshot {
+	ncs := service.GetNetworkContainersDebug()
+	imported := importedSnapshot{
+		Manifest:          manifest,
+		NetworkContainers: make([]snapshotNC, len(ncs)),
+		Endpoints:         len(service.EndpointState),
+	}
+	byID := map[string]*snapshotNC{}
+	for i := range ncs {
+		imported.NetworkContainers[i] = snapshotNC{NetworkContainerDebugInfo: ncs[i], IPs: map[types.IPState]int{}}
+		byID[ncs[i].ID] = &imported.NetworkContainers[i]
+	}
+	for _, ipconfig := range service.GetPodIPConfigState() {
+		if nc, ok := byID[ipconfig.NCID]; ok {
+			nc.IPs[ipconfig.GetState()]++
+		}
+	}
+	return imported
+}
@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/cns/restserver"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newPoolCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "pool",
+		Short: "Show the state of the IPAM pool monitor",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			data, err := c.GetHTTPServiceData(cmd.Context())
+			if err != nil {
+				return errors.Wrap(err, "failed to get pool monitor state")
+			}
+			pool := data.HTTPRestServiceData.IPAMPoolMonitor
+			return o.print(cmd.OutOrStdout(), pool, func() *table {
+				t := &table{header: []string{"MIN FREE", "MAX FREE", "UPDATING NOT IN USE", "REQUESTED", "NOT IN USE"}}
+				t.add(strconv.FormatInt(pool.MinimumFreeIps, 10), strconv.FormatInt(pool.MaximumFreeIps, 10),
+					strconv.FormatInt(pool.UpdatingIpsNotInUseCount, 10), strconv.FormatInt(pool.CachedNNC.Spec.RequestedIPCount, 10),
+					strconv.Itoa(len(pool.CachedNNC.Spec.IPsNotInUse)))
+				return t
+			})
+		},
+	}
+}
+
+func newNCsCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "ncs",
+		Short: "List the NCs of CNS with their versions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			ncs, err := c.GetNetworkContainersDebug(cmd.Context())
+			if err != nil {
+				return errors.Wrap(err, "failed to get ncs")
+			}
+			return o.print(cmd.OutOrStdout(), ncs, func() *table {
+				t := &table{header: []string{"ID", "TYPE", "VERSION", "HOST VERSION", "PRIMARY IP", "IPS"}}
+				for i := range ncs {
+					t.add(ncs[i].ID, ncs[i].Type, ncs[i].Version, ncs[i].HostVersion, ncs[i].PrimaryIP, strconv.Itoa(ncs[i].SecondaryIPCount))
+				}
+				return t
+			})
+		},
+	}
+}
+
+func newEndpointsCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "endpoints",
+		Short: "Dump the endpoint state of CNS",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			endpoints, err := c.GetEndpointState(cmd.Context())
+			if err != nil {
+				return errors.Wrap(err, "failed to get endpoint state")
+			}
+			return o.print(cmd.OutOrStdout(), endpoints, func() *table {
+				return endpointsTable(endpoints)
+			})
+		},
+	}
+}
+
+func endpointsTable(endpoints map[string]*restserver.EndpointInfo) *table {
+	t := &table{header: []string{"CONTAINER ID", "POD", "INTERFACE", "IPV4", "IPV6"}}
+	containerIDs := make([]string, 0, len(endpoints))
+	for id := range endpoints {
+		containerIDs = append(containerIDs, id)
+	}
+	sort.Strings(containerIDs)
+	for _, id := range containerIDs {
+		endpoint := endpoints[id]
+		pod := endpoint.PodNamespace + "/" + endpoint.PodName
+		ifnames := make([]string, 0, len(endpoint.IfnameToIPMap))
+		for ifname := range endpoint.IfnameToIPMap {
+			ifnames = append(ifnames, ifname)
+		}
+		sort.Strings(ifnames)
+		for _, ifname := range ifnames {
+			ips := endpoint.IfnameToIPMap[ifname]
+			var ipv4, ipv6 []string
+			for i := range ips.IPv4 {
+				ipv4 = append(ipv4, ips.IPv4[i].String())
+			}
+			for i := range ips.IPv6 {
+				ipv6 = append(ipv6, ips.IPv6[i].String())
+			}
+			t.add(id, pod, ifname, strings.Join(ipv4, ","), strings.Join(ipv6, ","))
+		}
+	}
+	return t
+}
+
+func newPodContextsCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "podcontexts",
+		Short: "List the pod interfaces of CNS with the IDs of their IPs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			contexts, err := c.GetPodOrchestratorContext(cmd.Context())
+			if err != nil {
+				return errors.Wrap(err, "failed to get pod contexts")
+			}
+			return o.print(cmd.OutOrStdout(), contexts, func() *table {
+				t := &table{header: []string{"POD INTERFACE", "IP IDS"}}
+				keys := make([]string, 0, len(contexts))
+				for key := range contexts {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				for _, key := range keys {
+					t.add(key, strings.Join(contexts[key], ","))
+				}
+				return t
+			})
+		},
+	}
+}
+
+func newRestDataCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "restdata",
+		Short: "Dump the in-memory state of CNS as JSON or YAML",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			data, err := c.GetHTTPServiceData(cmd.Context())
+			if err != nil {
+				return errors.Wrap(err, "failed to get in-memory state")
+			}
+			// the in-memory state has no table output, so it is printed as JSON instead.
+			out := *o
+			if out.output == outputTable {
+				out.output = outputJSON
+			}
+			return out.print(cmd.OutOrStdout(), data.HTTPRestServiceData, nil)
+		},
+	}
+}
+
+func newReconcileCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reconcile",
+		Short: "Make CNS reconcile the NodeNetworkConfig of the node",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+			if err := c.TriggerReconcile(cmd.Context()); err != nil {
+				return errors.Wrap(err, "failed to trigger reconcile")
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Enqueued a reconcile of the NodeNetworkConfig.")
+			return nil
+		},
+	}
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"os"
+
+	"github.com/Azure/azure-container-networking/cns/cmd/cli"
+)
+
+func main() {
+	if err := cli.NewCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
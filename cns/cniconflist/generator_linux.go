@@ -1,135 +1,25 @@
 package cniconflist
 
 import (
-	"encoding/json"
-
-	"github.com/Azure/azure-container-networking/cni"
-	cninet "github.com/Azure/azure-container-networking/cni/network"
-	"github.com/Azure/azure-container-networking/cni/util"
-	"github.com/Azure/azure-container-networking/network"
-	"github.com/pkg/errors"
+	"github.com/Azure/azure-container-networking/cni/conflist"
 )
 
-// portmapConfig is the config for the upstream portmap plugin
-var portmapConfig any = struct {
-	Type         string          `json:"type"`
-	Capabilities map[string]bool `json:"capabilities"`
-	SNAT         bool            `json:"snat"`
-}{
-	Type: "portmap",
-	Capabilities: map[string]bool{
-		"portMappings": true,
-	},
-	SNAT: true,
-}
-
 // Generate writes the CNI conflist to the Generator's output stream
 func (v *V4OverlayGenerator) Generate() error {
-	conflist := cniConflist{
-		CNIVersion: overlaycniVersion,
-		Name:       overlaycniName,
-		Plugins: []any{
-			cni.NetworkConfig{
-				Type:              overlaycniType,
-				Mode:              cninet.OpModeTransparent,
-				ExecutionMode:     string(util.V4Swift),
-				IPsToRouteViaHost: []string{nodeLocalDNSIP},
-				IPAM: cni.IPAM{
-					Type: network.AzureCNS,
-					Mode: string(util.V4Overlay),
-				},
-			},
-			portmapConfig,
-		},
-	}
-
-	enc := json.NewEncoder(v.Writer)
-	enc.SetIndent("", "\t")
-	if err := enc.Encode(conflist); err != nil {
-		return errors.Wrap(err, "error encoding conflist to json")
-	}
-
-	return nil
+	return conflist.Render(v.Writer, &conflist.Options{Scenario: conflist.V4Overlay, MTU: v.MTU}) //nolint:wrapcheck // the renderer wraps its errors
 }
 
 // Generate writes the CNI conflist to the Generator's output stream
 func (v *DualStackOverlayGenerator) Generate() error {
-	conflist := cniConflist{
-		CNIVersion: overlaycniVersion,
-		Name:       overlaycniName,
-		Plugins: []any{
-			cni.NetworkConfig{
-				Type:              overlaycniType,
-				Mode:              cninet.OpModeTransparent,
-				IPsToRouteViaHost: []string{nodeLocalDNSIP},
-				IPAM: cni.IPAM{
-					Type: network.AzureCNS,
-					Mode: string(util.DualStackOverlay),
-				},
-			},
-			portmapConfig,
-		},
-	}
-
-	enc := json.NewEncoder(v.Writer)
-	enc.SetIndent("", "\t")
-	if err := enc.Encode(conflist); err != nil {
-		return errors.Wrap(err, "error encoding conflist to json")
-	}
-
-	return nil
+	return conflist.Render(v.Writer, &conflist.Options{Scenario: conflist.DualStackOverlay, MTU: v.MTU}) //nolint:wrapcheck // the renderer wraps its errors
 }
 
 // Generate writes the CNI conflist to the Generator's output stream
 func (v *OverlayGenerator) Generate() error {
-	conflist := cniConflist{
-		CNIVersion: overlaycniVersion,
-		Name:       overlaycniName,
-		Plugins: []any{
-			cni.NetworkConfig{
-				Type:              overlaycniType,
-				Mode:              cninet.OpModeTransparent,
-				IPsToRouteViaHost: []string{nodeLocalDNSIP},
-				IPAM: cni.IPAM{
-					Type: network.AzureCNS,
-					Mode: string(util.Overlay),
-				},
-			},
-			portmapConfig,
-		},
-	}
-
-	enc := json.NewEncoder(v.Writer)
-	enc.SetIndent("", "\t")
-	if err := enc.Encode(conflist); err != nil {
-		return errors.Wrap(err, "error encoding conflist to json")
-	}
-
-	return nil
+	return conflist.Render(v.Writer, &conflist.Options{Scenario: conflist.Overlay, MTU: v.MTU}) //nolint:wrapcheck // the renderer wraps its errors
 }
 
 // Generate writes the CNI conflist to the Generator's output stream
 func (v *CiliumGenerator) Generate() error {
-	conflist := cniConflist{
-		CNIVersion: ciliumcniVersion,
-		Name:       ciliumcniName,
-		Plugins: []any{
-			NetConf{
-				Type:        ciliumcniType,
-				LogFile:     ciliumLogFile,
-				EnableDebug: true,
-				IPAM: IPAM{
-					Type: ciliumIPAM,
-				},
-			},
-		},
-	}
-
-	enc := json.NewEncoder(v.Writer)
-	enc.SetIndent("", "\t")
-	if err := enc.Encode(conflist); err != nil {
-		return errors.Wrap(err, "error encoding conflist to json")
-	}
-
-	return nil
+	return conflist.Render(v.Writer, &conflist.Options{Scenario: conflist.Cilium, MTU: v.MTU}) //nolint:wrapcheck // the renderer wraps its errors
 }
@@ -62,6 +62,21 @@ func TestGenerateOverlayConflist(t *testing.T) {
 	assert.Equal(t, removeNewLines(fixtureBytes), removeNewLines(buffer.Bytes()))
 }
 
+func TestGenerateOverlayConflistWithMTU(t *testing.T) {
+	fixture := "testdata/fixtures/azure-linux-swift-overlay-mtu.conflist"
+
+	buffer := new(bytes.Buffer)
+	g := cniconflist.OverlayGenerator{Writer: &bufferWriteCloser{buffer}, MTU: 9000}
+	err := g.Generate()
+	assert.NoError(t, err)
+
+	fixtureBytes, err := os.ReadFile(fixture)
+	assert.NoError(t, err)
+
+	// remove newlines and carriage returns in case these UTs are running on Windows
+	assert.Equal(t, removeNewLines(fixtureBytes), removeNewLines(buffer.Bytes()))
+}
+
 func TestGenerateCiliumConflist(t *testing.T) {
 	fixture := "testdata/fixtures/cilium.conflist"
 
@@ -0,0 +1,78 @@
+package cnireconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cni/api"
+	"github.com/Azure/azure-container-networking/cni/client"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/containernetworking/cni/libcni"
+	"github.com/pkg/errors"
+	"k8s.io/utils/exec"
+)
+
+// azureVnetType is the type of the plugin of the CNI in its network configuration list.
+const azureVnetType = "azure-vnet"
+
+var errNoAzureVnetPlugin = errors.New("the network configuration list has no azure-vnet plugin")
+
+type transparentVlanReconciler interface {
+	ReconcileTransparentVlan(netconf []byte, dryRun bool) (*api.TransparentVlanReconcileReport, error)
+}
+
+// ReconcileTransparentVlan runs the CNI to remove the orphaned transparent vlan namespaces, links and rules of the
+// node immediately and then every interval until the context is done. The CNI gets the NCs of the node from CNS, as
+// the network configuration of its plugin in the conflist says, so this should be started once the CNS API is
+// served.
+func ReconcileTransparentVlan(ctx context.Context, conflistPath string, interval time.Duration) {
+	reconcileTransparentVlan(ctx, client.New(exec.New()), conflistPath, interval)
+}
+
+func reconcileTransparentVlan(ctx context.Context, cli transparentVlanReconciler, conflistPath string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// the conflist is read on every run, as it is written after CNS starts.
+		netconf, err := azureVnetNetworkConfig(conflistPath)
+		if err != nil {
+			logger.Errorf("[cnireconciler] Failed to read the network configuration of the CNI, CNS is reached at its default URL: %v", err)
+		}
+		report, err := cli.ReconcileTransparentVlan(netconf, false)
+		if err != nil {
+			logger.Errorf("[cnireconciler] Failed to reconcile transparent vlan resources: %v", err)
+		} else if removed(report) {
+			logger.Printf("[cnireconciler] Reconciled transparent vlan resources: %+v", *report)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// azureVnetNetworkConfig returns the network configuration of the azure-vnet plugin in the conflist, with the name
+// and CNI version of the list, as the container runtime passes it to the CNI.
+func azureVnetNetworkConfig(conflistPath string) ([]byte, error) {
+	list, err := libcni.ConfListFromFile(conflistPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read conflist %s", conflistPath)
+	}
+	for _, plugin := range list.Plugins {
+		if plugin.Network.Type != azureVnetType {
+			continue
+		}
+		conf, err := libcni.InjectConf(plugin, map[string]interface{}{"name": list.Name, "cniVersion": list.CNIVersion})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to build the network configuration of conflist %s", conflistPath)
+		}
+		return conf.Bytes, nil
+	}
+	return nil, errors.Wrap(errNoAzureVnetPlugin, conflistPath)
+}
+
+func removed(report *api.TransparentVlanReconcileReport) bool {
+	return len(report.Namespaces)+len(report.VlanLinks)+len(report.Veths)+len(report.Rules)+len(report.Errors) > 0
+}
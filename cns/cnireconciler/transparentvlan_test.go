@@ -0,0 +1,60 @@
+package cnireconciler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cni/api"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTransparentVlanReconciler struct {
+	calls   int
+	netconf []byte
+	cancel  context.CancelFunc
+}
+
+func (f *fakeTransparentVlanReconciler) ReconcileTransparentVlan(netconf []byte, dryRun bool) (*api.TransparentVlanReconcileReport, error) {
+	f.calls++
+	f.netconf = netconf
+	if dryRun {
+		return nil, errors.New("unexpected dry run")
+	}
+	if f.calls == 2 {
+		f.cancel()
+		return nil, errors.New("cni failed")
+	}
+	return &api.TransparentVlanReconcileReport{Namespaces: []string{"az_ns_1"}}, nil
+}
+
+func TestReconcileTransparentVlan(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := &fakeTransparentVlanReconciler{cancel: cancel}
+
+	done := make(chan struct{})
+	go func() {
+		reconcileTransparentVlan(ctx, fake, "testdata/transparentvlan.conflist", time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reconcile did not stop when the context was done")
+	}
+	// runs at startup, then once per tick, and keeps going after a failed run until the context is done.
+	assert.GreaterOrEqual(t, fake.calls, 2)
+	// the CNI reaches CNS as its network configuration says.
+	assert.JSONEq(t, `{"name":"azure","cniVersion":"0.3.0","type":"azure-vnet","mode":"transparent-vlan","cnsurl":"/var/run/azure-cns/cns.sock"}`, string(fake.netconf))
+}
+
+func TestAzureVnetNetworkConfigNoPlugin(t *testing.T) {
+	_, err := azureVnetNetworkConfig("testdata/noazurevnet.conflist")
+	require.ErrorIs(t, err, errNoAzureVnetPlugin)
+	_, err = azureVnetNetworkConfig("testdata/missing.conflist")
+	require.Error(t, err)
+}
@@ -40,6 +40,16 @@ type ServiceConfig struct {
 	Store       store.KeyValueStore
 	ChannelMode string
 	TlsSettings tls.TlsSettings
+	// Middleware, if set, wraps the handlers of the CNS API, such as to authorize callers.
+	Middleware acn.Middleware
+	// UnixSocketPath, if set, is the path of a Unix socket that the CNS API is also served on.
+	UnixSocketPath string
+	// UnixSocketMiddleware wraps the handlers served on the Unix socket, such as to check the peer credentials.
+	UnixSocketMiddleware acn.Middleware
+	// GRPCSocketPath, if set, is the path of a Unix socket that the IPAM API of CNS is also served on over gRPC.
+	GRPCSocketPath string
+	// GRPCAuthorize, if set, allows or denies the callers of the gRPC API, by the credentials of their peer process.
+	GRPCAuthorize func(acn.Identity) bool
 }
 
 // NewService creates a new Service object.
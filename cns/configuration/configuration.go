@@ -32,6 +32,20 @@ type CNSConfig struct {
 	TLSEndpoint                 string
 	TLSPort                     string
 	TLSSubjectName              string
+	// TLSSecretNamespace and TLSSecretName name a kubernetes.io/tls Secret that the TLS certificate is read
+	// from, if TLSCertificatePath is not set.
+	TLSSecretNamespace string
+	TLSSecretName      string
+	// TLSRefreshIntervalInSecs is the interval at which the certificate file is checked for a rotated
+	// certificate, and after which a failed watch of the Secret is retried.
+	TLSRefreshIntervalInSecs int
+	// TLSClientCACertificatePath is the PEM bundle of the CAs that client certificates are verified against.
+	// If set, the HTTPS server requires callers to present a client certificate.
+	TLSClientCACertificatePath  string
+	AuthorizationSettings       AuthorizationSettings
+	UnixSocketSettings          UnixSocketSettings
+	GRPCSettings                GRPCSettings
+	LocalIPAMSettings           LocalIPAMSettings
 	TelemetrySettings           TelemetrySettings
 	UseHTTPS                    bool
 	WireserverIP                string
@@ -42,8 +56,27 @@ type CNSConfig struct {
 	CNIConflistScenario         string
 	EnableCNIConflistGeneration bool
 	CNIConflistFilepath         string
+	CNIConflistMTU              int
 	MellanoxMonitorIntervalSecs int
 	AZRSettings                 AZRSettings
+	EnablePodNetworkAnnotations bool
+	// NMAgentCircuitBreakerThreshold is the number of consecutive failed NMAgent requests after which
+	// CNS stops calling NMAgent for NMAgentCircuitBreakerCooldownSecs. Zero uses the client default and
+	// a negative value disables the circuit breaker.
+	NMAgentCircuitBreakerThreshold    int
+	NMAgentCircuitBreakerCooldownSecs int
+	// HealthCheckFailureThreshold is the number of consecutive failures of the store, NMAgent and listener
+	// health checks after which CNS is reported not ready or not live.
+	HealthCheckFailureThreshold int
+	// NCProgrammingTimeoutSecs is how long NMAgent has to program the version of an NC before CNS publishes the
+	// NC to NMAgent again.
+	NCProgrammingTimeoutSecs int
+	// NCProgrammingMaxRetries is the number of times CNS publishes an NC again before it marks the NC Failed.
+	// Zero uses the default and a negative value marks NCs Failed at their first timeout.
+	NCProgrammingMaxRetries int
+	// TransparentVlanReconcileIntervalSecs is how often CNS runs the CNI to remove the transparent vlan namespaces,
+	// links and rules that no endpoint or NC uses any more, starting at startup. Zero disables the reconcile.
+	TransparentVlanReconcileIntervalSecs int
 }
 
 type TelemetrySettings struct {
@@ -71,6 +104,12 @@ type TelemetrySettings struct {
 	SnapshotIntervalInMins int
 	// AppInsightsInstrumentationKey allows the user to override the default appinsights ikey
 	AppInsightsInstrumentationKey string
+	// OTLPEndpoint is the OTLP/HTTP endpoint of an OpenTelemetry collector. If set, telemetry is exported
+	// there instead of to Application Insights.
+	OTLPEndpoint string
+	// TracingEndpoint is the OTLP/HTTP endpoint of an OpenTelemetry collector that spans are exported to.
+	// If unset, the trace context of callers is propagated but no spans are exported.
+	TracingEndpoint string
 }
 
 type ManagedSettings struct {
@@ -89,6 +128,47 @@ type MSISettings struct {
 	ResourceID string
 }
 
+// AuthorizationSettings restricts the CNS API routes that each caller can call. Callers are allowed
+// everything if there are no rules.
+type AuthorizationSettings struct {
+	Rules []AuthorizationRule
+}
+
+// AuthorizationRule allows the callers it matches to call the routes of the route groups: "cni", "dnc",
+// "debug" or "read". Callers are matched by the common name of their client certificate, by the user ID of their Unix
+// socket peer process, or, if Anonymous is set, when they can not be identified.
+type AuthorizationRule struct {
+	Subjects    []string
+	UIDs        []uint32
+	Anonymous   bool
+	RouteGroups []string
+}
+
+// UnixSocketSettings configures the Unix socket that CNS serves the routes called by the CNI and azure-ipam
+// on, in addition to its TCP endpoint. Only the processes running as one of the AllowedUIDs, or as root if
+// there are none, can call CNS on the socket. The peer credentials are only available on Linux.
+type UnixSocketSettings struct {
+	Enable      bool
+	Path        string
+	AllowedUIDs []uint32
+}
+
+// GRPCSettings configures the Unix socket that CNS serves its IPAM API on over gRPC, in addition to HTTP. Only
+// the processes running as one of the AllowedUIDs, or as root if there are none, can call the gRPC API.
+type GRPCSettings struct {
+	Enable      bool
+	Path        string
+	AllowedUIDs []uint32
+}
+
+// LocalIPAMSettings configures the node-local IP pools that CNS runs its IPAM on in the LocalIPAM ChannelMode.
+// The pools are the podCIDRs of the Node with the NodePodCIDR Source, which is the default, or are read from the
+// JSON File with the File Source.
+type LocalIPAMSettings struct {
+	Source string
+	File   string
+}
+
 type KeyVaultSettings struct {
 	URL                  string
 	CertificateName      string
@@ -177,6 +257,18 @@ func setAZRSettingsDefaults(azrSettings *AZRSettings) {
 	}
 }
 
+func setUnixSocketSettingsDefaults(uss *UnixSocketSettings) {
+	if uss.Path == "" {
+		uss.Path = cns.DefaultUnixSocketPath
+	}
+}
+
+func setGRPCSettingsDefaults(gs *GRPCSettings) {
+	if gs.Path == "" {
+		gs.Path = cns.DefaultGRPCSocketPath
+	}
+}
+
 func setKeyVaultSettingsDefaults(kvs *KeyVaultSettings) {
 	if kvs.RefreshIntervalInHrs == 0 {
 		kvs.RefreshIntervalInHrs = 12 //nolint:gomnd // default times
@@ -189,6 +281,8 @@ func SetCNSConfigDefaults(config *CNSConfig) {
 	setManagedSettingDefaults(&config.ManagedSettings)
 	setKeyVaultSettingsDefaults(&config.KeyVaultSettings)
 	setAZRSettingsDefaults(&config.AZRSettings)
+	setUnixSocketSettingsDefaults(&config.UnixSocketSettings)
+	setGRPCSettingsDefaults(&config.GRPCSettings)
 
 	if config.ChannelMode == "" {
 		config.ChannelMode = cns.Direct
@@ -202,7 +296,19 @@ func SetCNSConfigDefaults(config *CNSConfig) {
 	if config.SyncHostNCTimeoutMs == 0 {
 		config.SyncHostNCTimeoutMs = 500 //nolint:gomnd // default times
 	}
+	if config.NCProgrammingTimeoutSecs == 0 {
+		config.NCProgrammingTimeoutSecs = 300 //nolint:gomnd // default times
+	}
+	if config.NCProgrammingMaxRetries == 0 {
+		config.NCProgrammingMaxRetries = 3 //nolint:gomnd // default retries
+	}
 	if config.WireserverIP == "" {
 		config.WireserverIP = "168.63.129.16"
 	}
+	if config.TLSRefreshIntervalInSecs == 0 {
+		config.TLSRefreshIntervalInSecs = 60 //nolint:gomnd // default times
+	}
+	if config.HealthCheckFailureThreshold == 0 {
+		config.HealthCheckFailureThreshold = 3 //nolint:gomnd // default threshold
+	}
 }
@@ -200,6 +200,10 @@ func TestSetCNSConfigDefaults(t *testing.T) {
 				MetricsBindAddress:          ":9090",
 				SyncHostNCTimeoutMs:         500,
 				SyncHostNCVersionIntervalMs: 1000,
+				TLSRefreshIntervalInSecs:    60,
+				HealthCheckFailureThreshold: 3,
+				NCProgrammingTimeoutSecs:    300,
+				NCProgrammingMaxRetries:     3,
 				TelemetrySettings: TelemetrySettings{
 					TelemetryBatchSizeBytes:      32768,
 					TelemetryBatchIntervalInSecs: 30,
@@ -213,6 +217,12 @@ func TestSetCNSConfigDefaults(t *testing.T) {
 				AZRSettings: AZRSettings{
 					PopulateHomeAzCacheRetryIntervalSecs: 60,
 				},
+				UnixSocketSettings: UnixSocketSettings{
+					Path: "/var/run/azure-cns/cns.sock",
+				},
+				GRPCSettings: GRPCSettings{
+					Path: "/var/run/azure-cns/cns-grpc.sock",
+				},
 				WireserverIP: "168.63.129.16",
 			},
 		},
@@ -226,6 +236,10 @@ func TestSetCNSConfigDefaults(t *testing.T) {
 				MetricsBindAddress:          ":9091",
 				SyncHostNCTimeoutMs:         5,
 				SyncHostNCVersionIntervalMs: 1,
+				TLSRefreshIntervalInSecs:    10,
+				HealthCheckFailureThreshold: 1,
+				NCProgrammingTimeoutSecs:    30,
+				NCProgrammingMaxRetries:     -1,
 				TelemetrySettings: TelemetrySettings{
 					TelemetryBatchSizeBytes:      3,
 					TelemetryBatchIntervalInSecs: 3,
@@ -240,6 +254,12 @@ func TestSetCNSConfigDefaults(t *testing.T) {
 					EnableAZR:                            true,
 					PopulateHomeAzCacheRetryIntervalSecs: 10,
 				},
+				UnixSocketSettings: UnixSocketSettings{
+					Path: "/run/cns.sock",
+				},
+				GRPCSettings: GRPCSettings{
+					Path: "/run/cns-grpc.sock",
+				},
 			},
 			want: CNSConfig{
 				ChannelMode: "Other",
@@ -249,6 +269,10 @@ func TestSetCNSConfigDefaults(t *testing.T) {
 				MetricsBindAddress:          ":9091",
 				SyncHostNCTimeoutMs:         5,
 				SyncHostNCVersionIntervalMs: 1,
+				TLSRefreshIntervalInSecs:    10,
+				HealthCheckFailureThreshold: 1,
+				NCProgrammingTimeoutSecs:    30,
+				NCProgrammingMaxRetries:     -1,
 				TelemetrySettings: TelemetrySettings{
 					TelemetryBatchSizeBytes:      3,
 					TelemetryBatchIntervalInSecs: 3,
@@ -263,6 +287,12 @@ func TestSetCNSConfigDefaults(t *testing.T) {
 					EnableAZR:                            true,
 					PopulateHomeAzCacheRetryIntervalSecs: 10,
 				},
+				UnixSocketSettings: UnixSocketSettings{
+					Path: "/run/cns.sock",
+				},
+				GRPCSettings: GRPCSettings{
+					Path: "/run/cns-grpc.sock",
+				},
 				WireserverIP: "168.63.129.16",
 			},
 		},
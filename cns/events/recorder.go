@@ -0,0 +1,183 @@
+// Package events emits Kubernetes Events on the Node and the Pods for the IPAM and NC lifecycle of CNS, so that
+// `kubectl describe` shows why a Pod is waiting for an IP. Identical Events are deduplicated and all Events are
+// rate limited, so that a failure that repeats on every request or reconcile does not flood the apiserver.
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// Reasons of the Events.
+const (
+	ReasonNCProgrammingFailed  = "NetworkContainerProgrammingFailed"
+	ReasonNCProgrammingStalled = "NetworkContainerProgrammingStalled"
+	ReasonSubnetExhausted      = "SubnetExhausted"
+	ReasonIPAllocationFailed   = "IPAllocationFailed"
+	ReasonPoolAtMaxIPCount     = "IPPoolAtMaxIPCount"
+)
+
+const (
+	// DefaultInterval is how long an Event is not emitted again after an identical Event.
+	DefaultInterval = 5 * time.Minute
+	// DefaultQPS and DefaultBurst limit the rate of all Events.
+	DefaultQPS   = 1
+	DefaultBurst = 10
+
+	podLookupTimeout = 5 * time.Second
+)
+
+// Options configure the deduplication and rate limiting of the Recorder.
+type Options struct {
+	Interval time.Duration
+	QPS      float32
+	Burst    int
+}
+
+// Recorder emits the CNS Events. A nil Recorder drops them, so that they are optional for its callers.
+type Recorder struct {
+	recorder record.EventRecorder
+	pods     corev1client.PodsGetter
+	node     *corev1.ObjectReference
+	interval time.Duration
+	limiter  flowcontrol.RateLimiter
+	now      func() time.Time
+
+	sync.Mutex
+	emitted map[key]time.Time
+}
+
+// key identifies identical Events.
+type key struct {
+	kind, namespace, name, reason, message string
+}
+
+// NewRecorder creates a Recorder that emits Events for the named Node with the EventRecorder. The Pods of Events
+// are looked up with the PodsGetter, since Events need the UID of the Pod to be listed by `kubectl describe`.
+func NewRecorder(recorder record.EventRecorder, pods corev1client.PodsGetter, nodeName string, opts *Options) *Recorder {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.QPS <= 0 {
+		opts.QPS = DefaultQPS
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = DefaultBurst
+	}
+	return &Recorder{
+		recorder: recorder,
+		pods:     pods,
+		// the UID of Node Events is the name of the Node, as for the Events of the kubelet.
+		node:     &corev1.ObjectReference{Kind: "Node", APIVersion: "v1", Name: nodeName, UID: k8stypes.UID(nodeName)},
+		interval: opts.Interval,
+		limiter:  flowcontrol.NewTokenBucketRateLimiter(opts.QPS, opts.Burst),
+		now:      time.Now,
+		emitted:  map[key]time.Time{},
+	}
+}
+
+// NCProgrammingFailed emits a warning on the Node that CNS failed to program the NC.
+func (r *Recorder) NCProgrammingFailed(ncID string, err error) {
+	if r == nil {
+		return
+	}
+	r.emit(r.node, corev1.EventTypeWarning, ReasonNCProgrammingFailed, fmt.Sprintf("failed to program network container %s: %v", ncID, err))
+}
+
+// NCProgrammingStalled emits a warning on the Node that NMAgent has not programmed the version of the NC, which
+// has been in its programming state for the duration.
+func (r *Recorder) NCProgrammingStalled(ncID, version string, state types.NCState, stalled time.Duration) {
+	if r == nil {
+		return
+	}
+	r.emit(r.node, corev1.EventTypeWarning, ReasonNCProgrammingStalled,
+		fmt.Sprintf("network container %s is not programmed to version %s after %s in state %s", ncID, version, stalled.Round(time.Second), state))
+}
+
+// SubnetExhausted emits a warning on the Node that the subnet of its Pods is exhausted, and that the IP pool
+// only scales by one IP at a time.
+func (r *Recorder) SubnetExhausted(subnet string) {
+	if r == nil {
+		return
+	}
+	r.emit(r.node, corev1.EventTypeWarning, ReasonSubnetExhausted, fmt.Sprintf("subnet %s is exhausted, the IP pool scales by one IP at a time", subnet))
+}
+
+// PoolAtMaxIPCount emits a warning on the Node that the IP pool needs more IPs than the max IP count of the Node.
+func (r *Recorder) PoolAtMaxIPCount(maxIPCount int64) {
+	if r == nil {
+		return
+	}
+	r.emit(r.node, corev1.EventTypeWarning, ReasonPoolAtMaxIPCount, fmt.Sprintf("IP pool is at the max IP count %d of the node and can not scale up", maxIPCount))
+}
+
+// IPAllocationFailed emits a warning on the Pod, and on the Node if the Pod is not known, that CNS failed to
+// allocate its IPs.
+func (r *Recorder) IPAllocationFailed(podName, podNamespace string, code types.ResponseCode, message string) {
+	if r == nil {
+		return
+	}
+	message = fmt.Sprintf("failed to allocate IP: %s: %s", code, message)
+	if podName == "" || podNamespace == "" {
+		r.emit(r.node, corev1.EventTypeWarning, ReasonIPAllocationFailed, message)
+		return
+	}
+	// the Pod is looked up in the background, so that the IP request is not slowed down by the apiserver.
+	ref := &corev1.ObjectReference{Kind: "Pod", APIVersion: "v1", Namespace: podNamespace, Name: podName}
+	if !r.allow(ref, ReasonIPAllocationFailed, message) {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), podLookupTimeout)
+		defer cancel()
+		pod, err := r.pods.Pods(podNamespace).Get(ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			logger.Errorf("[events] failed to get pod %s/%s for event: %v", podNamespace, podName, err)
+			return
+		}
+		ref.UID = pod.UID
+		r.recorder.Event(ref, corev1.EventTypeWarning, ReasonIPAllocationFailed, message)
+	}()
+}
+
+func (r *Recorder) emit(ref *corev1.ObjectReference, eventType, reason, message string) {
+	if !r.allow(ref, reason, message) {
+		return
+	}
+	r.recorder.Event(ref, eventType, reason, message)
+}
+
+// allow reports whether the Event is emitted, which it is not if an identical Event was emitted within the
+// interval or if the rate limit is exceeded.
+func (r *Recorder) allow(ref *corev1.ObjectReference, reason, message string) bool {
+	k := key{kind: ref.Kind, namespace: ref.Namespace, name: ref.Name, reason: reason, message: message}
+	now := r.now()
+
+	r.Lock()
+	defer r.Unlock()
+	if last, ok := r.emitted[k]; ok && now.Sub(last) < r.interval {
+		return false
+	}
+	if !r.limiter.TryAccept() {
+		return false
+	}
+	// forget the Events that are past the interval, so that the map does not grow with every Pod.
+	for emittedKey, last := range r.emitted {
+		if now.Sub(last) >= r.interval {
+			delete(r.emitted, emittedKey)
+		}
+	}
+	r.emitted[k] = now
+	return true
+}
@@ -0,0 +1,124 @@
+package events
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMain(m *testing.M) {
+	logDir, err := os.MkdirTemp("", "cns-events-")
+	if err != nil {
+		panic(err)
+	}
+	logger.InitLogger("azure-cns.log", 0, 0, logDir+"/")
+	code := m.Run()
+	os.RemoveAll(logDir)
+	os.Exit(code)
+}
+
+type event struct {
+	object            runtime.Object
+	eventType, reason string
+	message           string
+}
+
+// fakeRecorder records the Events with their object, unlike record.FakeRecorder.
+type fakeRecorder struct {
+	events chan event
+}
+
+func (f *fakeRecorder) Event(object runtime.Object, eventType, reason, message string) {
+	f.events <- event{object: object, eventType: eventType, reason: reason, message: message}
+}
+
+func (f *fakeRecorder) Eventf(object runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+}
+
+func (f *fakeRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventType, reason, messageFmt string, args ...interface{}) {
+}
+
+func newTestRecorder(opts *Options, objects ...runtime.Object) (*Recorder, *fakeRecorder, *time.Time) {
+	f := &fakeRecorder{events: make(chan event, 100)}
+	r := NewRecorder(f, fake.NewSimpleClientset(objects...).CoreV1(), "node", opts)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	return r, f, &now
+}
+
+func TestDeduplicate(t *testing.T) {
+	r, f, now := newTestRecorder(&Options{Interval: time.Minute})
+
+	r.SubnetExhausted("subnet")
+	r.SubnetExhausted("subnet")
+	r.PoolAtMaxIPCount(250)
+	require.Len(t, f.events, 2)
+
+	e := <-f.events
+	assert.Equal(t, ReasonSubnetExhausted, e.reason)
+	assert.Equal(t, corev1.EventTypeWarning, e.eventType)
+	ref, ok := e.object.(*corev1.ObjectReference)
+	require.True(t, ok)
+	assert.Equal(t, "Node", ref.Kind)
+	assert.Equal(t, "node", ref.Name)
+	assert.Equal(t, ReasonPoolAtMaxIPCount, (<-f.events).reason)
+
+	// identical Events are emitted again after the interval
+	*now = now.Add(time.Minute)
+	r.SubnetExhausted("subnet")
+	require.Len(t, f.events, 1)
+}
+
+func TestRateLimit(t *testing.T) {
+	r, f, _ := newTestRecorder(&Options{QPS: 0.001, Burst: 2})
+
+	r.NCProgrammingFailed("nc1", errors.New("failed"))
+	r.NCProgrammingFailed("nc2", errors.New("failed"))
+	r.NCProgrammingFailed("nc3", errors.New("failed"))
+	assert.Len(t, f.events, 2)
+}
+
+func TestIPAllocationFailedOnPod(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", UID: "pod-uid"}}
+	r, f, _ := newTestRecorder(&Options{}, pod)
+
+	r.IPAllocationFailed("pod", "ns", types.FailedToAllocateIPConfig, "no IPs available")
+	select {
+	case e := <-f.events:
+		assert.Equal(t, ReasonIPAllocationFailed, e.reason)
+		assert.Contains(t, e.message, "no IPs available")
+		ref, ok := e.object.(*corev1.ObjectReference)
+		require.True(t, ok)
+		assert.Equal(t, "Pod", ref.Kind)
+		assert.Equal(t, "ns", ref.Namespace)
+		assert.Equal(t, pod.UID, ref.UID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event on the pod")
+	}
+
+	// without the pod, the Event is on the Node
+	r.IPAllocationFailed("", "", types.FailedToAllocateIPConfig, "no IPs available")
+	e := <-f.events
+	ref, ok := e.object.(*corev1.ObjectReference)
+	require.True(t, ok)
+	assert.Equal(t, "Node", ref.Kind)
+}
+
+func TestNilRecorder(t *testing.T) {
+	var r *Recorder
+	r.NCProgrammingFailed("nc", errors.New("failed"))
+	r.NCProgrammingStalled("nc", "1", types.NCPublished, time.Minute)
+	r.SubnetExhausted("subnet")
+	r.PoolAtMaxIPCount(250)
+	r.IPAllocationFailed("pod", "ns", types.FailedToAllocateIPConfig, "failed")
+}
@@ -202,7 +202,7 @@ func (fake *HTTPServiceFake) SendNCSnapShotPeriodically(context.Context, int) {}
 
 func (fake *HTTPServiceFake) SetNodeOrchestrator(*cns.SetOrchestratorTypeRequest) {}
 
-func (fake *HTTPServiceFake) SyncNodeStatus(string, string, string, json.RawMessage) (types.ResponseCode, string) {
+func (fake *HTTPServiceFake) SyncNodeStatus(context.Context, string, string, string, json.RawMessage) (types.ResponseCode, string) {
 	return 0, ""
 }
 
@@ -0,0 +1,156 @@
+package healthserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+const (
+	checkTimeout = 5 * time.Second
+	// storeCheckPattern is the name pattern of the file that the store check writes to the store directory.
+	storeCheckPattern = ".healthcheck-*"
+)
+
+// Checks are the named readiness and liveness checks of CNS. Checks can be added after the healthserver is
+// started, as the components that they check are created.
+type Checks struct {
+	m      sync.RWMutex
+	readyz map[string]healthz.Checker
+	livez  map[string]healthz.Checker
+}
+
+// NewChecks returns Checks with a ping check, so that CNS is live and ready until other checks are added.
+func NewChecks() *Checks {
+	return &Checks{
+		readyz: map[string]healthz.Checker{"ping": healthz.Ping},
+		livez:  map[string]healthz.Checker{"ping": healthz.Ping},
+	}
+}
+
+// AddReadyzCheck adds a check that must pass for CNS to serve requests.
+func (c *Checks) AddReadyzCheck(name string, check healthz.Checker) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.readyz[name] = check
+}
+
+// AddLivezCheck adds a check that fails if CNS must be restarted.
+func (c *Checks) AddLivezCheck(name string, check healthz.Checker) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.livez[name] = check
+}
+
+func (c *Checks) readyzHandler() http.Handler {
+	return handler(func() map[string]healthz.Checker { return c.snapshot(c.readyz) })
+}
+
+func (c *Checks) livezHandler() http.Handler {
+	return handler(func() map[string]healthz.Checker { return c.snapshot(c.livez) })
+}
+
+func (c *Checks) snapshot(checks map[string]healthz.Checker) map[string]healthz.Checker {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	out := make(map[string]healthz.Checker, len(checks))
+	for name, check := range checks {
+		out[name] = check
+	}
+	return out
+}
+
+// Threshold returns a check that fails only once the check has failed the number of times in a row, so that a
+// transient failure does not restart CNS or take it out of service.
+func Threshold(check healthz.Checker, failures int) healthz.Checker {
+	var m sync.Mutex
+	var failed int
+	return func(req *http.Request) error {
+		err := check(req)
+		m.Lock()
+		defer m.Unlock()
+		if err == nil {
+			failed = 0
+			return nil
+		}
+		failed++
+		if failed < failures {
+			return nil
+		}
+		return errors.Wrapf(err, "failed %d times in a row", failed)
+	}
+}
+
+// Started returns a check that passes once started reports true, such as for the NNC Reconciler.
+func Started(started func(context.Context) (bool, error)) healthz.Checker {
+	return func(req *http.Request) error {
+		// started blocks until it is started, so it is given a short time to report
+		ctx, cancel := context.WithTimeout(req.Context(), 100*time.Millisecond) //nolint:gomnd // short wait
+		defer cancel()
+		ok, err := started(ctx)
+		if err != nil {
+			return errors.Wrap(err, "not started")
+		}
+		if !ok {
+			return errors.New("not started")
+		}
+		return nil
+	}
+}
+
+// Writable returns a check that passes if a file can be written to the directory of the store, such as the
+// directory of the store.KeyValueStore of CNS. The file is removed after the check, so that the check does
+// not write to the store itself.
+func Writable(dir string) healthz.Checker {
+	return func(*http.Request) error {
+		f, err := os.CreateTemp(dir, storeCheckPattern)
+		if err != nil {
+			return errors.Wrap(err, "store is not writable")
+		}
+		defer os.Remove(f.Name())
+		_, err = f.WriteString(time.Now().String())
+		if err == nil {
+			err = f.Sync()
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return errors.Wrap(err, "store is not writable")
+	}
+}
+
+type nmagentClient interface {
+	SupportedAPIs(context.Context) ([]string, error)
+}
+
+// NMAgent returns a check that passes if NMAgent responds with its supported APIs.
+func NMAgent(c nmagentClient) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), checkTimeout)
+		defer cancel()
+		_, err := c.SupportedAPIs(ctx)
+		return errors.Wrap(err, "nmagent is unreachable")
+	}
+}
+
+// Listening returns a check that passes if a connection to the listener at the URL, such as
+// tcp://localhost:10090, can be opened.
+func Listening(u *url.URL) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), checkTimeout)
+		defer cancel()
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, u.Scheme, u.Host+u.Path)
+		if err != nil {
+			return errors.Wrapf(err, "%s is not listening", u)
+		}
+		return errors.Wrap(conn.Close(), "failed to close connection")
+	}
+}
@@ -0,0 +1,127 @@
+package healthserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errCheck = errors.New("check failed")
+
+func serve(t *testing.T, h http.Handler, target string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, http.NoBody))
+	return rec.Code, rec.Body.String()
+}
+
+func TestChecksAddedAfterStart(t *testing.T) {
+	checks := NewChecks()
+	h := echo.New()
+	mount(h, "/readyz", checks.readyzHandler())
+	mount(h, "/livez", checks.livezHandler())
+
+	code, _ := serve(t, h, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+
+	checks.AddReadyzCheck("failing", func(*http.Request) error { return errCheck })
+	code, body := serve(t, h, "/readyz?verbose")
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Contains(t, body, "[+]ping ok")
+	assert.Contains(t, body, "[-]failing failed")
+
+	// each check is served on its own path
+	code, _ = serve(t, h, "/readyz/ping")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = serve(t, h, "/readyz/failing")
+	assert.Equal(t, http.StatusInternalServerError, code)
+
+	// liveness checks are separate
+	code, _ = serve(t, h, "/livez")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestThreshold(t *testing.T) {
+	var err error
+	check := Threshold(func(*http.Request) error { return err }, 3)
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+	err = errCheck
+	require.NoError(t, check(req))
+	require.NoError(t, check(req))
+	require.ErrorIs(t, check(req), errCheck)
+	require.ErrorIs(t, check(req), errCheck)
+
+	// a success resets the count
+	err = nil
+	require.NoError(t, check(req))
+	err = errCheck
+	require.NoError(t, check(req))
+}
+
+func TestStarted(t *testing.T) {
+	started := make(chan struct{})
+	check := Started(func(ctx context.Context) (bool, error) {
+		select {
+		case <-started:
+			return true, nil
+		case <-ctx.Done():
+			return false, errors.Wrap(ctx.Err(), "context closed")
+		}
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+	require.Error(t, check(req))
+	close(started)
+	require.NoError(t, check(req))
+}
+
+func TestWritable(t *testing.T) {
+	dir := t.TempDir()
+	check := Writable(dir)
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+	require.NoError(t, check(req))
+	// the check leaves nothing behind in the store directory
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	require.Error(t, Writable(filepath.Join(dir, "missing"))(req))
+}
+
+type fakeNMAgent struct {
+	err error
+}
+
+func (f *fakeNMAgent) SupportedAPIs(context.Context) ([]string, error) {
+	return nil, f.err
+}
+
+func TestNMAgent(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	require.NoError(t, NMAgent(&fakeNMAgent{})(req))
+	require.ErrorIs(t, NMAgent(&fakeNMAgent{err: errCheck})(req), errCheck)
+}
+
+func TestListening(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	u := &url.URL{Scheme: "tcp", Host: l.Addr().String()}
+	check := Listening(u)
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+	require.NoError(t, check(req))
+	require.NoError(t, l.Close())
+	require.Error(t, check(req))
+}
@@ -10,10 +10,15 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
-func Start(log *zap.Logger, addr string) {
+// Start serves the readiness and liveness checks on /readyz and /livez, and the metrics on /metrics.
+// /healthz serves the liveness checks for the probes that still use it. Each check is also served on its
+// own path, such as /readyz/nmagent, and ?verbose lists the result of every check.
+func Start(log *zap.Logger, addr string, checks *Checks) {
 	e := echo.New()
 	e.HideBanner = true
-	e.GET("/healthz", echo.WrapHandler(http.StripPrefix("/healthz", &healthz.Handler{})))
+	mount(e, "/healthz", checks.livezHandler())
+	mount(e, "/livez", checks.livezHandler())
+	mount(e, "/readyz", checks.readyzHandler())
 	e.GET("/metrics", echo.WrapHandler(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{
 		ErrorHandling: promhttp.HTTPErrorOnError,
 	})))
@@ -21,3 +26,17 @@ func Start(log *zap.Logger, addr string) {
 		log.Error("failed to run healthserver", zap.Error(err))
 	}
 }
+
+// mount serves the checks on the path and the path of each check.
+func mount(e *echo.Echo, path string, h http.Handler) {
+	wrapped := echo.WrapHandler(http.StripPrefix(path, h))
+	e.GET(path, wrapped)
+	e.GET(path+"/*", wrapped)
+}
+
+// handler serves the checks returned by the func, so that checks added after the server started are served.
+type handler func() map[string]healthz.Checker
+
+func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(&healthz.Handler{Checks: h()}).ServeHTTP(w, r)
+}
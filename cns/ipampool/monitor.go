@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/events"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/metric"
 	"github.com/Azure/azure-container-networking/cns/types"
@@ -49,6 +50,10 @@ type metaState struct {
 type Options struct {
 	RefreshDelay time.Duration
 	MaxIPs       int64
+	// Events is optional, and emits Events when the pool can not scale.
+	Events *events.Recorder
+	// SpecUpdated is optional, and is called with the spec after the Monitor updates the NodeNetworkConfig.
+	SpecUpdated func(v1alpha.NodeNetworkConfigSpec)
 }
 
 type Monitor struct {
@@ -61,6 +66,8 @@ type Monitor struct {
 	nncSource   chan v1alpha.NodeNetworkConfig
 	started     chan interface{}
 	once        sync.Once
+	statusLock  sync.RWMutex
+	status      v1alpha.PoolMonitorStatus
 }
 
 func NewMonitor(httpService cns.HTTPService, nnccli nodeNetworkConfigSpecUpdater, cssSource <-chan v1alpha1.ClusterSubnetState, opts *Options) *Monitor {
@@ -103,6 +110,9 @@ func (pm *Monitor) Start(ctx context.Context) error {
 		case css := <-pm.cssSource: // received an updated ClusterSubnetState
 			pm.metastate.exhausted = css.Status.Exhausted
 			logger.Printf("subnet exhausted status = %t", pm.metastate.exhausted)
+			if pm.metastate.exhausted {
+				pm.opts.Events.SubnetExhausted(pm.metastate.subnet)
+			}
 			ipamSubnetExhaustionCount.With(prometheus.Labels{
 				subnetLabel: pm.metastate.subnet, subnetCIDRLabel: pm.metastate.subnetCIDR,
 				podnetARMIDLabel: pm.metastate.subnetARMID, subnetExhaustionStateLabel: strconv.FormatBool(pm.metastate.exhausted),
@@ -154,6 +164,40 @@ func (pm *Monitor) Start(ctx context.Context) error {
 		if err != nil {
 			logger.Printf("[ipam-pool-monitor] Reconcile failed with err %v", err)
 		}
+		pm.setStatus()
+	}
+}
+
+// setStatus records the state of the pool after a reconcile, to be read by Status from other goroutines.
+func (pm *Monitor) setStatus() {
+	status := v1alpha.PoolMonitorStatus{
+		Started:               true,
+		RequestedIPCount:      pm.spec.RequestedIPCount,
+		PendingReleaseIPCount: int64(len(pm.httpService.GetPendingReleaseIPConfigs())),
+		SubnetExhausted:       pm.metastate.exhausted,
+	}
+	pm.statusLock.Lock()
+	pm.status = status
+	pm.statusLock.Unlock()
+}
+
+// Status returns the state of the pool as of the last reconcile of the Monitor. It is safe to call
+// concurrently with Start.
+func (pm *Monitor) Status() v1alpha.PoolMonitorStatus {
+	pm.statusLock.RLock()
+	defer pm.statusLock.RUnlock()
+	return pm.status
+}
+
+// Started blocks until the Monitor has received a NodeNetworkConfig and set the initial pool spec,
+// then, and any time that it is called after that, it immediately returns true.
+// If the context is closed before, it returns false.
+func (pm *Monitor) Started(ctx context.Context) (bool, error) {
+	select {
+	case <-pm.started:
+		return true, nil
+	case <-ctx.Done():
+		return false, errors.Wrap(ctx.Err(), "context closed")
 	}
 }
 
@@ -226,6 +270,7 @@ func (pm *Monitor) reconcile(ctx context.Context) error {
 	case state.expectedAvailableIPs < meta.minFreeCount:
 		if state.requestedIPs == meta.max {
 			// If we're already at the maxIPCount, don't try to increase
+			pm.opts.Events.PoolAtMaxIPCount(meta.max)
 			return nil
 		}
 		logger.Printf("ipam-pool-monitor state %+v", state)
@@ -270,6 +315,7 @@ func (pm *Monitor) increasePoolSize(ctx context.Context, meta metaState, state i
 	if tempNNCSpec.RequestedIPCount > meta.max {
 		// We don't want to ask for more ips than the max
 		logger.Printf("[ipam-pool-monitor] Requested IP count (%d) is over max limit (%d), requesting max limit instead.", tempNNCSpec.RequestedIPCount, meta.max)
+		pm.opts.Events.PoolAtMaxIPCount(meta.max)
 		tempNNCSpec.RequestedIPCount = meta.max
 	}
 
@@ -290,7 +336,7 @@ func (pm *Monitor) increasePoolSize(ctx context.Context, meta metaState, state i
 	// start an alloc timer
 	metric.StartPoolIncreaseTimer(batchSize)
 	// save the updated state to cachedSpec
-	pm.spec = tempNNCSpec
+	pm.setSpec(tempNNCSpec)
 	return nil
 }
 
@@ -365,7 +411,7 @@ func (pm *Monitor) decreasePoolSize(ctx context.Context, meta metaState, state i
 	metric.StartPoolDecreaseTimer(batchSize)
 
 	// save the updated state to cachedSpec
-	pm.spec = tempNNCSpec
+	pm.setSpec(tempNNCSpec)
 
 	// clear the updatingPendingIpsNotInUse, as we have Updated the CRD
 	logger.Printf("[ipam-pool-monitor] cleaning the updatingPendingIpsNotInUse, existing length %d", pm.metastate.notInUseCount)
@@ -387,10 +433,18 @@ func (pm *Monitor) cleanPendingRelease(ctx context.Context) error {
 	logger.Printf("[ipam-pool-monitor] cleanPendingRelease: UpdateCRDSpec succeeded for spec %+v", tempNNCSpec)
 
 	// save the updated state to cachedSpec
-	pm.spec = tempNNCSpec
+	pm.setSpec(tempNNCSpec)
 	return nil
 }
 
+// setSpec caches the spec that the Monitor updated the NodeNetworkConfig with.
+func (pm *Monitor) setSpec(spec v1alpha.NodeNetworkConfigSpec) {
+	pm.spec = spec
+	if pm.opts.SpecUpdated != nil {
+		pm.opts.SpecUpdated(spec)
+	}
+}
+
 // createNNCSpecForCRD translates CNS's map of IPs to be released and requested IP count into an NNC Spec.
 func (pm *Monitor) createNNCSpecForCRD() v1alpha.NodeNetworkConfigSpec {
 	var spec v1alpha.NodeNetworkConfigSpec
@@ -11,6 +11,7 @@ import (
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type fakeNodeNetworkConfigUpdater struct {
@@ -162,6 +163,29 @@ func TestPoolSizeIncrease(t *testing.T) {
 	}
 }
 
+func TestSpecUpdated(t *testing.T) {
+	_, fakerc, poolmonitor := initFakes(testState{
+		allocated:               10,
+		assigned:                8,
+		batch:                   10,
+		max:                     30,
+		releaseThresholdPercent: 150,
+		requestThresholdPercent: 50,
+	}, nil)
+	var specs []v1alpha.NodeNetworkConfigSpec
+	poolmonitor.opts.SpecUpdated = func(spec v1alpha.NodeNetworkConfigSpec) {
+		specs = append(specs, spec)
+	}
+	require.NoError(t, fakerc.Reconcile(true))
+
+	// the spec is passed when the monitor updates it only.
+	require.NoError(t, poolmonitor.reconcile(context.Background()))
+	require.NoError(t, fakerc.Reconcile(true))
+	require.NoError(t, poolmonitor.reconcile(context.Background()))
+	require.Len(t, specs, 1)
+	assert.Equal(t, int64(20), specs[0].RequestedIPCount)
+}
+
 func TestPoolIncreaseDoesntChangeWhenIncreaseIsAlreadyInProgress(t *testing.T) {
 	initState := testState{
 		batch:                   10,
@@ -288,6 +312,31 @@ func TestIncreaseWithPendingRelease(t *testing.T) {
 	assert.Empty(t, mon.spec.IPsNotInUse)
 }
 
+func TestStatus(t *testing.T) {
+	initState := testState{
+		batch:                   16,
+		assigned:                16,
+		allocated:               32,
+		exhausted:               true,
+		requestThresholdPercent: 50,
+		releaseThresholdPercent: 150,
+		max:                     250,
+		pendingRelease:          16,
+	}
+	_, rc, mon := initFakes(initState, nil)
+	assert.Equal(t, v1alpha.PoolMonitorStatus{}, mon.Status())
+
+	assert.NoError(t, rc.Reconcile(true))
+	assert.NoError(t, mon.reconcile(context.Background()))
+	mon.setStatus()
+	assert.Equal(t, v1alpha.PoolMonitorStatus{
+		Started:               true,
+		RequestedIPCount:      32,
+		PendingReleaseIPCount: 16,
+		SubnetExhausted:       true,
+	}, mon.Status())
+}
+
 func TestPoolDecrease(t *testing.T) {
 	tests := []struct {
 		name           string
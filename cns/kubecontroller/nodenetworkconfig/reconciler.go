@@ -2,9 +2,11 @@ package nodenetworkconfig
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/events"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/restserver"
 	cnstypes "github.com/Azure/azure-container-networking/cns/types"
@@ -12,48 +14,66 @@ import (
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 type cnsClient interface {
 	CreateOrUpdateNetworkContainerInternal(*cns.CreateNetworkContainerRequest) cnstypes.ResponseCode
+	GetAssignedIPConfigs() []cns.IPConfigurationStatus
 }
 
 type nodeNetworkConfigListener interface {
 	Update(*v1alpha.NodeNetworkConfig) error
 }
 
-type nncGetter interface {
+type poolMonitor interface {
+	nodeNetworkConfigListener
+	Status() v1alpha.PoolMonitorStatus
+}
+
+type nncClient interface {
 	Get(context.Context, types.NamespacedName) (*v1alpha.NodeNetworkConfig, error)
+	PatchCNSStatus(context.Context, types.NamespacedName, *v1alpha.CNSStatus) error
 }
 
 // Reconciler watches for CRD status changes
 type Reconciler struct {
 	cnscli             cnsClient
-	ipampoolmonitorcli nodeNetworkConfigListener
-	nnccli             nncGetter
+	ipampoolmonitorcli poolMonitor
+	nnccli             nncClient
 	once               sync.Once
 	started            chan interface{}
 	nodeIP             string
+	events             *events.Recorder
+	// triggers are the NNCs that a reconcile is requested for outside of the NNC watch.
+	triggers chan event.GenericEvent
 }
 
 // NewReconciler creates a NodeNetworkConfig Reconciler which will get updates from the Kubernetes
 // apiserver for NNC events.
 // Provided nncListeners are passed the NNC after the Reconcile preprocesses it. Note: order matters! The
 // passed Listeners are notified in the order provided.
-func NewReconciler(cnscli cnsClient, ipampoolmonitorcli nodeNetworkConfigListener, nodeIP string) *Reconciler {
+// The Recorder is optional, and emits Events when an NC fails to program.
+func NewReconciler(cnscli cnsClient, ipampoolmonitorcli poolMonitor, nodeIP string, recorder *events.Recorder) *Reconciler {
 	return &Reconciler{
 		cnscli:             cnscli,
 		ipampoolmonitorcli: ipampoolmonitorcli,
 		started:            make(chan interface{}),
 		nodeIP:             nodeIP,
+		events:             recorder,
+		triggers:           make(chan event.GenericEvent, 1),
 	}
 }
 
@@ -73,15 +93,23 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	logger.Printf("[cns-rc] CRD Spec: %+v", nnc.Spec)
 
 	ipAssignments := 0
+	ncStatuses := make([]v1alpha.NetworkContainerStatus, 0, len(nnc.Status.NetworkContainers))
 
 	// for each NC, parse it in to a CreateNCRequest and forward it to the appropriate Listener
 	for i := range nnc.Status.NetworkContainers {
+		ncStatus := v1alpha.NetworkContainerStatus{
+			ID:              nnc.Status.NetworkContainers[i].ID,
+			ObservedVersion: nnc.Status.NetworkContainers[i].Version,
+		}
 		// check if this NC matches the Node IP if we have one to check against
 		if r.nodeIP != "" {
 			if r.nodeIP != nnc.Status.NetworkContainers[i].NodeIP {
 				// skip this NC since it was created for a different node
 				logger.Printf("[cns-rc] skipping network container %s found in NNC because node IP doesn't match, got %s, expected %s",
 					nnc.Status.NetworkContainers[i].ID, nnc.Status.NetworkContainers[i].NodeIP, r.nodeIP)
+				ncStatus.Result = v1alpha.NCSkipped
+				ncStatus.LastError = fmt.Sprintf("node IP %s does not match node IP %s of CNS", nnc.Status.NetworkContainers[i].NodeIP, r.nodeIP)
+				ncStatuses = append(ncStatuses, ncStatus)
 				continue
 			}
 		}
@@ -102,28 +130,40 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		if err != nil {
 			logger.Errorf("[cns-rc] failed to generate CreateNCRequest from NC: %v, assignmentMode %s", err,
 				nnc.Status.NetworkContainers[i].AssignmentMode)
-			return reconcile.Result{}, errors.Wrapf(err, "failed to generate CreateNCRequest from NC "+
+			err = errors.Wrapf(err, "failed to generate CreateNCRequest from NC "+
 				"assignmentMode %s", nnc.Status.NetworkContainers[i].AssignmentMode)
+			r.events.NCProgrammingFailed(ncStatus.ID, err)
+			r.patchStatus(ctx, nnc, append(ncStatuses, failed(ncStatus, err)), ipAssignments, err)
+			return reconcile.Result{}, err
 		}
 
 		responseCode := r.cnscli.CreateOrUpdateNetworkContainerInternal(req)
 		if err := restserver.ResponseCodeToError(responseCode); err != nil {
 			logger.Errorf("[cns-rc] Error creating or updating NC in reconcile: %v", err)
-			return reconcile.Result{}, errors.Wrap(err, "failed to create or update network container")
+			err = errors.Wrap(err, "failed to create or update network container")
+			r.events.NCProgrammingFailed(ncStatus.ID, err)
+			r.patchStatus(ctx, nnc, append(ncStatuses, failed(ncStatus, err)), ipAssignments, err)
+			return reconcile.Result{}, err
 		}
 		ipAssignments += len(req.SecondaryIPConfigs)
+		ncStatus.Result = v1alpha.NCProgrammed
+		ncStatuses = append(ncStatuses, ncStatus)
 	}
 
 	// record assigned IPs metric
 	allocatedIPs.Set(float64(ipAssignments))
 
-	// push the NNC to the registered NNC listeners.
+	// push the NNC to the registered NNC listeners, before reporting the status of the pool monitor.
 	for _, l := range listenersToNotify {
 		if err := l.Update(nnc); err != nil {
-			return reconcile.Result{}, errors.Wrap(err, "nnc listener return error during update")
+			err = errors.Wrap(err, "nnc listener return error during update")
+			r.patchStatus(ctx, nnc, ncStatuses, ipAssignments, err)
+			return reconcile.Result{}, err
 		}
 	}
 
+	r.patchStatus(ctx, nnc, ncStatuses, ipAssignments, nil)
+
 	// we have received and pushed an NNC update, we are "Started"
 	r.once.Do(func() {
 		close(r.started)
@@ -132,6 +172,67 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	return reconcile.Result{}, nil
 }
 
+func failed(ncStatus v1alpha.NetworkContainerStatus, err error) v1alpha.NetworkContainerStatus {
+	ncStatus.Result = v1alpha.NCFailed
+	ncStatus.LastError = err.Error()
+	return ncStatus
+}
+
+// patchStatus reports the result of the reconcile in the CNS status of the NNC. The status is informational,
+// so failing to patch it is logged instead of failing the reconcile.
+func (r *Reconciler) patchStatus(ctx context.Context, nnc *v1alpha.NodeNetworkConfig, ncStatuses []v1alpha.NetworkContainerStatus, ipAssignments int, reconcileErr error) {
+	status := &v1alpha.CNSStatus{
+		ObservedGeneration: nnc.Generation,
+		NetworkContainers:  ncStatuses,
+		AllocatedIPCount:   int64(ipAssignments),
+		AssignedIPCount:    int64(len(r.cnscli.GetAssignedIPConfigs())),
+		PoolMonitor:        r.ipampoolmonitorcli.Status(),
+	}
+	if nnc.Status.CNS != nil {
+		// copy the conditions so that their transition times are kept, without changing the cached NNC.
+		status.Conditions = append([]metav1.Condition(nil), nnc.Status.CNS.Conditions...)
+	}
+	meta.SetStatusCondition(&status.Conditions, reconciledCondition(ncStatuses, nnc.Generation, reconcileErr))
+
+	key := types.NamespacedName{Namespace: nnc.Namespace, Name: nnc.Name}
+	if err := r.nnccli.PatchCNSStatus(ctx, key, status); err != nil {
+		logger.Errorf("[cns-rc] failed to report status on NNC %v: %v", key, err)
+	}
+}
+
+// reconciledCondition is true if CNS programmed every NC of its node. It is false if programming an NC
+// failed, or if every NC was made for another node, which means that the NNC is stale.
+func reconciledCondition(ncStatuses []v1alpha.NetworkContainerStatus, generation int64, reconcileErr error) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               v1alpha.ConditionReconciled,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             v1alpha.ReasonProgrammed,
+	}
+	programmed, skipped := 0, 0
+	for i := range ncStatuses {
+		if ncStatuses[i].Result == v1alpha.NCProgrammed {
+			programmed++
+		}
+		if ncStatuses[i].Result == v1alpha.NCSkipped {
+			skipped++
+		}
+	}
+	switch {
+	case reconcileErr != nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = v1alpha.ReasonProgrammingFailed
+		condition.Message = reconcileErr.Error()
+	case skipped > 0 && programmed == 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = v1alpha.ReasonNodeIPMismatch
+		condition.Message = fmt.Sprintf("all %d network containers were made for another node", skipped)
+	default:
+		condition.Message = fmt.Sprintf("programmed %d network containers, skipped %d", programmed, skipped)
+	}
+	return condition
+}
+
 // Started blocks until the Reconciler has reconciled at least once,
 // then, and any time that it is called after that, it immediately returns true.
 // It accepts a cancellable Context and if the context is closed
@@ -146,27 +247,61 @@ func (r *Reconciler) Started(ctx context.Context) (bool, error) {
 	}
 }
 
+// onlyCNSStatusChanged reports whether the update of the NNC changed the CNS status and nothing else in its
+// spec and status.
+func onlyCNSStatusChanged(oldObj, newObj client.Object) bool {
+	oldNNC, ok := oldObj.(*v1alpha.NodeNetworkConfig)
+	if !ok {
+		return false
+	}
+	newNNC, ok := newObj.(*v1alpha.NodeNetworkConfig)
+	if !ok {
+		return false
+	}
+	if equality.Semantic.DeepEqual(oldNNC.Status.CNS, newNNC.Status.CNS) {
+		return false
+	}
+	oldStatus, newStatus := oldNNC.Status, newNNC.Status
+	oldStatus.CNS, newStatus.CNS = nil, nil
+	return equality.Semantic.DeepEqual(oldNNC.Spec, newNNC.Spec) && equality.Semantic.DeepEqual(oldStatus, newStatus)
+}
+
+// Trigger enqueues a reconcile of the NNC, such as to recover from a missed update. The reconcile runs on the
+// controller like the reconciles of NNC updates, so it is not run concurrently with them. Trigger does not
+// wait for the reconcile, and does nothing if a triggered reconcile is already pending.
+func (r *Reconciler) Trigger(key types.NamespacedName) {
+	nnc := &v1alpha.NodeNetworkConfig{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}
+	select {
+	case r.triggers <- event.GenericEvent{Object: nnc}:
+	default:
+	}
+}
+
 // SetupWithManager Sets up the reconciler with a new manager, filtering using NodeNetworkConfigFilter on nodeName.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, node *v1.Node) error {
 	r.nnccli = nodenetworkconfig.NewClient(mgr.GetClient())
 	err := ctrl.NewControllerManagedBy(mgr).
-		For(&v1alpha.NodeNetworkConfig{}).
-		WithEventFilter(predicate.Funcs{
-			// ignore delete events.
-			DeleteFunc: func(event.DeleteEvent) bool {
-				return false
+		For(&v1alpha.NodeNetworkConfig{}, builder.WithPredicates(
+			predicate.Funcs{
+				// ignore delete events.
+				DeleteFunc: func(event.DeleteEvent) bool {
+					return false
+				},
 			},
-		}).
-		WithEventFilter(predicate.NewPredicateFuncs(func(object client.Object) bool {
-			// match on node controller ref for all other events.
-			return metav1.IsControlledBy(object, node)
-		})).
-		WithEventFilter(predicate.Funcs{
-			// check that the generation is the same - status changes don't update generation.
-			UpdateFunc: func(ue event.UpdateEvent) bool {
-				return ue.ObjectOld.GetGeneration() == ue.ObjectNew.GetGeneration()
+			predicate.NewPredicateFuncs(func(object client.Object) bool {
+				// match on node controller ref for all other events.
+				return metav1.IsControlledBy(object, node)
+			}),
+			predicate.Funcs{
+				// check that the generation is the same - status changes don't update generation.
+				// ignore the updates of the CNS status, which CNS patches on every reconcile.
+				UpdateFunc: func(ue event.UpdateEvent) bool {
+					return ue.ObjectOld.GetGeneration() == ue.ObjectNew.GetGeneration() && !onlyCNSStatusChanged(ue.ObjectOld, ue.ObjectNew)
+				},
 			},
-		}).
+		)).
+		// the triggered reconciles are not filtered, as they are requested for the NNC of the node.
+		Watches(&source.Channel{Source: r.triggers}, &handler.EnqueueRequestForObject{}).
 		Complete(r)
 	if err != nil {
 		return errors.Wrap(err, "failed to set up reconciler with manager")
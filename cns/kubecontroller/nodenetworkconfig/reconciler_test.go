@@ -3,6 +3,7 @@ package nodenetworkconfig
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/logger"
@@ -12,8 +13,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -33,19 +37,34 @@ func (m *mockCNSClient) CreateOrUpdateNetworkContainerInternal(req *cns.CreateNe
 	return m.createOrUpdateNC(req)
 }
 
+func (m *mockCNSClient) GetAssignedIPConfigs() []cns.IPConfigurationStatus {
+	return nil
+}
+
 func (m *mockCNSClient) Update(nnc *v1alpha.NodeNetworkConfig) error {
 	m.state.nnc = nnc
 	return m.update(nnc)
 }
 
+// Status reports the pool monitor as started once it was updated with an NNC.
+func (m *mockCNSClient) Status() v1alpha.PoolMonitorStatus {
+	return v1alpha.PoolMonitorStatus{Started: m.state.nnc != nil}
+}
+
 type mockNCGetter struct {
-	get func(context.Context, types.NamespacedName) (*v1alpha.NodeNetworkConfig, error)
+	get    func(context.Context, types.NamespacedName) (*v1alpha.NodeNetworkConfig, error)
+	status *v1alpha.CNSStatus
 }
 
 func (m *mockNCGetter) Get(ctx context.Context, key types.NamespacedName) (*v1alpha.NodeNetworkConfig, error) {
 	return m.get(ctx, key)
 }
 
+func (m *mockNCGetter) PatchCNSStatus(_ context.Context, _ types.NamespacedName, status *v1alpha.CNSStatus) error {
+	m.status = status
+	return nil
+}
+
 func TestReconcile(t *testing.T) {
 	logger.InitLogger("", 0, 0, "")
 	tests := []struct {
@@ -174,7 +193,7 @@ func TestReconcile(t *testing.T) {
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			r := NewReconciler(&tt.cnsClient, &tt.cnsClient, tt.nodeIP)
+			r := NewReconciler(&tt.cnsClient, &tt.cnsClient, tt.nodeIP, nil)
 			r.nnccli = &tt.ncGetter
 			got, err := r.Reconcile(context.Background(), tt.in)
 			if tt.wantErr {
@@ -187,3 +206,147 @@ func TestReconcile(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileStatus(t *testing.T) {
+	logger.InitLogger("", 0, 0, "")
+	tests := []struct {
+		name           string
+		createOrUpdate cnstypes.ResponseCode
+		nodeIP         string
+		wantNC         v1alpha.NetworkContainerStatus
+		wantAllocated  int64
+		wantCondition  metav1.ConditionStatus
+		wantReason     string
+	}{
+		{
+			name:           "programmed",
+			createOrUpdate: cnstypes.Success,
+			wantNC:         v1alpha.NetworkContainerStatus{ID: ncID, ObservedVersion: version, Result: v1alpha.NCProgrammed},
+			wantAllocated:  1,
+			wantCondition:  metav1.ConditionTrue,
+			wantReason:     v1alpha.ReasonProgrammed,
+		},
+		{
+			name:           "failed",
+			createOrUpdate: cnstypes.UnexpectedError,
+			wantNC:         v1alpha.NetworkContainerStatus{ID: ncID, ObservedVersion: version, Result: v1alpha.NCFailed},
+			wantCondition:  metav1.ConditionFalse,
+			wantReason:     v1alpha.ReasonProgrammingFailed,
+		},
+		{
+			name:           "node IP mismatch",
+			createOrUpdate: cnstypes.Success,
+			nodeIP:         "192.168.1.5",
+			wantNC:         v1alpha.NetworkContainerStatus{ID: ncID, ObservedVersion: version, Result: v1alpha.NCSkipped},
+			wantCondition:  metav1.ConditionFalse,
+			wantReason:     v1alpha.ReasonNodeIPMismatch,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ncGetter := &mockNCGetter{
+				get: func(context.Context, types.NamespacedName) (*v1alpha.NodeNetworkConfig, error) {
+					return &v1alpha.NodeNetworkConfig{
+						ObjectMeta: metav1.ObjectMeta{Generation: 2},
+						Status:     validSwiftStatus,
+					}, nil
+				},
+			}
+			cnsClient := &mockCNSClient{
+				createOrUpdateNC: func(*cns.CreateNetworkContainerRequest) cnstypes.ResponseCode {
+					return tt.createOrUpdate
+				},
+				update: func(*v1alpha.NodeNetworkConfig) error {
+					return nil
+				},
+			}
+			r := NewReconciler(cnsClient, cnsClient, tt.nodeIP, nil)
+			r.nnccli = ncGetter
+			_, _ = r.Reconcile(context.Background(), reconcile.Request{})
+
+			status := ncGetter.status
+			require.NotNil(t, status)
+			assert.Equal(t, int64(2), status.ObservedGeneration)
+			assert.Equal(t, tt.wantAllocated, status.AllocatedIPCount)
+			// the pool monitor status is read after the pool monitor is updated
+			assert.Equal(t, tt.createOrUpdate == cnstypes.Success && tt.nodeIP == "", status.PoolMonitor.Started)
+			require.Len(t, status.NetworkContainers, 1)
+			got := status.NetworkContainers[0]
+			assert.Equal(t, tt.wantNC.Result, got.Result)
+			assert.Equal(t, tt.wantNC.ObservedVersion, got.ObservedVersion)
+			assert.Equal(t, tt.wantNC.Result == v1alpha.NCProgrammed, got.LastError == "")
+
+			condition := meta.FindStatusCondition(status.Conditions, v1alpha.ConditionReconciled)
+			require.NotNil(t, condition)
+			assert.Equal(t, tt.wantCondition, condition.Status)
+			assert.Equal(t, tt.wantReason, condition.Reason)
+			assert.Equal(t, int64(2), condition.ObservedGeneration)
+		})
+	}
+}
+
+func TestReconcileStatusKeepsTransitionTime(t *testing.T) {
+	logger.InitLogger("", 0, 0, "")
+	transition := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	ncGetter := &mockNCGetter{
+		get: func(context.Context, types.NamespacedName) (*v1alpha.NodeNetworkConfig, error) {
+			status := validSwiftStatus
+			status.CNS = &v1alpha.CNSStatus{
+				Conditions: []metav1.Condition{{
+					Type:               v1alpha.ConditionReconciled,
+					Status:             metav1.ConditionTrue,
+					Reason:             v1alpha.ReasonProgrammed,
+					LastTransitionTime: transition,
+				}},
+			}
+			return &v1alpha.NodeNetworkConfig{Status: status}, nil
+		},
+	}
+	cnsClient := &mockCNSClient{
+		createOrUpdateNC: func(*cns.CreateNetworkContainerRequest) cnstypes.ResponseCode {
+			return cnstypes.Success
+		},
+		update: func(*v1alpha.NodeNetworkConfig) error {
+			return nil
+		},
+	}
+	r := NewReconciler(cnsClient, cnsClient, "", nil)
+	r.nnccli = ncGetter
+	_, err := r.Reconcile(context.Background(), reconcile.Request{})
+	require.NoError(t, err)
+
+	condition := meta.FindStatusCondition(ncGetter.status.Conditions, v1alpha.ConditionReconciled)
+	require.NotNil(t, condition)
+	assert.Equal(t, transition, condition.LastTransitionTime)
+}
+
+func TestOnlyCNSStatusChanged(t *testing.T) {
+	nnc := &v1alpha.NodeNetworkConfig{
+		Spec:   v1alpha.NodeNetworkConfigSpec{RequestedIPCount: 16},
+		Status: validSwiftStatus,
+	}
+	cnsStatus := nnc.DeepCopy()
+	cnsStatus.Status.CNS = &v1alpha.CNSStatus{ObservedGeneration: 1}
+	ncStatus := cnsStatus.DeepCopy()
+	ncStatus.Status.NetworkContainers[0].Version++
+	resourceVersion := nnc.DeepCopy()
+	resourceVersion.ResourceVersion = "2"
+
+	assert.True(t, onlyCNSStatusChanged(nnc, cnsStatus))
+	assert.False(t, onlyCNSStatusChanged(cnsStatus, ncStatus))
+	assert.False(t, onlyCNSStatusChanged(nnc, ncStatus))
+	assert.False(t, onlyCNSStatusChanged(nnc, resourceVersion))
+}
+
+func TestTrigger(t *testing.T) {
+	r := NewReconciler(&mockCNSClient{}, &mockCNSClient{}, "", nil)
+	key := types.NamespacedName{Namespace: "kube-system", Name: "node"}
+
+	// a trigger while one is pending is dropped, as the pending reconcile covers it
+	r.Trigger(key)
+	r.Trigger(key)
+	require.Len(t, r.triggers, 1)
+	e := <-r.triggers
+	assert.Equal(t, key, client.ObjectKeyFromObject(e.Object))
+}
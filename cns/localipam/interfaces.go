@@ -0,0 +1,74 @@
+package localipam
+
+import (
+	"context"
+	"net"
+
+	"github.com/Azure/azure-container-networking/cns/wireserver"
+	"github.com/pkg/errors"
+)
+
+// HostInterfaces gets the primary interface of the host from its network interfaces instead of from the
+// wireserver, for the host IP info that CNS gives to Pods with their IPs. The primary interface is the interface
+// with the node IP, or the first IPv4 interface that is up and is not a loopback if there is no node IP.
+type HostInterfaces struct {
+	nodeIP net.IP
+}
+
+// NewHostInterfaces creates HostInterfaces that look up the interface with the node IP, which can be empty.
+func NewHostInterfaces(nodeIP string) *HostInterfaces {
+	return &HostInterfaces{nodeIP: net.ParseIP(nodeIP)}
+}
+
+func (h *HostInterfaces) GetInterfaces(context.Context) (*wireserver.GetInterfacesResult, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list host interfaces")
+	}
+	for i := range ifaces {
+		if ifaces[i].Flags&net.FlagUp == 0 || ifaces[i].Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := ifaces[i].Addrs()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list addresses of host interface %s", ifaces[i].Name)
+		}
+		if res, ok := h.interfaceResult(ifaces[i].HardwareAddr.String(), addrs); ok {
+			return res, nil
+		}
+	}
+	return nil, errors.Wrapf(wireserver.ErrNoPrimaryInterface, "no host interface with node ip %s", h.nodeIP)
+}
+
+// interfaceResult returns the interface with the addresses as the primary interface, if it has the node IP, or
+// an IPv4 address that is not link local if there is no node IP.
+func (h *HostInterfaces) interfaceResult(mac string, addrs []net.Addr) (*wireserver.GetInterfacesResult, bool) {
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if h.nodeIP != nil && !ipnet.IP.Equal(h.nodeIP) {
+			continue
+		}
+		if h.nodeIP == nil && (ipnet.IP.To4() == nil || ipnet.IP.IsLinkLocalUnicast()) {
+			continue
+		}
+		subnet := net.IPNet{IP: ipnet.IP.Mask(ipnet.Mask), Mask: ipnet.Mask}
+		return &wireserver.GetInterfacesResult{
+			Interface: []wireserver.Interface{
+				{
+					MacAddress: mac,
+					IsPrimary:  true,
+					IPSubnet: []wireserver.Subnet{
+						{
+							Prefix:    subnet.String(),
+							IPAddress: []wireserver.Address{{Address: ipnet.IP.String(), IsPrimary: true}},
+						},
+					},
+				},
+			},
+		}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,34 @@
+package localipam
+
+import (
+	"net"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns/wireserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterfaceResult(t *testing.T) {
+	addrs := []net.Addr{
+		&net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)},
+		&net.IPNet{IP: net.ParseIP("169.254.0.2").To4(), Mask: net.CIDRMask(16, 32)},
+		&net.IPNet{IP: net.ParseIP("10.240.0.4").To4(), Mask: net.CIDRMask(16, 32)},
+		&net.IPNet{IP: net.ParseIP("10.241.0.4").To4(), Mask: net.CIDRMask(24, 32)},
+	}
+
+	res, ok := NewHostInterfaces("").interfaceResult("00:0d:3a:00:00:01", addrs)
+	require.True(t, ok)
+	primary, err := wireserver.GetPrimaryInterfaceFromResult(res)
+	require.NoError(t, err)
+	assert.Equal(t, &wireserver.InterfaceInfo{Subnet: "10.240.0.0/16", Gateway: "10.240.0.1", IsPrimary: true, PrimaryIP: "10.240.0.4"}, primary)
+
+	res, ok = NewHostInterfaces("10.241.0.4").interfaceResult("00:0d:3a:00:00:01", addrs)
+	require.True(t, ok)
+	primary, err = wireserver.GetPrimaryInterfaceFromResult(res)
+	require.NoError(t, err)
+	assert.Equal(t, &wireserver.InterfaceInfo{Subnet: "10.241.0.0/24", Gateway: "10.241.0.1", IsPrimary: true, PrimaryIP: "10.241.0.4"}, primary)
+
+	_, ok = NewHostInterfaces("10.242.0.4").interfaceResult("00:0d:3a:00:00:01", addrs)
+	assert.False(t, ok)
+}
@@ -0,0 +1,63 @@
+package localipam
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/pkg/errors"
+)
+
+// Monitor is the IPAM pool monitor of the node-local IP pools, which do not scale. It reports the
+// NodeNetworkConfig of the pools that it last got from the Source as the state of the pool.
+type Monitor struct {
+	source      Source
+	specUpdated func(v1alpha.NodeNetworkConfigSpec)
+
+	sync.RWMutex
+	nnc v1alpha.NodeNetworkConfig
+}
+
+// NewMonitor creates a Monitor of the pools of the Source. specUpdated is optional, and is called with the spec
+// of the pools when the Monitor is updated.
+func NewMonitor(source Source, specUpdated func(v1alpha.NodeNetworkConfigSpec)) *Monitor {
+	return &Monitor{source: source, specUpdated: specUpdated}
+}
+
+// Get gets the NodeNetworkConfig of the pools from the Source and updates the Monitor with it, so that the
+// Monitor reports the pools that CNS is initialized with.
+func (m *Monitor) Get(ctx context.Context) (*v1alpha.NodeNetworkConfig, error) {
+	nnc, err := m.source.Get(ctx)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the errors of the Sources are wrapped
+	}
+	_ = m.Update(nnc)
+	return nnc, nil
+}
+
+// Start blocks until the context is done, since the pools do not scale.
+func (m *Monitor) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return errors.Wrap(ctx.Err(), "pool monitor context closed")
+}
+
+// Update sets the NodeNetworkConfig of the pools.
+func (m *Monitor) Update(nnc *v1alpha.NodeNetworkConfig) error {
+	m.Lock()
+	m.nnc = *nnc.DeepCopy()
+	m.Unlock()
+	if m.specUpdated != nil {
+		m.specUpdated(nnc.Spec)
+	}
+	return nil
+}
+
+// GetStateSnapshot reports the pools, whose IPs are never released.
+func (m *Monitor) GetStateSnapshot() cns.IpamPoolMonitorStateSnapshot {
+	m.RLock()
+	defer m.RUnlock()
+	return cns.IpamPoolMonitorStateSnapshot{
+		CachedNNC: *m.nnc.DeepCopy(),
+	}
+}
@@ -0,0 +1,39 @@
+package localipam
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sourceFunc func(context.Context) (*v1alpha.NodeNetworkConfig, error)
+
+func (f sourceFunc) Get(ctx context.Context) (*v1alpha.NodeNetworkConfig, error) {
+	return f(ctx)
+}
+
+func TestMonitor(t *testing.T) {
+	source := sourceFunc(func(context.Context) (*v1alpha.NodeNetworkConfig, error) {
+		return newNodeNetworkConfig("node", []Pool{{Subnet: "10.0.0.0/29"}})
+	})
+	var specs []v1alpha.NodeNetworkConfigSpec
+	m := NewMonitor(source, func(spec v1alpha.NodeNetworkConfigSpec) { specs = append(specs, spec) })
+
+	nnc, err := m.Get(context.Background())
+	require.NoError(t, err)
+	snapshot := m.GetStateSnapshot()
+	assert.Equal(t, *nnc, snapshot.CachedNNC)
+	assert.Equal(t, int64(5), snapshot.CachedNNC.Spec.RequestedIPCount)
+	assert.Equal(t, []v1alpha.NodeNetworkConfigSpec{{RequestedIPCount: 5}}, specs)
+
+	// the snapshot is a copy of the pools.
+	snapshot.CachedNNC.Status.NetworkContainers[0].IPAssignments[0].IP = "10.0.0.7"
+	assert.Equal(t, "10.0.0.2", m.GetStateSnapshot().CachedNNC.Status.NetworkContainers[0].IPAssignments[0].IP)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, m.Start(ctx), context.Canceled)
+}
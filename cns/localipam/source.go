@@ -0,0 +1,238 @@
+// Package localipam provides node-local IP pools to CNS, so that CNS runs its IPAM without DNC or the
+// NodeNetworkConfig CRD. A Source builds a synthetic NodeNetworkConfig with an NC for each pool, which CNS
+// initializes its IPAM state with as it does with the NodeNetworkConfig, and the Monitor reports the pools as the
+// state of the IPAM pool.
+package localipam
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// Sources of the IP pools.
+const (
+	SourceFile        = "File"
+	SourceNodePodCIDR = "NodePodCIDR"
+)
+
+const (
+	// MaxPoolIPs is the max number of IPs in a pool, since CNS keeps the state of every IP in memory.
+	MaxPoolIPs = 65536
+	// ipv6PoolBits limits the pool of an IPv6 podCIDR to its first /120, since a Node is usually allocated a /64.
+	ipv6PoolBits = 120
+	// ncVersion is the version of the NCs of the pools. NMAgent does not program them, so their host version stays
+	// -1 and their IPs are Available as soon as they are added.
+	ncVersion = -1
+)
+
+var (
+	// ErrNoPools indicates that the Source has no IP pools.
+	ErrNoPools = errors.New("no ip pools")
+	// ErrIPNotInSubnet indicates that an IP of a pool is not in the subnet of the pool.
+	ErrIPNotInSubnet = errors.New("ip is not in the subnet of the pool")
+	// ErrPoolTooLarge indicates that a pool has more than MaxPoolIPs IPs.
+	ErrPoolTooLarge = errors.New("ip pool is too large")
+)
+
+// Source gets the NodeNetworkConfig of the node-local IP pools.
+type Source interface {
+	Get(context.Context) (*v1alpha.NodeNetworkConfig, error)
+}
+
+// Pool is a node-local pool of IPs in a subnet, which CNS manages as the secondary IPs of an NC.
+type Pool struct {
+	// ID of the NC of the pool. It defaults to an ID derived from the subnet.
+	ID string
+	// Subnet of the pool, such as "10.244.1.0/24".
+	Subnet string
+	// Gateway of the subnet. It defaults to the first IP of the subnet.
+	Gateway string
+	// IPs of the pool, as IPs or prefixes in the subnet. They default to the whole subnet. The network, gateway
+	// and IPv4 broadcast IPs of the subnet are never in the pool.
+	IPs []string
+}
+
+// Config is the JSON file of the File Source.
+type Config struct {
+	Pools []Pool
+}
+
+// FileSource gets the IP pools from a Config file, which is read again on every Get.
+type FileSource struct {
+	path string
+}
+
+// NewFileSource creates a Source of the IP pools of the Config file at the path.
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+func (s *FileSource) Get(context.Context) (*v1alpha.NodeNetworkConfig, error) {
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read local ipam config %s", s.path)
+	}
+	var config Config
+	if err := json.Unmarshal(b, &config); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode local ipam config %s", s.path)
+	}
+	return newNodeNetworkConfig("", config.Pools)
+}
+
+// NodePodCIDRSource gets an IP pool for each podCIDR of the Node, as they are allocated by the
+// kube-controller-manager. The pool of an IPv6 podCIDR is its first /120.
+type NodePodCIDRSource struct {
+	nodes    corev1client.NodesGetter
+	nodeName string
+}
+
+// NewNodePodCIDRSource creates a Source of the IP pools of the podCIDRs of the named Node.
+func NewNodePodCIDRSource(nodes corev1client.NodesGetter, nodeName string) *NodePodCIDRSource {
+	return &NodePodCIDRSource{nodes: nodes, nodeName: nodeName}
+}
+
+func (s *NodePodCIDRSource) Get(ctx context.Context) (*v1alpha.NodeNetworkConfig, error) {
+	node, err := s.nodes.Nodes().Get(ctx, s.nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get node %s", s.nodeName)
+	}
+	cidrs := node.Spec.PodCIDRs
+	if len(cidrs) == 0 && node.Spec.PodCIDR != "" {
+		cidrs = []string{node.Spec.PodCIDR}
+	}
+	if len(cidrs) == 0 {
+		return nil, errors.Wrapf(ErrNoPools, "node %s has no podCIDRs", s.nodeName)
+	}
+
+	pools := make([]Pool, len(cidrs))
+	for i, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid podCIDR %s of node %s", cidr, s.nodeName)
+		}
+		pools[i].Subnet = prefix.Masked().String()
+		if prefix.Addr().Is6() && prefix.Bits() < ipv6PoolBits {
+			pools[i].IPs = []string{netip.PrefixFrom(prefix.Masked().Addr(), ipv6PoolBits).String()}
+		}
+	}
+	return newNodeNetworkConfig(s.nodeName, pools)
+}
+
+// newNodeNetworkConfig builds the NodeNetworkConfig with an NC for each pool, which requests all the IPs of the
+// pools.
+func newNodeNetworkConfig(name string, pools []Pool) (*v1alpha.NodeNetworkConfig, error) {
+	if len(pools) == 0 {
+		return nil, ErrNoPools
+	}
+	nnc := &v1alpha.NodeNetworkConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}
+	for i := range pools {
+		nc, err := newNetworkContainer(&pools[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pool %s", pools[i].Subnet)
+		}
+		nnc.Spec.RequestedIPCount += int64(len(nc.IPAssignments))
+		nnc.Status.NetworkContainers = append(nnc.Status.NetworkContainers, nc)
+	}
+	return nnc, nil
+}
+
+// newNetworkContainer builds the NC of the pool, whose secondary IPs are the IPs of the pool.
+func newNetworkContainer(pool *Pool) (v1alpha.NetworkContainer, error) {
+	subnet, err := netip.ParsePrefix(pool.Subnet)
+	if err != nil {
+		return v1alpha.NetworkContainer{}, errors.Wrap(err, "invalid subnet")
+	}
+	subnet = subnet.Masked()
+
+	gateway := subnet.Addr().Next()
+	if pool.Gateway != "" {
+		if gateway, err = netip.ParseAddr(pool.Gateway); err != nil {
+			return v1alpha.NetworkContainer{}, errors.Wrap(err, "invalid gateway")
+		}
+		if !subnet.Contains(gateway) {
+			return v1alpha.NetworkContainer{}, errors.Wrapf(ErrIPNotInSubnet, "gateway %s", gateway)
+		}
+	}
+	reserved := map[netip.Addr]struct{}{subnet.Addr(): {}, gateway: {}}
+	if subnet.Addr().Is4() {
+		reserved[broadcast(subnet)] = struct{}{}
+	}
+
+	prefixes := []netip.Prefix{subnet}
+	if len(pool.IPs) > 0 {
+		prefixes = make([]netip.Prefix, len(pool.IPs))
+		for i, ip := range pool.IPs {
+			if prefixes[i], err = parsePrefix(ip); err != nil {
+				return v1alpha.NetworkContainer{}, errors.Wrapf(err, "invalid ip %s", ip)
+			}
+			if !subnet.Contains(prefixes[i].Addr()) || prefixes[i].Bits() < subnet.Bits() {
+				return v1alpha.NetworkContainer{}, errors.Wrapf(ErrIPNotInSubnet, "ip %s", ip)
+			}
+		}
+	}
+
+	var assignments []v1alpha.IPAssignment
+	seen := map[netip.Addr]struct{}{}
+	for _, prefix := range prefixes {
+		for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+			if _, ok := reserved[addr]; ok {
+				continue
+			}
+			if _, ok := seen[addr]; ok {
+				continue
+			}
+			if len(assignments) == MaxPoolIPs {
+				return v1alpha.NetworkContainer{}, errors.Wrapf(ErrPoolTooLarge, "more than %d ips", MaxPoolIPs)
+			}
+			seen[addr] = struct{}{}
+			assignments = append(assignments, v1alpha.IPAssignment{Name: addr.String(), IP: addr.String()})
+		}
+	}
+
+	id := pool.ID
+	if id == "" {
+		id = "local-" + strings.NewReplacer(".", "-", ":", "-", "/", "-").Replace(subnet.String())
+	}
+	return v1alpha.NetworkContainer{
+		ID:                 id,
+		AssignmentMode:     v1alpha.Dynamic,
+		PrimaryIP:          gateway.String(),
+		SubnetName:         id,
+		SubnetAddressSpace: subnet.String(),
+		DefaultGateway:     gateway.String(),
+		IPAssignments:      assignments,
+		Version:            ncVersion,
+	}, nil
+}
+
+// parsePrefix parses the IP or prefix, where an IP is the prefix of the IP alone.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), errors.Wrap(err, "failed to parse prefix")
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, errors.Wrap(err, "failed to parse ip")
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// broadcast returns the broadcast IP of the IPv4 prefix.
+func broadcast(prefix netip.Prefix) netip.Addr {
+	a := prefix.Addr().As4()
+	v := binary.BigEndian.Uint32(a[:]) | uint32(uint64(1)<<(32-prefix.Bits())-1)
+	binary.BigEndian.PutUint32(a[:], v)
+	return netip.AddrFrom4(a)
+}
@@ -0,0 +1,162 @@
+package localipam
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	nncctrl "github.com/Azure/azure-container-networking/cns/kubecontroller/nodenetworkconfig"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func ips(nc *v1alpha.NetworkContainer) []string {
+	ips := make([]string, len(nc.IPAssignments))
+	for i, assignment := range nc.IPAssignments {
+		ips[i] = assignment.IP
+	}
+	return ips
+}
+
+func TestNewNetworkContainer(t *testing.T) {
+	tests := []struct {
+		name    string
+		pool    Pool
+		id      string
+		gateway string
+		ips     []string
+		wantErr error
+	}{
+		{
+			name:    "subnet",
+			pool:    Pool{Subnet: "10.0.0.1/29"},
+			id:      "local-10-0-0-0-29",
+			gateway: "10.0.0.1",
+			ips:     []string{"10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6"},
+		},
+		{
+			name:    "ips",
+			pool:    Pool{ID: "nc", Subnet: "10.0.0.0/24", Gateway: "10.0.0.4", IPs: []string{"10.0.0.4/31", "10.0.0.9", "10.0.0.5"}},
+			id:      "nc",
+			gateway: "10.0.0.4",
+			ips:     []string{"10.0.0.5", "10.0.0.9"},
+		},
+		{
+			name:    "ipv6",
+			pool:    Pool{Subnet: "fd00::/126"},
+			id:      "local-fd00---126",
+			gateway: "fd00::1",
+			ips:     []string{"fd00::2", "fd00::3"},
+		},
+		{
+			name:    "ip not in subnet",
+			pool:    Pool{Subnet: "10.0.0.0/24", IPs: []string{"10.0.1.0/28"}},
+			wantErr: ErrIPNotInSubnet,
+		},
+		{
+			name:    "prefix larger than subnet",
+			pool:    Pool{Subnet: "10.0.0.0/24", IPs: []string{"10.0.0.0/16"}},
+			wantErr: ErrIPNotInSubnet,
+		},
+		{
+			name:    "gateway not in subnet",
+			pool:    Pool{Subnet: "10.0.0.0/24", Gateway: "10.0.1.1"},
+			wantErr: ErrIPNotInSubnet,
+		},
+		{
+			name:    "too large",
+			pool:    Pool{Subnet: "fd00::/64"},
+			wantErr: ErrPoolTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			nc, err := newNetworkContainer(&tt.pool)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.id, nc.ID)
+			assert.Equal(t, tt.gateway, nc.PrimaryIP)
+			assert.Equal(t, tt.gateway, nc.DefaultGateway)
+			assert.Equal(t, int64(ncVersion), nc.Version)
+			assert.Equal(t, tt.ips, ips(&nc))
+		})
+	}
+}
+
+func TestNCRequest(t *testing.T) {
+	nc, err := newNetworkContainer(&Pool{Subnet: "10.0.0.0/30"})
+	require.NoError(t, err)
+
+	// CNS converts the NCs of the pools as it does the NCs of the NodeNetworkConfig.
+	req, err := nncctrl.CreateNCRequestFromDynamicNC(nc)
+	require.NoError(t, err)
+	assert.Equal(t, cns.IPSubnet{IPAddress: "10.0.0.1", PrefixLength: 30}, req.IPConfiguration.IPSubnet)
+	assert.Equal(t, "10.0.0.1", req.IPConfiguration.GatewayIPAddress)
+	assert.Equal(t, "-1", req.Version)
+	assert.Equal(t, map[string]cns.SecondaryIPConfig{"10.0.0.2": {IPAddress: "10.0.0.2", NCVersion: -1}}, req.SecondaryIPConfigs)
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pools.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"Pools": [{"Subnet": "10.0.0.0/30"}, {"Subnet": "fd00::/126"}]}`), 0o600))
+
+	nnc, err := NewFileSource(path).Get(context.Background())
+	require.NoError(t, err)
+	require.Len(t, nnc.Status.NetworkContainers, 2)
+	assert.Equal(t, []string{"10.0.0.2"}, ips(&nnc.Status.NetworkContainers[0]))
+	assert.Equal(t, []string{"fd00::2", "fd00::3"}, ips(&nnc.Status.NetworkContainers[1]))
+	assert.Equal(t, int64(3), nnc.Spec.RequestedIPCount)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"Pools": []}`), 0o600))
+	_, err = NewFileSource(path).Get(context.Background())
+	require.ErrorIs(t, err, ErrNoPools)
+}
+
+func TestNodePodCIDRSource(t *testing.T) {
+	cli := fake.NewSimpleClientset(
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "dualstack"},
+			Spec:       corev1.NodeSpec{PodCIDR: "10.244.1.0/24", PodCIDRs: []string{"10.244.1.0/24", "fd00:1::/64"}},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "podcidr"},
+			Spec:       corev1.NodeSpec{PodCIDR: "10.244.2.0/24"},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "none"},
+		},
+	)
+
+	nnc, err := NewNodePodCIDRSource(cli.CoreV1(), "dualstack").Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "dualstack", nnc.Name)
+	require.Len(t, nnc.Status.NetworkContainers, 2)
+	v4, v6 := nnc.Status.NetworkContainers[0], nnc.Status.NetworkContainers[1]
+	assert.Equal(t, "10.244.1.0/24", v4.SubnetAddressSpace)
+	assert.Len(t, v4.IPAssignments, 253)
+	// the pool of the IPv6 podCIDR is its first /120, less the network and gateway IPs.
+	assert.Equal(t, "fd00:1::/64", v6.SubnetAddressSpace)
+	assert.Len(t, v6.IPAssignments, 254)
+	assert.Equal(t, "fd00:1::ff", v6.IPAssignments[253].IP)
+	assert.Equal(t, int64(507), nnc.Spec.RequestedIPCount)
+
+	nnc, err = NewNodePodCIDRSource(cli.CoreV1(), "podcidr").Get(context.Background())
+	require.NoError(t, err)
+	require.Len(t, nnc.Status.NetworkContainers, 1)
+	assert.Equal(t, "10.244.2.0/24", nnc.Status.NetworkContainers[0].SubnetAddressSpace)
+
+	_, err = NewNodePodCIDRSource(cli.CoreV1(), "none").Get(context.Background())
+	require.ErrorIs(t, err, ErrNoPools)
+	_, err = NewNodePodCIDRSource(cli.CoreV1(), "missing").Get(context.Background())
+	require.Error(t, err)
+}
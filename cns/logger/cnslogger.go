@@ -49,6 +49,22 @@ func (c *CNSLogger) InitAIWithIKey(aiConfig aitelemetry.AIConfig, instrumentatio
 	c.DisableEventLogging = disableEventLogging
 }
 
+// InitOTLP creates the telemetry handle that exports to the OTLP/HTTP endpoint of an OpenTelemetry collector
+// instead of Application Insights.
+func (c *CNSLogger) InitOTLP(aiConfig aitelemetry.AIConfig, endpoint string, disableTraceLogging, disableMetricLogging, disableEventLogging bool) {
+	th, err := aitelemetry.NewOTLPTelemetry(endpoint, aiConfig)
+	if err != nil {
+		c.logger.Errorf("Error initializing OTLP Telemetry:%v", err)
+		return
+	}
+
+	c.th = th
+	c.logger.Printf("OTLP Telemetry Handle created")
+	c.DisableMetricLogging = disableMetricLogging
+	c.DisableTraceLogging = disableTraceLogging
+	c.DisableEventLogging = disableEventLogging
+}
+
 // wait time for closing AI telemetry session.
 const waitTimeInSecs = 10
 
@@ -25,6 +25,10 @@ func InitAI(aiConfig aitelemetry.AIConfig, disableTraceLogging, disableMetricLog
 	Log.InitAI(aiConfig, disableTraceLogging, disableMetricLogging, disableEventLogging)
 }
 
+func InitOTLP(aiConfig aitelemetry.AIConfig, endpoint string, disableTraceLogging, disableMetricLogging, disableEventLogging bool) {
+	Log.InitOTLP(aiConfig, endpoint, disableTraceLogging, disableMetricLogging, disableEventLogging)
+}
+
 func InitAIWithIKey(aiConfig aitelemetry.AIConfig, instrumentationKey string, disableTraceLogging, disableMetricLogging, disableEventLogging bool) {
 	Log.InitAIWithIKey(aiConfig, instrumentationKey, disableTraceLogging, disableMetricLogging, disableEventLogging)
 }
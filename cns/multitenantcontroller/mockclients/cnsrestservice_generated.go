@@ -5,6 +5,7 @@
 package mockclients
 
 import (
+	context "context"
 	reflect "reflect"
 
 	cns "github.com/Azure/azure-container-networking/cns"
@@ -64,16 +65,16 @@ func (mr *MockcnsRESTserviceMockRecorder) DeleteNetworkContainerInternal(arg0 in
 }
 
 // GetNetworkContainerInternal mocks base method.
-func (m *MockcnsRESTservice) GetNetworkContainerInternal(arg0 cns.GetNetworkContainerRequest) (cns.GetNetworkContainerResponse, types.ResponseCode) {
+func (m *MockcnsRESTservice) GetNetworkContainerInternal(arg0 context.Context, arg1 cns.GetNetworkContainerRequest) (cns.GetNetworkContainerResponse, types.ResponseCode) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetNetworkContainerInternal", arg0)
+	ret := m.ctrl.Call(m, "GetNetworkContainerInternal", arg0, arg1)
 	ret0, _ := ret[0].(cns.GetNetworkContainerResponse)
 	ret1, _ := ret[1].(types.ResponseCode)
 	return ret0, ret1
 }
 
 // GetNetworkContainerInternal indicates an expected call of GetNetworkContainerInternal.
-func (mr *MockcnsRESTserviceMockRecorder) GetNetworkContainerInternal(arg0 interface{}) *gomock.Call {
+func (mr *MockcnsRESTserviceMockRecorder) GetNetworkContainerInternal(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetworkContainerInternal", reflect.TypeOf((*MockcnsRESTservice)(nil).GetNetworkContainerInternal), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetworkContainerInternal", reflect.TypeOf((*MockcnsRESTservice)(nil).GetNetworkContainerInternal), arg0, arg1)
 }
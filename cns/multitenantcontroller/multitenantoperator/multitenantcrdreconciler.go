@@ -33,7 +33,7 @@ const (
 
 type cnsRESTservice interface {
 	DeleteNetworkContainerInternal(cns.DeleteNetworkContainerRequest) types.ResponseCode
-	GetNetworkContainerInternal(cns.GetNetworkContainerRequest) (cns.GetNetworkContainerResponse, types.ResponseCode)
+	GetNetworkContainerInternal(context.Context, cns.GetNetworkContainerRequest) (cns.GetNetworkContainerResponse, types.ResponseCode)
 	CreateOrUpdateNetworkContainerInternal(*cns.CreateNetworkContainerRequest) types.ResponseCode
 }
 
@@ -105,7 +105,7 @@ func (r *multiTenantCrdReconciler) Reconcile(ctx context.Context, request reconc
 	}
 
 	// Check CNS NC states.
-	_, returnCode := r.CNSRestService.GetNetworkContainerInternal(cns.GetNetworkContainerRequest{
+	_, returnCode := r.CNSRestService.GetNetworkContainerInternal(ctx, cns.GetNetworkContainerRequest{
 		NetworkContainerid:  nc.Spec.UUID,
 		OrchestratorContext: orchestratorContext,
 	})
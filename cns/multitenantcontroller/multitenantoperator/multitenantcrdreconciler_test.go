@@ -131,7 +131,7 @@ var _ = Describe("multiTenantCrdReconciler", func() {
 			Expect(err).To(BeNil())
 
 			kubeClient.EXPECT().Get(gomock.Any(), namespacedName, gomock.Any()).SetArg(2, nc)
-			cnsRestService.EXPECT().GetNetworkContainerInternal(cns.GetNetworkContainerRequest{
+			cnsRestService.EXPECT().GetNetworkContainerInternal(gomock.Any(), cns.GetNetworkContainerRequest{
 				NetworkContainerid:  uuid,
 				OrchestratorContext: orchestratorContext,
 			}).Return(cns.GetNetworkContainerResponse{}, cnstypes.UnknownContainerID)
@@ -190,7 +190,7 @@ var _ = Describe("multiTenantCrdReconciler", func() {
 			Expect(err).To(BeNil())
 
 			kubeClient.EXPECT().Get(gomock.Any(), namespacedName, gomock.Any()).SetArg(2, nc)
-			cnsRestService.EXPECT().GetNetworkContainerInternal(cns.GetNetworkContainerRequest{
+			cnsRestService.EXPECT().GetNetworkContainerInternal(gomock.Any(), cns.GetNetworkContainerRequest{
 				NetworkContainerid:  uuid,
 				OrchestratorContext: orchestratorContext,
 			}).Return(cns.GetNetworkContainerResponse{}, cnstypes.Success)
@@ -224,7 +224,7 @@ var _ = Describe("multiTenantCrdReconciler", func() {
 			Expect(err).To(BeNil())
 
 			kubeClient.EXPECT().Get(gomock.Any(), namespacedName, gomock.Any()).SetArg(2, nc)
-			cnsRestService.EXPECT().GetNetworkContainerInternal(cns.GetNetworkContainerRequest{
+			cnsRestService.EXPECT().GetNetworkContainerInternal(gomock.Any(), cns.GetNetworkContainerRequest{
 				NetworkContainerid:  uuid,
 				OrchestratorContext: orchestratorContext,
 			}).Return(cns.GetNetworkContainerResponse{}, cnstypes.UnknownContainerID)
@@ -282,7 +282,7 @@ var _ = Describe("multiTenantCrdReconciler", func() {
 			statusWriter := mockclients.NewMockSubResourceClient(mockCtl) // .NewMockStatusClient(mockCtl)
 			statusWriter.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
 			kubeClient.EXPECT().Status().Return(statusWriter)
-			cnsRestService.EXPECT().GetNetworkContainerInternal(cns.GetNetworkContainerRequest{
+			cnsRestService.EXPECT().GetNetworkContainerInternal(gomock.Any(), cns.GetNetworkContainerRequest{
 				NetworkContainerid:  uuid,
 				OrchestratorContext: orchestratorContext,
 			}).Return(cns.GetNetworkContainerResponse{}, cnstypes.Success)
@@ -0,0 +1,251 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.19.1
+// source: ipam.proto
+
+package protos
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// IPAMClient is the client API for IPAM service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type IPAMClient interface {
+	// RequestIPs assigns IPs to a pod, one from each NC.
+	RequestIPs(ctx context.Context, in *IPConfigsRequest, opts ...grpc.CallOption) (*IPConfigsResponse, error)
+	// ReleaseIPs releases the IPs assigned to a pod.
+	ReleaseIPs(ctx context.Context, in *IPConfigsRequest, opts ...grpc.CallOption) (*Response, error)
+	// GetIPs returns the IPs in any of the states, or every IP if there are no states.
+	GetIPs(ctx context.Context, in *GetIPsRequest, opts ...grpc.CallOption) (*GetIPsResponse, error)
+	// WatchIPs streams the IPs in any of the states, or every IP if there are no states, followed by the state
+	// transitions from or to any of the states.
+	WatchIPs(ctx context.Context, in *GetIPsRequest, opts ...grpc.CallOption) (IPAM_WatchIPsClient, error)
+}
+
+type iPAMClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIPAMClient(cc grpc.ClientConnInterface) IPAMClient {
+	return &iPAMClient{cc}
+}
+
+func (c *iPAMClient) RequestIPs(ctx context.Context, in *IPConfigsRequest, opts ...grpc.CallOption) (*IPConfigsResponse, error) {
+	out := new(IPConfigsResponse)
+	err := c.cc.Invoke(ctx, "/protos.IPAM/RequestIPs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iPAMClient) ReleaseIPs(ctx context.Context, in *IPConfigsRequest, opts ...grpc.CallOption) (*Response, error) {
+	out := new(Response)
+	err := c.cc.Invoke(ctx, "/protos.IPAM/ReleaseIPs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iPAMClient) GetIPs(ctx context.Context, in *GetIPsRequest, opts ...grpc.CallOption) (*GetIPsResponse, error) {
+	out := new(GetIPsResponse)
+	err := c.cc.Invoke(ctx, "/protos.IPAM/GetIPs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iPAMClient) WatchIPs(ctx context.Context, in *GetIPsRequest, opts ...grpc.CallOption) (IPAM_WatchIPsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &IPAM_ServiceDesc.Streams[0], "/protos.IPAM/WatchIPs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &iPAMWatchIPsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type IPAM_WatchIPsClient interface {
+	Recv() (*IPStateEvent, error)
+	grpc.ClientStream
+}
+
+type iPAMWatchIPsClient struct {
+	grpc.ClientStream
+}
+
+func (x *iPAMWatchIPsClient) Recv() (*IPStateEvent, error) {
+	m := new(IPStateEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// IPAMServer is the server API for IPAM service.
+// All implementations must embed UnimplementedIPAMServer
+// for forward compatibility
+type IPAMServer interface {
+	// RequestIPs assigns IPs to a pod, one from each NC.
+	RequestIPs(context.Context, *IPConfigsRequest) (*IPConfigsResponse, error)
+	// ReleaseIPs releases the IPs assigned to a pod.
+	ReleaseIPs(context.Context, *IPConfigsRequest) (*Response, error)
+	// GetIPs returns the IPs in any of the states, or every IP if there are no states.
+	GetIPs(context.Context, *GetIPsRequest) (*GetIPsResponse, error)
+	// WatchIPs streams the IPs in any of the states, or every IP if there are no states, followed by the state
+	// transitions from or to any of the states.
+	WatchIPs(*GetIPsRequest, IPAM_WatchIPsServer) error
+	mustEmbedUnimplementedIPAMServer()
+}
+
+// UnimplementedIPAMServer must be embedded to have forward compatible implementations.
+type UnimplementedIPAMServer struct {
+}
+
+func (UnimplementedIPAMServer) RequestIPs(context.Context, *IPConfigsRequest) (*IPConfigsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestIPs not implemented")
+}
+func (UnimplementedIPAMServer) ReleaseIPs(context.Context, *IPConfigsRequest) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseIPs not implemented")
+}
+func (UnimplementedIPAMServer) GetIPs(context.Context, *GetIPsRequest) (*GetIPsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIPs not implemented")
+}
+func (UnimplementedIPAMServer) WatchIPs(*GetIPsRequest, IPAM_WatchIPsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchIPs not implemented")
+}
+func (UnimplementedIPAMServer) mustEmbedUnimplementedIPAMServer() {}
+
+// UnsafeIPAMServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IPAMServer will
+// result in compilation errors.
+type UnsafeIPAMServer interface {
+	mustEmbedUnimplementedIPAMServer()
+}
+
+func RegisterIPAMServer(s grpc.ServiceRegistrar, srv IPAMServer) {
+	s.RegisterService(&IPAM_ServiceDesc, srv)
+}
+
+func _IPAM_RequestIPs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IPConfigsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IPAMServer).RequestIPs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.IPAM/RequestIPs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IPAMServer).RequestIPs(ctx, req.(*IPConfigsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IPAM_ReleaseIPs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IPConfigsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IPAMServer).ReleaseIPs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.IPAM/ReleaseIPs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IPAMServer).ReleaseIPs(ctx, req.(*IPConfigsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IPAM_GetIPs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetIPsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IPAMServer).GetIPs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.IPAM/GetIPs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IPAMServer).GetIPs(ctx, req.(*GetIPsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IPAM_WatchIPs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetIPsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IPAMServer).WatchIPs(m, &iPAMWatchIPsServer{stream})
+}
+
+type IPAM_WatchIPsServer interface {
+	Send(*IPStateEvent) error
+	grpc.ServerStream
+}
+
+type iPAMWatchIPsServer struct {
+	grpc.ServerStream
+}
+
+func (x *iPAMWatchIPsServer) Send(m *IPStateEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// IPAM_ServiceDesc is the grpc.ServiceDesc for IPAM service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IPAM_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "protos.IPAM",
+	HandlerType: (*IPAMServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RequestIPs",
+			Handler:    _IPAM_RequestIPs_Handler,
+		},
+		{
+			MethodName: "ReleaseIPs",
+			Handler:    _IPAM_ReleaseIPs_Handler,
+		},
+		{
+			MethodName: "GetIPs",
+			Handler:    _IPAM_GetIPs_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchIPs",
+			Handler:       _IPAM_WatchIPs_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ipam.proto",
+}
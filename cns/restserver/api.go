@@ -884,7 +884,8 @@ func (service *HTTPRestService) getAllNetworkContainers(w http.ResponseWriter, r
 		return
 	}
 
-	getAllNetworkContainerResponses := service.getAllNetworkContainerResponses(req) // nolint
+	getAllNetworkContainerResponses := service.getAllNetworkContainerResponses(r.Context(), req) // nolint
+	service.setPodNetworkAnnotations(r.Context(), req.OrchestratorContext, getAllNetworkContainerResponses)
 
 	var resp cns.GetAllNetworkContainersResponse
 
@@ -925,7 +926,8 @@ func (service *HTTPRestService) getNetworkContainerByOrchestratorContext(w http.
 		return
 	}
 
-	getNetworkContainerResponses := service.getAllNetworkContainerResponses(req) // nolint
+	getNetworkContainerResponses := service.getAllNetworkContainerResponses(r.Context(), req) // nolint
+	service.setPodNetworkAnnotations(r.Context(), req.OrchestratorContext, getNetworkContainerResponses)
 	err = service.Listener.Encode(w, &getNetworkContainerResponses[0])
 	logger.Response(service.Name, getNetworkContainerResponses[0], getNetworkContainerResponses[0].Response.ReturnCode, err)
 }
@@ -994,9 +996,11 @@ func (service *HTTPRestService) deleteNetworkContainer(w http.ResponseWriter, r
 		service.Lock()
 		defer service.Unlock()
 
-		if service.state.ContainerStatus != nil {
+		if nc, ok := service.state.ContainerStatus[ncid]; ok {
 			delete(service.state.ContainerStatus, ncid)
+			service.publishNCEvent(cns.WatchNCDeleted, ncid, &nc)
 		}
+		service.forgetNCPublish(ncid)
 
 		if service.state.ContainerIDByOrchestratorContext != nil {
 			for orchestratorContext, networkContainerIDs := range service.state.ContainerIDByOrchestratorContext { //nolint:gocritic // copy is ok
@@ -1090,7 +1094,7 @@ func (service *HTTPRestService) attachNetworkContainerToNetwork(w http.ResponseW
 		return
 	}
 
-	resp := service.attachOrDetachHelper(req, attach, r.Method)
+	resp := service.attachOrDetachHelper(r.Context(), req, attach, r.Method)
 	attachResp := &cns.AttachContainerToNetworkResponse{Response: resp}
 	err = service.Listener.Encode(w, &attachResp)
 	logger.Response(service.Name, attachResp, resp.ReturnCode, err)
@@ -1106,7 +1110,7 @@ func (service *HTTPRestService) detachNetworkContainerFromNetwork(w http.Respons
 		return
 	}
 
-	resp := service.attachOrDetachHelper(req, detach, r.Method)
+	resp := service.attachOrDetachHelper(r.Context(), req, detach, r.Method)
 	detachResp := &cns.DetachContainerFromNetworkResponse{Response: resp}
 	err = service.Listener.Encode(w, &detachResp)
 	logger.Response(service.Name, detachResp, resp.ReturnCode, err)
@@ -1272,6 +1276,8 @@ func (service *HTTPRestService) publishNetworkContainer(w http.ResponseWriter, r
 			ReturnCode: types.NetworkContainerPublishFailed,
 			Message:    fmt.Sprintf("failed to publish nc %s. did not get 200 from wireserver", req.NetworkContainerID),
 		}
+	} else {
+		service.recordNCPublish(ncParams, req.CreateNetworkContainerRequestBody)
 	}
 
 	respondJSON(w, http.StatusOK, resp)
@@ -1370,6 +1376,11 @@ func (service *HTTPRestService) unpublishNetworkContainer(w http.ResponseWriter,
 			ReturnCode: types.NetworkContainerUnpublishFailed,
 			Message:    fmt.Sprintf("failed to unpublish nc %s. did not get 200 from wireserver", req.NetworkContainerID),
 		}
+	} else {
+		service.Lock()
+		service.forgetNCPublish(ncParams.NCID)
+		service.saveState()
+		service.Unlock()
 	}
 
 	respondJSON(w, http.StatusOK, resp)
@@ -9,7 +9,8 @@ const (
 	attach           = "Attach"
 	detach           = "Detach"
 	// Rest service state identifier for named lock
-	stateJoinedNetworks = "JoinedNetworks"
-	dncApiVersion       = "?api-version=2018-03-01"
-	nmaAPICallTimeout   = 2 * time.Second
+	stateJoinedNetworks   = "JoinedNetworks"
+	dncApiVersion         = "?api-version=2018-03-01"
+	nmaAPICallTimeout     = 2 * time.Second
+	podAnnotationsTimeout = 2 * time.Second
 )
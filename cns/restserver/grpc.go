@@ -0,0 +1,298 @@
+package restserver
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/filter"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/protos"
+	"github.com/Azure/azure-container-networking/cns/types"
+	acn "github.com/Azure/azure-container-networking/common"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var allIPStates = []types.IPState{types.Available, types.Assigned, types.PendingRelease, types.PendingProgramming}
+
+// NewGRPCServer returns a gRPC server of the IPAM API of the service, which allows the callers that authorize
+// allows, or every caller if authorize is nil. The server reads the identity of the callers from their Unix
+// socket connection.
+func (service *HTTPRestService) NewGRPCServer(authorize func(acn.Identity) bool) *grpc.Server {
+	a := &grpcAuthorizer{authorize: authorize}
+	s := grpc.NewServer(
+		grpc.Creds(peerCredentials{}),
+		grpc.UnaryInterceptor(a.unary),
+		grpc.StreamInterceptor(a.stream),
+	)
+	protos.RegisterIPAMServer(s, &ipamGRPCServer{service: service})
+	return s
+}
+
+// startGRPCServer serves the gRPC API on the Unix socket, replacing the socket left behind by a previous process.
+func (service *HTTPRestService) startGRPCServer(errChan chan<- error) error {
+	if err := os.MkdirAll(filepath.Dir(service.grpcSocketPath), 0o755); err != nil { //nolint:gomnd // socket directory permissions
+		return errors.Wrap(err, "failed to create grpc socket directory")
+	}
+	if err := os.Remove(service.grpcSocketPath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to remove stale grpc socket")
+	}
+	l, err := net.Listen("unix", service.grpcSocketPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to listen on grpc socket")
+	}
+	logger.Printf("[Azure CNS] Serving the gRPC IPAM API on Unix socket %s", service.grpcSocketPath)
+	go func() {
+		errChan <- service.grpcServer.Serve(l)
+	}()
+	return nil
+}
+
+// peerAuthInfo is the identity of the peer of a gRPC connection.
+type peerAuthInfo struct {
+	credentials.CommonAuthInfo
+	acn.Identity
+}
+
+func (peerAuthInfo) AuthType() string {
+	return "peercred"
+}
+
+// peerCredentials identifies the peers of the server connections by their Unix socket credentials. It does not
+// secure the connections, which is left to the permissions of the socket.
+type peerCredentials struct{}
+
+func (peerCredentials) ClientHandshake(_ context.Context, _ string, c net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return c, peerAuthInfo{CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.NoSecurity}}, nil
+}
+
+func (peerCredentials) ServerHandshake(c net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return c, peerAuthInfo{
+		CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.NoSecurity},
+		Identity:       acn.ConnIdentity(c),
+	}, nil
+}
+
+func (peerCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "peercred"}
+}
+
+func (peerCredentials) Clone() credentials.TransportCredentials {
+	return peerCredentials{}
+}
+
+func (peerCredentials) OverrideServerName(string) error {
+	return nil
+}
+
+// grpcAuthorizer denies the calls of the callers that are not allowed with PermissionDenied and audit logs them.
+type grpcAuthorizer struct {
+	authorize func(acn.Identity) bool
+}
+
+func (a *grpcAuthorizer) check(ctx context.Context, method string) error {
+	if a.authorize == nil {
+		return nil
+	}
+	var id acn.Identity
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(peerAuthInfo); ok {
+			id = info.Identity
+		}
+	}
+	if !a.authorize(id) {
+		logger.Printf("[Azure CNS] [audit] denied gRPC %s to %s", method, id)
+		return status.Error(codes.PermissionDenied, "caller is not allowed to call the CNS gRPC API")
+	}
+	return nil
+}
+
+func (a *grpcAuthorizer) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := a.check(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *grpcAuthorizer) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.check(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// ipamGRPCServer serves the IPAM API of CNS over gRPC, backed by the same methods as its HTTP handlers. The CNS
+// return codes are in the responses, as they are over HTTP, and gRPC errors are only returned for invalid calls.
+type ipamGRPCServer struct {
+	protos.UnimplementedIPAMServer
+	service *HTTPRestService
+}
+
+func (s *ipamGRPCServer) RequestIPs(ctx context.Context, in *protos.IPConfigsRequest) (*protos.IPConfigsResponse, error) {
+	req := ipConfigsRequestFromProto(in)
+	logger.Request(s.service.Name+"grpcRequestIPs", req, nil)
+	resp, err := s.service.requestIPConfigHandlerHelper(ctx, req)
+	logger.ResponseEx(s.service.Name+"grpcRequestIPs", req, resp, resp.Response.ReturnCode, err)
+	return ipConfigsResponseToProto(resp), nil
+}
+
+func (s *ipamGRPCServer) ReleaseIPs(_ context.Context, in *protos.IPConfigsRequest) (*protos.Response, error) {
+	req := ipConfigsRequestFromProto(in)
+	logger.Request(s.service.Name+"grpcReleaseIPs", req, nil)
+	resp, err := s.service.releaseIPConfigHandlerHelper(req)
+	logger.ResponseEx(s.service.Name+"grpcReleaseIPs", req, resp, resp.ReturnCode, err)
+	return responseToProto(resp), nil
+}
+
+func (s *ipamGRPCServer) GetIPs(_ context.Context, in *protos.GetIPsRequest) (*protos.GetIPsResponse, error) {
+	states, err := ipStatesFromProto(in.GetStates())
+	if err != nil {
+		return nil, err
+	}
+	s.service.RLock()
+	ips := filter.MatchAnyIPConfigState(s.service.PodIPConfigState, filter.PredicatesForStates(states...)...)
+	s.service.RUnlock()
+
+	resp := &protos.GetIPsResponse{Response: &protos.Response{}, Ips: make([]*protos.IPConfigurationStatus, 0, len(ips))}
+	for i := range ips {
+		resp.Ips = append(resp.Ips, ipConfigurationStatusToProto(&ips[i]))
+	}
+	return resp, nil
+}
+
+func (s *ipamGRPCServer) WatchIPs(in *protos.GetIPsRequest, stream protos.IPAM_WatchIPsServer) error {
+	states, err := ipStatesFromProto(in.GetStates())
+	if err != nil {
+		return err
+	}
+	watched := map[types.IPState]bool{}
+	for _, state := range states {
+		watched[state] = true
+	}
+
+	// the watcher is added with the IPs read, so that it receives every transition after them.
+	s.service.RLock()
+	ips := filter.MatchAnyIPConfigState(s.service.PodIPConfigState, filter.PredicatesForStates(states...)...)
+	w, _, err := s.service.watchHub.watch("", func(event *cns.WatchEvent) bool {
+		return event.Type == cns.WatchIPStateChanged &&
+			(watched[event.IPState.PreviousState] || watched[event.IPState.IP.GetState()])
+	})
+	s.service.RUnlock()
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer s.service.watchHub.stop(w)
+
+	for i := range ips {
+		if err := stream.Send(&protos.IPStateEvent{Ip: ipConfigurationStatusToProto(&ips[i])}); err != nil {
+			return errors.Wrap(err, "failed to send ip state")
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-w.overflowed:
+			return status.Error(codes.ResourceExhausted, "watch fell behind the ip state transitions")
+		case event := <-w.events:
+			if err := stream.Send(&protos.IPStateEvent{
+				Ip:            ipConfigurationStatusToProto(&event.IPState.IP),
+				PreviousState: string(event.IPState.PreviousState),
+			}); err != nil {
+				return errors.Wrap(err, "failed to send ip state transition")
+			}
+		}
+	}
+}
+
+// ipStatesFromProto returns the states, or every state if there are none.
+func ipStatesFromProto(in []string) ([]types.IPState, error) {
+	if len(in) == 0 {
+		return allIPStates, nil
+	}
+	states := make([]types.IPState, 0, len(in))
+	for _, s := range in {
+		state := types.IPState(s)
+		switch state {
+		case types.Available, types.Assigned, types.PendingRelease, types.PendingProgramming:
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown ip state %q", s)
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+func ipConfigsRequestFromProto(in *protos.IPConfigsRequest) cns.IPConfigsRequest {
+	return cns.IPConfigsRequest{
+		DesiredIPAddresses:  in.GetDesiredIpAddresses(),
+		PodInterfaceID:      in.GetPodInterfaceId(),
+		InfraContainerID:    in.GetInfraContainerId(),
+		OrchestratorContext: in.GetOrchestratorContext(),
+		Ifname:              in.GetIfname(),
+	}
+}
+
+func responseToProto(resp *cns.Response) *protos.Response {
+	return &protos.Response{ReturnCode: int32(resp.ReturnCode), Message: resp.Message}
+}
+
+func ipSubnetToProto(in cns.IPSubnet) *protos.IPSubnet {
+	return &protos.IPSubnet{IpAddress: in.IPAddress, PrefixLength: uint32(in.PrefixLength)}
+}
+
+func ipConfigsResponseToProto(resp *cns.IPConfigsResponse) *protos.IPConfigsResponse {
+	out := &protos.IPConfigsResponse{
+		Response:       responseToProto(&resp.Response),
+		PodIpInfo:      make([]*protos.PodIPInfo, 0, len(resp.PodIPInfo)),
+		PodAnnotations: resp.PodAnnotations,
+	}
+	for i := range resp.PodIPInfo {
+		info := &resp.PodIPInfo[i]
+		out.PodIpInfo = append(out.PodIpInfo, &protos.PodIPInfo{
+			PodIpConfig: ipSubnetToProto(info.PodIPConfig),
+			NetworkContainerPrimaryIpConfig: &protos.IPConfiguration{
+				IpSubnet:         ipSubnetToProto(info.NetworkContainerPrimaryIPConfig.IPSubnet),
+				DnsServers:       info.NetworkContainerPrimaryIPConfig.DNSServers,
+				GatewayIpAddress: info.NetworkContainerPrimaryIPConfig.GatewayIPAddress,
+			},
+			HostPrimaryIpInfo: &protos.HostIPInfo{
+				Gateway:   info.HostPrimaryIPInfo.Gateway,
+				PrimaryIp: info.HostPrimaryIPInfo.PrimaryIP,
+				Subnet:    info.HostPrimaryIPInfo.Subnet,
+			},
+			Mtu: int32(info.MTU),
+		})
+	}
+	return out
+}
+
+func ipConfigurationStatusToProto(ipconfig *cns.IPConfigurationStatus) *protos.IPConfigurationStatus {
+	out := &protos.IPConfigurationStatus{
+		Id:        ipconfig.ID,
+		IpAddress: ipconfig.IPAddress,
+		NcId:      ipconfig.NCID,
+		State:     string(ipconfig.GetState()),
+	}
+	if !ipconfig.LastStateTransition.IsZero() {
+		out.LastStateTransition = timestamppb.New(ipconfig.LastStateTransition)
+	}
+	if ipconfig.PodInfo != nil {
+		out.PodInfo = &protos.PodInfo{
+			Name:             ipconfig.PodInfo.Name(),
+			Namespace:        ipconfig.PodInfo.Namespace(),
+			InterfaceId:      ipconfig.PodInfo.InterfaceID(),
+			InfraContainerId: ipconfig.PodInfo.InfraContainerID(),
+		}
+	}
+	return out
+}
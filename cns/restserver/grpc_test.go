@@ -0,0 +1,161 @@
+package restserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/protos"
+	"github.com/Azure/azure-container-networking/cns/types"
+	acn "github.com/Azure/azure-container-networking/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCTestClient serves the gRPC API of the service on an in-memory connection and returns its client.
+func newGRPCTestClient(t *testing.T, service *HTTPRestService, authorize func(acn.Identity) bool) protos.IPAMClient {
+	t.Helper()
+	l := bufconn.Listen(1 << 20)
+	s := service.NewGRPCServer(authorize)
+	go func() {
+		_ = s.Serve(l)
+	}()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return protos.NewIPAMClient(conn)
+}
+
+// newGRPCTestService returns a service with one available IP.
+func newGRPCTestService(t *testing.T) *HTTPRestService {
+	t.Helper()
+	svc := getTestService()
+	state := NewPodState(testIP1, testIPID1, testNCID, types.Available, 0)
+	require.NoError(t, UpdatePodIPConfigState(t, svc, map[string]cns.IPConfigurationStatus{state.ID: state}, testNCID))
+	return svc
+}
+
+func testPod1IPConfigsRequest(t *testing.T) *protos.IPConfigsRequest {
+	t.Helper()
+	orchestratorContext, err := testPod1Info.OrchestratorContext()
+	require.NoError(t, err)
+	return &protos.IPConfigsRequest{
+		PodInterfaceId:      testPod1Info.InterfaceID(),
+		InfraContainerId:    testPod1Info.InfraContainerID(),
+		OrchestratorContext: orchestratorContext,
+		Ifname:              "eth0",
+	}
+}
+
+func TestGRPCRequestAndReleaseIPs(t *testing.T) {
+	client := newGRPCTestClient(t, newGRPCTestService(t), nil)
+	ctx := context.Background()
+
+	resp, err := client.RequestIPs(ctx, testPod1IPConfigsRequest(t))
+	require.NoError(t, err)
+	require.Equal(t, int32(types.Success), resp.GetResponse().GetReturnCode(), resp.GetResponse().GetMessage())
+	require.Len(t, resp.GetPodIpInfo(), 1)
+	assert.Equal(t, testIP1, resp.GetPodIpInfo()[0].GetPodIpConfig().GetIpAddress())
+	assert.Equal(t, primaryIp, resp.GetPodIpInfo()[0].GetNetworkContainerPrimaryIpConfig().GetIpSubnet().GetIpAddress())
+
+	ips, err := client.GetIPs(ctx, &protos.GetIPsRequest{States: []string{string(types.Assigned)}})
+	require.NoError(t, err)
+	require.Len(t, ips.GetIps(), 1)
+	assert.Equal(t, testIPID1, ips.GetIps()[0].GetId())
+	assert.Equal(t, testPod1Info.Name(), ips.GetIps()[0].GetPodInfo().GetName())
+	assert.NotNil(t, ips.GetIps()[0].GetLastStateTransition())
+
+	release, err := client.ReleaseIPs(ctx, testPod1IPConfigsRequest(t))
+	require.NoError(t, err)
+	require.Equal(t, int32(types.Success), release.GetReturnCode(), release.GetMessage())
+
+	ips, err = client.GetIPs(ctx, &protos.GetIPsRequest{})
+	require.NoError(t, err)
+	require.Len(t, ips.GetIps(), 1)
+	assert.Equal(t, string(types.Available), ips.GetIps()[0].GetState())
+	assert.Nil(t, ips.GetIps()[0].GetPodInfo())
+}
+
+func TestGRPCRequestIPsInvalidRequest(t *testing.T) {
+	client := newGRPCTestClient(t, newGRPCTestService(t), nil)
+	req := testPod1IPConfigsRequest(t)
+	req.OrchestratorContext = []byte("not json")
+
+	// the CNS return code is in the response, as it is over HTTP.
+	resp, err := client.RequestIPs(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int32(types.UnsupportedOrchestratorContext), resp.GetResponse().GetReturnCode())
+}
+
+func TestGRPCUnknownIPState(t *testing.T) {
+	client := newGRPCTestClient(t, newGRPCTestService(t), nil)
+	_, err := client.GetIPs(context.Background(), &protos.GetIPsRequest{States: []string{"Stuck"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCWatchIPs(t *testing.T) {
+	client := newGRPCTestClient(t, newGRPCTestService(t), nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	all, err := client.WatchIPs(ctx, &protos.GetIPsRequest{})
+	require.NoError(t, err)
+	assigned, err := client.WatchIPs(ctx, &protos.GetIPsRequest{States: []string{string(types.Assigned)}})
+	require.NoError(t, err)
+
+	// the watch of every IP receives the available IP first, without a previous state.
+	event, err := all.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, testIPID1, event.GetIp().GetId())
+	assert.Equal(t, string(types.Available), event.GetIp().GetState())
+	assert.Empty(t, event.GetPreviousState())
+
+	resp, err := client.RequestIPs(ctx, testPod1IPConfigsRequest(t))
+	require.NoError(t, err)
+	require.Equal(t, int32(types.Success), resp.GetResponse().GetReturnCode(), resp.GetResponse().GetMessage())
+	release, err := client.ReleaseIPs(ctx, testPod1IPConfigsRequest(t))
+	require.NoError(t, err)
+	require.Equal(t, int32(types.Success), release.GetReturnCode(), release.GetMessage())
+
+	for _, stream := range []protos.IPAM_WatchIPsClient{all, assigned} {
+		event, err = stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, string(types.Available), event.GetPreviousState())
+		assert.Equal(t, string(types.Assigned), event.GetIp().GetState())
+		assert.Equal(t, testPod1Info.Name(), event.GetIp().GetPodInfo().GetName())
+
+		// the watch of assigned IPs receives the transitions from assigned too.
+		event, err = stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, string(types.Assigned), event.GetPreviousState())
+		assert.Equal(t, string(types.Available), event.GetIp().GetState())
+		assert.Nil(t, event.GetIp().GetPodInfo())
+	}
+}
+
+func TestGRPCAuthorize(t *testing.T) {
+	client := newGRPCTestClient(t, newGRPCTestService(t), func(id acn.Identity) bool {
+		// the in-memory connections are not Unix sockets, so their callers are anonymous.
+		return !id.Anonymous()
+	})
+	ctx := context.Background()
+
+	_, err := client.RequestIPs(ctx, testPod1IPConfigsRequest(t))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	stream, err := client.WatchIPs(ctx, &protos.GetIPsRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
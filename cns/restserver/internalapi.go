@@ -44,7 +44,7 @@ func (service *HTTPRestService) SetNodeOrchestrator(r *cns.SetOrchestratorTypeRe
 	service.setOrchestratorType(httptest.NewRecorder(), req)
 }
 
-func (service *HTTPRestService) SyncNodeStatus(dncEP, infraVnet, nodeID string, contextFromCNI json.RawMessage) (returnCode types.ResponseCode, errStr string) {
+func (service *HTTPRestService) SyncNodeStatus(ctx context.Context, dncEP, infraVnet, nodeID string, contextFromCNI json.RawMessage) (returnCode types.ResponseCode, errStr string) {
 	logger.Printf("[Azure CNS] SyncNodeStatus")
 	var (
 		resp             *http.Response
@@ -95,12 +95,12 @@ func (service *HTTPRestService) SyncNodeStatus(dncEP, infraVnet, nodeID string,
 	service.RUnlock()
 
 	skipNCVersionCheck := false
-	ctx, cancel := context.WithTimeout(context.Background(), nmaAPICallTimeout)
+	ctx, cancel := context.WithTimeout(ctx, nmaAPICallTimeout)
 	defer cancel()
 	ncVersionListResp, err := service.nma.GetNCVersionList(ctx)
 	if err != nil {
 		skipNCVersionCheck = true
-		logger.Errorf("failed to get nc version list from nmagent")
+		logger.Errorf("failed to get nc version list from nmagent: %v", err)
 	}
 
 	if !skipNCVersionCheck {
@@ -164,9 +164,12 @@ func (service *HTTPRestService) SyncNodeStatus(dncEP, infraVnet, nodeID string,
 // If NMAgent NC version got updated, CNS will refresh the pending programming IP status.
 func (service *HTTPRestService) SyncHostNCVersion(ctx context.Context, channelMode string) {
 	service.Lock()
-	defer service.Unlock()
 	start := time.Now()
 	programmedNCCount, err := service.syncHostNCVersion(ctx, channelMode)
+	republishes := service.updateNCProgrammingStates()
+	service.Unlock()
+	// the stalled NCs are published to NMAgent again without the service lock, which the IPAM requests need.
+	service.republishNCs(ctx, republishes)
 	// even if we get an error, we want to write the CNI conflist if we have any NC programmed to any version
 	if programmedNCCount > 0 {
 		// This will only be done once per lifetime of the CNS process. This function is threadsafe and will panic
@@ -436,9 +439,9 @@ type podIPs struct {
 
 // GetNetworkContainerInternal gets network container details.
 func (service *HTTPRestService) GetNetworkContainerInternal(
-	req cns.GetNetworkContainerRequest,
+	ctx context.Context, req cns.GetNetworkContainerRequest,
 ) (cns.GetNetworkContainerResponse, types.ResponseCode) {
-	getNetworkContainerResponses := service.getAllNetworkContainerResponses(req)
+	getNetworkContainerResponses := service.getAllNetworkContainerResponses(ctx, req)
 	return getNetworkContainerResponses[0], getNetworkContainerResponses[0].Response.ReturnCode
 }
 
@@ -455,9 +458,11 @@ func (service *HTTPRestService) DeleteNetworkContainerInternal(
 
 	service.Lock()
 	defer service.Unlock()
-	if service.state.ContainerStatus != nil {
+	if nc, ok := service.state.ContainerStatus[ncid]; ok {
 		delete(service.state.ContainerStatus, ncid)
+		service.publishNCEvent(cns.WatchNCDeleted, ncid, &nc)
 	}
+	service.forgetNCPublish(ncid)
 
 	if service.state.ContainerIDByOrchestratorContext != nil {
 		for orchestratorContext, networkContainerIDs := range service.state.ContainerIDByOrchestratorContext { //nolint:gocritic // copy is ok
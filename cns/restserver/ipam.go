@@ -4,9 +4,11 @@
 package restserver
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
+	"sort"
 	"strconv"
 
 	"github.com/Azure/azure-container-networking/cns"
@@ -24,7 +26,7 @@ var (
 )
 
 // requestIPConfigHandlerHelper validates the request, assigns IPs, and returns a response
-func (service *HTTPRestService) requestIPConfigHandlerHelper(ipconfigsRequest cns.IPConfigsRequest) (*cns.IPConfigsResponse, error) {
+func (service *HTTPRestService) requestIPConfigHandlerHelper(ctx context.Context, ipconfigsRequest cns.IPConfigsRequest) (*cns.IPConfigsResponse, error) {
 	podInfo, returnCode, returnMessage := service.validateIPConfigsRequest(ipconfigsRequest)
 	if returnCode != types.Success {
 		return &cns.IPConfigsResponse{
@@ -40,6 +42,7 @@ func (service *HTTPRestService) requestIPConfigHandlerHelper(ipconfigsRequest cn
 
 	podIPInfo, err := requestIPConfigsHelper(service, ipconfigsRequest)
 	if err != nil {
+		service.Events.IPAllocationFailed(podInfo.Name(), podInfo.Namespace(), types.FailedToAllocateIPConfig, err.Error())
 		return &cns.IPConfigsResponse{
 			Response: cns.Response{
 				ReturnCode: types.FailedToAllocateIPConfig,
@@ -75,7 +78,8 @@ func (service *HTTPRestService) requestIPConfigHandlerHelper(ipconfigsRequest cn
 		Response: cns.Response{
 			ReturnCode: types.Success,
 		},
-		PodIPInfo: podIPInfo,
+		PodIPInfo:      podIPInfo,
+		PodAnnotations: service.getPodNetworkAnnotations(ctx, podInfo),
 	}, nil
 }
 
@@ -117,7 +121,7 @@ func (service *HTTPRestService) requestIPConfigHandler(w http.ResponseWriter, r
 		}
 	}
 
-	ipConfigsResp, errResp := service.requestIPConfigHandlerHelper(ipconfigsRequest) //nolint:contextcheck // appease linter
+	ipConfigsResp, errResp := service.requestIPConfigHandlerHelper(r.Context(), ipconfigsRequest)
 	if errResp != nil {
 		// As this API is expected to return IPConfigResponse, generate it from the IPConfigsResponse returned above
 		reserveResp := &cns.IPConfigResponse{
@@ -164,7 +168,7 @@ func (service *HTTPRestService) requestIPConfigsHandler(w http.ResponseWriter, r
 		return
 	}
 
-	ipConfigsResp, err := service.requestIPConfigHandlerHelper(ipconfigsRequest) // nolint:contextcheck // appease linter
+	ipConfigsResp, err := service.requestIPConfigHandlerHelper(r.Context(), ipconfigsRequest)
 	if err != nil {
 		w.Header().Set(cnsReturnCode, ipConfigsResp.Response.ReturnCode.String())
 		err = service.Listener.Encode(w, &ipConfigsResp)
@@ -291,15 +295,13 @@ func (service *HTTPRestService) releaseIPConfigHandler(w http.ResponseWriter, r
 
 	// check to make sure there is only one NC
 	if len(service.state.ContainerStatus) != 1 {
-		reserveResp := &cns.IPConfigResponse{
-			Response: cns.Response{
-				ReturnCode: types.InvalidRequest,
-				Message:    fmt.Sprintf("Expected 1 NC when calling this API but found %d NCs", len(service.state.ContainerStatus)),
-			},
+		resp := cns.Response{
+			ReturnCode: types.InvalidRequest,
+			Message:    fmt.Sprintf("Expected 1 NC when calling this API but found %d NCs", len(service.state.ContainerStatus)),
 		}
-		w.Header().Set(cnsReturnCode, reserveResp.Response.ReturnCode.String())
-		err = service.Listener.Encode(w, &reserveResp)
-		logger.ResponseEx(service.Name, ipconfigRequest, reserveResp, reserveResp.Response.ReturnCode, err)
+		w.Header().Set(cnsReturnCode, resp.ReturnCode.String())
+		err = service.Listener.Encode(w, &resp)
+		logger.ResponseEx(service.Name, ipconfigRequest, resp, resp.ReturnCode, err)
 		return
 	}
 
@@ -318,6 +320,7 @@ func (service *HTTPRestService) releaseIPConfigHandler(w http.ResponseWriter, r
 		w.Header().Set(cnsReturnCode, resp.ReturnCode.String())
 		err = service.Listener.Encode(w, &resp)
 		logger.ResponseEx(service.Name, ipconfigRequest, resp, resp.ReturnCode, err)
+		return
 	}
 
 	w.Header().Set(cnsReturnCode, resp.ReturnCode.String())
@@ -347,6 +350,7 @@ func (service *HTTPRestService) releaseIPConfigsHandler(w http.ResponseWriter, r
 		w.Header().Set(cnsReturnCode, resp.ReturnCode.String())
 		err = service.Listener.Encode(w, &resp)
 		logger.ResponseEx(service.Name, ipconfigsRequest, resp, resp.ReturnCode, err)
+		return
 	}
 
 	w.Header().Set(cnsReturnCode, resp.ReturnCode.String())
@@ -418,8 +422,9 @@ func (service *HTTPRestService) MarkIPAsPendingRelease(totalIpsToRelease int) (m
 func (service *HTTPRestService) updateIPConfigState(ipID string, updatedState types.IPState, podInfo cns.PodInfo) (cns.IPConfigurationStatus, error) {
 	if ipConfig, found := service.PodIPConfigState[ipID]; found {
 		logger.Printf("[updateIPConfigState] Changing IpId [%s] state to [%s], podInfo [%+v]. Current config [%+v]", ipID, updatedState, podInfo, ipConfig)
-		ipConfig.SetState(updatedState)
+		// the pod is set first, so that the state middlewares see the IP as it transitions.
 		ipConfig.PodInfo = podInfo
+		ipConfig.SetState(updatedState)
 		service.PodIPConfigState[ipID] = ipConfig
 		return ipConfig, nil
 	}
@@ -521,6 +526,76 @@ func (service *HTTPRestService) handleDebugIPAddresses(w http.ResponseWriter, r
 	logger.ResponseEx(service.Name, req, resp, resp.Response.ReturnCode, err)
 }
 
+func ncDebugInfo(ncID string, nc *containerstatus) cns.NetworkContainerDebugInfo {
+	return cns.NetworkContainerDebugInfo{
+		ID:               ncID,
+		Type:             nc.CreateNetworkContainerRequest.NetworkContainerType,
+		Version:          nc.CreateNetworkContainerRequest.Version,
+		HostVersion:      nc.HostVersion,
+		PrimaryIP:        nc.CreateNetworkContainerRequest.IPConfiguration.IPSubnet.IPAddress,
+		SecondaryIPCount: len(nc.CreateNetworkContainerRequest.SecondaryIPConfigs),
+		ProgrammingState: nc.ProgrammingState,
+	}
+}
+
+// GetNetworkContainersDebug returns the NCs of CNS with their versions, sorted by ID.
+func (service *HTTPRestService) GetNetworkContainersDebug() []cns.NetworkContainerDebugInfo {
+	service.RLock()
+	ncs := make([]cns.NetworkContainerDebugInfo, 0, len(service.state.ContainerStatus))
+	for ncID := range service.state.ContainerStatus {
+		nc := service.state.ContainerStatus[ncID]
+		ncs = append(ncs, ncDebugInfo(ncID, &nc))
+	}
+	service.RUnlock()
+	sort.Slice(ncs, func(i, j int) bool {
+		return ncs[i].ID < ncs[j].ID
+	})
+	return ncs
+}
+
+func (service *HTTPRestService) handleDebugNetworkContainers(w http.ResponseWriter, r *http.Request) {
+	resp := cns.GetNetworkContainersDebugResponse{
+		NetworkContainers: service.GetNetworkContainersDebug(),
+	}
+	err := service.Listener.Encode(w, &resp)
+	logger.Response(service.Name, resp, resp.Response.ReturnCode, err)
+}
+
+func (service *HTTPRestService) handleDebugEndpoints(w http.ResponseWriter, r *http.Request) {
+	// the state is copied under the lock and encoded after it is released, so that a slow client does not
+	// hold up the IPAM requests.
+	var resp GetEndpointStateResponse
+	service.RLock()
+	err := copyJSON(&resp.EndpointState, service.EndpointState)
+	service.RUnlock()
+	if err != nil {
+		resp.Response.ReturnCode = types.UnexpectedError
+		resp.Response.Message = err.Error()
+	}
+	err = service.Listener.Encode(w, &resp)
+	logger.Response(service.Name, resp, resp.Response.ReturnCode, err)
+}
+
+// handleDebugReconcile enqueues a reconcile of the NodeNetworkConfig of the node, such as to recover from a
+// missed update.
+func (service *HTTPRestService) handleDebugReconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if service.TriggerReconcile == nil {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	var resp cns.Response
+	if err := service.TriggerReconcile(r.Context()); err != nil {
+		resp.ReturnCode = types.UnexpectedError
+		resp.Message = err.Error()
+	}
+	err := service.Listener.Encode(w, &resp)
+	logger.Response(service.Name, resp, resp.ReturnCode, err)
+}
+
 // GetAssignedIPConfigs returns a filtered list of IPs which are in
 // Assigned State.
 func (service *HTTPRestService) GetAssignedIPConfigs() []cns.IPConfigurationStatus {
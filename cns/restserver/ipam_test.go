@@ -4,8 +4,11 @@
 package restserver
 
 import (
+	"encoding/json"
 	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"net/netip"
 	"strconv"
 	"testing"
@@ -1529,3 +1532,17 @@ func TestIPAMFailToRequestPartialIPsInPool(t *testing.T) {
 		t.Fatalf("Expected fail requesting IPs due to only having one in the ipconfig map, IPs in the pool will not be assigned")
 	}
 }
+
+func TestHandleDebugEndpoints(t *testing.T) {
+	svc := getTestService()
+	ipNet := net.IPNet{IP: net.ParseIP("10.0.0.1"), Mask: net.CIDRMask(24, 32)}
+	svc.EndpointState = map[string]*EndpointInfo{
+		"container": {PodName: "pod", PodNamespace: "default", IfnameToIPMap: map[string]*IPInfo{"eth0": {IPv4: []net.IPNet{ipNet}}}},
+	}
+
+	w := httptest.NewRecorder()
+	svc.handleDebugEndpoints(w, httptest.NewRequest(http.MethodGet, cns.PathDebugEndpoints, http.NoBody))
+	var resp GetEndpointStateResponse
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, svc.EndpointState, resp.EndpointState)
+}
@@ -6,7 +6,11 @@ import (
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
@@ -62,6 +66,27 @@ var (
 		},
 		[]string{"ok"},
 	)
+	ncProgrammingStateCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nc_programming_state_ncs",
+			Help: "Count of NCs in each programming state",
+		},
+		[]string{"state"},
+	)
+	ncProgrammingStalledCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nc_programming_stalled_ncs",
+			Help: "Count of NCs in each programming state for longer than the programming timeout",
+		},
+		[]string{"state"},
+	)
+	ncRepublishCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nc_republish_total",
+			Help: "Count of the publishes of NCs to NMAgent again by success or failure",
+		},
+		[]string{"ok"},
+	)
 	allocatedIPCount = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name:        "cx_allocated_ips_v2",
@@ -111,6 +136,9 @@ func init() {
 		ipConfigStatusStateTransitionTime,
 		syncHostNCVersionCount,
 		syncHostNCVersionLatency,
+		ncProgrammingStateCount,
+		ncProgrammingStalledCount,
+		ncRepublishCount,
 		allocatedIPCount,
 		assignedIPCount,
 		availableIPCount,
@@ -123,12 +151,24 @@ func init() {
 // Hard tto do with middleware unless we derserialize the responses but making it an explit header works around it.
 // if that doesn't work we could have a separate countervec just for response codes.
 
+// The handler also continues the trace of the caller, so that its work and the NMAgent calls it makes are
+// recorded as children of the CNI operation that called it.
 func newHandlerFuncWithHistogram(handler http.HandlerFunc, histogram *prometheus.HistogramVec) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, span := tracing.Start(tracing.Extract(req.Context(), req.Header), req.URL.Path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("http.method", req.Method)))
+		req = req.WithContext(ctx)
+
 		start := time.Now()
 		defer func() {
 			code := w.Header().Get(cnsReturnCode)
 			histogram.WithLabelValues(req.URL.RequestURI(), req.Method, code).Observe(time.Since(start).Seconds())
+			span.SetAttributes(attribute.String("cns.return_code", code))
+			if code != "" && code != types.Success.String() {
+				span.SetStatus(codes.Error, "cns returned "+code)
+			}
+			span.End()
 		}()
 		handler(w, req)
 	}
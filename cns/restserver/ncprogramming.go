@@ -0,0 +1,276 @@
+package restserver
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+	nma "github.com/Azure/azure-container-networking/nmagent"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultNCProgrammingTimeout    = 5 * time.Minute
+	DefaultNCProgrammingMaxRetries = 3
+)
+
+var errNoNCPublish = errors.New("the nc was not published through cns")
+
+// NCProgrammingOptions configure when CNS publishes an NC again that NMAgent has not programmed, and when it marks
+// the NC Failed.
+type NCProgrammingOptions struct {
+	// Timeout is how long an NC can be Requested or Published before CNS publishes it again.
+	Timeout time.Duration
+	// MaxRetries is the number of times CNS publishes an NC again before it marks the NC Failed.
+	MaxRetries int
+}
+
+// SetNCProgrammingOptions sets when CNS publishes NCs again, and when it marks them Failed. A negative MaxRetries
+// marks the NCs Failed at their first timeout.
+func (service *HTTPRestService) SetNCProgrammingOptions(opts NCProgrammingOptions) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultNCProgrammingTimeout
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	service.Lock()
+	defer service.Unlock()
+	service.ncProgramming = opts
+}
+
+// ncVersionProgrammed returns whether NMAgent programmed the version of the NC, and whether both of its versions
+// are numbers, as the versions of the NCs that NMAgent programs are.
+func ncVersionProgrammed(nc *containerstatus) (programmed, ok bool) {
+	hostVersion, err := strconv.Atoi(nc.HostVersion)
+	if err != nil {
+		return false, false
+	}
+	version, err := strconv.Atoi(nc.CreateNetworkContainerRequest.Version)
+	if err != nil {
+		return false, false
+	}
+	return hostVersion >= version, true
+}
+
+// setNCProgrammingState transitions the NC to the state.
+func setNCProgrammingState(nc *containerstatus, state types.NCState, now time.Time) {
+	logger.Printf("[Azure CNS] NC %s version %s programming state %s -> %s",
+		nc.ID, nc.CreateNetworkContainerRequest.Version, nc.ProgrammingState, state)
+	nc.ProgrammingState = state
+	nc.ProgrammingStateTime = now
+}
+
+// ncPublish is the publish of an NC through CNS: the parameters of the NC and the body of the request to NMAgent.
+type ncPublish struct {
+	Params cns.NetworkContainerParameters
+	Body   json.RawMessage
+}
+
+// request returns the NMAgent request of the publish.
+func (p *ncPublish) request() (*nma.PutNetworkContainerRequest, error) {
+	var req nma.PutNetworkContainerRequest
+	if err := json.Unmarshal(p.Body, &req); err != nil {
+		return nil, errors.Wrap(err, "failed to decode nc publish")
+	}
+	req.ID = p.Params.NCID
+	req.AuthenticationToken = p.Params.AuthToken
+	req.PrimaryAddress = p.Params.AssociatedInterfaceID
+	return &req, nil
+}
+
+// ncRepublish is an NC that CNS publishes to NMAgent again, with the request of its version.
+type ncRepublish struct {
+	ncID    string
+	version string
+	req     *nma.PutNetworkContainerRequest
+}
+
+// updateNCProgrammingStates advances the programming state machine of every NC, after syncHostNCVersion updated
+// their host versions: an NC is Requested when CNS receives its version, and Programmed when NMAgent programs
+// it. An NC that is not Programmed within the timeout is returned to be published to NMAgent again by
+// republishNCs, and after the max retries it is Failed, until NMAgent programs it. The caller holds the service
+// lock.
+func (service *HTTPRestService) updateNCProgrammingStates() []ncRepublish {
+	now := time.Now()
+	var republishes []ncRepublish
+	states := map[types.NCState]int{}
+	stalled := map[types.NCState]int{}
+	changed := false
+	for ncID := range service.state.ContainerStatus {
+		nc := service.state.ContainerStatus[ncID]
+		updated, req := service.updateNCProgrammingState(&nc, now)
+		if req != nil {
+			republishes = append(republishes, ncRepublish{ncID: ncID, version: nc.CreateNetworkContainerRequest.Version, req: req})
+		}
+		if nc.ProgrammingState == "" {
+			continue
+		}
+		states[nc.ProgrammingState]++
+		if nc.ProgrammingState == types.NCFailed ||
+			(nc.ProgrammingState != types.NCProgrammed && now.Sub(nc.ProgrammingStateTime) >= service.ncProgramming.Timeout) {
+			stalled[nc.ProgrammingState]++
+		}
+		if updated {
+			service.state.ContainerStatus[ncID] = nc
+			service.publishNCEvent(cns.WatchNCUpdated, ncID, &nc)
+			changed = true
+		}
+	}
+	if changed {
+		if err := service.saveState(); err != nil {
+			logger.Errorf("[Azure CNS] Failed to save the NC programming states: %v", err)
+		}
+	}
+
+	for _, state := range []types.NCState{types.NCRequested, types.NCPublished, types.NCProgrammed, types.NCFailed} {
+		ncProgrammingStateCount.WithLabelValues(string(state)).Set(float64(states[state]))
+		ncProgrammingStalledCount.WithLabelValues(string(state)).Set(float64(stalled[state]))
+	}
+	return republishes
+}
+
+// updateNCProgrammingState advances the programming state of the NC, and returns whether it updated the NC, and
+// the request to publish the NC again with if it stalled.
+func (service *HTTPRestService) updateNCProgrammingState(nc *containerstatus, now time.Time) (bool, *nma.PutNetworkContainerRequest) {
+	programmed, ok := ncVersionProgrammed(nc)
+	if !ok {
+		return false, nil
+	}
+	switch {
+	case programmed:
+		if nc.ProgrammingState == types.NCProgrammed {
+			return false, nil
+		}
+		setNCProgrammingState(nc, types.NCProgrammed, now)
+		return true, nil
+	case nc.ProgrammingState == "" || nc.ProgrammingState == types.NCProgrammed:
+		// the NCs of a previous CNS, and the NCs whose host version went back, wait for NMAgent again.
+		setNCProgrammingState(nc, types.NCRequested, now)
+		nc.PublishRetries = 0
+		return true, nil
+	case nc.ProgrammingState == types.NCFailed:
+		return false, nil
+	}
+
+	waited := now.Sub(nc.ProgrammingStateTime)
+	if waited < service.ncProgramming.Timeout {
+		return false, nil
+	}
+	version := nc.CreateNetworkContainerRequest.Version
+	logger.Errorf("[Azure CNS] NC %s is not programmed to version %s after %s in state %s", nc.ID, version, waited, nc.ProgrammingState)
+	service.Events.NCProgrammingStalled(nc.ID, version, nc.ProgrammingState, waited)
+	req, err := service.ncPublishRequest(nc.ID, version)
+	if err != nil {
+		// CNS can not publish a version that it did not see published, so the NC fails without any publish.
+		setNCProgrammingState(nc, types.NCFailed, now)
+		service.Events.NCProgrammingFailed(nc.ID, errors.Wrapf(err, "NMAgent did not program version %s in %s, and it can not be published again", version, waited))
+		return true, nil
+	}
+	if nc.PublishRetries >= service.ncProgramming.MaxRetries {
+		setNCProgrammingState(nc, types.NCFailed, now)
+		service.Events.NCProgrammingFailed(nc.ID, errors.Errorf("NMAgent did not program version %s after %d publishes", version, nc.PublishRetries))
+		return true, nil
+	}
+
+	// the NC waits for another timeout in its state before it is published again, unless republishNCs puts it.
+	nc.PublishRetries++
+	nc.ProgrammingStateTime = now
+	return true, req
+}
+
+// ncPublishRequest returns the request that published the version of the NC through CNS, or fails if CNS did not
+// see the version published. The caller holds the service lock.
+func (service *HTTPRestService) ncPublishRequest(ncID, version string) (*nma.PutNetworkContainerRequest, error) {
+	publish, ok := service.state.NCPublishes[ncPublishKey(ncID)]
+	if !ok {
+		return nil, errors.Wrapf(errNoNCPublish, "nc %s version %s", ncID, version)
+	}
+	req, err := publish.request()
+	if err != nil {
+		return nil, err
+	}
+	if strconv.FormatUint(req.Version, 10) != version {
+		return nil, errors.Wrapf(errNoNCPublish, "nc %s version %s", ncID, version)
+	}
+	return req, nil
+}
+
+// republishNCs calls PutNetworkContainer on NMAgent with the requests of the stalled NCs, without the service lock,
+// and marks the NCs that were put Published, unless they changed in the meantime.
+func (service *HTTPRestService) republishNCs(ctx context.Context, republishes []ncRepublish) {
+	if len(republishes) == 0 {
+		return
+	}
+	published := make([]bool, len(republishes))
+	for i := range republishes {
+		err := service.nma.PutNetworkContainer(ctx, republishes[i].req)
+		ncRepublishCount.WithLabelValues(strconv.FormatBool(err == nil)).Inc()
+		if err != nil {
+			logger.Errorf("[Azure CNS] Failed to publish NC %s version %s again: %v", republishes[i].ncID, republishes[i].version, err)
+			continue
+		}
+		published[i] = true
+	}
+
+	service.Lock()
+	defer service.Unlock()
+	now := time.Now()
+	changed := false
+	for i := range republishes {
+		if !published[i] {
+			continue
+		}
+		nc, ok := service.state.ContainerStatus[republishes[i].ncID]
+		if !ok || nc.CreateNetworkContainerRequest.Version != republishes[i].version ||
+			(nc.ProgrammingState != types.NCRequested && nc.ProgrammingState != types.NCPublished) {
+			continue
+		}
+		setNCProgrammingState(&nc, types.NCPublished, now)
+		service.state.ContainerStatus[republishes[i].ncID] = nc
+		service.publishNCEvent(cns.WatchNCUpdated, republishes[i].ncID, &nc)
+		changed = true
+	}
+	if changed {
+		if err := service.saveState(); err != nil {
+			logger.Errorf("[Azure CNS] Failed to save the NC programming states: %v", err)
+		}
+	}
+}
+
+// recordNCPublish persists the publish of an NC through CNS, so that CNS can publish the NC again if NMAgent does
+// not program it, also after CNS restarts. The publish has the auth token of the NC, as the NC request has.
+func (service *HTTPRestService) recordNCPublish(ncParams cns.NetworkContainerParameters, body []byte) {
+	publish := &ncPublish{Params: ncParams, Body: append(json.RawMessage(nil), body...)}
+	if _, err := publish.request(); err != nil {
+		logger.Errorf("[Azure CNS] Failed to decode the publish of NC %s, it can not be published again: %v", ncParams.NCID, err)
+		return
+	}
+
+	service.Lock()
+	defer service.Unlock()
+	if service.state.NCPublishes == nil {
+		service.state.NCPublishes = map[string]*ncPublish{}
+	}
+	service.state.NCPublishes[ncPublishKey(ncParams.NCID)] = publish
+	if err := service.saveState(); err != nil {
+		logger.Errorf("[Azure CNS] Failed to save the publish of NC %s: %v", ncParams.NCID, err)
+	}
+}
+
+// forgetNCPublish removes the publish of the NC, when it is unpublished or deleted. The caller holds the service
+// lock, and saves the state.
+func (service *HTTPRestService) forgetNCPublish(ncID string) {
+	delete(service.state.NCPublishes, ncPublishKey(ncID))
+}
+
+// ncPublishKey returns the key of the publish of the NC, which is the lowercase NC ID without the Swift prefix
+// that the IDs of the NCs created from the NodeNetworkConfig have, so that the publish is found by either ID.
+func ncPublishKey(ncID string) string {
+	return strings.TrimPrefix(strings.ToLower(ncID), strings.ToLower(cns.SwiftPrefix))
+}
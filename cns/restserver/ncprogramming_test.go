@@ -0,0 +1,195 @@
+package restserver
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/fakes"
+	"github.com/Azure/azure-container-networking/cns/types"
+	nma "github.com/Azure/azure-container-networking/nmagent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNCProgrammingNMAgent returns an NMAgent that programs the NC to the version, or not at all if it is empty,
+// and records the NCs that are put.
+func fakeNCProgrammingNMAgent(version *string, puts *[]*nma.PutNetworkContainerRequest) *fakes.NMAgentClientFake {
+	return &fakes.NMAgentClientFake{
+		GetNCVersionListF: func(context.Context) (nma.NCVersionList, error) {
+			if *version == "" {
+				return nma.NCVersionList{}, nil
+			}
+			return nma.NCVersionList{Containers: []nma.NCVersion{{NetworkContainerID: ncID, Version: *version}}}, nil
+		},
+		PutNetworkContainerF: func(_ context.Context, req *nma.PutNetworkContainerRequest) error {
+			*puts = append(*puts, req)
+			return nil
+		},
+	}
+}
+
+// stallNC makes the NC wait in its programming state for longer than the programming timeout.
+func stallNC(t *testing.T, id string) {
+	t.Helper()
+	nc, ok := svc.state.ContainerStatus[id]
+	require.True(t, ok)
+	nc.ProgrammingStateTime = time.Now().Add(-svc.ncProgramming.Timeout)
+	svc.state.ContainerStatus[id] = nc
+}
+
+func TestNCProgrammingProgrammed(t *testing.T) {
+	createNCReqeustForSyncHostNCVersion(t)
+	require.Equal(t, types.NCRequested, svc.state.ContainerStatus[ncID].ProgrammingState)
+
+	var version string
+	var puts []*nma.PutNetworkContainerRequest
+	defer setMockNMAgent(svc, fakeNCProgrammingNMAgent(&version, &puts))()
+
+	svc.SyncHostNCVersion(context.Background(), cns.CRD)
+	assert.Equal(t, types.NCRequested, svc.state.ContainerStatus[ncID].ProgrammingState)
+
+	version = "0"
+	svc.SyncHostNCVersion(context.Background(), cns.CRD)
+	assert.Equal(t, types.NCProgrammed, svc.state.ContainerStatus[ncID].ProgrammingState)
+	assert.Empty(t, puts)
+
+	// a new version of the NC is requested again.
+	createNCReqInternal(t, svc.state.ContainerStatus[ncID].CreateNetworkContainerRequest.SecondaryIPConfigs, ncID, "1")
+	assert.Equal(t, types.NCRequested, svc.state.ContainerStatus[ncID].ProgrammingState)
+}
+
+func TestNCProgrammingRepublish(t *testing.T) {
+	createNCReqeustForSyncHostNCVersion(t)
+	svc.SetNCProgrammingOptions(NCProgrammingOptions{Timeout: time.Minute, MaxRetries: 1})
+	defer svc.SetNCProgrammingOptions(NCProgrammingOptions{MaxRetries: DefaultNCProgrammingMaxRetries})
+
+	var version string
+	var puts []*nma.PutNetworkContainerRequest
+	defer setMockNMAgent(svc, fakeNCProgrammingNMAgent(&version, &puts))()
+
+	body, err := json.Marshal(&nma.PutNetworkContainerRequest{
+		Version:    0,
+		VNetID:     "vnet",
+		SubnetName: "subnet",
+		IPv4Addrs:  []string{"10.0.0.16"},
+	})
+	require.NoError(t, err)
+	svc.recordNCPublish(cns.NetworkContainerParameters{NCID: ncID, AuthToken: "token", AssociatedInterfaceID: "10.240.0.4"}, body)
+
+	// the stalled NC is published again with the request that published it.
+	stallNC(t, ncID)
+	svc.SyncHostNCVersion(context.Background(), cns.CRD)
+	nc := svc.state.ContainerStatus[ncID]
+	assert.Equal(t, types.NCPublished, nc.ProgrammingState)
+	assert.Equal(t, 1, nc.PublishRetries)
+	require.Len(t, puts, 1)
+	assert.Equal(t, ncID, puts[0].ID)
+	assert.Equal(t, "token", puts[0].AuthenticationToken)
+	assert.Equal(t, "10.240.0.4", puts[0].PrimaryAddress)
+	assert.Equal(t, "vnet", puts[0].VNetID)
+
+	// the NC fails after the max retries, and is still programmed when NMAgent catches up.
+	stallNC(t, ncID)
+	svc.SyncHostNCVersion(context.Background(), cns.CRD)
+	assert.Equal(t, types.NCFailed, svc.state.ContainerStatus[ncID].ProgrammingState)
+	assert.Len(t, puts, 1)
+
+	version = "0"
+	svc.SyncHostNCVersion(context.Background(), cns.CRD)
+	assert.Equal(t, types.NCProgrammed, svc.state.ContainerStatus[ncID].ProgrammingState)
+}
+
+func TestNCProgrammingRepublishAfterRestart(t *testing.T) {
+	// the NC is created from the NodeNetworkConfig, after it was published through CNS.
+	createNCReqeustForSyncHostNCVersion(t)
+	svc.SetNCProgrammingOptions(NCProgrammingOptions{Timeout: time.Minute, MaxRetries: 1})
+	defer svc.SetNCProgrammingOptions(NCProgrammingOptions{MaxRetries: DefaultNCProgrammingMaxRetries})
+	body, err := json.Marshal(&nma.PutNetworkContainerRequest{Version: 0, VNetID: "vnet", SubnetName: "subnet"})
+	require.NoError(t, err)
+	svc.recordNCPublish(cns.NetworkContainerParameters{NCID: ncID, AuthToken: "token", AssociatedInterfaceID: "10.240.0.4"}, body)
+
+	// the publish is restored with the state of CNS.
+	svc.state = &httpRestServiceState{}
+	svc.restoreState()
+	require.Contains(t, svc.state.ContainerStatus, ncID)
+
+	// the NC is put without the service lock, which the IPAM requests need.
+	var version string
+	var puts []*nma.PutNetworkContainerRequest
+	nmagent := fakeNCProgrammingNMAgent(&version, &puts)
+	put := nmagent.PutNetworkContainerF
+	nmagent.PutNetworkContainerF = func(ctx context.Context, req *nma.PutNetworkContainerRequest) error {
+		require.True(t, svc.TryLock(), "the service is locked while the NC is put")
+		svc.Unlock()
+		return put(ctx, req)
+	}
+	defer setMockNMAgent(svc, nmagent)()
+
+	stallNC(t, ncID)
+	svc.SyncHostNCVersion(context.Background(), cns.CRD)
+	assert.Equal(t, types.NCPublished, svc.state.ContainerStatus[ncID].ProgrammingState)
+	require.Len(t, puts, 1)
+	assert.Equal(t, ncID, puts[0].ID)
+	assert.Equal(t, "token", puts[0].AuthenticationToken)
+	assert.Equal(t, "10.240.0.4", puts[0].PrimaryAddress)
+	assert.Equal(t, "subnet", puts[0].SubnetName)
+}
+
+func TestNCProgrammingNotPublishedThroughCNS(t *testing.T) {
+	createNCReqeustForSyncHostNCVersion(t)
+	svc.SetNCProgrammingOptions(NCProgrammingOptions{Timeout: time.Minute, MaxRetries: 1})
+	defer svc.SetNCProgrammingOptions(NCProgrammingOptions{MaxRetries: DefaultNCProgrammingMaxRetries})
+
+	var version string
+	var puts []*nma.PutNetworkContainerRequest
+	defer setMockNMAgent(svc, fakeNCProgrammingNMAgent(&version, &puts))()
+
+	// the NC can not be published again, so it fails without being published or counting publishes.
+	stallNC(t, ncID)
+	svc.SyncHostNCVersion(context.Background(), cns.CRD)
+	nc := svc.state.ContainerStatus[ncID]
+	assert.Equal(t, types.NCFailed, nc.ProgrammingState)
+	assert.Zero(t, nc.PublishRetries)
+	assert.Empty(t, puts)
+
+	version = "0"
+	svc.SyncHostNCVersion(context.Background(), cns.CRD)
+	assert.Equal(t, types.NCProgrammed, svc.state.ContainerStatus[ncID].ProgrammingState)
+}
+
+func TestNCProgrammingRepublishSwiftNC(t *testing.T) {
+	// the NC has the Swift prefix in CNS, and was published with its uppercase ID.
+	restartService()
+	setEnv(t)
+	setOrchestratorTypeInternal(cns.KubernetesCRD)
+	swiftNCID := cns.SwiftPrefix + ncID
+	createNCReqInternal(t, map[string]cns.SecondaryIPConfig{"ip": newSecondaryIPConfig("10.0.0.16", 0)}, swiftNCID, "0")
+	svc.SetNCProgrammingOptions(NCProgrammingOptions{Timeout: time.Minute, MaxRetries: 1})
+	defer svc.SetNCProgrammingOptions(NCProgrammingOptions{MaxRetries: DefaultNCProgrammingMaxRetries})
+
+	var version string
+	var puts []*nma.PutNetworkContainerRequest
+	defer setMockNMAgent(svc, fakeNCProgrammingNMAgent(&version, &puts))()
+
+	body, err := json.Marshal(&nma.PutNetworkContainerRequest{Version: 0, VNetID: "vnet", SubnetName: "subnet"})
+	require.NoError(t, err)
+	svc.recordNCPublish(cns.NetworkContainerParameters{NCID: strings.ToUpper(ncID), AuthToken: "token"}, body)
+
+	stallNC(t, swiftNCID)
+	svc.SyncHostNCVersion(context.Background(), cns.CRD)
+	nc := svc.state.ContainerStatus[swiftNCID]
+	assert.Equal(t, types.NCPublished, nc.ProgrammingState)
+	assert.Equal(t, 1, nc.PublishRetries)
+	require.Len(t, puts, 1)
+	assert.Equal(t, "token", puts[0].AuthenticationToken)
+
+	// the publish is forgotten with the NC.
+	svc.Lock()
+	svc.forgetNCPublish(swiftNCID)
+	svc.Unlock()
+	assert.Empty(t, svc.state.NCPublishes)
+}
@@ -0,0 +1,280 @@
+package restserver
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	openAPIVersion  = "3.0.3"
+	openAPITitle    = "Azure Container Networking Service"
+	contentTypeJSON = "application/json"
+)
+
+// OpenAPIDocument is an OpenAPI 3 document of the routes of the CNS API.
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas"`
+}
+
+type OpenAPIOperation struct {
+	OperationID string                  `json:"operationId"`
+	Summary     string                  `json:"summary,omitempty"`
+	RequestBody *OpenAPIBody            `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIBody `json:"responses"`
+}
+
+type OpenAPIBody struct {
+	Description string                      `json:"description,omitempty"`
+	Required    bool                        `json:"required,omitempty"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPISchema is the schema of a JSON value. The empty schema allows any value.
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+const schemaRefPrefix = "#/components/schemas/"
+
+// Schema returns the schema of the component that the schema refers to, or the schema itself if it is not a
+// reference.
+func (d *OpenAPIDocument) Schema(s *OpenAPISchema) *OpenAPISchema {
+	if s != nil && s.Ref != "" {
+		return d.Components.Schemas[strings.TrimPrefix(s.Ref, schemaRefPrefix)]
+	}
+	return s
+}
+
+var (
+	jsonMarshaler = typeOf[json.Marshaler]()
+	textMarshaler = typeOf[encoding.TextMarshaler]()
+	// marshalerSchemas are the schemas of the types with a custom JSON marshaller, which cannot be reflected.
+	marshalerSchemas = map[reflect.Type]*OpenAPISchema{
+		typeOf[time.Time]():   {Type: "string", Format: "date-time"},
+		typeOf[metav1.Time](): {Type: "string", Format: "date-time"},
+	}
+	// extraProperties are the properties that the custom JSON marshaller of a struct adds to its fields.
+	extraProperties = map[reflect.Type]map[string]reflect.Type{
+		typeOf[cns.IPConfigurationStatus](): {"state": typeOf[types.IPState]()},
+	}
+)
+
+// schemaGenerator generates the schemas of Go types, and collects the schemas of the named structs as components.
+type schemaGenerator struct {
+	schemas map[string]*OpenAPISchema
+	types   map[string]reflect.Type
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) *OpenAPISchema {
+	if s, ok := marshalerSchemas[t]; ok {
+		c := *s
+		return &c
+	}
+	_, extra := extraProperties[t]
+	if !extra && (t.Implements(jsonMarshaler) || reflect.PointerTo(t).Implements(jsonMarshaler)) {
+		return &OpenAPISchema{}
+	}
+	if t.Implements(textMarshaler) || reflect.PointerTo(t).Implements(textMarshaler) {
+		return &OpenAPISchema{Type: "string"}
+	}
+
+	switch t.Kind() { //nolint:exhaustive // the remaining kinds cannot be marshalled to JSON
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &OpenAPISchema{Ref: schemaRefPrefix + g.component(t)}
+	default:
+		return &OpenAPISchema{}
+	}
+}
+
+// component adds the schema of the named struct to the components, and returns its name.
+func (g *schemaGenerator) component(t reflect.Type) string {
+	name := path.Base(t.PkgPath()) + "." + t.Name()
+	if other, ok := g.types[name]; ok && other != t {
+		// structs of packages with the same name are named by their full package path.
+		name = strings.ReplaceAll(t.PkgPath(), "/", ".") + "." + t.Name()
+	}
+	if _, ok := g.types[name]; ok {
+		return name
+	}
+	g.types[name] = t
+	g.schemas[name] = nil // the struct may refer to itself.
+	g.schemas[name] = g.structSchema(t)
+	return name
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *OpenAPISchema {
+	s := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	g.addFields(s, t)
+	for name, extra := range extraProperties[t] {
+		s.Properties[name] = g.schema(extra)
+	}
+	return s
+}
+
+// addFields adds the fields of the struct to the properties of the schema the way encoding/json marshals them:
+// embedded structs are flattened, and the fields of the outer struct win over the embedded ones.
+func (g *schemaGenerator) addFields(s *OpenAPISchema, t reflect.Type) {
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = g.schema(f.Type)
+	}
+	for _, e := range embedded {
+		inner := &OpenAPISchema{Properties: map[string]*OpenAPISchema{}}
+		g.addFields(inner, e)
+		for name, p := range inner.Properties {
+			if _, ok := s.Properties[name]; !ok {
+				s.Properties[name] = p
+			}
+		}
+	}
+}
+
+func jsonContent(s *OpenAPISchema) map[string]OpenAPIMediaType {
+	return map[string]OpenAPIMediaType{contentTypeJSON: {Schema: s}}
+}
+
+// NewOpenAPIDocument generates the OpenAPI document of the routes of the CNS API.
+func NewOpenAPIDocument(version string) *OpenAPIDocument {
+	g := &schemaGenerator{schemas: map[string]*OpenAPISchema{}, types: map[string]reflect.Type{}}
+	doc := &OpenAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info:    OpenAPIInfo{Title: openAPITitle, Version: version},
+		Paths:   map[string]map[string]*OpenAPIOperation{},
+	}
+
+	for i := range Routes {
+		route := &Routes[i]
+		ops := map[string]*OpenAPIOperation{}
+		v2ops := map[string]*OpenAPIOperation{}
+		for _, op := range route.Operations {
+			o := &OpenAPIOperation{
+				OperationID: op.ID,
+				Summary:     op.Summary,
+				Responses: map[string]*OpenAPIBody{
+					"200": {Description: "The response of CNS, with the CNS return code of the operation", Content: jsonContent(g.schema(op.Response))},
+				},
+			}
+			if op.Stream {
+				o.Responses["200"] = &OpenAPIBody{
+					Description: "The server-sent events of CNS, with the JSON of each event as its data",
+					Content:     map[string]OpenAPIMediaType{contentTypeEventStream: {Schema: g.schema(op.Response)}},
+				}
+			}
+			if op.Archive {
+				o.Responses["200"] = &OpenAPIBody{
+					Description: "The gzipped tar archive of the JSON files of CNS, with the response as its manifest.json",
+					Content:     map[string]OpenAPIMediaType{contentTypeGzip: {Schema: &OpenAPISchema{Type: "string", Format: "binary"}}},
+				}
+			}
+			if op.Request != nil {
+				o.RequestBody = &OpenAPIBody{Required: true, Content: jsonContent(g.schema(op.Request))}
+			}
+			ops[strings.ToLower(op.Method)] = o
+			v2 := *o
+			v2.OperationID = op.ID + "V2"
+			v2ops[strings.ToLower(op.Method)] = &v2
+		}
+		doc.Paths[route.Path] = ops
+		if route.V2 {
+			doc.Paths[cns.V2Prefix+route.Path] = v2ops
+		}
+	}
+
+	doc.Components.Schemas = g.schemas
+	return doc
+}
+
+// handleOpenAPI serves the OpenAPI document of the CNS API.
+func (service *HTTPRestService) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	b, err := json.Marshal(NewOpenAPIDocument(service.Version))
+	if err != nil {
+		err = errors.Wrap(err, "failed to marshal openapi document")
+		logger.Errorf("[Azure CNS] %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentTypeJSON)
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,53 @@
+package restserver
+
+import (
+	"context"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+)
+
+// SetPodAnnotationsProvider sets the source used to look up the network annotations of a Pod.
+// When unset, CNS does not pass any Pod annotations to CNI.
+func (service *HTTPRestService) SetPodAnnotationsProvider(p cns.PodAnnotationsProvider) {
+	service.podAnnotationsProvider = p
+}
+
+// getPodNetworkAnnotations returns the network annotations of the Pod for CNI to apply.
+// The lookup is best effort: a failure is logged and the Pod is networked without annotations.
+func (service *HTTPRestService) getPodNetworkAnnotations(ctx context.Context, podInfo cns.PodInfo) map[string]string {
+	if service.podAnnotationsProvider == nil || podInfo == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, podAnnotationsTimeout)
+	defer cancel()
+	annotations, err := service.podAnnotationsProvider.PodAnnotations(ctx, podInfo.Name(), podInfo.Namespace())
+	if err != nil {
+		logger.Errorf("[Azure CNS] Failed to get annotations for pod %s/%s: %v", podInfo.Namespace(), podInfo.Name(), err)
+		return nil
+	}
+
+	return cns.FilterPodNetworkAnnotations(annotations)
+}
+
+// setPodNetworkAnnotations adds the network annotations of the Pod in the orchestrator context
+// to the successful NC responses.
+func (service *HTTPRestService) setPodNetworkAnnotations(ctx context.Context, orchestratorContext []byte, responses []cns.GetNetworkContainerResponse) {
+	if service.podAnnotationsProvider == nil {
+		return
+	}
+
+	podInfo, err := cns.UnmarshalPodInfo(orchestratorContext)
+	if err != nil {
+		return
+	}
+
+	annotations := service.getPodNetworkAnnotations(ctx, podInfo)
+	for i := range responses {
+		if responses[i].Response.ReturnCode == types.Success {
+			responses[i].PodAnnotations = annotations
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/common"
 	"github.com/Azure/azure-container-networking/cns/dockerclient"
+	"github.com/Azure/azure-container-networking/cns/events"
 	"github.com/Azure/azure-container-networking/cns/ipamclient"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/networkcontainers"
@@ -19,9 +20,11 @@ import (
 	"github.com/Azure/azure-container-networking/cns/types/bounded"
 	"github.com/Azure/azure-container-networking/cns/wireserver"
 	acn "github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
 	nma "github.com/Azure/azure-container-networking/nmagent"
 	"github.com/Azure/azure-container-networking/store"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
 )
 
 // This file contains the initialization of RestServer.
@@ -43,6 +46,7 @@ type nmagentClient interface {
 	SupportedAPIs(context.Context) ([]string, error)
 	GetNCVersionList(context.Context) (nma.NCVersionList, error)
 	GetHomeAz(context.Context) (nma.AzResponse, error)
+	PutNetworkContainer(context.Context, *nma.PutNetworkContainerRequest) error
 }
 
 type wireserverProxy interface {
@@ -64,6 +68,9 @@ type HTTPRestService struct {
 	PodIPIDByPodInterfaceKey map[string][]string                  // PodInterfaceId is key and value is slice of Pod IP (SecondaryIP) uuids.
 	PodIPConfigState         map[string]cns.IPConfigurationStatus // Secondary IP ID(uuid) is key
 	IPAMPoolMonitor          cns.IPAMPoolMonitor
+	Events                   *events.Recorder
+	TriggerReconcile         func(context.Context) error
+	GetNodeNetworkConfig     func(context.Context) (*v1alpha.NodeNetworkConfig, error) // gets the NNC of the node for the snapshots, if set
 	routingTable             *routes.RoutingTable
 	store                    store.KeyValueStore
 	state                    *httpRestServiceState
@@ -74,6 +81,11 @@ type HTTPRestService struct {
 	EndpointStateStore      store.KeyValueStore
 	cniConflistGenerator    CNIConflistGenerator
 	generateCNIConflistOnce sync.Once
+	podAnnotationsProvider  cns.PodAnnotationsProvider
+	grpcServer              *grpc.Server
+	grpcSocketPath          string
+	watchHub                watchHub
+	ncProgramming           NCProgrammingOptions
 }
 
 type CNIConflistGenerator interface {
@@ -102,6 +114,12 @@ type IPInfo struct {
 	IPv6 []net.IPNet
 }
 
+// GetEndpointStateResponse is used in CNS Client debug mode to get the endpoint state of CNS.
+type GetEndpointStateResponse struct {
+	EndpointState map[string]*EndpointInfo // key : container id
+	Response      Response
+}
+
 type GetHTTPServiceDataResponse struct {
 	HTTPRestServiceData HTTPRestServiceData
 	Response            Response
@@ -126,6 +144,11 @@ type containerstatus struct {
 	HostVersion                   string
 	CreateNetworkContainerRequest cns.CreateNetworkContainerRequest
 	VfpUpdateComplete             bool // True when VFP programming is completed for the NC
+	// ProgrammingState is the state of the programming of the version of the NC on the host, since
+	// ProgrammingStateTime, and PublishRetries is the number of times CNS published the version again.
+	ProgrammingState     types.NCState `json:",omitempty"`
+	ProgrammingStateTime time.Time
+	PublishRetries       int `json:",omitempty"`
 }
 
 // httpRestServiceState contains the state we would like to persist.
@@ -137,6 +160,7 @@ type httpRestServiceState struct {
 	Initialized                      bool
 	ContainerIDByOrchestratorContext map[string]*ncList         // OrchestratorContext is the key and value is a list of NetworkContainerIDs separated by comma
 	ContainerStatus                  map[string]containerstatus // NetworkContainerID is key.
+	NCPublishes                      map[string]*ncPublish      `json:",omitempty"` // lowercase NetworkContainerID without the Swift prefix is key.
 	Networks                         map[string]*networkInfo
 	TimeStamp                        time.Time
 	joinedNetworks                   map[string]struct{}
@@ -210,6 +234,7 @@ func NewHTTPRestService(config *common.ServiceConfig, wscli interfaceGetter, wsp
 		EndpointState:            make(map[string]*EndpointInfo),
 		homeAzMonitor:            homeAzMonitor,
 		cniConflistGenerator:     gen,
+		ncProgramming:            NCProgrammingOptions{Timeout: DefaultNCProgrammingTimeout, MaxRetries: DefaultNCProgrammingMaxRetries},
 	}, nil
 }
 
@@ -229,66 +254,12 @@ func (service *HTTPRestService) Init(config *common.ServiceConfig) error {
 	}
 
 	// Add handlers.
-	listener := service.Listener
-	// default handlers
-	listener.AddHandler(cns.SetEnvironmentPath, service.setEnvironment)
-	listener.AddHandler(cns.CreateNetworkPath, service.createNetwork)
-	listener.AddHandler(cns.DeleteNetworkPath, service.deleteNetwork)
-	listener.AddHandler(cns.ReserveIPAddressPath, service.reserveIPAddress)
-	listener.AddHandler(cns.ReleaseIPAddressPath, service.releaseIPAddress)
-	listener.AddHandler(cns.GetHostLocalIPPath, service.getHostLocalIP)
-	listener.AddHandler(cns.GetIPAddressUtilizationPath, service.getIPAddressUtilization)
-	listener.AddHandler(cns.GetUnhealthyIPAddressesPath, service.getUnhealthyIPAddresses)
-	listener.AddHandler(cns.CreateOrUpdateNetworkContainer, service.createOrUpdateNetworkContainer)
-	listener.AddHandler(cns.DeleteNetworkContainer, service.deleteNetworkContainer)
-	listener.AddHandler(cns.GetInterfaceForContainer, service.getInterfaceForContainer)
-	listener.AddHandler(cns.SetOrchestratorType, service.setOrchestratorType)
-	listener.AddHandler(cns.GetNetworkContainerByOrchestratorContext, service.getNetworkContainerByOrchestratorContext)
-	listener.AddHandler(cns.GetAllNetworkContainers, service.getAllNetworkContainers)
-	listener.AddHandler(cns.AttachContainerToNetwork, service.attachNetworkContainerToNetwork)
-	listener.AddHandler(cns.DetachContainerFromNetwork, service.detachNetworkContainerFromNetwork)
-	listener.AddHandler(cns.CreateHnsNetworkPath, service.createHnsNetwork)
-	listener.AddHandler(cns.DeleteHnsNetworkPath, service.deleteHnsNetwork)
-	listener.AddHandler(cns.NumberOfCPUCoresPath, service.getNumberOfCPUCores)
-	listener.AddHandler(cns.CreateHostNCApipaEndpointPath, service.createHostNCApipaEndpoint)
-	listener.AddHandler(cns.DeleteHostNCApipaEndpointPath, service.deleteHostNCApipaEndpoint)
-	listener.AddHandler(cns.PublishNetworkContainer, service.publishNetworkContainer)
-	listener.AddHandler(cns.UnpublishNetworkContainer, service.unpublishNetworkContainer)
-	listener.AddHandler(cns.RequestIPConfig, newHandlerFuncWithHistogram(service.requestIPConfigHandler, httpRequestLatency))
-	listener.AddHandler(cns.RequestIPConfigs, newHandlerFuncWithHistogram(service.requestIPConfigsHandler, httpRequestLatency))
-	listener.AddHandler(cns.ReleaseIPConfig, newHandlerFuncWithHistogram(service.releaseIPConfigHandler, httpRequestLatency))
-	listener.AddHandler(cns.ReleaseIPConfigs, newHandlerFuncWithHistogram(service.releaseIPConfigsHandler, httpRequestLatency))
-	listener.AddHandler(cns.NmAgentSupportedApisPath, service.nmAgentSupportedApisHandler)
-	listener.AddHandler(cns.PathDebugIPAddresses, service.handleDebugIPAddresses)
-	listener.AddHandler(cns.PathDebugPodContext, service.handleDebugPodContext)
-	listener.AddHandler(cns.PathDebugRestData, service.handleDebugRestData)
-	listener.AddHandler(cns.NetworkContainersURLPath, service.getOrRefreshNetworkContainers)
-	listener.AddHandler(cns.GetHomeAz, service.getHomeAz)
-
-	// handlers for v0.2
-	listener.AddHandler(cns.V2Prefix+cns.SetEnvironmentPath, service.setEnvironment)
-	listener.AddHandler(cns.V2Prefix+cns.CreateNetworkPath, service.createNetwork)
-	listener.AddHandler(cns.V2Prefix+cns.DeleteNetworkPath, service.deleteNetwork)
-	listener.AddHandler(cns.V2Prefix+cns.ReserveIPAddressPath, service.reserveIPAddress)
-	listener.AddHandler(cns.V2Prefix+cns.ReleaseIPAddressPath, service.releaseIPAddress)
-	listener.AddHandler(cns.V2Prefix+cns.GetHostLocalIPPath, service.getHostLocalIP)
-	listener.AddHandler(cns.V2Prefix+cns.GetIPAddressUtilizationPath, service.getIPAddressUtilization)
-	listener.AddHandler(cns.V2Prefix+cns.GetUnhealthyIPAddressesPath, service.getUnhealthyIPAddresses)
-	listener.AddHandler(cns.V2Prefix+cns.CreateOrUpdateNetworkContainer, service.createOrUpdateNetworkContainer)
-	listener.AddHandler(cns.V2Prefix+cns.DeleteNetworkContainer, service.deleteNetworkContainer)
-	listener.AddHandler(cns.V2Prefix+cns.GetInterfaceForContainer, service.getInterfaceForContainer)
-	listener.AddHandler(cns.V2Prefix+cns.SetOrchestratorType, service.setOrchestratorType)
-	listener.AddHandler(cns.V2Prefix+cns.GetNetworkContainerByOrchestratorContext, service.getNetworkContainerByOrchestratorContext)
-	listener.AddHandler(cns.V2Prefix+cns.GetAllNetworkContainers, service.getAllNetworkContainers)
-	listener.AddHandler(cns.V2Prefix+cns.AttachContainerToNetwork, service.attachNetworkContainerToNetwork)
-	listener.AddHandler(cns.V2Prefix+cns.DetachContainerFromNetwork, service.detachNetworkContainerFromNetwork)
-	listener.AddHandler(cns.V2Prefix+cns.CreateHnsNetworkPath, service.createHnsNetwork)
-	listener.AddHandler(cns.V2Prefix+cns.DeleteHnsNetworkPath, service.deleteHnsNetwork)
-	listener.AddHandler(cns.V2Prefix+cns.NumberOfCPUCoresPath, service.getNumberOfCPUCores)
-	listener.AddHandler(cns.V2Prefix+cns.CreateHostNCApipaEndpointPath, service.createHostNCApipaEndpoint)
-	listener.AddHandler(cns.V2Prefix+cns.DeleteHostNCApipaEndpointPath, service.deleteHostNCApipaEndpoint)
-	listener.AddHandler(cns.V2Prefix+cns.NmAgentSupportedApisPath, service.nmAgentSupportedApisHandler)
-	listener.AddHandler(cns.V2Prefix+cns.GetHomeAz, service.getHomeAz)
+	service.registerRoutes()
+
+	if config.GRPCSocketPath != "" {
+		service.grpcServer = service.NewGRPCServer(config.GRPCAuthorize)
+		service.grpcSocketPath = config.GRPCSocketPath
+	}
 
 	// Initialize HTTP client to be reused in CNS
 	connectionTimeout, _ := service.GetOption(acn.OptHttpConnectionTimeout).(int)
@@ -327,11 +298,20 @@ func (service *HTTPRestService) Start(config *common.ServiceConfig) error {
 		return err
 	}
 
+	if service.grpcServer != nil {
+		if err := service.startGRPCServer(config.ErrChan); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 // Stop stops the CNS.
 func (service *HTTPRestService) Stop() {
+	if service.grpcServer != nil {
+		service.grpcServer.Stop()
+	}
 	service.Uninitialize()
 	logger.Printf("[Azure CNS]  Service stopped.")
 }
@@ -0,0 +1,371 @@
+package restserver
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	nma "github.com/Azure/azure-container-networking/nmagent"
+	"github.com/google/uuid"
+)
+
+// Route declares a route of the CNS API. The routes are registered on the listener, documented in the OpenAPI
+// document and called by the CNS client from these declarations, so they are declared here only.
+type Route struct {
+	Path string
+	// V2 is true if the route is also served under cns.V2Prefix.
+	V2         bool
+	Operations []Operation
+	handler    func(*HTTPRestService, http.ResponseWriter, *http.Request)
+	// latency is true if the latency of the requests of the route is recorded in the request latency histogram.
+	latency bool
+}
+
+// Operation declares an HTTP method of a route with the types of its JSON request and response bodies.
+type Operation struct {
+	Method  string
+	ID      string
+	Summary string
+	// Request is the type of the request body, or nil if the operation takes no body.
+	Request  reflect.Type
+	Response reflect.Type
+	// Stream is true if the operation responds with a stream of server-sent events with the response as their data.
+	Stream bool
+	// Archive is true if the operation responds with a gzipped tar archive of JSON files, whose manifest is the
+	// response.
+	Archive bool
+}
+
+// Operation returns the operation of the route with the method, if it is declared.
+func (r *Route) Operation(method string) (Operation, bool) {
+	for _, op := range r.Operations {
+		if op.Method == method {
+			return op, true
+		}
+	}
+	return Operation{}, false
+}
+
+// typeOf returns the reflect.Type of T, for the declarations of the request and response bodies.
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// Routes are the routes of the CNS API.
+var Routes = []Route{
+	{
+		Path: cns.SetEnvironmentPath, V2: true, handler: (*HTTPRestService).setEnvironment,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "SetEnvironment", Summary: "Set the environment of CNS",
+			Request: typeOf[cns.SetEnvironmentRequest](), Response: typeOf[cns.Response](),
+		}},
+	},
+	{
+		Path: cns.CreateNetworkPath, V2: true, handler: (*HTTPRestService).createNetwork,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "CreateNetwork", Summary: "Create a network",
+			Request: typeOf[cns.CreateNetworkRequest](), Response: typeOf[cns.Response](),
+		}},
+	},
+	{
+		Path: cns.DeleteNetworkPath, V2: true, handler: (*HTTPRestService).deleteNetwork,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "DeleteNetwork", Summary: "Delete a network",
+			Request: typeOf[cns.DeleteNetworkRequest](), Response: typeOf[cns.Response](),
+		}},
+	},
+	{
+		Path: cns.ReserveIPAddressPath, V2: true, handler: (*HTTPRestService).reserveIPAddress,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "ReserveIPAddress", Summary: "Reserve an IP address with the IPAM plugin",
+			Request: typeOf[cns.ReserveIPAddressRequest](), Response: typeOf[cns.ReserveIPAddressResponse](),
+		}},
+	},
+	{
+		Path: cns.ReleaseIPAddressPath, V2: true, handler: (*HTTPRestService).releaseIPAddress,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "ReleaseIPAddress", Summary: "Release an IP address reserved with the IPAM plugin",
+			Request: typeOf[cns.ReleaseIPAddressRequest](), Response: typeOf[cns.Response](),
+		}},
+	},
+	{
+		Path: cns.GetHostLocalIPPath, V2: true, handler: (*HTTPRestService).getHostLocalIP,
+		Operations: []Operation{{
+			Method: http.MethodGet, ID: "GetHostLocalIP", Summary: "Get the local IP address of the host",
+			Response: typeOf[cns.HostLocalIPAddressResponse](),
+		}},
+	},
+	{
+		Path: cns.GetIPAddressUtilizationPath, V2: true, handler: (*HTTPRestService).getIPAddressUtilization,
+		Operations: []Operation{{
+			Method: http.MethodGet, ID: "GetIPAddressUtilization", Summary: "Get the utilization of the IP addresses of the IPAM plugin",
+			Response: typeOf[cns.IPAddressesUtilizationResponse](),
+		}},
+	},
+	{
+		Path: cns.GetUnhealthyIPAddressesPath, V2: true, handler: (*HTTPRestService).getUnhealthyIPAddresses,
+		Operations: []Operation{{
+			Method: http.MethodGet, ID: "GetUnhealthyIPAddresses", Summary: "Get the unhealthy IP addresses of the IPAM plugin",
+			Response: typeOf[cns.GetIPAddressesResponse](),
+		}},
+	},
+	{
+		Path: cns.CreateOrUpdateNetworkContainer, V2: true, handler: (*HTTPRestService).createOrUpdateNetworkContainer,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "CreateOrUpdateNetworkContainer", Summary: "Create or update an NC",
+			Request: typeOf[cns.CreateNetworkContainerRequest](), Response: typeOf[cns.CreateNetworkContainerResponse](),
+		}},
+	},
+	{
+		Path: cns.DeleteNetworkContainer, V2: true, handler: (*HTTPRestService).deleteNetworkContainer,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "DeleteNetworkContainer", Summary: "Delete an NC",
+			Request: typeOf[cns.DeleteNetworkContainerRequest](), Response: typeOf[cns.DeleteNetworkContainerResponse](),
+		}},
+	},
+	{
+		Path: cns.GetInterfaceForContainer, V2: true, handler: (*HTTPRestService).getInterfaceForContainer,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "GetInterfaceForContainer", Summary: "Get the interface of an NC",
+			Request: typeOf[cns.GetInterfaceForContainerRequest](), Response: typeOf[cns.GetInterfaceForContainerResponse](),
+		}},
+	},
+	{
+		Path: cns.SetOrchestratorType, V2: true, handler: (*HTTPRestService).setOrchestratorType,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "SetOrchestratorType", Summary: "Set the orchestrator type and the node ID of CNS",
+			Request: typeOf[cns.SetOrchestratorTypeRequest](), Response: typeOf[cns.Response](),
+		}},
+	},
+	{
+		Path: cns.GetNetworkContainerByOrchestratorContext, V2: true, handler: (*HTTPRestService).getNetworkContainerByOrchestratorContext,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "GetNetworkContainerByOrchestratorContext", Summary: "Get the NC of a pod",
+			Request: typeOf[cns.GetNetworkContainerRequest](), Response: typeOf[cns.GetNetworkContainerResponse](),
+		}},
+	},
+	{
+		Path: cns.GetAllNetworkContainers, V2: true, handler: (*HTTPRestService).getAllNetworkContainers,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "GetAllNetworkContainers", Summary: "Get all NCs of a pod",
+			Request: typeOf[cns.GetNetworkContainerRequest](), Response: typeOf[cns.GetAllNetworkContainersResponse](),
+		}},
+	},
+	{
+		Path: cns.AttachContainerToNetwork, V2: true, handler: (*HTTPRestService).attachNetworkContainerToNetwork,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "AttachContainerToNetwork", Summary: "Attach a container to the network of an NC",
+			Request: typeOf[cns.ConfigureContainerNetworkingRequest](), Response: typeOf[cns.AttachContainerToNetworkResponse](),
+		}},
+	},
+	{
+		Path: cns.DetachContainerFromNetwork, V2: true, handler: (*HTTPRestService).detachNetworkContainerFromNetwork,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "DetachContainerFromNetwork", Summary: "Detach a container from the network of an NC",
+			Request: typeOf[cns.ConfigureContainerNetworkingRequest](), Response: typeOf[cns.DetachContainerFromNetworkResponse](),
+		}},
+	},
+	{
+		Path: cns.CreateHnsNetworkPath, V2: true, handler: (*HTTPRestService).createHnsNetwork,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "CreateHnsNetwork", Summary: "Create an HNS network",
+			Request: typeOf[cns.CreateHnsNetworkRequest](), Response: typeOf[cns.Response](),
+		}},
+	},
+	{
+		Path: cns.DeleteHnsNetworkPath, V2: true, handler: (*HTTPRestService).deleteHnsNetwork,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "DeleteHnsNetwork", Summary: "Delete an HNS network",
+			Request: typeOf[cns.DeleteHnsNetworkRequest](), Response: typeOf[cns.Response](),
+		}},
+	},
+	{
+		Path: cns.NumberOfCPUCoresPath, V2: true, handler: (*HTTPRestService).getNumberOfCPUCores,
+		Operations: []Operation{{
+			Method: http.MethodGet, ID: "GetNumberOfCPUCores", Summary: "Get the number of CPU cores of the host",
+			Response: typeOf[cns.NumOfCPUCoresResponse](),
+		}},
+	},
+	{
+		Path: cns.CreateHostNCApipaEndpointPath, V2: true, handler: (*HTTPRestService).createHostNCApipaEndpoint,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "CreateHostNCApipaEndpoint", Summary: "Create the APIPA endpoint of the host for an NC",
+			Request: typeOf[cns.CreateHostNCApipaEndpointRequest](), Response: typeOf[cns.CreateHostNCApipaEndpointResponse](),
+		}},
+	},
+	{
+		Path: cns.DeleteHostNCApipaEndpointPath, V2: true, handler: (*HTTPRestService).deleteHostNCApipaEndpoint,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "DeleteHostNCApipaEndpoint", Summary: "Delete the APIPA endpoint of the host for an NC",
+			Request: typeOf[cns.DeleteHostNCApipaEndpointRequest](), Response: typeOf[cns.DeleteHostNCApipaEndpointResponse](),
+		}},
+	},
+	{
+		Path: cns.PublishNetworkContainer, handler: (*HTTPRestService).publishNetworkContainer,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "PublishNetworkContainer", Summary: "Publish an NC to NMAgent",
+			Request: typeOf[cns.PublishNetworkContainerRequest](), Response: typeOf[cns.PublishNetworkContainerResponse](),
+		}},
+	},
+	{
+		Path: cns.UnpublishNetworkContainer, handler: (*HTTPRestService).unpublishNetworkContainer,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "UnpublishNetworkContainer", Summary: "Unpublish an NC from NMAgent",
+			Request: typeOf[cns.UnpublishNetworkContainerRequest](), Response: typeOf[cns.UnpublishNetworkContainerResponse](),
+		}},
+	},
+	{
+		Path: cns.RequestIPConfig, handler: (*HTTPRestService).requestIPConfigHandler, latency: true,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "RequestIPConfig", Summary: "Assign an IP to a pod",
+			Request: typeOf[cns.IPConfigRequest](), Response: typeOf[cns.IPConfigResponse](),
+		}},
+	},
+	{
+		Path: cns.RequestIPConfigs, handler: (*HTTPRestService).requestIPConfigsHandler, latency: true,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "RequestIPConfigs", Summary: "Assign an IP of every NC to a pod",
+			Request: typeOf[cns.IPConfigsRequest](), Response: typeOf[cns.IPConfigsResponse](),
+		}},
+	},
+	{
+		Path: cns.ReleaseIPConfig, handler: (*HTTPRestService).releaseIPConfigHandler, latency: true,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "ReleaseIPConfig", Summary: "Release the IP of a pod",
+			Request: typeOf[cns.IPConfigRequest](), Response: typeOf[cns.Response](),
+		}},
+	},
+	{
+		Path: cns.ReleaseIPConfigs, handler: (*HTTPRestService).releaseIPConfigsHandler, latency: true,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "ReleaseIPConfigs", Summary: "Release the IPs of a pod",
+			Request: typeOf[cns.IPConfigsRequest](), Response: typeOf[cns.Response](),
+		}},
+	},
+	{
+		Path: cns.NmAgentSupportedApisPath, V2: true, handler: (*HTTPRestService).nmAgentSupportedApisHandler,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "GetNmAgentSupportedApis", Summary: "Get the APIs supported by NMAgent",
+			Request: typeOf[cns.NmAgentSupportedApisRequest](), Response: typeOf[cns.NmAgentSupportedApisResponse](),
+		}},
+	},
+	{
+		Path: cns.PathDebugIPAddresses, handler: (*HTTPRestService).handleDebugIPAddresses,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "GetIPAddresses", Summary: "Get the IPs of CNS in the states of the filter",
+			Request: typeOf[cns.GetIPAddressesRequest](), Response: typeOf[cns.GetIPAddressStatusResponse](),
+		}},
+	},
+	{
+		Path: cns.PathDebugPodContext, handler: (*HTTPRestService).handleDebugPodContext,
+		Operations: []Operation{{
+			Method: http.MethodGet, ID: "GetPodContext", Summary: "Get the IP IDs of the pod interfaces",
+			Response: typeOf[cns.GetPodContextResponse](),
+		}},
+	},
+	{
+		Path: cns.PathDebugRestData, handler: (*HTTPRestService).handleDebugRestData,
+		Operations: []Operation{{
+			Method: http.MethodGet, ID: "GetRestData", Summary: "Get the in-memory state of CNS",
+			Response: typeOf[GetHTTPServiceDataResponse](),
+		}},
+	},
+	{
+		Path: cns.PathDebugNetworkContainers, handler: (*HTTPRestService).handleDebugNetworkContainers,
+		Operations: []Operation{{
+			Method: http.MethodGet, ID: "GetNetworkContainersDebug", Summary: "Get the NCs of CNS with their versions",
+			Response: typeOf[cns.GetNetworkContainersDebugResponse](),
+		}},
+	},
+	{
+		Path: cns.PathDebugWatch, handler: (*HTTPRestService).handleWatch,
+		Operations: []Operation{{
+			Method: http.MethodGet, ID: "Watch", Summary: "Watch the IP state, NC and pool spec changes of CNS",
+			Response: typeOf[cns.WatchEvent](), Stream: true,
+		}},
+	},
+	{
+		Path: cns.PathDebugEndpoints, handler: (*HTTPRestService).handleDebugEndpoints,
+		Operations: []Operation{{
+			Method: http.MethodGet, ID: "GetEndpointState", Summary: "Get the endpoint state of CNS",
+			Response: typeOf[GetEndpointStateResponse](),
+		}},
+	},
+	{
+		Path: cns.PathDebugSnapshot, handler: (*HTTPRestService).handleDebugSnapshot,
+		Operations: []Operation{{
+			Method: http.MethodGet, ID: "GetSnapshot", Summary: "Get a snapshot of the state of CNS, with the secrets redacted",
+			Response: typeOf[SnapshotManifest](), Archive: true,
+		}},
+	},
+	{
+		Path: cns.PathDebugReconcile, handler: (*HTTPRestService).handleDebugReconcile,
+		Operations: []Operation{{
+			Method: http.MethodPost, ID: "Reconcile", Summary: "Enqueue a reconcile of the NodeNetworkConfig of the node",
+			Response: typeOf[cns.Response](),
+		}},
+	},
+	{
+		Path: cns.NetworkContainersURLPath, handler: (*HTTPRestService).getOrRefreshNetworkContainers,
+		Operations: []Operation{
+			{
+				Method: http.MethodGet, ID: "GetNetworkContainers", Summary: "Get all NCs of CNS",
+				Response: typeOf[cns.GetAllNetworkContainersResponse](),
+			},
+			{
+				Method: http.MethodPost, ID: "PostNetworkContainers", Summary: "Create or update all NCs of CNS",
+				Request: typeOf[cns.PostNetworkContainersRequest](), Response: typeOf[cns.PostNetworkContainersResponse](),
+			},
+		},
+	},
+	{
+		Path: cns.GetHomeAz, V2: true, handler: (*HTTPRestService).getHomeAz,
+		Operations: []Operation{{
+			Method: http.MethodGet, ID: "GetHomeAz", Summary: "Get the home AZ of the node",
+			Response: typeOf[cns.GetHomeAzResponse](),
+		}},
+	},
+}
+
+// LookupOperation returns the declared operation of the method on the route of the path.
+func LookupOperation(path, method string) (Operation, bool) {
+	for i := range Routes {
+		if Routes[i].Path == path {
+			return Routes[i].Operation(method)
+		}
+	}
+	return Operation{}, false
+}
+
+// handlerFunc returns the handler of the route for the service. The correlation ID of the request, which is the one
+// of the caller or a random one, is carried by the request context to the NMAgent client, returned in the response
+// and logged with the response.
+func (service *HTTPRestService) handlerFunc(route *Route) http.HandlerFunc {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(nma.HeaderCorrelationID)
+		if id == "" {
+			id = uuid.New().String()
+		}
+		w.Header().Set(nma.HeaderCorrelationID, id)
+		route.handler(service, w, r.WithContext(nma.WithCorrelationID(r.Context(), id)))
+		logger.Printf("[Azure CNS] Responded to %s %s with return code %q, correlation id %s",
+			r.Method, r.URL.Path, w.Header().Get(cnsReturnCode), id)
+	}
+	if route.latency {
+		return newHandlerFuncWithHistogram(h, httpRequestLatency)
+	}
+	return h
+}
+
+// registerRoutes adds the handlers of the routes, and of the OpenAPI document, to the listener.
+func (service *HTTPRestService) registerRoutes() {
+	for i := range Routes {
+		h := service.handlerFunc(&Routes[i])
+		service.Listener.AddHandler(Routes[i].Path, h)
+		if Routes[i].V2 {
+			service.Listener.AddHandler(cns.V2Prefix+Routes[i].Path, h)
+		}
+	}
+	service.Listener.AddHandler(cns.PathOpenAPI, service.handleOpenAPI)
+}
@@ -0,0 +1,244 @@
+package restserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/common"
+	"github.com/Azure/azure-container-networking/cns/fakes"
+	"github.com/Azure/azure-container-networking/cns/types"
+	acncommon "github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/Azure/azure-container-networking/nmagent"
+	"github.com/Azure/azure-container-networking/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newConformanceService returns a service with empty state, so that calling its handlers does not change the
+// state of the service shared by the other tests.
+func newConformanceService(t *testing.T) *HTTPRestService {
+	t.Helper()
+	nma := &fakes.NMAgentClientFake{
+		SupportedAPIsF: func(context.Context) ([]string, error) {
+			return nil, nil
+		},
+		GetNCVersionListF: func(context.Context) (nmagent.NCVersionList, error) {
+			return nmagent.NCVersionList{}, nil
+		},
+		GetHomeAzF: func(context.Context) (nmagent.AzResponse, error) {
+			return nmagent.AzResponse{}, nil
+		},
+	}
+	homeAzMonitor := NewHomeAzMonitor(nma, time.Hour)
+	config := &common.ServiceConfig{Store: store.NewMockStore("")}
+	s, err := NewHTTPRestService(config, &fakes.WireserverClientFake{}, &fakes.WireserverProxyFake{}, nma,
+		store.NewMockStore(""), nil, homeAzMonitor)
+	require.NoError(t, err)
+	s.Name = "cns-conformance-test-server"
+	s.Listener = &acncommon.Listener{}
+	s.IPAMPoolMonitor = &fakes.MonitorFake{NodeNetworkConfig: &v1alpha.NodeNetworkConfig{}}
+	s.TriggerReconcile = func(context.Context) error { return nil }
+	return s
+}
+
+// rejectsMethod is true if the response is a rejection of the method of the request by a handler, which is
+// reported in the message of the response by the handlers that predate the 405 status.
+func rejectsMethod(code int, resp *cns.Response) bool {
+	return code == http.StatusMethodNotAllowed ||
+		resp.ReturnCode == types.UnsupportedVerb ||
+		strings.Contains(resp.Message, "did not receive") ||
+		strings.Contains(resp.Message, "expects a")
+}
+
+// TestRoutesConformToDeclarations calls every declared operation with the zero request, and fails if the handler
+// rejects the method, or responds with anything but one JSON value with the fields of the declared response.
+// The zero requests are invalid for most operations, so the handlers respond with an error, which is fine.
+func TestRoutesConformToDeclarations(t *testing.T) {
+	doc := NewOpenAPIDocument("test")
+	s := newConformanceService(t)
+
+	for i := range Routes {
+		route := &Routes[i]
+		h := s.handlerFunc(route)
+		for _, op := range route.Operations {
+			op := op
+			if op.Stream || op.Archive {
+				// the streams do not end, and the archives are not JSON, and both are tested by their own tests.
+				continue
+			}
+			t.Run(op.Method+" "+route.Path, func(t *testing.T) {
+				var body io.Reader = http.NoBody
+				if op.Request != nil {
+					b, err := json.Marshal(reflect.New(op.Request).Interface())
+					require.NoError(t, err)
+					body = bytes.NewReader(b)
+				}
+				w := httptest.NewRecorder()
+				h(w, httptest.NewRequest(op.Method, route.Path, body))
+
+				fields := map[string]json.RawMessage{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &fields), w.Body.String())
+
+				schema := doc.Schema(doc.Paths[route.Path][strings.ToLower(op.Method)].Responses["200"].Content[contentTypeJSON].Schema)
+				require.NotNil(t, schema)
+				for name := range fields {
+					assert.Contains(t, schema.Properties, name, "field %s of the response is not declared by %s", name, op.Response)
+				}
+
+				resp := &cns.Response{}
+				if nested, ok := fields["Response"]; ok {
+					require.NoError(t, json.Unmarshal(nested, resp))
+				} else {
+					require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+				}
+				assert.False(t, rejectsMethod(w.Code, resp), "handler rejects the declared method: %+v", resp)
+			})
+		}
+	}
+}
+
+func TestRoutesRegistered(t *testing.T) {
+	for i := range Routes {
+		paths := []string{Routes[i].Path}
+		if Routes[i].V2 {
+			paths = append(paths, cns.V2Prefix+Routes[i].Path)
+		}
+		for _, path := range paths {
+			_, pattern := mux.Handler(httptest.NewRequest(http.MethodGet, path, http.NoBody))
+			assert.Equal(t, path, pattern)
+		}
+	}
+}
+
+func TestRoutesUniqueOperationIDs(t *testing.T) {
+	ids := map[string]string{}
+	for i := range Routes {
+		for _, op := range Routes[i].Operations {
+			require.NotEmpty(t, op.ID, Routes[i].Path)
+			require.NotNil(t, op.Response, Routes[i].Path)
+			other, ok := ids[op.ID]
+			require.False(t, ok, "operation %s of %s is also declared by %s", op.ID, Routes[i].Path, other)
+			ids[op.ID] = Routes[i].Path
+		}
+	}
+}
+
+// collectRefs adds the components referred to by the schema, and the schemas it contains, to the refs.
+func collectRefs(s *OpenAPISchema, refs map[string]bool) {
+	if s == nil {
+		return
+	}
+	if s.Ref != "" {
+		refs[strings.TrimPrefix(s.Ref, schemaRefPrefix)] = true
+	}
+	collectRefs(s.Items, refs)
+	collectRefs(s.AdditionalProperties, refs)
+	for _, p := range s.Properties {
+		collectRefs(p, refs)
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, cns.PathOpenAPI, http.NoBody))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, contentTypeJSON, w.Header().Get("Content-Type"))
+
+	doc := &OpenAPIDocument{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), doc))
+	assert.Equal(t, openAPIVersion, doc.OpenAPI)
+
+	// every route and operation is documented.
+	for i := range Routes {
+		ops, ok := doc.Paths[Routes[i].Path]
+		require.True(t, ok, Routes[i].Path)
+		assert.Len(t, ops, len(Routes[i].Operations))
+		if Routes[i].V2 {
+			assert.Contains(t, doc.Paths, cns.V2Prefix+Routes[i].Path)
+		}
+	}
+	assert.Contains(t, doc.Paths[cns.NetworkContainersURLPath], "get")
+	assert.Contains(t, doc.Paths[cns.NetworkContainersURLPath], "post")
+
+	// every component that is referred to is in the document.
+	refs := map[string]bool{}
+	for _, ops := range doc.Paths {
+		for _, op := range ops {
+			if op.RequestBody != nil {
+				collectRefs(op.RequestBody.Content[contentTypeJSON].Schema, refs)
+			}
+			for _, content := range op.Responses["200"].Content {
+				collectRefs(content.Schema, refs)
+			}
+		}
+	}
+	for _, s := range doc.Components.Schemas {
+		collectRefs(s, refs)
+	}
+	for ref := range refs {
+		assert.Contains(t, doc.Components.Schemas, ref)
+	}
+
+	// the schemas follow the JSON marshalling of the types.
+	status := doc.Components.Schemas["cns.IPConfigurationStatus"]
+	require.NotNil(t, status)
+	assert.Equal(t, "string", status.Properties["state"].Type)
+	assert.Equal(t, "date-time", status.Properties["LastStateTransition"].Format)
+	assert.Equal(t, &OpenAPISchema{}, status.Properties["PodInfo"])
+	assert.NotContains(t, status.Properties, "stateMiddlewareFuncs")
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, cns.PathOpenAPI, http.NoBody))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestHandlersPassCorrelationIDToNMAgent(t *testing.T) {
+	var gotCorrelationID string
+	nmaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCorrelationID = r.Header.Get(nmagent.HeaderCorrelationID)
+		_, _ = w.Write([]byte(`<SupportedApis></SupportedApis>`))
+	}))
+	defer nmaServer.Close()
+
+	addr := nmaServer.Listener.Addr().(*net.TCPAddr)
+	nmaClient, err := nmagent.NewClient(nmagent.Config{Host: addr.IP.String(), Port: uint16(addr.Port)})
+	require.NoError(t, err)
+	s := newConformanceService(t)
+	s.nma = nmaClient
+
+	route := Routes[0]
+	for i := range Routes {
+		if Routes[i].Path == cns.NmAgentSupportedApisPath {
+			route = Routes[i]
+		}
+	}
+	h := s.handlerFunc(&route)
+
+	body, err := json.Marshal(cns.NmAgentSupportedApisRequest{})
+	require.NoError(t, err)
+	req := httptest.NewRequest(route.Operations[0].Method, route.Path, bytes.NewReader(body))
+	req.Header.Set(nmagent.HeaderCorrelationID, "correlation-id")
+	w := httptest.NewRecorder()
+	h(w, req)
+	assert.Equal(t, "correlation-id", gotCorrelationID)
+	assert.Equal(t, "correlation-id", w.Header().Get(nmagent.HeaderCorrelationID))
+
+	// without an ID from the caller, CNS makes one and returns it
+	req = httptest.NewRequest(route.Operations[0].Method, route.Path, bytes.NewReader(body))
+	w = httptest.NewRecorder()
+	h(w, req)
+	assert.NotEmpty(t, gotCorrelationID)
+	assert.NotEqual(t, "correlation-id", gotCorrelationID)
+	assert.Equal(t, gotCorrelationID, w.Header().Get(nmagent.HeaderCorrelationID))
+}
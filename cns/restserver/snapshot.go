@@ -0,0 +1,412 @@
+package restserver
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/common"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/wireserver"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/pkg/errors"
+)
+
+// SnapshotVersion is the version of the format of the snapshots of the state of CNS. Snapshots of other versions
+// cannot be read.
+const SnapshotVersion = 1
+
+const (
+	snapshotManifestFile         = "manifest.json"
+	snapshotStateFile            = "state.json"
+	snapshotPrimaryInterfaceFile = "primaryinterface.json"
+	snapshotPodIPIDFile          = "podipidbypodinterfacekey.json"
+	snapshotPodIPConfigStateFile = "podipconfigstate.json"
+	snapshotEndpointStateFile    = "endpoints.json"
+	snapshotPoolMonitorFile      = "ipampoolmonitor.json"
+	snapshotNNCFile              = "nodenetworkconfig.json"
+	// maxSnapshotFileSize is the largest file of a snapshot that is read.
+	maxSnapshotFileSize = 64 << 20
+	// snapshotServiceName is the name of the services that snapshots are imported into.
+	snapshotServiceName = "azure-cns-snapshot"
+	// redacted replaces the secrets in snapshots.
+	redacted = "REDACTED"
+	// contentTypeGzip is the content type of the snapshot archives.
+	contentTypeGzip = "application/gzip"
+)
+
+var (
+	ErrSnapshotVersion      = errors.New("unsupported snapshot version")
+	ErrSnapshotNoManifest   = errors.New("snapshot has no manifest")
+	ErrSnapshotFileTooLarge = errors.New("snapshot file is too large")
+)
+
+// SnapshotManifest describes a snapshot of the state of CNS.
+type SnapshotManifest struct {
+	Version     int
+	Time        time.Time
+	CNSVersion  string
+	ChannelMode string
+	// Errors are the failures to get parts of the state, which are missing from the snapshot.
+	Errors []string `json:",omitempty"`
+}
+
+// Snapshot is a copy of the persisted and in-memory state of CNS, such as to attach to a support bundle or to
+// inspect the state of a node offline. The fields of the NCs that may hold secrets are redacted.
+type Snapshot struct {
+	Manifest                 SnapshotManifest
+	PrimaryInterface         *wireserver.InterfaceInfo
+	PodIPIDByPodInterfaceKey map[string][]string
+	PodIPConfigState         map[string]cns.IPConfigurationStatus
+	EndpointState            map[string]*EndpointInfo
+	IPAMPoolMonitor          *cns.IpamPoolMonitorStateSnapshot
+	NodeNetworkConfig        *v1alpha.NodeNetworkConfig
+	// state is the persisted state of the service.
+	state *httpRestServiceState
+}
+
+// copyJSON deep copies src to dst by their JSON, which is how the state is persisted and exported.
+func copyJSON(dst, src any) error {
+	b, err := json.Marshal(src)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal")
+	}
+	return errors.Wrap(json.Unmarshal(b, dst), "failed to unmarshal")
+}
+
+// Snapshot copies the state of the service. The parts of the state that cannot be got, such as the
+// NodeNetworkConfig when the API server is down, are listed in the errors of the manifest of the snapshot.
+func (service *HTTPRestService) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		Manifest: SnapshotManifest{
+			Version:     SnapshotVersion,
+			Time:        time.Now().UTC(),
+			CNSVersion:  service.Version,
+			ChannelMode: service.ChannelMode,
+		},
+		state: &httpRestServiceState{},
+	}
+
+	service.RLock()
+	err := service.copySnapshotState(snapshot)
+	service.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	redactSnapshot(snapshot)
+
+	if service.IPAMPoolMonitor != nil {
+		pool := service.IPAMPoolMonitor.GetStateSnapshot()
+		snapshot.IPAMPoolMonitor = &pool
+	}
+	if service.GetNodeNetworkConfig != nil {
+		nnc, err := service.GetNodeNetworkConfig(ctx)
+		if err != nil {
+			snapshot.Manifest.Errors = append(snapshot.Manifest.Errors, errors.Wrap(err, "failed to get nnc").Error())
+		} else {
+			snapshot.NodeNetworkConfig = nnc
+		}
+	}
+	return snapshot, nil
+}
+
+// copySnapshotState copies the state of the service to the snapshot. The service must be locked.
+func (service *HTTPRestService) copySnapshotState(snapshot *Snapshot) error {
+	if err := copyJSON(snapshot.state, service.state); err != nil {
+		return errors.Wrap(err, "failed to copy state")
+	}
+	if service.state.primaryInterface != nil {
+		primaryInterface := *service.state.primaryInterface
+		snapshot.PrimaryInterface = &primaryInterface
+	}
+	if err := copyJSON(&snapshot.PodIPIDByPodInterfaceKey, service.PodIPIDByPodInterfaceKey); err != nil {
+		return errors.Wrap(err, "failed to copy pod ip ids")
+	}
+	if err := copyJSON(&snapshot.PodIPConfigState, service.PodIPConfigState); err != nil {
+		return errors.Wrap(err, "failed to copy ip config state")
+	}
+	if err := copyJSON(&snapshot.EndpointState, service.EndpointState); err != nil {
+		return errors.Wrap(err, "failed to copy endpoint state")
+	}
+	return nil
+}
+
+// The fields of the NC requests and publishes that snapshots keep. The other fields, such as the auth tokens and
+// the fields that are added later, are redacted until they are added here.
+var (
+	snapshotNCRequestFields = map[string]bool{
+		"HostPrimaryIP":              true,
+		"Version":                    true,
+		"NetworkContainerType":       true,
+		"NetworkContainerid":         true,
+		"PrimaryInterfaceIdentifier": true,
+		"LocalIPConfiguration":       true,
+		"OrchestratorContext":        true,
+		"IPConfiguration":            true,
+		"SecondaryIPConfigs":         true,
+		"MultiTenancyInfo":           true,
+		"CnetAddressSpace":           true,
+		"Routes":                     true,
+		"AllowHostToNCCommunication": true,
+		"AllowNCToHostCommunication": true,
+		"EndpointPolicies":           true,
+		"MTU":                        true,
+	}
+	snapshotNCPublishFields    = map[string]bool{"Params": true}
+	snapshotNCParametersFields = map[string]bool{"NCID": true, "AssociatedInterfaceID": true}
+)
+
+// redactSnapshot redacts the fields of the NCs of the snapshot that are not kept.
+func redactSnapshot(snapshot *Snapshot) {
+	for ncID, nc := range snapshot.state.ContainerStatus {
+		redactFields(&nc.CreateNetworkContainerRequest, snapshotNCRequestFields)
+		snapshot.state.ContainerStatus[ncID] = nc
+	}
+	for _, publish := range snapshot.state.NCPublishes {
+		redactFields(publish, snapshotNCPublishFields)
+		redactFields(&publish.Params, snapshotNCParametersFields)
+	}
+}
+
+// redactFields replaces the fields of the struct that v points to that are not kept: the strings are replaced by
+// redacted, and the other fields are cleared.
+func redactFields(v any, keep map[string]bool) {
+	s := reflect.ValueOf(v).Elem()
+	for i := 0; i < s.NumField(); i++ {
+		f := s.Field(i)
+		if keep[s.Type().Field(i).Name] || f.IsZero() || !f.CanSet() {
+			continue
+		}
+		if f.Kind() == reflect.String {
+			f.SetString(redacted)
+			continue
+		}
+		f.Set(reflect.Zero(f.Type()))
+	}
+}
+
+// SnapshotFileName is the name of the archive of a snapshot taken at the time.
+func SnapshotFileName(t time.Time) string {
+	return "cns-snapshot-" + t.UTC().Format("20060102T150405Z") + ".tar.gz"
+}
+
+// files are the contents of the files of the snapshot by their names. The parts of the state that the snapshot
+// does not have are omitted.
+func (s *Snapshot) files() map[string]any {
+	files := map[string]any{
+		snapshotManifestFile:         &s.Manifest,
+		snapshotStateFile:            s.state,
+		snapshotPodIPIDFile:          &s.PodIPIDByPodInterfaceKey,
+		snapshotPodIPConfigStateFile: &s.PodIPConfigState,
+		snapshotEndpointStateFile:    &s.EndpointState,
+	}
+	if s.PrimaryInterface != nil {
+		files[snapshotPrimaryInterfaceFile] = s.PrimaryInterface
+	}
+	if s.IPAMPoolMonitor != nil {
+		files[snapshotPoolMonitorFile] = s.IPAMPoolMonitor
+	}
+	if s.NodeNetworkConfig != nil {
+		files[snapshotNNCFile] = s.NodeNetworkConfig
+	}
+	return files
+}
+
+// Write writes the snapshot as a gzipped tar archive of JSON files, starting with the manifest.
+func (s *Snapshot) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	files := s.files()
+	names := []string{snapshotManifestFile, snapshotStateFile, snapshotPrimaryInterfaceFile, snapshotPodIPIDFile,
+		snapshotPodIPConfigStateFile, snapshotEndpointStateFile, snapshotPoolMonitorFile, snapshotNNCFile}
+	for _, name := range names {
+		v, ok := files[name]
+		if !ok {
+			continue
+		}
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal %s", name)
+		}
+		hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(b)), ModTime: s.Manifest.Time}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return errors.Wrapf(err, "failed to write header of %s", name)
+		}
+		if _, err := tw.Write(b); err != nil {
+			return errors.Wrapf(err, "failed to write %s", name)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, "failed to close tar")
+	}
+	return errors.Wrap(gz.Close(), "failed to close gzip")
+}
+
+// ReadSnapshot reads a snapshot written by Write. It fails with ErrSnapshotVersion if the snapshot is of another
+// version.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read gzip")
+	}
+	defer gz.Close()
+
+	s := &Snapshot{state: &httpRestServiceState{}, PrimaryInterface: &wireserver.InterfaceInfo{}}
+	s.IPAMPoolMonitor = &cns.IpamPoolMonitorStateSnapshot{}
+	s.NodeNetworkConfig = &v1alpha.NodeNetworkConfig{}
+	files := s.files()
+	read := map[string]bool{}
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read tar")
+		}
+		v, ok := files[hdr.Name]
+		if !ok {
+			// snapshots of the same version can add files.
+			continue
+		}
+		if hdr.Size > maxSnapshotFileSize {
+			return nil, errors.Wrapf(ErrSnapshotFileTooLarge, "%s is %d bytes", hdr.Name, hdr.Size)
+		}
+		if err := json.NewDecoder(tr).Decode(v); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode %s", hdr.Name)
+		}
+		read[hdr.Name] = true
+		if hdr.Name == snapshotManifestFile && s.Manifest.Version != SnapshotVersion {
+			return nil, errors.Wrapf(ErrSnapshotVersion, "snapshot version %d, supported version %d", s.Manifest.Version, SnapshotVersion)
+		}
+	}
+
+	if !read[snapshotManifestFile] {
+		return nil, ErrSnapshotNoManifest
+	}
+	if !read[snapshotPrimaryInterfaceFile] {
+		s.PrimaryInterface = nil
+	}
+	if !read[snapshotPoolMonitorFile] {
+		s.IPAMPoolMonitor = nil
+	}
+	if !read[snapshotNNCFile] {
+		s.NodeNetworkConfig = nil
+	}
+	return s, nil
+}
+
+// snapshotInterfaces gets the primary interface of a snapshot.
+type snapshotInterfaces struct {
+	primaryInterface *wireserver.InterfaceInfo
+}
+
+func (s snapshotInterfaces) GetInterfaces(context.Context) (*wireserver.GetInterfacesResult, error) {
+	if s.primaryInterface == nil {
+		return nil, errors.Wrap(wireserver.ErrNoPrimaryInterface, "snapshot has no primary interface")
+	}
+	return &wireserver.GetInterfacesResult{
+		Interface: []wireserver.Interface{
+			{
+				IsPrimary: true,
+				IPSubnet: []wireserver.Subnet{
+					{
+						Prefix:    s.primaryInterface.Subnet,
+						IPAddress: []wireserver.Address{{Address: s.primaryInterface.PrimaryIP, IsPrimary: true}},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// snapshotPoolMonitor reports the pool monitor state of a snapshot, and never scales the pool.
+type snapshotPoolMonitor struct {
+	state cns.IpamPoolMonitorStateSnapshot
+}
+
+func (m *snapshotPoolMonitor) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return errors.Wrap(ctx.Err(), "pool monitor context closed")
+}
+
+func (m *snapshotPoolMonitor) Update(*v1alpha.NodeNetworkConfig) error {
+	return nil
+}
+
+func (m *snapshotPoolMonitor) GetStateSnapshot() cns.IpamPoolMonitorStateSnapshot {
+	return m.state
+}
+
+// NewHTTPRestServiceFromSnapshot creates a service with the state of the snapshot, such as to reproduce the
+// behavior of CNS on a node offline. The service is not initialized, so it does not serve the CNS API and does
+// not persist its state, and it has no NMAgent or wireserver. The service takes ownership of the state of the
+// snapshot.
+func NewHTTPRestServiceFromSnapshot(snapshot *Snapshot) (*HTTPRestService, error) {
+	config := &common.ServiceConfig{
+		Name:        snapshotServiceName,
+		Version:     snapshot.Manifest.CNSVersion,
+		ChannelMode: snapshot.Manifest.ChannelMode,
+	}
+	service, err := NewHTTPRestService(config, snapshotInterfaces{primaryInterface: snapshot.PrimaryInterface}, nil, nil, nil, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create service")
+	}
+
+	state := snapshot.state
+	if state == nil {
+		state = &httpRestServiceState{}
+	}
+	if state.Networks == nil {
+		state.Networks = map[string]*networkInfo{}
+	}
+	state.joinedNetworks = map[string]struct{}{}
+	state.primaryInterface = snapshot.PrimaryInterface
+	service.state = state
+
+	if snapshot.PodIPIDByPodInterfaceKey != nil {
+		service.PodIPIDByPodInterfaceKey = snapshot.PodIPIDByPodInterfaceKey
+	}
+	for id, ipconfig := range snapshot.PodIPConfigState {
+		ipconfig.WithStateMiddleware(stateTransitionMiddleware, service.publishIPStateTransition)
+		service.PodIPConfigState[id] = ipconfig
+	}
+	if snapshot.EndpointState != nil {
+		service.EndpointState = snapshot.EndpointState
+	}
+	pool := &snapshotPoolMonitor{}
+	if snapshot.IPAMPoolMonitor != nil {
+		pool.state = *snapshot.IPAMPoolMonitor
+	}
+	service.IPAMPoolMonitor = pool
+	logger.Printf("[Azure CNS] Imported snapshot of CNS %s taken at %s", snapshot.Manifest.CNSVersion, snapshot.Manifest.Time)
+	return service, nil
+}
+
+// handleDebugSnapshot responds with a snapshot of the state of CNS, as a gzipped tar archive.
+func (service *HTTPRestService) handleDebugSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	snapshot, err := service.Snapshot(r.Context())
+	if err != nil {
+		logger.Errorf("[Azure CNS] Failed to take snapshot, err:%v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentTypeGzip)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", SnapshotFileName(snapshot.Manifest.Time)))
+	if err := snapshot.Write(w); err != nil {
+		logger.Errorf("[Azure CNS] Failed to write snapshot, err:%v", err)
+	}
+}
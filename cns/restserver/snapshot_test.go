@@ -0,0 +1,188 @@
+package restserver
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// setNCToken sets the authorization token of the NC of the service.
+func setNCToken(t *testing.T, token string) {
+	t.Helper()
+	nc, ok := svc.state.ContainerStatus[ncID]
+	require.True(t, ok)
+	nc.CreateNetworkContainerRequest.AuthorizationToken = token
+	svc.state.ContainerStatus[ncID] = nc
+}
+
+func writeSnapshot(t *testing.T, snapshot *Snapshot) *bytes.Buffer {
+	t.Helper()
+	b := &bytes.Buffer{}
+	require.NoError(t, snapshot.Write(b))
+	return b
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	createNCReqeustForSyncHostNCVersion(t)
+	setNCToken(t, "secret")
+	svc.GetNodeNetworkConfig = func(context.Context) (*v1alpha.NodeNetworkConfig, error) {
+		return &v1alpha.NodeNetworkConfig{ObjectMeta: metav1.ObjectMeta{Name: "node", Namespace: "kube-system"}}, nil
+	}
+	defer func() { svc.GetNodeNetworkConfig = nil }()
+
+	snapshot, err := svc.Snapshot(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, snapshot.Manifest.Errors)
+	// the secrets are redacted from the snapshot, not from CNS.
+	assert.Equal(t, redacted, snapshot.state.ContainerStatus[ncID].CreateNetworkContainerRequest.AuthorizationToken)
+	assert.Equal(t, "secret", svc.state.ContainerStatus[ncID].CreateNetworkContainerRequest.AuthorizationToken)
+
+	b := writeSnapshot(t, snapshot)
+	read, err := ReadSnapshot(b)
+	require.NoError(t, err)
+	assert.Equal(t, SnapshotVersion, read.Manifest.Version)
+	assert.Equal(t, redacted, read.state.ContainerStatus[ncID].CreateNetworkContainerRequest.AuthorizationToken)
+	assert.Equal(t, svc.state.primaryInterface, read.PrimaryInterface)
+	assert.Equal(t, svc.PodIPIDByPodInterfaceKey, read.PodIPIDByPodInterfaceKey)
+	require.NotNil(t, read.NodeNetworkConfig)
+	assert.Equal(t, "node", read.NodeNetworkConfig.Name)
+	require.NotNil(t, read.IPAMPoolMonitor)
+
+	imported, err := NewHTTPRestServiceFromSnapshot(read)
+	require.NoError(t, err)
+	assert.Equal(t, svc.GetNetworkContainersDebug(), imported.GetNetworkContainersDebug())
+	require.Len(t, imported.PodIPConfigState, len(svc.PodIPConfigState))
+	for id, ipconfig := range svc.PodIPConfigState {
+		importedIPConfig := imported.PodIPConfigState[id]
+		assert.Equal(t, ipconfig.IPAddress, importedIPConfig.IPAddress)
+		assert.Equal(t, ipconfig.GetState(), importedIPConfig.GetState())
+	}
+	assert.Equal(t, svc.IPAMPoolMonitor.GetStateSnapshot(), imported.IPAMPoolMonitor.GetStateSnapshot())
+}
+
+// assertNoSecret asserts that no string of the decoded JSON contains the secret.
+func assertNoSecret(t *testing.T, path string, v any, secret string) {
+	t.Helper()
+	switch v := v.(type) {
+	case string:
+		assert.NotContains(t, v, secret, path)
+	case []any:
+		for i := range v {
+			assertNoSecret(t, fmt.Sprintf("%s[%d]", path, i), v[i], secret)
+		}
+	case map[string]any:
+		for k := range v {
+			assert.NotContains(t, k, secret, path)
+			assertNoSecret(t, path+"."+k, v[k], secret)
+		}
+	}
+}
+
+func TestSnapshotRedactsSecrets(t *testing.T) {
+	createNCReqeustForSyncHostNCVersion(t)
+	setNCToken(t, "secret")
+	svc.recordNCPublish(cns.NetworkContainerParameters{NCID: ncID, AuthToken: "secret", AssociatedInterfaceID: "10.240.0.4"},
+		[]byte(`{"version":"0","subnetName":"secret"}`))
+	require.Contains(t, svc.state.NCPublishes, ncID)
+
+	snapshot, err := svc.Snapshot(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "10.240.0.4", snapshot.state.NCPublishes[ncID].Params.AssociatedInterfaceID)
+
+	// every string of every file of the exported snapshot is checked for the secrets.
+	gz, err := gzip.NewReader(writeSnapshot(t, snapshot))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, hdr.Name)
+		var v any
+		require.NoError(t, json.NewDecoder(tr).Decode(&v))
+		assertNoSecret(t, hdr.Name, v, "secret")
+	}
+	assert.Contains(t, names, snapshotStateFile)
+
+	// the kept fields are fields of the NC requests and publishes.
+	for fields, v := range map[*map[string]bool]any{
+		&snapshotNCRequestFields:    cns.CreateNetworkContainerRequest{},
+		&snapshotNCPublishFields:    ncPublish{},
+		&snapshotNCParametersFields: cns.NetworkContainerParameters{},
+	} {
+		for name := range *fields {
+			_, ok := reflect.TypeOf(v).FieldByName(name)
+			assert.True(t, ok, "%T has no field %s", v, name)
+		}
+	}
+}
+
+func TestSnapshotNNCError(t *testing.T) {
+	createNCReqeustForSyncHostNCVersion(t)
+	svc.GetNodeNetworkConfig = func(context.Context) (*v1alpha.NodeNetworkConfig, error) {
+		return nil, errors.New("apiserver down")
+	}
+	defer func() { svc.GetNodeNetworkConfig = nil }()
+
+	snapshot, err := svc.Snapshot(context.Background())
+	require.NoError(t, err)
+	require.Len(t, snapshot.Manifest.Errors, 1)
+	assert.Contains(t, snapshot.Manifest.Errors[0], "apiserver down")
+
+	read, err := ReadSnapshot(writeSnapshot(t, snapshot))
+	require.NoError(t, err)
+	assert.Nil(t, read.NodeNetworkConfig)
+	assert.Equal(t, snapshot.Manifest.Errors, read.Manifest.Errors)
+}
+
+func TestReadSnapshotVersion(t *testing.T) {
+	createNCReqeustForSyncHostNCVersion(t)
+	snapshot, err := svc.Snapshot(context.Background())
+	require.NoError(t, err)
+
+	snapshot.Manifest.Version = SnapshotVersion + 1
+	_, err = ReadSnapshot(writeSnapshot(t, snapshot))
+	require.ErrorIs(t, err, ErrSnapshotVersion)
+
+	empty := &bytes.Buffer{}
+	gz := gzip.NewWriter(empty)
+	require.NoError(t, tar.NewWriter(gz).Close())
+	require.NoError(t, gz.Close())
+	_, err = ReadSnapshot(empty)
+	require.ErrorIs(t, err, ErrSnapshotNoManifest)
+}
+
+func TestHandleDebugSnapshot(t *testing.T) {
+	createNCReqeustForSyncHostNCVersion(t)
+
+	w := httptest.NewRecorder()
+	svc.handleDebugSnapshot(w, httptest.NewRequest(http.MethodGet, cns.PathDebugSnapshot, http.NoBody))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, contentTypeGzip, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "cns-snapshot-")
+	read, err := ReadSnapshot(w.Body)
+	require.NoError(t, err)
+	assert.Contains(t, read.state.ContainerStatus, ncID)
+
+	w = httptest.NewRecorder()
+	svc.handleDebugSnapshot(w, httptest.NewRequest(http.MethodPost, cns.PathDebugSnapshot, http.NoBody))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -122,6 +122,9 @@ func (service *HTTPRestService) saveNetworkContainerGoalState(
 		hostVersion                string
 		existingSecondaryIPConfigs map[string]cns.SecondaryIPConfig // uuid is key
 		vfpUpdateComplete          bool
+		programmingState           = types.NCRequested
+		programmingStateTime       = time.Now()
+		publishRetries             int
 	)
 
 	if service.state.ContainerStatus == nil {
@@ -133,6 +136,12 @@ func (service *HTTPRestService) saveNetworkContainerGoalState(
 		hostVersion = existingNCStatus.HostVersion
 		existingSecondaryIPConfigs = existingNCStatus.CreateNetworkContainerRequest.SecondaryIPConfigs
 		vfpUpdateComplete = existingNCStatus.VfpUpdateComplete
+		// the programming of the NC starts again when its version changes.
+		if existingNCStatus.CreateNetworkContainerRequest.Version == req.Version && existingNCStatus.ProgrammingState != "" {
+			programmingState = existingNCStatus.ProgrammingState
+			programmingStateTime = existingNCStatus.ProgrammingStateTime
+			publishRetries = existingNCStatus.PublishRetries
+		}
 	}
 	if hostVersion == "" {
 		// Host version is the NC version from NMAgent, set it -1 to indicate no result from NMAgent yet.
@@ -150,6 +159,9 @@ func (service *HTTPRestService) saveNetworkContainerGoalState(
 		CreateNetworkContainerRequest: createNetworkContainerRequest,
 		HostVersion:                   hostVersion,
 		VfpUpdateComplete:             vfpUpdateComplete,
+		ProgrammingState:              programmingState,
+		ProgrammingStateTime:          programmingStateTime,
+		PublishRetries:                publishRetries,
 	}
 
 	switch req.NetworkContainerType {
@@ -218,6 +230,12 @@ func (service *HTTPRestService) saveNetworkContainerGoalState(
 	}
 
 	service.saveState()
+	ncStatus := service.state.ContainerStatus[req.NetworkContainerid]
+	if ok {
+		service.publishNCEvent(cns.WatchNCUpdated, req.NetworkContainerid, &ncStatus)
+	} else {
+		service.publishNCEvent(cns.WatchNCCreated, req.NetworkContainerid, &ncStatus)
+	}
 	return 0, ""
 }
 
@@ -311,7 +329,7 @@ func (service *HTTPRestService) addIPConfigStateUntransacted(ncID string, hostVe
 			IPAddress: ipconfig.IPAddress,
 			PodInfo:   nil,
 		}
-		ipconfigStatus.WithStateMiddleware(stateTransitionMiddleware)
+		ipconfigStatus.WithStateMiddleware(stateTransitionMiddleware, service.publishIPStateTransition)
 		ipconfigStatus.SetState(newIPCNSStatus)
 		logger.Printf("[Azure-Cns] Add IP %s as %s", ipconfig.IPAddress, newIPCNSStatus)
 
@@ -358,7 +376,7 @@ func (service *HTTPRestService) removeToBeDeletedIPStateUntransacted(
 }
 
 func (service *HTTPRestService) getAllNetworkContainerResponses(
-	req cns.GetNetworkContainerRequest,
+	ctx context.Context, req cns.GetNetworkContainerRequest,
 ) []cns.GetNetworkContainerResponse {
 	var (
 		getNetworkContainerResponse cns.GetNetworkContainerResponse
@@ -403,12 +421,12 @@ func (service *HTTPRestService) getAllNetworkContainerResponses(
 			return getNetworkContainersResponse
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), nmaAPICallTimeout)
+		ctx, cancel := context.WithTimeout(ctx, nmaAPICallTimeout)
 		defer cancel()
 		ncVersionListResp, err := service.nma.GetNCVersionList(ctx)
 		if err != nil {
 			skipNCVersionCheck = true
-			logger.Errorf("failed to get nc version list from nmagent")
+			logger.Errorf("failed to get nc version list from nmagent: %v", err)
 			// TODO: Add telemetry as this has potential to have containers in the running state w/o datapath working
 		}
 		nmaNCs := map[string]string{}
@@ -455,7 +473,7 @@ func (service *HTTPRestService) getAllNetworkContainerResponses(
 			)
 
 			service.Unlock()
-			getNetworkContainerResponse.Response.ReturnCode, getNetworkContainerResponse.Response.Message = service.SyncNodeStatus(dncEP, infraVnet, nodeID, req.OrchestratorContext)
+			getNetworkContainerResponse.Response.ReturnCode, getNetworkContainerResponse.Response.Message = service.SyncNodeStatus(ctx, dncEP, infraVnet, nodeID, req.OrchestratorContext)
 			service.Lock()
 			if getNetworkContainerResponse.Response.ReturnCode == types.NotFound {
 				getNetworkContainersResponse = append(getNetworkContainersResponse, getNetworkContainerResponse)
@@ -501,6 +519,7 @@ func (service *HTTPRestService) getAllNetworkContainerResponses(
 			LocalIPConfiguration:       savedReq.LocalIPConfiguration,
 			AllowHostToNCCommunication: savedReq.AllowHostToNCCommunication,
 			AllowNCToHostCommunication: savedReq.AllowNCToHostCommunication,
+			MTU:                        savedReq.MTU,
 		}
 
 		// If the NC version check wasn't skipped, take into account the VFP programming status when returning the response
@@ -572,7 +591,7 @@ func (service *HTTPRestService) restoreNetworkState() error {
 	return nil
 }
 
-func (service *HTTPRestService) attachOrDetachHelper(req cns.ConfigureContainerNetworkingRequest, operation, method string) cns.Response {
+func (service *HTTPRestService) attachOrDetachHelper(ctx context.Context, req cns.ConfigureContainerNetworkingRequest, operation, method string) cns.Response {
 	if method != "POST" {
 		return cns.Response{
 			ReturnCode: types.InvalidParameter,
@@ -596,11 +615,11 @@ func (service *HTTPRestService) attachOrDetachHelper(req cns.ConfigureContainerN
 	if service.ChannelMode == cns.Managed && operation == attach {
 		if ok {
 			if !existing.VfpUpdateComplete {
-				ctx, cancel := context.WithTimeout(context.Background(), nmaAPICallTimeout)
+				ctx, cancel := context.WithTimeout(ctx, nmaAPICallTimeout)
 				defer cancel()
 				ncVersionListResp, err := service.nma.GetNCVersionList(ctx)
 				if err != nil {
-					logger.Errorf("failed to get nc version list from nmagent")
+					logger.Errorf("failed to get nc version list from nmagent: %v", err)
 					return cns.Response{
 						ReturnCode: types.NmAgentInternalServerError,
 						Message:    err.Error(),
@@ -626,7 +645,7 @@ func (service *HTTPRestService) attachOrDetachHelper(req cns.ConfigureContainerN
 				nodeID    = service.GetOption(acn.OptNodeID).(string)
 			)
 
-			returnCode, msg := service.SyncNodeStatus(dncEP, infraVnet, nodeID, json.RawMessage{})
+			returnCode, msg := service.SyncNodeStatus(ctx, dncEP, infraVnet, nodeID, json.RawMessage{})
 			if returnCode != types.Success {
 				return cns.Response{
 					ReturnCode: returnCode,
@@ -820,6 +839,7 @@ func (service *HTTPRestService) populateIPConfigInfoUntransacted(ipConfigStatus
 	}
 
 	podIPInfo.NetworkContainerPrimaryIPConfig = primaryIPCfg
+	podIPInfo.MTU = ncStatus.CreateNetworkContainerRequest.MTU
 	primaryHostInterface, err := service.getPrimaryHostInterface(context.TODO())
 	if err != nil {
 		return err
@@ -918,6 +938,7 @@ func (service *HTTPRestService) handleGetNetworkContainers(w http.ResponseWriter
 			LocalIPConfiguration:       ncDetails.CreateNetworkContainerRequest.LocalIPConfiguration,
 			AllowHostToNCCommunication: ncDetails.CreateNetworkContainerRequest.AllowHostToNCCommunication,
 			AllowNCToHostCommunication: ncDetails.CreateNetworkContainerRequest.AllowNCToHostCommunication,
+			MTU:                        ncDetails.CreateNetworkContainerRequest.MTU,
 		}
 		networkContainers[i] = getNcResp
 		i++
@@ -0,0 +1,302 @@
+package restserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/pkg/errors"
+)
+
+const (
+	// watchHistory is the number of events that are kept to resume watches from, and the number of events that a
+	// watcher can fall behind by before its watch is ended.
+	watchHistory = 1024
+	// watchHeartbeat is the interval of the comments that are sent on idle watches to keep their connection open.
+	watchHeartbeat         = 30 * time.Second
+	contentTypeEventStream = "text/event-stream"
+	// lastEventID is the header of the ID of the last server-sent event received, which is sent on reconnects.
+	lastEventID = "Last-Event-ID"
+)
+
+var (
+	// ErrRevisionCompacted is returned when a watch is resumed after a revision whose events are not kept anymore.
+	ErrRevisionCompacted = errors.New("the events after the revision are not kept anymore")
+	// ErrRevisionUnknown is returned when a watch is resumed after a revision that CNS has not reached.
+	ErrRevisionUnknown = errors.New("the revision is newer than the revision of CNS")
+	// ErrRevisionEpoch is returned when a watch is resumed after a revision of another CNS process, whose events
+	// are not kept by this one.
+	ErrRevisionEpoch    = errors.New("the revision is of another CNS process")
+	ErrUnknownWatchType = errors.New("unknown watch event type")
+)
+
+// watcher receives the events that it accepts, or every event if accept is nil.
+type watcher struct {
+	accept     func(*cns.WatchEvent) bool
+	events     chan cns.WatchEvent
+	overflowed chan struct{}
+}
+
+// watchHub numbers the events of CNS with their revision in the epoch of the hub, keeps the latest ones to resume watches from, and sends
+// them to the watchers without blocking the changes of CNS. A watcher whose buffer is full is removed, and its
+// overflowed channel is closed.
+type watchHub struct {
+	sync.Mutex
+	epoch    string
+	revision uint64
+	history  []cns.WatchEvent
+	watchers map[*watcher]struct{}
+}
+
+// newWatchEpoch returns an epoch that is unique to the CNS process, so that the revisions of a previous process
+// are not mistaken for the ones of this process.
+func newWatchEpoch() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36)
+}
+
+// epochLocked returns the epoch of the hub, which is set on first use.
+func (h *watchHub) epochLocked() string {
+	if h.epoch == "" {
+		h.epoch = newWatchEpoch()
+	}
+	return h.epoch
+}
+
+func (h *watchHub) publish(event cns.WatchEvent) {
+	h.Lock()
+	defer h.Unlock()
+	h.revision++
+	event.Revision = cns.NewWatchRevision(h.epochLocked(), h.revision)
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if len(h.history) == watchHistory {
+		h.history = h.history[1:]
+	}
+	h.history = append(h.history, event)
+
+	for w := range h.watchers {
+		if w.accept != nil && !w.accept(&event) {
+			continue
+		}
+		select {
+		case w.events <- event:
+		default:
+			logger.Errorf("[Azure CNS] Ending the watch that fell behind by %d events at revision %s", watchHistory, event.Revision)
+			close(w.overflowed)
+			delete(h.watchers, w)
+		}
+	}
+}
+
+// watch adds a watcher of the events after the revision, or after the current revision if it is empty, and
+// returns it with the current revision.
+func (h *watchHub) watch(afterRevision cns.WatchRevision, accept func(*cns.WatchEvent) bool) (*watcher, cns.WatchRevision, error) {
+	h.Lock()
+	defer h.Unlock()
+	epoch := h.epochLocked()
+	current := cns.NewWatchRevision(epoch, h.revision)
+	var after uint64
+	if afterRevision != "" {
+		afterEpoch, n, err := afterRevision.Parse()
+		if err != nil {
+			return nil, "", err //nolint:wrapcheck // the error of the revision is returned as is
+		}
+		if afterEpoch != epoch {
+			return nil, "", errors.Wrapf(ErrRevisionEpoch, "revision %s is not of epoch %s", afterRevision, epoch)
+		}
+		after = n
+	}
+	if after > h.revision {
+		return nil, "", errors.Wrapf(ErrRevisionUnknown, "revision %s is after %s", afterRevision, current)
+	}
+	w := &watcher{
+		accept:     accept,
+		events:     make(chan cns.WatchEvent, watchHistory),
+		overflowed: make(chan struct{}),
+	}
+	if afterRevision != "" && after < h.revision {
+		// the history holds the events of the revisions up to the current one.
+		first := h.revision - uint64(len(h.history)) + 1
+		if first > after+1 {
+			return nil, "", errors.Wrapf(ErrRevisionCompacted, "revision %s is before %s", afterRevision, cns.NewWatchRevision(epoch, first-1))
+		}
+		for i := after + 1 - first; i < uint64(len(h.history)); i++ {
+			if accept == nil || accept(&h.history[i]) {
+				w.events <- h.history[i]
+			}
+		}
+	}
+	if h.watchers == nil {
+		h.watchers = map[*watcher]struct{}{}
+	}
+	h.watchers[w] = struct{}{}
+	return w, current, nil
+}
+
+func (h *watchHub) stop(w *watcher) {
+	h.Lock()
+	defer h.Unlock()
+	delete(h.watchers, w)
+}
+
+// publishIPStateTransition is the state middleware of the IPs that publishes their transitions.
+func (service *HTTPRestService) publishIPStateTransition(ipconfig *cns.IPConfigurationStatus, newState types.IPState) {
+	// the IP is copied without the middlewares, which would publish the transition again.
+	ip := cns.IPConfigurationStatus{
+		ID:        ipconfig.ID,
+		IPAddress: ipconfig.IPAddress,
+		NCID:      ipconfig.NCID,
+		PodInfo:   ipconfig.PodInfo,
+	}
+	ip.SetState(newState)
+	service.watchHub.publish(cns.WatchEvent{
+		Type:    cns.WatchIPStateChanged,
+		Time:    ip.LastStateTransition,
+		IPState: &cns.IPStateChange{IP: ip, PreviousState: ipconfig.GetState()},
+	})
+}
+
+// publishNCEvent publishes the creation, update or deletion of the NC.
+func (service *HTTPRestService) publishNCEvent(eventType cns.WatchEventType, ncID string, nc *containerstatus) {
+	info := ncDebugInfo(ncID, nc)
+	service.watchHub.publish(cns.WatchEvent{Type: eventType, NC: &info})
+}
+
+// PublishPoolSpec publishes the spec of the NodeNetworkConfig that the IPAM pool monitor updated.
+func (service *HTTPRestService) PublishPoolSpec(spec v1alpha.NodeNetworkConfigSpec) {
+	spec.IPsNotInUse = append([]string(nil), spec.IPsNotInUse...)
+	service.watchHub.publish(cns.WatchEvent{Type: cns.WatchPoolSpecChanged, PoolSpec: &spec})
+}
+
+// parseWatchRequest returns the revision to resume the watch after, from the Last-Event-ID header or the revision
+// query parameter, and the event types of the types query parameter, as a comma separated list.
+func parseWatchRequest(r *http.Request) (cns.WatchRevision, map[cns.WatchEventType]bool, error) {
+	after := cns.WatchRevision(r.Header.Get(lastEventID))
+	if after == "" {
+		after = cns.WatchRevision(r.URL.Query().Get("revision"))
+	}
+	if after != "" {
+		if _, _, err := after.Parse(); err != nil {
+			return "", nil, err //nolint:wrapcheck // the error of the revision is returned as is
+		}
+	}
+
+	var eventTypes map[cns.WatchEventType]bool
+	if param := r.URL.Query().Get("types"); param != "" {
+		eventTypes = map[cns.WatchEventType]bool{}
+		for _, t := range strings.Split(param, ",") {
+			eventType, ok := watchEventType(strings.TrimSpace(t))
+			if !ok {
+				return "", nil, errors.Wrapf(ErrUnknownWatchType, "%q", t)
+			}
+			eventTypes[eventType] = true
+		}
+	}
+	return after, eventTypes, nil
+}
+
+func watchEventType(s string) (cns.WatchEventType, bool) {
+	for _, t := range cns.WatchEventTypes {
+		if strings.EqualFold(s, string(t)) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// writeWatchEvent writes the event as a server-sent event, with its revision as its ID.
+func writeWatchEvent(w io.Writer, event *cns.WatchEvent) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal watch event")
+	}
+	if event.Revision != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", event.Revision); err != nil {
+			return errors.Wrap(err, "failed to write watch event")
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, b)
+	return errors.Wrap(err, "failed to write watch event")
+}
+
+// handleWatch streams the events of CNS after the revision of the request, or after the current revision, as
+// server-sent events. The watch is ended with an Overflow event if the watcher falls behind, and the request of
+// a revision whose events are not kept, such as a revision of another CNS process, is responded to with 410 Gone,
+// after which the watcher must list the state of CNS again.
+func (service *HTTPRestService) handleWatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	after, eventTypes, err := parseWatchRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	var accept func(*cns.WatchEvent) bool
+	if eventTypes != nil {
+		accept = func(event *cns.WatchEvent) bool {
+			return eventTypes[event.Type]
+		}
+	}
+	watcher, revision, err := service.watchHub.watch(after, accept)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+	defer service.watchHub.stop(watcher)
+	logger.Printf("[Azure CNS] Watch of %s started after revision %q at revision %s", r.RemoteAddr, after, revision)
+
+	w.Header().Set("Content-Type", contentTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set(cns.WatchRevisionHeader, string(revision))
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(watchHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-watcher.overflowed:
+			// the events that were sent before the overflow are written first, so that the watch can be resumed.
+			for len(watcher.events) > 0 {
+				event := <-watcher.events
+				if err := writeWatchEvent(w, &event); err != nil {
+					return
+				}
+			}
+			_ = writeWatchEvent(w, &cns.WatchEvent{Type: cns.WatchOverflow, Time: time.Now()})
+			flusher.Flush()
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event := <-watcher.events:
+			if err := writeWatchEvent(w, &event); err != nil {
+				logger.Errorf("[Azure CNS] Ending the watch of %s: %v", r.RemoteAddr, err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
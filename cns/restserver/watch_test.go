@@ -0,0 +1,204 @@
+package restserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchHubResume(t *testing.T) {
+	h := watchHub{epoch: "e1"}
+	for i := 0; i < 3; i++ {
+		h.publish(cns.WatchEvent{Type: cns.WatchNCCreated})
+	}
+
+	// a watch that is resumed receives the events after its revision.
+	w, revision, err := h.watch("e1.1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, cns.WatchRevision("e1.3"), revision)
+	require.Len(t, w.events, 2)
+	event := <-w.events
+	assert.Equal(t, cns.WatchRevision("e1.2"), event.Revision)
+	assert.False(t, event.Time.IsZero())
+
+	// a watch that is resumed from the start of the epoch receives all of its events.
+	w, _, err = h.watch("e1.0", nil)
+	require.NoError(t, err)
+	require.Len(t, w.events, 3)
+	assert.Equal(t, cns.WatchRevision("e1.1"), (<-w.events).Revision)
+
+	// a new watch receives the events after it started.
+	w, _, err = h.watch("", nil)
+	require.NoError(t, err)
+	assert.Empty(t, w.events)
+	h.publish(cns.WatchEvent{Type: cns.WatchNCDeleted})
+	event = <-w.events
+	assert.Equal(t, cns.WatchRevision("e1.4"), event.Revision)
+	assert.Equal(t, cns.WatchNCDeleted, event.Type)
+
+	_, _, err = h.watch("e1.5", nil)
+	require.ErrorIs(t, err, ErrRevisionUnknown)
+
+	// the revisions of another CNS process can not be resumed after, even if this process reached them.
+	_, _, err = h.watch("e0.2", nil)
+	require.ErrorIs(t, err, ErrRevisionEpoch)
+	_, _, err = h.watch("2", nil)
+	require.ErrorIs(t, err, cns.ErrInvalidWatchRevision)
+}
+
+func TestWatchHubCompacted(t *testing.T) {
+	h := watchHub{epoch: "e1"}
+	for i := 0; i < watchHistory+2; i++ {
+		h.publish(cns.WatchEvent{Type: cns.WatchNCUpdated})
+	}
+
+	_, _, err := h.watch("e1.1", nil)
+	require.ErrorIs(t, err, ErrRevisionCompacted)
+
+	// the oldest revision that is kept can still be resumed after.
+	w, _, err := h.watch("e1.2", nil)
+	require.NoError(t, err)
+	assert.Len(t, w.events, watchHistory)
+}
+
+func TestWatchHubOverflow(t *testing.T) {
+	var h watchHub
+	ncs, _, err := h.watch("", func(event *cns.WatchEvent) bool { return event.Type == cns.WatchNCCreated })
+	require.NoError(t, err)
+	ips, _, err := h.watch("", func(event *cns.WatchEvent) bool { return event.Type == cns.WatchIPStateChanged })
+	require.NoError(t, err)
+
+	for i := 0; i <= watchHistory; i++ {
+		h.publish(cns.WatchEvent{Type: cns.WatchNCCreated})
+	}
+
+	// the watch that fell behind is ended, and the other one is not sent the events of other types.
+	<-ncs.overflowed
+	assert.Len(t, ncs.events, watchHistory)
+	assert.Empty(t, ips.events)
+	assert.NotContains(t, h.watchers, ncs)
+	assert.Contains(t, h.watchers, ips)
+}
+
+func TestPublishIPStateTransition(t *testing.T) {
+	svc := getTestService()
+	w, _, err := svc.watchHub.watch("", nil)
+	require.NoError(t, err)
+
+	ip := NewPodState(testIP1, testIPID1, testNCID, types.Available, 0)
+	ip.WithStateMiddleware(svc.publishIPStateTransition)
+	ip.PodInfo = testPod1Info
+	ip.SetState(types.Assigned)
+
+	event := <-w.events
+	require.Equal(t, cns.WatchIPStateChanged, event.Type)
+	assert.Equal(t, types.Available, event.IPState.PreviousState)
+	assert.Equal(t, types.Assigned, event.IPState.IP.GetState())
+	assert.Equal(t, testIPID1, event.IPState.IP.ID)
+	assert.True(t, testPod1Info.Equals(event.IPState.IP.PodInfo))
+	assert.Empty(t, w.events)
+}
+
+// sseEvent is a server-sent event read by readSSE.
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// readSSE reads the next event of the stream, skipping the comments.
+func readSSE(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var e sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if e.data != "" {
+				return e
+			}
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestHandleWatch(t *testing.T) {
+	svc := getTestService()
+	svc.watchHub.epoch = "e1"
+	srv := httptest.NewServer(http.HandlerFunc(svc.handleWatch))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "?types=NCCreated,ncdeleted") //nolint:noctx // test
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, contentTypeEventStream, res.Header.Get("Content-Type"))
+	assert.Equal(t, "e1.0", res.Header.Get(cns.WatchRevisionHeader))
+
+	state := NewPodState(testIP1, testIPID1, testNCID, types.Available, 0)
+	require.NoError(t, UpdatePodIPConfigState(t, svc, map[string]cns.IPConfigurationStatus{state.ID: state}, testNCID))
+	require.Equal(t, types.Success, svc.DeleteNetworkContainerInternal(cns.DeleteNetworkContainerRequest{NetworkContainerid: testNCID}))
+
+	r := bufio.NewReader(res.Body)
+	created := readSSE(t, r)
+	assert.Equal(t, string(cns.WatchNCCreated), created.event)
+	var event cns.WatchEvent
+	require.NoError(t, json.Unmarshal([]byte(created.data), &event))
+	assert.Equal(t, created.id, string(event.Revision))
+	require.NotNil(t, event.NC)
+	assert.Equal(t, testNCID, event.NC.ID)
+	assert.Equal(t, 1, event.NC.SecondaryIPCount)
+	deleted := readSSE(t, r)
+	assert.Equal(t, string(cns.WatchNCDeleted), deleted.event)
+
+	// a watch that is resumed after the created NC receives its deletion.
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"?types=NCDeleted", http.NoBody) //nolint:noctx // test
+	require.NoError(t, err)
+	req.Header.Set(lastEventID, created.id)
+	resumed, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resumed.Body.Close()
+	require.Equal(t, http.StatusOK, resumed.StatusCode)
+	assert.Equal(t, deleted, readSSE(t, bufio.NewReader(resumed.Body)))
+}
+
+func TestHandleWatchInvalidRequest(t *testing.T) {
+	svc := getTestService()
+	svc.watchHub.epoch = "e1"
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		code   int
+	}{
+		{name: "method", method: http.MethodPost, code: http.StatusMethodNotAllowed},
+		{name: "revision", method: http.MethodGet, query: "?revision=first", code: http.StatusBadRequest},
+		{name: "type", method: http.MethodGet, query: "?types=NCCreated,Stuck", code: http.StatusBadRequest},
+		{name: "revision without epoch", method: http.MethodGet, query: "?revision=1", code: http.StatusBadRequest},
+		{name: "future revision", method: http.MethodGet, query: "?revision=e1.100", code: http.StatusGone},
+		{name: "revision of another epoch", method: http.MethodGet, query: "?revision=e0.1", code: http.StatusGone},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			svc.handleWatch(w, httptest.NewRequest(tt.method, cns.PathDebugWatch+tt.query, http.NoBody))
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
@@ -6,10 +6,12 @@ package cns
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
 
 	"github.com/Azure/azure-container-networking/cns/common"
@@ -21,6 +23,8 @@ import (
 	"github.com/Azure/azure-container-networking/store"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/pkg/errors"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
 const (
@@ -34,6 +38,9 @@ type Service struct {
 	*common.Service
 	EndpointType string
 	Listener     *acn.Listener
+	UnixListener *acn.Listener
+	// stopTLSRefresh stops the refresh of the TLS certificate.
+	stopTLSRefresh context.CancelFunc
 }
 
 // NewService creates a new Service object.
@@ -80,6 +87,10 @@ func (service *Service) Initialize(config *common.ServiceConfig) error {
 			return err
 		}
 
+		if config.Middleware != nil {
+			listener.Use(config.Middleware)
+		}
+
 		if config.TlsSettings.TLSPort != "" {
 			// listener.URL.Host will always be hostname:port, passed in to CNS via CNS command
 			// else it will default to localhost
@@ -88,12 +99,22 @@ func (service *Service) Initialize(config *common.ServiceConfig) error {
 			tlsAddress := net.JoinHostPort(hostParts[0], config.TlsSettings.TLSPort)
 
 			// Start the listener and HTTP and HTTPS server.
-			tlsConfig, err := getTLSConfig(config.TlsSettings, config.ErrChan)
+			ctx, cancel := context.WithCancel(context.Background())
+			service.stopTLSRefresh = cancel
+			tlsConfig, err := getTLSConfig(ctx, config.TlsSettings, config.ErrChan)
 			if err != nil {
 				log.Printf("Failed to compose Tls Configuration with error: %+v", err)
 				return errors.Wrap(err, "could not get tls config")
 			}
 
+			if config.TlsSettings.TLSClientCACertificatePath != "" {
+				clientCAs, err := loadClientCAs(config.TlsSettings.TLSClientCACertificatePath)
+				if err != nil {
+					return err
+				}
+				listener.RequireClientCertificates(clientCAs)
+			}
+
 			if err := listener.StartTLS(config.ErrChan, tlsConfig, tlsAddress); err != nil {
 				return err
 			}
@@ -105,60 +126,111 @@ func (service *Service) Initialize(config *common.ServiceConfig) error {
 
 	service.Listener = config.Listener
 
+	if config.UnixSocketPath != "" {
+		listener, err := acn.NewListener(&url.URL{Scheme: "unix", Path: config.UnixSocketPath})
+		if err != nil {
+			return err
+		}
+		if config.UnixSocketMiddleware != nil {
+			listener.Use(config.UnixSocketMiddleware)
+		}
+		// the socket serves the handlers registered on the main listener, as restricted by the middleware
+		listener.GetMux().Handle("/", service.Listener.GetMux())
+		service.UnixListener = listener
+	}
+
 	log.Debugf("[Azure CNS] Successfully initialized a service with config: %+v", config)
 	return nil
 }
 
-func getTLSConfig(tlsSettings localtls.TlsSettings, errChan chan<- error) (*tls.Config, error) {
+// getTLSConfig returns the TLS config of the configured certificate source. The certificate is refreshed until
+// the context is done.
+func getTLSConfig(ctx context.Context, tlsSettings localtls.TlsSettings, errChan chan<- error) (*tls.Config, error) {
 	if tlsSettings.TLSCertificatePath != "" {
-		return getTLSConfigFromFile(tlsSettings)
+		return getTLSConfigFromFile(ctx, tlsSettings, errChan)
+	}
+
+	if tlsSettings.TLSSecretName != "" {
+		return getTLSConfigFromSecret(ctx, tlsSettings, errChan)
 	}
 
 	if tlsSettings.KeyVaultURL != "" {
-		return getTLSConfigFromKeyVault(tlsSettings, errChan)
+		return getTLSConfigFromKeyVault(ctx, tlsSettings, errChan)
 	}
 
 	return nil, errors.Errorf("invalid tls settings: %+v", tlsSettings)
 }
 
-func getTLSConfigFromFile(tlsSettings localtls.TlsSettings) (*tls.Config, error) {
-	tlsCertRetriever, err := localtls.GetTlsCertificateRetriever(tlsSettings)
+// loadClientCAs reads the PEM bundle of the CAs that client certificates are verified against.
+func loadClientCAs(path string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(path)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get certificate retriever")
+		return nil, errors.Wrapf(err, "failed to read client CA certificates %s", path)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, errors.Errorf("no client CA certificates found in %s", path)
 	}
+	return pool, nil
+}
 
-	leafCertificate, err := tlsCertRetriever.GetCertificate()
+// reportRefreshError sends the error of a certificate refresh to errChan, unless the refresh was stopped.
+func reportRefreshError(ctx context.Context, errChan chan<- error, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	errChan <- err
+}
+
+func getTLSConfigFromFile(ctx context.Context, tlsSettings localtls.TlsSettings, errChan chan<- error) (*tls.Config, error) {
+	w, err := localtls.NewFileTlsCertificateWatcher(tlsSettings, logger.Log)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get certificate")
+		return nil, errors.Wrap(err, "failed to get certificate retriever")
 	}
 
-	if leafCertificate == nil {
-		return nil, errors.New("certificate retrieval returned empty")
+	go func() {
+		reportRefreshError(ctx, errChan, w.Watch(ctx, tlsSettings.TLSRefreshInterval))
+	}()
+
+	tlsConfig := &tls.Config{
+		MaxVersion:     tls.VersionTLS13,
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: w.GetTLSCertificate,
 	}
 
-	privateKey, err := tlsCertRetriever.GetPrivateKey()
+	return tlsConfig, nil
+}
+
+func getTLSConfigFromSecret(ctx context.Context, tlsSettings localtls.TlsSettings, errChan chan<- error) (*tls.Config, error) {
+	kubeConfig, err := config.GetConfig()
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get certificate private key")
+		return nil, errors.Wrap(err, "failed to get kubeconfig")
 	}
 
-	tlsCert := tls.Certificate{
-		Certificate: [][]byte{leafCertificate.Raw},
-		PrivateKey:  privateKey,
-		Leaf:        leafCertificate,
+	clientset, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build clientset")
 	}
 
+	r, err := localtls.NewSecretTlsCertificateRetriever(ctx, clientset.CoreV1().Secrets(tlsSettings.TLSSecretNamespace), tlsSettings.TLSSecretName, logger.Log)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create secret certificate retriever")
+	}
+
+	go func() {
+		reportRefreshError(ctx, errChan, r.Watch(ctx, tlsSettings.TLSRefreshInterval))
+	}()
+
 	tlsConfig := &tls.Config{
-		MaxVersion: tls.VersionTLS13,
-		MinVersion: tls.VersionTLS12,
-		Certificates: []tls.Certificate{
-			tlsCert,
-		},
+		MinVersion:     tls.VersionTLS12,
+		MaxVersion:     tls.VersionTLS13,
+		GetCertificate: r.GetTLSCertificate,
 	}
 
 	return tlsConfig, nil
 }
 
-func getTLSConfigFromKeyVault(tlsSettings localtls.TlsSettings, errChan chan<- error) (*tls.Config, error) {
+func getTLSConfigFromKeyVault(ctx context.Context, tlsSettings localtls.TlsSettings, errChan chan<- error) (*tls.Config, error) {
 	credOpts := azidentity.ManagedIdentityCredentialOptions{ID: azidentity.ResourceID(tlsSettings.MSIResourceID)}
 	cred, err := azidentity.NewManagedIdentityCredential(&credOpts)
 	if err != nil {
@@ -170,22 +242,25 @@ func getTLSConfigFromKeyVault(tlsSettings localtls.TlsSettings, errChan chan<- e
 		return nil, errors.Wrap(err, "could not create new keyvault shim")
 	}
 
-	ctx := context.TODO()
-
 	cr, err := keyvault.NewCertRefresher(ctx, kvs, logger.Log, tlsSettings.KeyVaultCertificateName)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create new cert refresher")
 	}
 
+	localtls.SetKeyVaultCertificateExpiry(cr.GetCertificate().Leaf)
+
 	go func() {
-		errChan <- cr.Refresh(ctx, tlsSettings.KeyVaultCertificateRefreshInterval)
+		reportRefreshError(ctx, errChan, cr.Refresh(ctx, tlsSettings.KeyVaultCertificateRefreshInterval))
 	}()
 
 	tlsConfig := tls.Config{
 		MinVersion: tls.VersionTLS12,
 		MaxVersion: tls.VersionTLS13,
 		GetCertificate: func(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
-			return cr.GetCertificate(), nil
+			// the refresher replaces the certificate in the background, so the expiry is recorded as it is served
+			cert := cr.GetCertificate()
+			localtls.SetKeyVaultCertificateExpiry(cert.Leaf)
+			return cert, nil
 		},
 	}
 
@@ -204,6 +279,11 @@ func (service *Service) StartListener(config *common.ServiceConfig) error {
 		if err := service.Listener.Start(config.ErrChan); err != nil {
 			return err
 		}
+		if service.UnixListener != nil {
+			if err := service.UnixListener.Start(config.ErrChan); err != nil {
+				return err
+			}
+		}
 	} else {
 		return fmt.Errorf("Failed to start a listener, it is not initialized, config %+v", config)
 	}
@@ -213,7 +293,13 @@ func (service *Service) StartListener(config *common.ServiceConfig) error {
 
 // Uninitialize cleans up the plugin.
 func (service *Service) Uninitialize() {
+	if service.stopTLSRefresh != nil {
+		service.stopTLSRefresh()
+	}
 	service.Listener.Stop()
+	if service.UnixListener != nil {
+		service.UnixListener.Stop()
+	}
 	service.Service.Uninitialize()
 }
 
@@ -22,16 +22,19 @@ import (
 	"github.com/Azure/azure-container-networking/cnm/ipam"
 	"github.com/Azure/azure-container-networking/cnm/network"
 	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/authz"
 	cnscli "github.com/Azure/azure-container-networking/cns/cmd/cli"
 	"github.com/Azure/azure-container-networking/cns/cniconflist"
 	"github.com/Azure/azure-container-networking/cns/cnireconciler"
 	"github.com/Azure/azure-container-networking/cns/common"
 	"github.com/Azure/azure-container-networking/cns/configuration"
+	"github.com/Azure/azure-container-networking/cns/events"
 	"github.com/Azure/azure-container-networking/cns/healthserver"
 	"github.com/Azure/azure-container-networking/cns/hnsclient"
 	"github.com/Azure/azure-container-networking/cns/ipampool"
 	cssctrl "github.com/Azure/azure-container-networking/cns/kubecontroller/clustersubnetstate"
 	nncctrl "github.com/Azure/azure-container-networking/cns/kubecontroller/nodenetworkconfig"
+	"github.com/Azure/azure-container-networking/cns/localipam"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/multitenantcontroller"
 	"github.com/Azure/azure-container-networking/cns/multitenantcontroller/multitenantoperator"
@@ -51,19 +54,23 @@ import (
 	localtls "github.com/Azure/azure-container-networking/server/tls"
 	"github.com/Azure/azure-container-networking/store"
 	"github.com/Azure/azure-container-networking/telemetry"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/avast/retry-go/v3"
 	"github.com/pkg/errors"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	kuberuntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 const (
@@ -415,10 +422,54 @@ func sendRegisterNodeRequest(httpc *http.Client, httpRestService cns.HTTPService
 	return nil
 }
 
-func startTelemetryService(ctx context.Context) {
+// newAuthorizer creates the authorizer of the CNS API callers from the authorization rules of the config.
+func newAuthorizer(settings configuration.AuthorizationSettings) (*authz.Authorizer, error) {
+	rules := make([]authz.Rule, len(settings.Rules))
+	for i, r := range settings.Rules {
+		rules[i] = authz.Rule{
+			Subjects:  r.Subjects,
+			UIDs:      r.UIDs,
+			Anonymous: r.Anonymous,
+		}
+		for _, group := range r.RouteGroups {
+			rules[i].Groups = append(rules[i].Groups, authz.RouteGroup(group))
+		}
+	}
+	authorizer, err := authz.New(rules)
+	return authorizer, errors.Wrap(err, "invalid authorization rules")
+}
+
+// newUnixSocketAuthorizer creates the authorizer of the Unix socket, which allows the peers running as one of the
+// allowed UIDs, or as root, to call the routes of the CNI.
+func newUnixSocketAuthorizer(settings configuration.UnixSocketSettings) (*authz.Authorizer, error) {
+	uids := settings.AllowedUIDs
+	if len(uids) == 0 {
+		uids = []uint32{0}
+	}
+	authorizer, err := authz.New([]authz.Rule{{UIDs: uids, Groups: []authz.RouteGroup{authz.CNI}}})
+	return authorizer, errors.Wrap(err, "invalid unix socket settings")
+}
+
+// newGRPCAuthorizer creates the authorizer of the gRPC API, which allows the peers running as one of the allowed
+// UIDs, or as root, to call the IPAM API of the CNI.
+func newGRPCAuthorizer(settings configuration.GRPCSettings) (*authz.Authorizer, error) {
+	uids := settings.AllowedUIDs
+	if len(uids) == 0 {
+		uids = []uint32{0}
+	}
+	authorizer, err := authz.New([]authz.Rule{{UIDs: uids, Groups: []authz.RouteGroup{authz.CNI}}})
+	return authorizer, errors.Wrap(err, "invalid grpc settings")
+}
+
+func startTelemetryService(ctx context.Context, otlpEndpoint string) {
 	var config aitelemetry.AIConfig
 
-	err := telemetry.CreateAITelemetryHandle(config, false, false, false)
+	var err error
+	if otlpEndpoint != "" {
+		err = telemetry.CreateOTLPTelemetryHandle(otlpEndpoint, config, false, false, false)
+	} else {
+		err = telemetry.CreateAITelemetryHandle(config, false, false, false)
+	}
 	if err != nil {
 		log.Errorf("AI telemetry handle creation failed..:%w", err)
 		return
@@ -434,6 +485,8 @@ func startTelemetryService(ctx context.Context) {
 		log.Errorf("Telemetry service failed to start: %w", err)
 		return
 	}
+	// send the reports CNI spooled while the service was unavailable
+	go tb.DrainSpool(telemetry.NewDefaultSpool())
 	tb.PushData(rootCtx)
 }
 
@@ -538,13 +591,13 @@ func main() {
 
 		switch scenario := cniConflistScenario(scenarioString); scenario {
 		case scenarioV4Overlay:
-			conflistGenerator = &cniconflist.V4OverlayGenerator{Writer: writer}
+			conflistGenerator = &cniconflist.V4OverlayGenerator{Writer: writer, MTU: cnsconfig.CNIConflistMTU}
 		case scenarioDualStackOverlay:
-			conflistGenerator = &cniconflist.DualStackOverlayGenerator{Writer: writer}
+			conflistGenerator = &cniconflist.DualStackOverlayGenerator{Writer: writer, MTU: cnsconfig.CNIConflistMTU}
 		case scenarioOverlay:
-			conflistGenerator = &cniconflist.OverlayGenerator{Writer: writer}
+			conflistGenerator = &cniconflist.OverlayGenerator{Writer: writer, MTU: cnsconfig.CNIConflistMTU}
 		case scenarioCilium:
-			conflistGenerator = &cniconflist.CiliumGenerator{Writer: writer}
+			conflistGenerator = &cniconflist.CiliumGenerator{Writer: writer, MTU: cnsconfig.CNIConflistMTU}
 		default:
 			logger.Errorf("unable to generate cni conflist for unknown scenario: %s", scenario)
 			os.Exit(1)
@@ -553,13 +606,17 @@ func main() {
 
 	// start the health server
 	z, _ := zap.NewProduction()
-	go healthserver.Start(z, cnsconfig.MetricsBindAddress)
+	healthChecks := healthserver.NewChecks()
+	go healthserver.Start(z, cnsconfig.MetricsBindAddress, healthChecks)
 
 	nmaConfig, err := nmagent.NewConfig(cnsconfig.WireserverIP)
 	if err != nil {
 		logger.Errorf("[Azure CNS] Failed to produce NMAgent config from the supplied wireserver ip: %v", err)
 		return
 	}
+	nmaConfig.CircuitBreakerThreshold = cnsconfig.NMAgentCircuitBreakerThreshold
+	nmaConfig.CircuitBreakerCooldown = time.Duration(cnsconfig.NMAgentCircuitBreakerCooldownSecs) * time.Second
+	nmaConfig.MetricsRegisterer = metrics.Registry
 
 	nmaClient, err := nmagent.NewClient(nmaConfig)
 	if err != nil {
@@ -567,6 +624,11 @@ func main() {
 		return
 	}
 
+	// the NCs of the node-local IP pools are not programmed by NMAgent, so CNS does not need it to be ready.
+	if cnsconfig.ChannelMode != cns.LocalIPAM {
+		healthChecks.AddReadyzCheck("nmagent", healthserver.Threshold(healthserver.NMAgent(nmaClient), cnsconfig.HealthCheckFailureThreshold))
+	}
+
 	homeAzMonitor := restserver.NewHomeAzMonitor(nmaClient, time.Duration(cnsconfig.AZRSettings.PopulateHomeAzCacheRetryIntervalSecs)*time.Second)
 	if cnsconfig.AZRSettings.EnableAZR {
 		logger.Printf("start the goroutine for refreshing homeAz")
@@ -582,6 +644,8 @@ func main() {
 		config.ChannelMode = cns.CRD
 	} else if cnsconfig.ChannelMode == cns.MultiTenantCRD {
 		config.ChannelMode = cns.MultiTenantCRD
+	} else if cnsconfig.ChannelMode == cns.LocalIPAM {
+		config.ChannelMode = cns.LocalIPAM
 	} else if acn.GetArg(acn.OptManaged).(bool) {
 		config.ChannelMode = cns.Managed
 	}
@@ -599,7 +663,9 @@ func main() {
 			DebugMode:                    ts.DebugMode,
 		}
 
-		if aiKey := cnsconfig.TelemetrySettings.AppInsightsInstrumentationKey; aiKey != "" {
+		if ts.OTLPEndpoint != "" {
+			logger.InitOTLP(aiConfig, ts.OTLPEndpoint, ts.DisableTrace, ts.DisableMetric, ts.DisableEvent)
+		} else if aiKey := cnsconfig.TelemetrySettings.AppInsightsInstrumentationKey; aiKey != "" {
 			logger.InitAIWithIKey(aiConfig, aiKey, ts.DisableTrace, ts.DisableMetric, ts.DisableEvent)
 		} else {
 			logger.InitAI(aiConfig, ts.DisableTrace, ts.DisableMetric, ts.DisableEvent)
@@ -607,8 +673,17 @@ func main() {
 	}
 
 	if telemetryDaemonEnabled {
-		go startTelemetryService(rootCtx)
+		go startTelemetryService(rootCtx, cnsconfig.TelemetrySettings.OTLPEndpoint)
+	}
+
+	var spanExporter sdktrace.SpanExporter
+	if endpoint := cnsconfig.TelemetrySettings.TracingEndpoint; endpoint != "" {
+		if spanExporter, err = tracing.NewOTLPExporter(endpoint); err != nil {
+			logger.Errorf("Failed to create span exporter, spans will not be exported: %v", err)
+			spanExporter = nil
+		}
 	}
+	shutdownTracing := tracing.Init(name, spanExporter)
 
 	// Log platform information.
 	logger.Printf("Running on %v", platform.GetOSInfo())
@@ -632,6 +707,8 @@ func main() {
 		logger.Errorf("Failed to create store file: %s, due to error %v\n", storeFileName, err)
 		return
 	}
+	healthChecks.AddReadyzCheck("store", healthserver.Threshold(healthserver.Writable(storeFileLocation), cnsconfig.HealthCheckFailureThreshold))
+	healthChecks.AddLivezCheck("store", healthserver.Threshold(healthserver.Writable(storeFileLocation), cnsconfig.HealthCheckFailureThreshold))
 
 	// Initialize endpoint state store if cns is managing endpoint state.
 	if cnsconfig.ManageEndpointState {
@@ -662,13 +739,25 @@ func main() {
 		HTTPClient: &http.Client{},
 	}
 
-	httpRestService, err := restserver.NewHTTPRestService(&config, &wireserver.Client{HTTPClient: &http.Client{}}, &wsProxy, nmaClient,
+	// Pods get the primary interface of the host that the wireserver reports with their IPs, or the network
+	// interface of the host with the node IP if there is no wireserver for the node-local IP pools.
+	var interfaces interfaceGetter = &wireserver.Client{HTTPClient: &http.Client{}}
+	if config.ChannelMode == cns.LocalIPAM {
+		interfaces = localipam.NewHostInterfaces(configuration.NodeIP())
+	}
+
+	httpRestService, err := restserver.NewHTTPRestService(&config, interfaces, &wsProxy, nmaClient,
 		endpointStateStore, conflistGenerator, homeAzMonitor)
 	if err != nil {
 		logger.Errorf("Failed to create CNS object, err:%v.\n", err)
 		return
 	}
 
+	httpRestService.SetNCProgrammingOptions(restserver.NCProgrammingOptions{
+		Timeout:    time.Duration(cnsconfig.NCProgrammingTimeoutSecs) * time.Second,
+		MaxRetries: cnsconfig.NCProgrammingMaxRetries,
+	})
+
 	// Set CNS options.
 	httpRestService.SetOption(acn.OptCnsURL, cnsURL)
 	httpRestService.SetOption(acn.OptNetPluginPath, cniPath)
@@ -696,6 +785,10 @@ func main() {
 				TLSSubjectName:                     cnsconfig.TLSSubjectName,
 				TLSCertificatePath:                 cnsconfig.TLSCertificatePath,
 				TLSPort:                            cnsconfig.TLSPort,
+				TLSSecretNamespace:                 cnsconfig.TLSSecretNamespace,
+				TLSSecretName:                      cnsconfig.TLSSecretName,
+				TLSRefreshInterval:                 time.Duration(cnsconfig.TLSRefreshIntervalInSecs) * time.Second,
+				TLSClientCACertificatePath:         cnsconfig.TLSClientCACertificatePath,
 				KeyVaultURL:                        cnsconfig.KeyVaultSettings.URL,
 				KeyVaultCertificateName:            cnsconfig.KeyVaultSettings.CertificateName,
 				MSIResourceID:                      cnsconfig.MSISettings.ResourceID,
@@ -703,11 +796,46 @@ func main() {
 			}
 		}
 
+		if len(cnsconfig.AuthorizationSettings.Rules) > 0 {
+			authorizer, err := newAuthorizer(cnsconfig.AuthorizationSettings)
+			if err != nil {
+				logger.Errorf("Failed to create CNS API authorizer, err:%v.\n", err)
+				return
+			}
+			config.Middleware = authorizer.Middleware
+			logger.Printf("[Azure CNS] Authorizing CNS API callers with %d rules", len(cnsconfig.AuthorizationSettings.Rules))
+		}
+
+		if cnsconfig.UnixSocketSettings.Enable {
+			authorizer, err := newUnixSocketAuthorizer(cnsconfig.UnixSocketSettings)
+			if err != nil {
+				logger.Errorf("Failed to create CNS Unix socket authorizer, err:%v.\n", err)
+				return
+			}
+			config.UnixSocketPath = cnsconfig.UnixSocketSettings.Path
+			config.UnixSocketMiddleware = authorizer.Middleware
+			logger.Printf("[Azure CNS] Serving CNI routes on Unix socket %s", config.UnixSocketPath)
+		}
+
+		if cnsconfig.GRPCSettings.Enable {
+			authorizer, err := newGRPCAuthorizer(cnsconfig.GRPCSettings)
+			if err != nil {
+				logger.Errorf("Failed to create CNS gRPC authorizer, err:%v.\n", err)
+				return
+			}
+			config.GRPCSocketPath = cnsconfig.GRPCSettings.Path
+			config.GRPCAuthorize = func(id acn.Identity) bool {
+				return authorizer.AllowedGroup(id, authz.CNI)
+			}
+		}
+
 		err = httpRestService.Init(&config)
 		if err != nil {
 			logger.Errorf("Failed to init HTTPService, err:%v.\n", err)
 			return
 		}
+		// CNS is not ready until the listener is started, after its state is initialized
+		healthChecks.AddReadyzCheck("listener", healthserver.Listening(config.Listener.URL))
 	}
 
 	// Setting the remote ARP MAC address to 12-34-56-78-9a-bc on windows for external traffic
@@ -728,9 +856,9 @@ func main() {
 		}
 	}
 
-	// Initialze state in if CNS is running in CRD mode
+	// Initialze state in if CNS is running in CRD or LocalIPAM mode
 	// State must be initialized before we start HTTPRestService
-	if config.ChannelMode == cns.CRD {
+	if config.ChannelMode == cns.CRD || config.ChannelMode == cns.LocalIPAM {
 		// Check the CNI statefile mount, and if the file is empty
 		// stub an empty JSON object
 		if err := cnireconciler.WriteObjectToCNIStatefile(); err != nil {
@@ -766,10 +894,18 @@ func main() {
 
 		logger.Printf("Set GlobalPodInfoScheme %v (InitializeFromCNI=%t)", cns.GlobalPodInfoScheme, cnsconfig.InitializeFromCNI)
 
-		err = InitializeCRDState(rootCtx, httpRestService, cnsconfig)
-		if err != nil {
-			logger.Errorf("Failed to start CRD Controller, err:%v.\n", err)
-			return
+		if config.ChannelMode == cns.LocalIPAM {
+			err = InitializeLocalIPAMState(rootCtx, httpRestService, cnsconfig)
+			if err != nil {
+				logger.Errorf("Failed to initialize local IPAM state, err:%v.\n", err)
+				return
+			}
+		} else {
+			err = InitializeCRDState(rootCtx, httpRestService, cnsconfig, healthChecks)
+			if err != nil {
+				logger.Errorf("Failed to start CRD Controller, err:%v.\n", err)
+				return
+			}
 		}
 	}
 
@@ -795,6 +931,11 @@ func main() {
 			logger.Errorf("Failed to start CNS, err:%v.\n", err)
 			return
 		}
+		healthChecks.AddLivezCheck("listener", healthserver.Threshold(healthserver.Listening(config.Listener.URL), cnsconfig.HealthCheckFailureThreshold))
+	}
+
+	if cnsconfig.TransparentVlanReconcileIntervalSecs > 0 {
+		go cnireconciler.ReconcileTransparentVlan(rootCtx, cniConfigFile, time.Duration(cnsconfig.TransparentVlanReconcileIntervalSecs)*time.Second)
 	}
 
 	if !disableTelemetry {
@@ -830,7 +971,7 @@ func main() {
 			tickerChannel := time.Tick(time.Duration(cnsconfig.ManagedSettings.NodeSyncIntervalInSeconds) * time.Second)
 			for {
 				<-tickerChannel
-				httpRestService.SyncNodeStatus(ep, vnet, node, json.RawMessage{})
+				httpRestService.SyncNodeStatus(rootCtx, ep, vnet, node, json.RawMessage{})
 			}
 		}(privateEndpoint, infravnet, nodeID)
 	}
@@ -936,6 +1077,12 @@ func main() {
 		log.Errorf("lockclient cns unlock error:%v", err)
 	}
 
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), 5*time.Second)
+	if err = shutdownTracing(tracingCtx); err != nil {
+		logger.Errorf("Failed to export spans: %v", err)
+	}
+	cancelTracing()
+
 	logger.Printf("CNS exited")
 	logger.Close()
 }
@@ -1013,6 +1160,10 @@ func InitializeMultiTenantController(ctx context.Context, httpRestService cns.HT
 	return nil
 }
 
+type interfaceGetter interface {
+	GetInterfaces(context.Context) (*wireserver.GetInterfacesResult, error)
+}
+
 type nodeNetworkConfigGetter interface {
 	Get(context.Context) (*v1alpha.NodeNetworkConfig, error)
 }
@@ -1080,8 +1231,9 @@ func reconcileInitialCNSState(ctx context.Context, cli nodeNetworkConfigGetter,
 	return nil
 }
 
-// InitializeCRDState builds and starts the CRD controllers.
-func InitializeCRDState(ctx context.Context, httpRestService cns.HTTPService, cnsconfig *configuration.CNSConfig) error {
+// InitializeLocalIPAMState initializes the IPAM state of CNS with the node-local IP pools of its LocalIPAMSettings,
+// instead of with the NCs of the NodeNetworkConfig. The pools do not scale, so there are no controllers to start.
+func InitializeLocalIPAMState(ctx context.Context, httpRestService cns.HTTPService, cnsconfig *configuration.CNSConfig) error {
 	// convert interface type to implementation type
 	httpRestServiceImplementation, ok := httpRestService.(*restserver.HTTPRestService)
 	if !ok {
@@ -1099,7 +1251,7 @@ func InitializeCRDState(ctx context.Context, httpRestService cns.HTTPService, cn
 	// build default clientset.
 	kubeConfig, err := ctrl.GetConfig()
 	if err != nil {
-		logger.Errorf("[Azure CNS] Failed to get kubeconfig for request controller: %v", err)
+		logger.Errorf("[Azure CNS] Failed to get kubeconfig for local IPAM: %v", err)
 		return errors.Wrap(err, "failed to get kubeconfig")
 	}
 	kubeConfig.UserAgent = fmt.Sprintf("azure-cns-%s", version)
@@ -1115,7 +1267,84 @@ func InitializeCRDState(ctx context.Context, httpRestService cns.HTTPService, cn
 		return errors.Wrap(err, "failed to get NodeName")
 	}
 
+	var source localipam.Source
+	switch cnsconfig.LocalIPAMSettings.Source {
+	case localipam.SourceFile:
+		logger.Printf("Using the local IP pools of %s", cnsconfig.LocalIPAMSettings.File)
+		source = localipam.NewFileSource(cnsconfig.LocalIPAMSettings.File)
+	case localipam.SourceNodePodCIDR, "":
+		logger.Printf("Using the local IP pools of the podCIDRs of node %s", nodeName)
+		source = localipam.NewNodePodCIDRSource(clientset.CoreV1(), nodeName)
+	default:
+		return errors.Errorf("unknown local IPAM source %q", cnsconfig.LocalIPAMSettings.Source)
+	}
+
+	podInfoByIPProvider, err := newPodInfoByIPProvider(ctx, cnsconfig, httpRestServiceImplementation, clientset, nodeName)
+	if err != nil {
+		return err
+	}
+
+	// the Monitor reports the pools that CNS is initialized with.
+	poolMonitor := localipam.NewMonitor(source, httpRestServiceImplementation.PublishPoolSpec)
+	httpRestServiceImplementation.IPAMPoolMonitor = poolMonitor
+	httpRestServiceImplementation.GetNodeNetworkConfig = source.Get
+
+	logger.Printf("Reconciling initial CNS state")
+	attempt := 0
+	err = retry.Do(func() error {
+		attempt++
+		logger.Printf("reconciling initial CNS state attempt: %d", attempt)
+		err = reconcileInitialCNSState(ctx, poolMonitor, httpRestServiceImplementation, podInfoByIPProvider)
+		if err != nil {
+			logger.Errorf("failed to reconcile initial CNS state, attempt: %d err: %v", attempt, err)
+		}
+		return errors.Wrap(err, "failed to initialize CNS state")
+	}, retry.Context(ctx), retry.Delay(initCNSInitalDelay), retry.MaxDelay(time.Minute))
+	if err != nil {
+		return err
+	}
+	logger.Printf("reconciled initial CNS state after %d attempts", attempt)
+
+	// the NCs of the pools are programmed as soon as they are created, since NMAgent does not program them. Syncing
+	// their host versions once marks them Programmed without calling NMAgent, and the conflist can be written.
+	httpRestServiceImplementation.SyncHostNCVersion(ctx, cnsconfig.ChannelMode)
+	go httpRestServiceImplementation.MustGenerateCNIConflistOnce()
+	return nil
+}
+
+// newPodAnnotationsProvider returns a PodAnnotationsProvider backed by an informer cache of the Pods of the Node.
+// A Pod that is not in the cache yet, because it was only just scheduled, is read from the API server.
+func newPodAnnotationsProvider(ctx context.Context, clientset kubernetes.Interface, nodeName string) (cns.PodAnnotationsProvider, error) {
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0, informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+		opts.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
+	}))
+	lister := factory.Core().V1().Pods().Lister()
+	factory.Start(ctx.Done())
+	for informer, synced := range factory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return nil, errors.Errorf("failed to sync the %v informer", informer)
+		}
+	}
+
+	return cns.PodAnnotationsProviderFunc(func(ctx context.Context, podName, podNamespace string) (map[string]string, error) {
+		pod, err := lister.Pods(podNamespace).Get(podName)
+		if apierrors.IsNotFound(err) {
+			pod, err = clientset.CoreV1().Pods(podNamespace).Get(ctx, podName, metav1.GetOptions{})
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get Pod %s/%s", podNamespace, podName)
+		}
+		return pod.Annotations, nil
+	}), nil
+}
+
+// newPodInfoByIPProvider creates the provider of the Pods that CNS initializes its IPAM state with: the endpoint
+// state of CNS, the state of the CNI, or the Pods of the Node in Kubernetes.
+func newPodInfoByIPProvider(ctx context.Context, cnsconfig *configuration.CNSConfig, httpRestServiceImplementation *restserver.HTTPRestService,
+	clientset kubernetes.Interface, nodeName string,
+) (cns.PodInfoByIPProvider, error) {
 	var podInfoByIPProvider cns.PodInfoByIPProvider
+	var err error
 	switch {
 	case cnsconfig.ManageEndpointState:
 		logger.Printf("Initializing from self managed endpoint store")
@@ -1124,14 +1353,14 @@ func InitializeCRDState(ctx context.Context, httpRestService cns.HTTPService, cn
 			if errors.Is(err, store.ErrKeyNotFound) {
 				logger.Printf("[Azure CNS] No endpoint state found, skipping initializing CNS state")
 			} else {
-				return errors.Wrap(err, "failed to create CNS PodInfoProvider")
+				return nil, errors.Wrap(err, "failed to create CNS PodInfoProvider")
 			}
 		}
 	case cnsconfig.InitializeFromCNI:
 		logger.Printf("Initializing from CNI")
 		podInfoByIPProvider, err = cnireconciler.NewCNIPodInfoProvider()
 		if err != nil {
-			return errors.Wrap(err, "failed to create CNI PodInfoProvider")
+			return nil, errors.Wrap(err, "failed to create CNI PodInfoProvider")
 		}
 	default:
 		logger.Printf("Initializing from Kubernetes")
@@ -1149,6 +1378,57 @@ func InitializeCRDState(ctx context.Context, httpRestService cns.HTTPService, cn
 			return podInfo, nil
 		})
 	}
+	return podInfoByIPProvider, nil
+}
+
+// InitializeCRDState builds and starts the CRD controllers.
+func InitializeCRDState(ctx context.Context, httpRestService cns.HTTPService, cnsconfig *configuration.CNSConfig, healthChecks *healthserver.Checks) error {
+	// convert interface type to implementation type
+	httpRestServiceImplementation, ok := httpRestService.(*restserver.HTTPRestService)
+	if !ok {
+		logger.Errorf("[Azure CNS] Failed to convert interface httpRestService to implementation: %v", httpRestService)
+		return fmt.Errorf("[Azure CNS] Failed to convert interface httpRestService to implementation: %v",
+			httpRestService)
+	}
+
+	// Set orchestrator type
+	orchestrator := cns.SetOrchestratorTypeRequest{
+		OrchestratorType: cns.KubernetesCRD,
+	}
+	httpRestServiceImplementation.SetNodeOrchestrator(&orchestrator)
+
+	// build default clientset.
+	kubeConfig, err := ctrl.GetConfig()
+	if err != nil {
+		logger.Errorf("[Azure CNS] Failed to get kubeconfig for request controller: %v", err)
+		return errors.Wrap(err, "failed to get kubeconfig")
+	}
+	kubeConfig.UserAgent = fmt.Sprintf("azure-cns-%s", version)
+
+	clientset, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		return errors.Wrap(err, "failed to build clientset")
+	}
+
+	// get nodename for scoping kube requests to node.
+	nodeName, err := configuration.NodeName()
+	if err != nil {
+		return errors.Wrap(err, "failed to get NodeName")
+	}
+
+	podInfoByIPProvider, err := newPodInfoByIPProvider(ctx, cnsconfig, httpRestServiceImplementation, clientset, nodeName)
+	if err != nil {
+		return err
+	}
+	if cnsconfig.EnablePodNetworkAnnotations {
+		logger.Printf("Passing Pod network annotations to CNI")
+		podAnnotationsProvider, err := newPodAnnotationsProvider(ctx, clientset, nodeName) //nolint:govet // ignore err shadow
+		if err != nil {
+			return err
+		}
+		httpRestServiceImplementation.SetPodAnnotationsProvider(podAnnotationsProvider)
+	}
+
 	// create scoped kube clients.
 	directcli, err := client.New(kubeConfig, client.Options{Scheme: nodenetworkconfig.Scheme})
 	if err != nil {
@@ -1160,6 +1440,8 @@ func InitializeCRDState(ctx context.Context, httpRestService cns.HTTPService, cn
 	}
 	// TODO(rbtr): nodename and namespace should be in the cns config
 	directscopedcli := nncctrl.NewScopedClient(directnnccli, types.NamespacedName{Namespace: "kube-system", Name: nodeName})
+	// the snapshots of the state of CNS include the NNC of the node.
+	httpRestServiceImplementation.GetNodeNetworkConfig = directscopedcli.Get
 
 	logger.Printf("Reconciling initial CNS state")
 	// apiserver nnc might not be registered or api server might be down and crashloop backof puts us outside of 5-10 minutes we have for
@@ -1209,6 +1491,10 @@ func InitializeCRDState(ctx context.Context, httpRestService cns.HTTPService, cn
 		return errors.Wrap(err, "failed to create manager")
 	}
 
+	// Events on the Node and Pods are recorded with the Manager's event broadcaster.
+	eventRecorder := events.NewRecorder(manager.GetEventRecorderFor(name), clientset.CoreV1(), nodeName, &events.Options{})
+	httpRestServiceImplementation.Events = eventRecorder
+
 	// Build the IPAM Pool monitor
 	clusterSubnetStateChan := make(chan v1alpha1.ClusterSubnetState)
 
@@ -1222,9 +1508,12 @@ func InitializeCRDState(ctx context.Context, httpRestService cns.HTTPService, cn
 
 	poolOpts := ipampool.Options{
 		RefreshDelay: poolIPAMRefreshRateInMilliseconds * time.Millisecond,
+		Events:       eventRecorder,
+		SpecUpdated:  httpRestServiceImplementation.PublishPoolSpec,
 	}
 	poolMonitor := ipampool.NewMonitor(httpRestServiceImplementation, cachedscopedcli, clusterSubnetStateChan, &poolOpts)
 	httpRestServiceImplementation.IPAMPoolMonitor = poolMonitor
+	healthChecks.AddReadyzCheck("ipam-pool-monitor", healthserver.Started(poolMonitor.Started))
 
 	// Start building the NNC Reconciler
 
@@ -1239,11 +1528,17 @@ func InitializeCRDState(ctx context.Context, httpRestService cns.HTTPService, cn
 	nodeIP := configuration.NodeIP()
 
 	// NodeNetworkConfig reconciler
-	nncReconciler := nncctrl.NewReconciler(httpRestServiceImplementation, poolMonitor, nodeIP)
+	nncReconciler := nncctrl.NewReconciler(httpRestServiceImplementation, poolMonitor, nodeIP, eventRecorder)
 	// pass Node to the Reconciler for Controller xref
 	if err := nncReconciler.SetupWithManager(manager, node); err != nil { //nolint:govet // intentional shadow
 		return errors.Wrapf(err, "failed to setup nnc reconciler with manager")
 	}
+	// the debug API can trigger a reconcile of the NNC, such as to recover from a missed update.
+	httpRestServiceImplementation.TriggerReconcile = func(context.Context) error {
+		nncReconciler.Trigger(types.NamespacedName{Namespace: "kube-system", Name: nodeName})
+		return nil
+	}
+	healthChecks.AddReadyzCheck("nnc-reconciler", healthserver.Started(nncReconciler.Started))
 
 	if cnsconfig.EnableSubnetScarcity {
 		// ClusterSubnetState reconciler
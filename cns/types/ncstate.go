@@ -0,0 +1,19 @@
+package types
+
+// NCState is the programming state of an NC, which is the state of the programming of its version on the host by
+// NMAgent.
+type NCState string
+
+const (
+	// NCRequested is the state of an NC whose version CNS received, and which NMAgent has not programmed yet.
+	NCRequested NCState = "Requested"
+	// NCPublished is the state of an NC that CNS published to NMAgent again, because NMAgent did not program it
+	// within the programming timeout.
+	NCPublished NCState = "Published"
+	// NCProgrammed is the state of an NC that NMAgent programmed to its version.
+	NCProgrammed NCState = "Programmed"
+	// NCFailed is the state of an NC that NMAgent did not program after CNS published it again the max number of
+	// times, or that CNS can not publish again because it did not see its version published. CNS still programs
+	// the NC if NMAgent catches up.
+	NCFailed NCState = "Failed"
+)
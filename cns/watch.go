@@ -0,0 +1,81 @@
+package cns
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/pkg/errors"
+)
+
+// WatchEventType is the type of a WatchEvent.
+type WatchEventType string
+
+const (
+	// WatchIPStateChanged is sent when an IP transitions to another state.
+	WatchIPStateChanged WatchEventType = "IPStateChanged"
+	// WatchNCCreated is sent when CNS receives a new NC.
+	WatchNCCreated WatchEventType = "NCCreated"
+	// WatchNCUpdated is sent when CNS receives a new goal state of an NC it already has, or when the programming
+	// state of an NC changes.
+	WatchNCUpdated WatchEventType = "NCUpdated"
+	// WatchNCDeleted is sent when CNS deletes an NC.
+	WatchNCDeleted WatchEventType = "NCDeleted"
+	// WatchPoolSpecChanged is sent when the IPAM pool monitor updates the spec of the NodeNetworkConfig.
+	WatchPoolSpecChanged WatchEventType = "PoolSpecChanged"
+	// WatchOverflow is sent, without a revision, when a watcher falls behind the events and its watch is ended.
+	// The watcher can resume the watch after the last revision that it received.
+	WatchOverflow WatchEventType = "Overflow"
+)
+
+// WatchRevisionHeader is the header of the watch response with the revision of CNS when the watch started, which
+// the watcher can resume after if it did not receive any event.
+const WatchRevisionHeader = "Cns-Watch-Revision"
+
+// ErrInvalidWatchRevision is returned when a WatchRevision is not an epoch and a revision separated by a dot.
+var ErrInvalidWatchRevision = errors.New("invalid watch revision")
+
+// WatchRevision is the position of a watch in the events of CNS, as the epoch of the CNS process and the revision
+// of the event in the process, separated by a dot. The revisions of each process start again at 1, so a watch can
+// only be resumed after a revision of the same epoch.
+type WatchRevision string
+
+// NewWatchRevision returns the WatchRevision of the revision in the epoch.
+func NewWatchRevision(epoch string, revision uint64) WatchRevision {
+	return WatchRevision(epoch + "." + strconv.FormatUint(revision, 10))
+}
+
+// Parse returns the epoch and the revision of the WatchRevision.
+func (r WatchRevision) Parse() (epoch string, revision uint64, err error) {
+	epoch, n, ok := strings.Cut(string(r), ".")
+	if !ok || epoch == "" {
+		return "", 0, errors.Wrapf(ErrInvalidWatchRevision, "%q", r)
+	}
+	if revision, err = strconv.ParseUint(n, 10, 64); err != nil {
+		return "", 0, errors.Wrapf(ErrInvalidWatchRevision, "%q: %v", r, err)
+	}
+	return epoch, revision, nil
+}
+
+// WatchEventTypes are the types of the events that a watch can be filtered by.
+var WatchEventTypes = []WatchEventType{WatchIPStateChanged, WatchNCCreated, WatchNCUpdated, WatchNCDeleted, WatchPoolSpecChanged}
+
+// WatchEvent is a change of the state of CNS, which is streamed by the watch API. The revision of CNS increases by
+// one with each event of the CNS process, so that a watcher can resume after the last revision that it received. Only one of IPState,
+// NC and PoolSpec is set, by the type of the event.
+type WatchEvent struct {
+	Revision WatchRevision `json:",omitempty"`
+	Type     WatchEventType
+	Time     time.Time
+	IPState  *IPStateChange                 `json:",omitempty"`
+	NC       *NetworkContainerDebugInfo     `json:",omitempty"`
+	PoolSpec *v1alpha.NodeNetworkConfigSpec `json:",omitempty"`
+}
+
+// IPStateChange is the transition of an IP, which is in its new state, from its previous state.
+type IPStateChange struct {
+	IP            IPConfigurationStatus
+	PreviousState types.IPState
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 Microsoft. All rights reserved.
+// MIT License
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+type connContextKey struct{}
+
+// withConn keeps the connection of a request in its context, so that the identity of the peer can be read.
+func withConn(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, c)
+}
+
+// Identity is the identity of the caller of a request, as proven by its connection.
+type Identity struct {
+	// Subject is the common name of the verified client certificate, if any.
+	Subject string
+	// UID is the user ID of the peer process of a Unix socket connection, if HasUID is set.
+	UID    uint32
+	HasUID bool
+}
+
+// Anonymous reports whether the caller could not be identified.
+func (i Identity) Anonymous() bool {
+	return i.Subject == "" && !i.HasUID
+}
+
+func (i Identity) String() string {
+	switch {
+	case i.Subject != "" && i.HasUID:
+		return fmt.Sprintf("subject=%s uid=%d", i.Subject, i.UID)
+	case i.Subject != "":
+		return "subject=" + i.Subject
+	case i.HasUID:
+		return fmt.Sprintf("uid=%d", i.UID)
+	default:
+		return "anonymous"
+	}
+}
+
+// CallerIdentity returns the identity of the caller of a request served by a Listener.
+func CallerIdentity(r *http.Request) Identity {
+	var id Identity
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		id.Subject = r.TLS.VerifiedChains[0][0].Subject.CommonName
+	}
+	if c, ok := r.Context().Value(connContextKey{}).(net.Conn); ok {
+		id.UID, id.HasUID = peerUID(c)
+	}
+	return id
+}
+
+// ConnIdentity returns the identity of the peer of a Unix socket connection, which is served by another server
+// than a Listener.
+func ConnIdentity(c net.Conn) Identity {
+	var id Identity
+	id.UID, id.HasUID = peerUID(c)
+	return id
+}
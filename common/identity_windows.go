@@ -0,0 +1,11 @@
+// Copyright 2023 Microsoft. All rights reserved.
+// MIT License
+
+package common
+
+import "net"
+
+// peerUID is not supported on Windows, where callers are identified by their client certificate only.
+func peerUID(net.Conn) (uint32, bool) {
+	return 0, false
+}
@@ -5,11 +5,13 @@ package common
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 
 	"github.com/Azure/azure-container-networking/log"
 	"github.com/pkg/errors"
@@ -25,8 +27,13 @@ type Listener struct {
 	listener     net.Listener
 	tlsListener  net.Listener
 	mux          *http.ServeMux
+	middlewares  []Middleware
+	clientCAs    *x509.CertPool
 }
 
+// Middleware wraps the handler of the listener, such as to authorize requests.
+type Middleware func(http.Handler) http.Handler
+
 // NewListener creates a new Listener.
 func NewListener(u *url.URL) (*Listener, error) {
 	listener := Listener{
@@ -40,11 +47,39 @@ func NewListener(u *url.URL) (*Listener, error) {
 	return &listener, nil
 }
 
+// Use adds a middleware to the handler of the listener. Middlewares must be added before the listener is
+// started, and the first one added is the outermost.
+func (l *Listener) Use(m Middleware) {
+	l.middlewares = append(l.middlewares, m)
+}
+
+// RequireClientCertificates makes the HTTPS server require client certificates that are verified against the
+// CAs. It must be called before StartTLS.
+func (l *Listener) RequireClientCertificates(clientCAs *x509.CertPool) {
+	l.clientCAs = clientCAs
+}
+
+// handler returns the mux wrapped by the middlewares.
+func (l *Listener) handler() http.Handler {
+	var h http.Handler = l.mux
+	for i := len(l.middlewares) - 1; i >= 0; i-- {
+		h = l.middlewares[i](h)
+	}
+	return h
+}
+
 // StartTLS creates the listener socket and starts the HTTPS server.
 func (l *Listener) StartTLS(errChan chan<- error, tlsConfig *tls.Config, address string) error {
+	if l.clientCAs != nil {
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ClientCAs = l.clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
 	server := http.Server{
-		TLSConfig: tlsConfig,
-		Handler:   l.mux,
+		TLSConfig:   tlsConfig,
+		Handler:     l.handler(),
+		ConnContext: withConn,
 	}
 
 	// listen on a separate endpoint for secure tls connections
@@ -68,6 +103,18 @@ func (l *Listener) StartTLS(errChan chan<- error, tlsConfig *tls.Config, address
 
 // Start creates the listener socket and starts the HTTP server.
 func (l *Listener) Start(errChan chan<- error) error {
+	if l.protocol == "unix" {
+		if err := os.MkdirAll(filepath.Dir(l.localAddress), 0o755); err != nil { //nolint:gomnd // socket directory permissions
+			log.Printf("[Listener] Failed to create socket directory: %+v", err)
+			return err
+		}
+		// remove the socket left behind by a previous process, which would fail the listen
+		if err := os.Remove(l.localAddress); err != nil && !os.IsNotExist(err) {
+			log.Printf("[Listener] Failed to remove stale socket: %+v", err)
+			return err
+		}
+	}
+
 	list, err := net.Listen(l.protocol, l.localAddress)
 	if err != nil {
 		log.Printf("[Listener] Failed to listen: %+v", err)
@@ -77,9 +124,14 @@ func (l *Listener) Start(errChan chan<- error) error {
 	l.listener = list
 	log.Printf("[Listener] Started listening on %s.", l.localAddress)
 
+	server := http.Server{
+		Handler:     l.handler(),
+		ConnContext: withConn,
+	}
+
 	// Launch goroutine for servicing requests.
 	go func() {
-		errChan <- http.Serve(l.listener, l.mux)
+		errChan <- server.Serve(l.listener)
 	}()
 
 	l.active = true
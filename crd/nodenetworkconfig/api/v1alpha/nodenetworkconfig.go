@@ -66,8 +66,80 @@ type NodeNetworkConfigStatus struct {
 	Scaler            Scaler             `json:"scaler,omitempty"`
 	Status            Status             `json:"status,omitempty"`
 	NetworkContainers []NetworkContainer `json:"networkContainers,omitempty"`
+	// CNS is the state of the NodeNetworkConfig as reconciled by CNS on the node. It is only written by CNS.
+	// +kubebuilder:validation:Optional
+	CNS *CNSStatus `json:"cns,omitempty"`
 }
 
+// CNSStatus is the state of the NodeNetworkConfig as reconciled by CNS.
+type CNSStatus struct {
+	// ObservedGeneration is the generation of the NodeNetworkConfig that CNS last reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// NetworkContainers is the result of programming each NC of the status into CNS.
+	NetworkContainers []NetworkContainerStatus `json:"networkContainers,omitempty"`
+	// AllocatedIPCount is the number of IPs of the NCs that CNS programmed.
+	// +kubebuilder:default=0
+	// +kubebuilder:validation:Optional
+	AllocatedIPCount int64 `json:"allocatedIPCount"`
+	// AssignedIPCount is the number of IPs that CNS assigned to pods.
+	// +kubebuilder:default=0
+	// +kubebuilder:validation:Optional
+	AssignedIPCount int64 `json:"assignedIPCount"`
+	// PoolMonitor is the state of the IPAM pool monitor of CNS.
+	PoolMonitor PoolMonitorStatus `json:"poolMonitor,omitempty"`
+	// Conditions are the Reconciled condition of CNS.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// NCResult is the result of programming an NC into CNS.
+// +kubebuilder:validation:Enum=Programmed;Failed;Skipped
+type NCResult string
+
+const (
+	// NCProgrammed is the result of an NC that CNS created or updated.
+	NCProgrammed NCResult = "Programmed"
+	// NCFailed is the result of an NC that CNS failed to create or update.
+	NCFailed NCResult = "Failed"
+	// NCSkipped is the result of an NC that was made for another node.
+	NCSkipped NCResult = "Skipped"
+)
+
+// NetworkContainerStatus is the result of programming an NC into CNS.
+type NetworkContainerStatus struct {
+	ID string `json:"id"`
+	// ObservedVersion is the version of the NC that CNS last reconciled.
+	// +kubebuilder:default=0
+	// +kubebuilder:validation:Optional
+	ObservedVersion int64    `json:"observedVersion"`
+	Result          NCResult `json:"result"`
+	// LastError is the error of the last failed or skipped reconcile of the NC.
+	LastError string `json:"lastError,omitempty"`
+}
+
+// PoolMonitorStatus is the state of the IPAM pool monitor of CNS.
+type PoolMonitorStatus struct {
+	// Started is whether the pool monitor received its first NodeNetworkConfig.
+	Started bool `json:"started,omitempty"`
+	// RequestedIPCount is the IP count that the pool monitor last requested.
+	RequestedIPCount int64 `json:"requestedIPCount,omitempty"`
+	// PendingReleaseIPCount is the number of IPs that the pool monitor is releasing.
+	PendingReleaseIPCount int64 `json:"pendingReleaseIPCount,omitempty"`
+	// SubnetExhausted is whether the pool monitor scales as if the subnet is exhausted.
+	SubnetExhausted bool `json:"subnetExhausted,omitempty"`
+}
+
+// ConditionReconciled is the type of the condition that is true if CNS programmed every NC made for its node.
+const ConditionReconciled = "Reconciled"
+
+// Reasons of the Reconciled condition.
+const (
+	ReasonProgrammed        = "Programmed"
+	ReasonProgrammingFailed = "ProgrammingFailed"
+	ReasonNodeIPMismatch    = "NodeIPMismatch"
+)
+
 // Scaler groups IP request params together
 type Scaler struct {
 	BatchSize               int64 `json:"batchSize,omitempty"`
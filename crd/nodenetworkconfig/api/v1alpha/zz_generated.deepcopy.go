@@ -6,9 +6,38 @@
 package v1alpha
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CNSStatus) DeepCopyInto(out *CNSStatus) {
+	*out = *in
+	if in.NetworkContainers != nil {
+		in, out := &in.NetworkContainers, &out.NetworkContainers
+		*out = make([]NetworkContainerStatus, len(*in))
+		copy(*out, *in)
+	}
+	out.PoolMonitor = in.PoolMonitor
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CNSStatus.
+func (in *CNSStatus) DeepCopy() *CNSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CNSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IPAssignment) DeepCopyInto(out *IPAssignment) {
 	*out = *in
@@ -44,6 +73,21 @@ func (in *NetworkContainer) DeepCopy() *NetworkContainer {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkContainerStatus) DeepCopyInto(out *NetworkContainerStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkContainerStatus.
+func (in *NetworkContainerStatus) DeepCopy() *NetworkContainerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkContainerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeNetworkConfig) DeepCopyInto(out *NodeNetworkConfig) {
 	*out = *in
@@ -134,6 +178,11 @@ func (in *NodeNetworkConfigStatus) DeepCopyInto(out *NodeNetworkConfigStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CNS != nil {
+		in, out := &in.CNS, &out.CNS
+		*out = new(CNSStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeNetworkConfigStatus.
@@ -146,6 +195,21 @@ func (in *NodeNetworkConfigStatus) DeepCopy() *NodeNetworkConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolMonitorStatus) DeepCopyInto(out *PoolMonitorStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolMonitorStatus.
+func (in *PoolMonitorStatus) DeepCopy() *PoolMonitorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolMonitorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Scaler) DeepCopyInto(out *Scaler) {
 	*out = *in
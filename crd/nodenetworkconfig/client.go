@@ -6,15 +6,20 @@ import (
 
 	"github.com/Azure/azure-container-networking/crd"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	typedv1 "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -99,14 +104,18 @@ func NewClient(cli client.Client) *Client {
 }
 
 // Get returns the NodeNetworkConfig identified by the NamespacedName.
-func (c *Client) Get(ctx context.Context, key types.NamespacedName) (*v1alpha.NodeNetworkConfig, error) {
+func (c *Client) Get(ctx context.Context, key types.NamespacedName) (_ *v1alpha.NodeNetworkConfig, err error) {
+	ctx, span := startSpan(ctx, "nnc.Get", key)
+	defer func() { tracing.End(span, err) }()
 	nodeNetworkConfig := &v1alpha.NodeNetworkConfig{}
-	err := c.cli.Get(ctx, key, nodeNetworkConfig)
+	err = c.cli.Get(ctx, key, nodeNetworkConfig)
 	return nodeNetworkConfig, errors.Wrapf(err, "failed to get nnc %v", key)
 }
 
 // PatchSpec performs a server-side patch of the passed NodeNetworkConfigSpec to the NodeNetworkConfig specified by the NamespacedName.
-func (c *Client) PatchSpec(ctx context.Context, key types.NamespacedName, spec *v1alpha.NodeNetworkConfigSpec, fieldManager string) (*v1alpha.NodeNetworkConfig, error) {
+func (c *Client) PatchSpec(ctx context.Context, key types.NamespacedName, spec *v1alpha.NodeNetworkConfigSpec, fieldManager string) (_ *v1alpha.NodeNetworkConfig, err error) {
+	ctx, span := startSpan(ctx, "nnc.PatchSpec", key)
+	defer func() { tracing.End(span, err) }()
 	obj := genPatchSkel(key)
 	obj.Spec = *spec
 	if err := c.cli.Patch(ctx, obj, client.Apply, client.ForceOwnership, client.FieldOwner(fieldManager)); err != nil {
@@ -117,7 +126,9 @@ func (c *Client) PatchSpec(ctx context.Context, key types.NamespacedName, spec *
 
 // UpdateSpec does a fetch, deepcopy, and update of the NodeNetworkConfig with the passed spec.
 // Deprecated: UpdateSpec is deprecated and usage should migrate to PatchSpec.
-func (c *Client) UpdateSpec(ctx context.Context, key types.NamespacedName, spec *v1alpha.NodeNetworkConfigSpec) (*v1alpha.NodeNetworkConfig, error) {
+func (c *Client) UpdateSpec(ctx context.Context, key types.NamespacedName, spec *v1alpha.NodeNetworkConfigSpec) (_ *v1alpha.NodeNetworkConfig, err error) {
+	ctx, span := startSpan(ctx, "nnc.UpdateSpec", key)
+	defer func() { tracing.End(span, err) }()
 	nnc, err := c.Get(ctx, key)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get nnc")
@@ -129,6 +140,27 @@ func (c *Client) UpdateSpec(ctx context.Context, key types.NamespacedName, spec
 	return nnc, nil
 }
 
+// PatchCNSStatus patches the CNS section of the status of the NodeNetworkConfig specified by the NamespacedName,
+// if it changed. The patch fails on conflict with a concurrent write of the NodeNetworkConfig, in which case it
+// is retried with the latest NodeNetworkConfig.
+func (c *Client) PatchCNSStatus(ctx context.Context, key types.NamespacedName, status *v1alpha.CNSStatus) (err error) {
+	ctx, span := startSpan(ctx, "nnc.PatchCNSStatus", key)
+	defer func() { tracing.End(span, err) }()
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		nnc, err := c.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if equality.Semantic.DeepEqual(nnc.Status.CNS, status) {
+			return nil
+		}
+		patched := nnc.DeepCopy()
+		patched.Status.CNS = status.DeepCopy()
+		return c.cli.Status().Patch(ctx, patched, client.MergeFromWithOptions(nnc, client.MergeFromWithOptimisticLock{})) //nolint:wrapcheck // wrapped below
+	})
+	return errors.Wrap(err, "failed to patch nnc cns status")
+}
+
 // SetOwnerRef sets the controller of the NodeNetworkConfig to the given object atomically, using HTTP Patch.
 // Deprecated: SetOwnerRef is deprecated, use the more correctly named SetControllerRef.
 func (c *Client) SetOwnerRef(ctx context.Context, key types.NamespacedName, owner metav1.Object, fieldManager string) (*v1alpha.NodeNetworkConfig, error) {
@@ -147,6 +179,13 @@ func (c *Client) SetControllerRef(ctx context.Context, key types.NamespacedName,
 	return obj, nil
 }
 
+// startSpan starts a span for a request to the API server for the NodeNetworkConfig.
+func startSpan(ctx context.Context, name string, key types.NamespacedName) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("nnc", key.String())))
+}
+
 func genPatchSkel(key types.NamespacedName) *v1alpha.NodeNetworkConfig {
 	return &v1alpha.NodeNetworkConfig{
 		TypeMeta: metav1.TypeMeta{
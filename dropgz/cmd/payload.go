@@ -1,8 +1,15 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 
+	"github.com/Azure/azure-container-networking/dropgz/pkg/conflist"
 	"github.com/Azure/azure-container-networking/dropgz/pkg/embed"
 	"github.com/Azure/azure-container-networking/dropgz/pkg/hash"
 	"github.com/pkg/errors"
@@ -28,35 +35,141 @@ var list = &cobra.Command{
 	},
 }
 
-func checksum(srcs, dests []string) error {
-	if len(srcs) != len(dests) {
-		return errors.Wrapf(embed.ErrArgsMismatched, "%d and %d", len(srcs), len(dests))
+const (
+	sumFile       = "sum.txt"
+	signatureFile = "sum.txt.sig"
+)
+
+// checksums returns the embedded checksums. If a public key is set, the
+// checksum file must have a valid detached signature from the key.
+func checksums() (hash.Checksums, error) {
+	return verifiedChecksums(extractAll, publicKey)
+}
+
+// verifiedChecksums reads the checksum file with extract and verifies its
+// signature with the public key at keyPath. Without a key the signature is not
+// verified, so a tampered payload with matching checksums is deployed, which is
+// logged as a warning on every use.
+func verifiedChecksums(extract func(string) ([]byte, error), keyPath string) (hash.Checksums, error) {
+	sums, err := extract(sumFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to extract checksum file")
+	}
+
+	if keyPath == "" {
+		z.Warn("the checksum signature is NOT verified, pass --public-key to reject a tampered payload", zap.String("checksums", sumFile))
+	} else {
+		b, err := os.ReadFile(keyPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read public key %s", keyPath)
+		}
+		pub, err := hash.ParsePublicKey(b)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse public key %s", keyPath)
+		}
+		sig, err := extract(signatureFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to extract checksum signature")
+		}
+		if err := hash.Verify(pub, sums, sig); err != nil {
+			return nil, errors.Wrapf(err, "failed to verify %s with %s", sumFile, keyPath)
+		}
 	}
-	rc, err := embed.Extract("sum.txt")
+
+	checksums, err := hash.Parse(bytes.NewReader(sums))
 	if err != nil {
-		return errors.Wrap(err, "failed to extract checksum file")
+		return nil, errors.Wrap(err, "failed to parse checksums")
 	}
-	defer rc.Close()
+	return checksums, nil
+}
 
-	checksums, err := hash.Parse(rc)
+func extractAll(src string) ([]byte, error) {
+	rc, err := embed.Extract(src)
 	if err != nil {
-		return errors.Wrap(err, "failed to parse checksums")
+		return nil, err
 	}
-	for i := range srcs {
-		valid, err := checksums.Check(srcs[i], dests[i])
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	return b, errors.Wrapf(err, "failed to read %s", src)
+}
+
+func check(checksums hash.Checksums) embed.Check {
+	return func(src, path string) error {
+		valid, err := checksums.Check(src, path)
 		if err != nil {
-			return errors.Wrapf(err, "failed to validate file at %s", dests[i])
+			return errors.Wrapf(err, "failed to validate file at %s", path)
 		}
 		if !valid {
-			return errors.Errorf("%s checksum validation failed", dests[i])
+			return errors.Errorf("%s checksum validation failed", path)
+		}
+		return nil
+	}
+}
+
+func checksum(srcs, dests []string) error {
+	if len(srcs) != len(dests) {
+		return errors.Wrapf(embed.ErrArgsMismatched, "%d and %d", len(srcs), len(dests))
+	}
+	checksums, err := checksums()
+	if err != nil {
+		return err
+	}
+	validate := check(checksums)
+	for i := range srcs {
+		if err := validate(srcs[i], dests[i]); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+const conflistExt = ".conflist"
+
+var ErrMultipleConflists = errors.New("only one conflist can be rendered")
+
+// renderConflist replaces the embedded conflist with the conflist rendered for the scenario. All other files
+// are extracted from the payload.
+func renderConflist(scenario string, mtu int) embed.Open {
+	return func(src string) (io.ReadCloser, error) {
+		if filepath.Ext(src) != conflistExt {
+			return embed.Extract(src)
+		}
+		b, err := conflist.Render(runtime.GOOS, scenario, mtu)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to render %s", src)
+		}
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+}
+
+// conflists returns the conflists among the sources.
+func conflists(srcs []string) []string {
+	var found []string
+	for _, src := range srcs {
+		if filepath.Ext(src) == conflistExt {
+			found = append(found, src)
+		}
+	}
+	return found
+}
+
+// skipConflists does not validate the checksum of the rendered conflist, whose template is validated by
+// cni/conflist instead.
+func skipConflists(check embed.Check) embed.Check {
+	return func(src, path string) error {
+		if filepath.Ext(src) == conflistExt {
+			return nil
+		}
+		return check(src, path)
+	}
+}
+
 var (
 	skipVerify bool
+	publicKey  string
 	outs       []string
+	render     string
+	renderMTU  int
 )
 
 // deploy subcommand
@@ -73,17 +186,31 @@ var deploy = &cobra.Command{
 			return errors.Wrapf(embed.ErrArgsMismatched, "%d files, %d outputs", len(srcs), len(outs))
 		}
 		log := z.With(zap.Strings("sources", srcs), zap.Strings("outputs", outs), zap.String("cmd", "deploy"))
-		if err := embed.Deploy(log, srcs, outs); err != nil {
-			return errors.Wrapf(err, "failed to deploy %s", srcs)
+		// the checksums, and their signature, are verified before anything is extracted
+		var validate embed.Check
+		if !skipVerify {
+			checksums, err := checksums()
+			if err != nil {
+				return err
+			}
+			validate = check(checksums)
 		}
-		log.Info("successfully wrote files")
-		if skipVerify {
-			return nil
+		var src embed.Open
+		if render != "" {
+			// a scenario has a single conflist, so it is rendered for one conflist source at most
+			if found := conflists(srcs); len(found) > 1 {
+				return errors.Wrapf(ErrMultipleConflists, "%s", found)
+			}
+			log = log.With(zap.String("scenario", render))
+			src = renderConflist(render, renderMTU)
+			if validate != nil {
+				validate = skipConflists(validate)
+			}
 		}
-		if err := checksum(srcs, outs); err != nil {
-			return err
+		if err := embed.Deploy(log, srcs, outs, src, validate); err != nil {
+			return errors.Wrapf(err, "failed to deploy %s", srcs)
 		}
-		log.Info("verified file integrity")
+		log.Info("successfully wrote files")
 		return nil
 	},
 	Args: cobra.OnlyValidArgs,
@@ -112,15 +239,50 @@ var verify = &cobra.Command{
 	Args: cobra.OnlyValidArgs,
 }
 
+// rollback subcommand
+var rollback = &cobra.Command{
+	Use: "rollback",
+	RunE: func(_ *cobra.Command, srcs []string) error {
+		if err := setLogLevel(); err != nil {
+			return err
+		}
+		if len(outs) == 0 {
+			outs = srcs
+		}
+		if len(srcs) != len(outs) {
+			return errors.Wrapf(embed.ErrArgsMismatched, "%d files, %d outputs", len(srcs), len(outs))
+		}
+		log := z.With(zap.Strings("sources", srcs), zap.Strings("outputs", outs), zap.String("cmd", "rollback"))
+		if err := embed.Rollback(log, outs); err != nil {
+			return errors.Wrapf(err, "failed to roll back %s", outs)
+		}
+		log.Info("restored previous files")
+		return nil
+	},
+	Args: cobra.OnlyValidArgs,
+}
+
+func scenarios() string {
+	return strings.Join(conflist.Scenarios(runtime.GOOS), ",")
+}
+
 func init() {
 	root.AddCommand(list)
 
 	verify.ValidArgs, _ = embed.Contents()
 	verify.Flags().StringSliceVarP(&outs, "output", "o", []string{}, "output file path")
+	verify.Flags().StringVar(&publicKey, "public-key", "", "path to a PEM ed25519 public key to verify the checksum signature with")
 	root.AddCommand(verify)
 
 	deploy.ValidArgs, _ = embed.Contents() // setting this after the command is initialized is required
 	deploy.Flags().BoolVar(&skipVerify, "skip-verify", false, "set to disable checksum validation")
 	deploy.Flags().StringSliceVarP(&outs, "output", "o", []string{}, "output file path")
+	deploy.Flags().StringVar(&publicKey, "public-key", "", "path to a PEM ed25519 public key to verify the checksum signature with")
+	deploy.Flags().StringVar(&render, "render", "", fmt.Sprintf("render the deployed conflist for a scenario instead of extracting it [%s]", scenarios()))
+	deploy.Flags().IntVar(&renderMTU, "mtu", 0, "MTU of the pod interfaces in the rendered conflist")
 	root.AddCommand(deploy)
+
+	rollback.ValidArgs, _ = embed.Contents()
+	rollback.Flags().StringSliceVarP(&outs, "output", "o", []string{}, "output file path")
+	root.AddCommand(rollback)
 }
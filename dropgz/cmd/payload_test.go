@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/dropgz/pkg/hash"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+const testSums = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  azure-vnet\n"
+
+// payload returns an extract func that reads the files from the map.
+func payload(files map[string][]byte) func(string) ([]byte, error) {
+	return func(src string) ([]byte, error) {
+		b, ok := files[src]
+		if !ok {
+			return nil, errors.Errorf("no file %s", src)
+		}
+		return b, nil
+	}
+}
+
+func writePublicKey(t *testing.T, pub ed25519.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "pub.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+	return path
+}
+
+func TestVerifiedChecksums(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keyPath := writePublicKey(t, pub)
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherKeyPath := writePublicKey(t, otherPub)
+
+	sums := []byte(testSums)
+	signed := map[string][]byte{sumFile: sums, signatureFile: ed25519.Sign(priv, sums)}
+	tampered := map[string][]byte{sumFile: []byte("0000  azure-vnet\n"), signatureFile: signed[signatureFile]}
+
+	tests := []struct {
+		name    string
+		files   map[string][]byte
+		keyPath string
+		wantErr bool
+		errIs   error
+	}{
+		{name: "signed", files: signed, keyPath: keyPath},
+		{name: "tampered checksums", files: tampered, keyPath: keyPath, wantErr: true, errIs: hash.ErrInvalidSignature},
+		{name: "signed by another key", files: signed, keyPath: otherKeyPath, wantErr: true, errIs: hash.ErrInvalidSignature},
+		{name: "unsigned", files: map[string][]byte{sumFile: sums}, keyPath: keyPath, wantErr: true},
+		{name: "missing key", files: signed, keyPath: filepath.Join(t.TempDir(), "missing.pem"), wantErr: true, errIs: os.ErrNotExist},
+		// without a key the signature is not verified, which is only warned about
+		{name: "no key", files: tampered, keyPath: ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifiedChecksums(payload(tt.files), tt.keyPath)
+			if tt.wantErr {
+				require.Error(t, err)
+				if tt.errIs != nil {
+					require.ErrorIs(t, err, tt.errIs)
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.Contains(t, got, "azure-vnet")
+		})
+	}
+}
+
+func TestConflists(t *testing.T) {
+	require.Equal(t, []string{"azure.conflist"}, conflists([]string{"azure-vnet", "azure.conflist", "azure-vnet-ipam"}))
+	require.Len(t, conflists([]string{"azure.conflist", "azure-swift.conflist"}), 2)
+	require.Empty(t, conflists([]string{"azure-vnet"}))
+}
@@ -0,0 +1,73 @@
+// Package conflist renders the Azure CNI conflists that dropgz writes at install time. The templates are the
+// conflists rendered by cni/conflist of the root module, checked in so that dropgz does not depend on the root
+// module. TestRenderDropgzTemplates of cni/conflist keeps them in sync.
+package conflist
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	templatesDir = "templates"
+	ext          = ".conflist"
+)
+
+var ErrUnsupportedScenario = errors.New("unsupported scenario")
+
+// templates holds the conflist of each scenario in templates/<os>/<scenario>.conflist.
+//
+//go:embed templates
+var templates embed.FS
+
+// Scenarios returns the scenarios supported on the OS, sorted by name.
+func Scenarios(goos string) []string {
+	entries, err := fs.ReadDir(templates, path.Join(templatesDir, goos))
+	if err != nil {
+		return nil
+	}
+	scenarios := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() && path.Ext(e.Name()) == ext {
+			scenarios = append(scenarios, strings.TrimSuffix(e.Name(), ext))
+		}
+	}
+	return scenarios
+}
+
+// Render returns the conflist of the scenario on the OS. The MTU of the pod interfaces is set in the first
+// plugin when it is not zero, otherwise the plugin picks it.
+func Render(goos, scenario string, mtu int) ([]byte, error) {
+	b, err := templates.ReadFile(path.Join(templatesDir, goos, scenario+ext))
+	if err != nil || strings.ContainsRune(scenario, '/') {
+		return nil, errors.Wrapf(ErrUnsupportedScenario, "%q on %s", scenario, goos)
+	}
+	if mtu == 0 {
+		return b, nil
+	}
+
+	var conflist map[string]any
+	if err := json.Unmarshal(b, &conflist); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode the conflist of scenario %s", scenario)
+	}
+	plugins, _ := conflist["plugins"].([]any)
+	if len(plugins) == 0 {
+		return nil, errors.Errorf("conflist of scenario %s has no plugins", scenario)
+	}
+	plugin, ok := plugins[0].(map[string]any)
+	if !ok {
+		return nil, errors.Errorf("first plugin of scenario %s is not an object", scenario)
+	}
+	plugin["mtu"] = mtu
+
+	b, err = json.MarshalIndent(conflist, "", "\t")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode the conflist of scenario %s", scenario)
+	}
+	return append(b, '\n'), nil
+}
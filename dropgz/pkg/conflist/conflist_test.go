@@ -0,0 +1,48 @@
+package conflist
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScenarios(t *testing.T) {
+	require.Contains(t, Scenarios("linux"), "cilium")
+	require.Contains(t, Scenarios("windows"), "baremetal")
+	require.NotContains(t, Scenarios("linux"), "baremetal")
+	require.Empty(t, Scenarios("darwin"))
+}
+
+func TestRender(t *testing.T) {
+	for _, goos := range []string{"linux", "windows"} {
+		for _, scenario := range Scenarios(goos) {
+			b, err := Render(goos, scenario, 1400)
+			require.NoError(t, err, "%s on %s", scenario, goos)
+
+			var conflist struct {
+				Plugins []struct {
+					MTU int `json:"mtu"`
+				} `json:"plugins"`
+			}
+			require.NoError(t, json.Unmarshal(b, &conflist))
+			require.NotEmpty(t, conflist.Plugins)
+			require.Equal(t, 1400, conflist.Plugins[0].MTU, "%s on %s", scenario, goos)
+		}
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	b, err := Render("linux", "vnet", 0)
+	require.NoError(t, err)
+	template, err := templates.ReadFile("templates/linux/vnet.conflist")
+	require.NoError(t, err)
+	require.Equal(t, template, b)
+}
+
+func TestRenderUnsupported(t *testing.T) {
+	_, err := Render("linux", "baremetal", 0)
+	require.ErrorIs(t, err, ErrUnsupportedScenario)
+	_, err = Render("linux", "../windows/vnet", 0)
+	require.ErrorIs(t, err, ErrUnsupportedScenario)
+}
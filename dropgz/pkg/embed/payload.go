@@ -15,11 +15,15 @@ import (
 )
 
 const (
-	cwd           = "fs"
-	oldFileSuffix = ".old"
+	cwd            = "fs"
+	oldFileSuffix  = ".old"
+	tmpFilePattern = ".dropgz-*"
 )
 
-var ErrArgsMismatched = errors.New("mismatched argument count")
+var (
+	ErrArgsMismatched = errors.New("mismatched argument count")
+	ErrNoBackup       = errors.New("no backup to restore")
+)
 
 // embedfs contains the embedded files for deployment, as a read-only FileSystem containing only "embedfs/".
 //
@@ -82,39 +86,190 @@ func Extract(p string) (*compoundReadCloser, error) {
 	return &compoundReadCloser{closer: f, readcloser: r}, nil
 }
 
-func deploy(src, dest string) error {
-	rc, err := Extract(src)
+// Check validates a file extracted from src at path before it replaces its destination.
+type Check func(src, path string) error
+
+// Open returns the contents that are deployed for src.
+type Open func(src string) (io.ReadCloser, error)
+
+// open extracts the embedded src.
+func open(src string) (io.ReadCloser, error) {
+	return Extract(src)
+}
+
+// stage writes the contents of src to a temp file in the directory of dest, so that it can be renamed over dest.
+func stage(open Open, src, dest string) (string, error) {
+	rc, err := open(src)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer rc.Close()
-	// check if the file exists at dest already and rename it as an old one
-	if _, err := os.Stat(dest); err == nil {
-		oldDest := dest + oldFileSuffix
-		if err = os.Rename(dest, oldDest); err != nil {
-			return errors.Wrapf(err, "failed to rename the %s to %s", dest, oldDest)
-		}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+tmpFilePattern)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create temp file for %s", dest)
+	}
+	path := tmp.Name()
+
+	w := bufio.NewWriter(tmp)
+	if _, err = io.Copy(w, rc); err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(path, 0o755) //nolint:gomnd // executable file bitmask
 	}
-	target, err := os.OpenFile(dest, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o755) //nolint:gomnd // executable file bitmask
 	if err != nil {
-		return errors.Wrapf(err, "failed to create file %s", dest)
+		_ = os.Remove(path)
+		return "", errors.Wrapf(err, "failed to copy %s to %s", src, path)
+	}
+	return path, nil
+}
+
+// backup keeps the current file at dest as dest.old and reports whether there was one. The file is hard
+// linked, or copied if that fails, so that dest stays in place until it is replaced. Only one backup is kept:
+// the backup of the deploy before is replaced, so Rollback can only go back one deploy.
+func backup(dest string) (bool, error) {
+	old := dest + oldFileSuffix
+	if err := os.Remove(old); err != nil && !os.IsNotExist(err) {
+		return false, errors.Wrapf(err, "failed to remove previous backup %s", old)
+	}
+	if _, err := os.Stat(dest); os.IsNotExist(err) {
+		return false, nil
+	}
+	if err := os.Link(dest, old); err == nil {
+		return true, nil
 	}
-	defer target.Close()
-	_, err = io.Copy(bufio.NewWriter(target), rc)
-	return errors.Wrapf(err, "failed to copy %s to %s", src, dest)
+	if err := copyFile(dest, old); err != nil {
+		return false, errors.Wrapf(err, "failed to back up %s to %s", dest, old)
+	}
+	return true, nil
 }
 
-func Deploy(log *zap.Logger, srcs, dests []string) error {
+// copyFile copies src to dest through a temp file, so that dest is either absent or complete.
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", src)
+	}
+	defer in.Close()
+
+	fi, err := in.Stat()
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat %s", src)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+tmpFilePattern)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temp file for %s", dest)
+	}
+	path := tmp.Name()
+
+	_, err = io.Copy(tmp, in)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(path, fi.Mode().Perm())
+	}
+	if err == nil {
+		err = os.Rename(path, dest)
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return errors.Wrapf(err, "failed to copy %s to %s", src, dest)
+	}
+	return nil
+}
+
+// Deploy extracts the srcs to temp files next to their dests and validates them with check, if it is not
+// nil. The contents of the srcs are read with src, or extracted from the payload if it is nil. Only once
+// every file is staged are the dests replaced, each by an atomic rename. The previous files are kept with an
+// .old suffix so that Rollback can restore them; if replacing any dest fails, the dests already replaced are
+// restored before returning.
+func Deploy(log *zap.Logger, srcs, dests []string, src Open, check Check) error {
 	if len(srcs) != len(dests) {
 		return errors.Wrapf(ErrArgsMismatched, "%d and %d", len(srcs), len(dests))
 	}
+	if src == nil {
+		src = open
+	}
+
+	staged := make([]string, 0, len(srcs))
+	defer func() {
+		// installed files have been renamed away, this only removes the leftovers of a failed deploy
+		for _, path := range staged {
+			_ = os.Remove(path)
+		}
+	}()
 	for i := range srcs {
-		src := srcs[i]
-		dest := dests[i]
-		if err := deploy(src, dest); err != nil {
+		path, err := stage(src, srcs[i], dests[i])
+		if err != nil {
+			return err
+		}
+		staged = append(staged, path)
+		if check != nil {
+			if err := check(srcs[i], path); err != nil {
+				return err
+			}
+		}
+		log.Debug("staged file", zap.String("src", srcs[i]), zap.String("path", path))
+	}
+
+	backedUp := make([]bool, 0, len(dests))
+	for i := range dests {
+		ok, err := backup(dests[i])
+		if err == nil {
+			err = os.Rename(staged[i], dests[i])
+		}
+		if err != nil {
+			for j := len(backedUp) - 1; j >= 0; j-- {
+				if rerr := restore(dests[j], backedUp[j]); rerr != nil {
+					log.Error("failed to restore file", zap.String("dest", dests[j]), zap.Error(rerr))
+				}
+			}
+			return errors.Wrapf(err, "failed to replace %s", dests[i])
+		}
+		backedUp = append(backedUp, ok)
+		log.Info("wrote file", zap.String("src", srcs[i]), zap.String("dest", dests[i]))
+	}
+	return nil
+}
+
+// restore replaces dest with its backup, or removes it if there was no previous file.
+func restore(dest string, backedUp bool) error {
+	if !backedUp {
+		return errors.Wrapf(os.Remove(dest), "failed to remove %s", dest)
+	}
+	old := dest + oldFileSuffix
+	return errors.Wrapf(os.Rename(old, dest), "failed to rename %s to %s", old, dest)
+}
+
+// Rollback restores the files kept by the last Deploy to the dests. Nothing is restored unless every dest
+// has a backup. Restoring consumes the backups, so a second Rollback fails with ErrNoBackup rather than going
+// back another deploy.
+func Rollback(log *zap.Logger, dests []string) error {
+	for _, dest := range dests {
+		if _, err := os.Stat(dest + oldFileSuffix); err != nil {
+			if os.IsNotExist(err) {
+				return errors.Wrap(ErrNoBackup, dest)
+			}
+			return errors.Wrapf(err, "failed to stat backup of %s", dest)
+		}
+	}
+	for _, dest := range dests {
+		if err := restore(dest, true); err != nil {
 			return err
 		}
-		log.Info("wrote file", zap.String("src", src), zap.String("dest", dest))
+		log.Info("restored file", zap.String("dest", dest))
 	}
 	return nil
 }
@@ -0,0 +1,121 @@
+package embed
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// contents returns an Open that reads the srcs from the map.
+func contents(files map[string]string) Open {
+	return func(src string) (io.ReadCloser, error) {
+		c, ok := files[src]
+		if !ok {
+			return nil, errors.Errorf("no file %s", src)
+		}
+		return io.NopCloser(strings.NewReader(c)), nil
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(b)
+}
+
+// requireNoTempFiles fails if a deploy left a staged file in the dir.
+func requireNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, e := range entries {
+		require.NotContains(t, e.Name(), ".dropgz-", "staged file left behind")
+	}
+}
+
+func TestDeployAndRollback(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	srcs, dests := []string{"a", "b"}, []string{a, b}
+
+	// the first deploy has nothing to back up
+	require.NoError(t, Deploy(zap.NewNop(), srcs, dests, contents(map[string]string{"a": "a1", "b": "b1"}), nil))
+	require.Equal(t, "a1", readFile(t, a))
+	require.Equal(t, "b1", readFile(t, b))
+	require.ErrorIs(t, Rollback(zap.NewNop(), dests), ErrNoBackup)
+
+	require.NoError(t, Deploy(zap.NewNop(), srcs, dests, contents(map[string]string{"a": "a2", "b": "b2"}), nil))
+	require.NoError(t, Deploy(zap.NewNop(), srcs, dests, contents(map[string]string{"a": "a3", "b": "b3"}), nil))
+	require.Equal(t, "a3", readFile(t, a))
+	requireNoTempFiles(t, dir)
+
+	// only the files of the deploy before are kept, so rollback goes back one deploy
+	require.NoError(t, Rollback(zap.NewNop(), dests))
+	require.Equal(t, "a2", readFile(t, a))
+	require.Equal(t, "b2", readFile(t, b))
+	require.ErrorIs(t, Rollback(zap.NewNop(), dests), ErrNoBackup)
+	require.Equal(t, "a2", readFile(t, a))
+}
+
+func TestRollbackNeedsEveryBackup(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	require.NoError(t, os.WriteFile(a, []byte("a1"), 0o600))
+	require.NoError(t, Deploy(zap.NewNop(), []string{"a", "b"}, []string{a, b}, contents(map[string]string{"a": "a2", "b": "b2"}), nil))
+
+	// b had no previous file, so nothing is restored
+	require.ErrorIs(t, Rollback(zap.NewNop(), []string{a, b}), ErrNoBackup)
+	require.Equal(t, "a2", readFile(t, a))
+	require.Equal(t, "b2", readFile(t, b))
+}
+
+func TestDeployFailedCheckChangesNothing(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	require.NoError(t, os.WriteFile(a, []byte("a1"), 0o600))
+	require.NoError(t, os.WriteFile(b, []byte("b1"), 0o600))
+
+	errBadChecksum := errors.New("bad checksum")
+	check := func(src, path string) error {
+		if src == "b" {
+			return errBadChecksum
+		}
+		return nil
+	}
+	err := Deploy(zap.NewNop(), []string{"a", "b"}, []string{a, b}, contents(map[string]string{"a": "a2", "b": "b2"}), check)
+	require.ErrorIs(t, err, errBadChecksum)
+
+	// the files are staged and checked before any is replaced
+	require.Equal(t, "a1", readFile(t, a))
+	require.Equal(t, "b1", readFile(t, b))
+	requireNoTempFiles(t, dir)
+	_, err = os.Stat(a + oldFileSuffix)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestDeployFailedReplaceRestoresReplaced(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	require.NoError(t, os.WriteFile(a, []byte("a1"), 0o600))
+	// a non-empty directory at b can not be backed up or replaced
+	require.NoError(t, os.MkdirAll(filepath.Join(b, "child"), 0o755))
+
+	err := Deploy(zap.NewNop(), []string{"a", "b"}, []string{a, b}, contents(map[string]string{"a": "a2", "b": "b2"}), nil)
+	require.Error(t, err)
+
+	// a was replaced before b failed, and is restored
+	require.Equal(t, "a1", readFile(t, a))
+	requireNoTempFiles(t, dir)
+}
+
+func TestDeployMismatchedArgs(t *testing.T) {
+	err := Deploy(zap.NewNop(), []string{"a"}, nil, contents(nil), nil)
+	require.ErrorIs(t, err, ErrArgsMismatched)
+}
@@ -0,0 +1,40 @@
+package hash
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidPublicKey = errors.New("invalid public key")
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// ParsePublicKey parses a PEM encoded PKIX Ed25519 public key, as written by
+// `openssl pkey -pubout`.
+func ParsePublicKey(b []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.Wrap(ErrInvalidPublicKey, "no PEM block found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidPublicKey, err.Error())
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.Wrapf(ErrInvalidPublicKey, "%T is not an ed25519 key", key)
+	}
+	return pub, nil
+}
+
+// Verify checks the detached Ed25519 signature over the checksum file.
+func Verify(pub ed25519.PublicKey, sums, sig []byte) error {
+	if !ed25519.Verify(pub, sums, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package hash
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func pemPublicKey(t *testing.T, key interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestParsePublicKey(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	got, err := ParsePublicKey(pemPublicKey(t, pub))
+	require.NoError(t, err)
+	require.Equal(t, pub, got)
+
+	_, err = ParsePublicKey([]byte("not a pem"))
+	require.ErrorIs(t, err, ErrInvalidPublicKey)
+
+	_, err = ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("garbage")}))
+	require.ErrorIs(t, err, ErrInvalidPublicKey)
+}
+
+func TestVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	sums := []byte("abc123  azure-vnet\n")
+	sig := ed25519.Sign(priv, sums)
+
+	require.NoError(t, Verify(pub, sums, sig))
+	require.ErrorIs(t, Verify(otherPub, sums, sig), ErrInvalidSignature)
+	require.ErrorIs(t, Verify(pub, []byte("abc124  azure-vnet\n"), sig), ErrInvalidSignature)
+	require.ErrorIs(t, Verify(pub, sums, sig[:len(sig)-1]), ErrInvalidSignature)
+}
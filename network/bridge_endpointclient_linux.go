@@ -64,6 +64,10 @@ func (client *LinuxBridgeEndpointClient) AddEndpoints(epInfo *EndpointInfo) erro
 		return err
 	}
 
+	if err := client.nuc.SetVethMTU(client.hostVethName, client.containerVethName, epInfo.MTU); err != nil {
+		return err
+	}
+
 	containerIf, err := net.InterfaceByName(client.containerVethName)
 	if err != nil {
 		return err
@@ -55,6 +55,7 @@ func (client *LinuxBridgeClient) CreateBridge() error {
 		LinkInfo: netlink.LinkInfo{
 			Type: netlink.LINK_TYPE_BRIDGE,
 			Name: client.bridgeName,
+			MTU:  uint(client.nwInfo.MTU),
 		},
 	}
 
@@ -83,6 +83,7 @@ type EndpointInfo struct {
 	VnetCidrs                string
 	ServiceCidrs             string
 	NATInfo                  []policy.NATInfo
+	MTU                      int
 }
 
 // RouteInfo contains information about an IP route.
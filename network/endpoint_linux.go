@@ -47,6 +47,30 @@ func ConstructEndpointID(containerID string, _ string, ifName string) (string, s
 	return infraEpName, ""
 }
 
+// getEndpointMTU returns the MTU of the endpoint's veths. It defaults to the MTU of the network and
+// cannot exceed it.
+func (nw *network) getEndpointMTU(epInfo *EndpointInfo) (int, error) {
+	if epInfo.MTU == 0 {
+		return nw.MTU, nil
+	}
+
+	if nw.MTU > 0 && epInfo.MTU > nw.MTU {
+		return 0, fmt.Errorf("%w: %d exceeds mtu %d of network %s", errInvalidMTU, epInfo.MTU, nw.MTU, nw.Id)
+	}
+
+	ipv6 := false
+	for _, ipAddr := range epInfo.IPAddresses {
+		if ipAddr.IP.To4() == nil {
+			ipv6 = true
+		}
+	}
+	if epInfo.MTU < minMTU(ipv6) {
+		return 0, fmt.Errorf("%w: %d is below the minimum of %d", errInvalidMTU, epInfo.MTU, minMTU(ipv6))
+	}
+
+	return epInfo.MTU, nil
+}
+
 // newEndpointImpl creates a new endpoint in the network.
 func (nw *network) newEndpointImpl(
 	_ apipaClient,
@@ -94,6 +118,10 @@ func (nw *network) newEndpointImpl(
 		contIfName = fmt.Sprintf("%s%s-2", hostVEthInterfacePrefix, epInfo.Id[:7])
 	}
 
+	if epInfo.MTU, err = nw.getEndpointMTU(epInfo); err != nil {
+		return nil, err
+	}
+
 	// epClient is non-nil only when the endpoint is created for the unit test.
 	if epClient == nil {
 		//nolint:gocritic
@@ -484,7 +512,8 @@ func (nm *networkManager) updateRoutes(existingEp *EndpointInfo, targetEp *Endpo
 
 	for _, existingRoute := range existingRoutes {
 		dst := existingRoute.Dst.String()
-		if _, ok := targetRoutes[dst]; !ok {
+		// a route whose gateway changed is replaced by deleting and re-adding it
+		if targetRoute, ok := targetRoutes[dst]; !ok || !targetRoute.Gw.Equal(existingRoute.Gw) {
 			tobeDeletedRoutes = append(tobeDeletedRoutes, existingRoute)
 			log.Printf("Adding following route to the tobeDeleted list: %+v", existingRoute)
 		}
@@ -492,7 +521,7 @@ func (nm *networkManager) updateRoutes(existingEp *EndpointInfo, targetEp *Endpo
 
 	for _, targetRoute := range targetRoutes {
 		dst := targetRoute.Dst.String()
-		if _, ok := existingRoutes[dst]; !ok {
+		if existingRoute, ok := existingRoutes[dst]; !ok || !existingRoute.Gw.Equal(targetRoute.Gw) {
 			tobeAddedRoutes = append(tobeAddedRoutes, targetRoute)
 			log.Printf("Adding following route to the tobeAdded list: %+v", targetRoute)
 		}
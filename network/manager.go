@@ -21,6 +21,7 @@ const (
 	// Network store key.
 	storeKey        = "Network"
 	VlanIDKey       = "VlanID"
+	EncapTypeKey    = "EncapType"
 	AzureCNS        = "azure-cns"
 	SNATIPKey       = "NCPrimaryIPKey"
 	RoutesKey       = "RoutesKey"
@@ -9,6 +9,9 @@ import (
 type MockNetworkManager struct {
 	TestNetworkInfoMap  map[string]*NetworkInfo
 	TestEndpointInfoMap map[string]*EndpointInfo
+
+	// endpointNetworks is the network of each endpoint created through the mock, by endpoint ID.
+	endpointNetworks map[string]string
 }
 
 // NewMockNetworkmanager returns a new mock
@@ -16,6 +19,7 @@ func NewMockNetworkmanager() *MockNetworkManager {
 	return &MockNetworkManager{
 		TestNetworkInfoMap:  make(map[string]*NetworkInfo),
 		TestEndpointInfoMap: make(map[string]*EndpointInfo),
+		endpointNetworks:    make(map[string]string),
 	}
 }
 
@@ -54,22 +58,40 @@ func (nm *MockNetworkManager) GetNetworkInfo(networkID string) (NetworkInfo, err
 // CreateEndpoint mock
 func (nm *MockNetworkManager) CreateEndpoint(_ apipaClient, networkID string, epInfo *EndpointInfo) error {
 	nm.TestEndpointInfoMap[epInfo.Id] = epInfo
+	nm.endpointNetworks[epInfo.Id] = networkID
 	return nil
 }
 
+// inNetwork returns true if the endpoint is in the network. Endpoints that were not created through
+// the mock are in every network.
+func (nm *MockNetworkManager) inNetwork(networkID, endpointID string) bool {
+	epNetworkID, ok := nm.endpointNetworks[endpointID]
+	return !ok || epNetworkID == networkID
+}
+
 // DeleteEndpoint mock
 func (nm *MockNetworkManager) DeleteEndpoint(networkID, endpointID string) error {
+	if !nm.inNetwork(networkID, endpointID) {
+		return errEndpointNotFound
+	}
 	delete(nm.TestEndpointInfoMap, endpointID)
+	delete(nm.endpointNetworks, endpointID)
 	return nil
 }
 
 func (nm *MockNetworkManager) GetAllEndpoints(networkID string) (map[string]*EndpointInfo, error) {
-	return nm.TestEndpointInfoMap, nil
+	endpoints := make(map[string]*EndpointInfo)
+	for id, info := range nm.TestEndpointInfoMap {
+		if nm.inNetwork(networkID, id) {
+			endpoints[id] = info
+		}
+	}
+	return endpoints, nil
 }
 
 // GetEndpointInfo mock
 func (nm *MockNetworkManager) GetEndpointInfo(networkID string, endpointID string) (*EndpointInfo, error) {
-	if info, exists := nm.TestEndpointInfoMap[endpointID]; exists {
+	if info, exists := nm.TestEndpointInfoMap[endpointID]; exists && nm.inNetwork(networkID, endpointID) {
 		return info, nil
 	}
 	return nil, errEndpointNotFound
@@ -54,6 +54,7 @@ type network struct {
 	EnableSnatOnHost bool
 	NetNs            string
 	SnatBridgeIP     string
+	MTU              int `json:",omitempty"`
 }
 
 // NetworkInfo contains read-only information about a container network.
@@ -75,6 +76,7 @@ type NetworkInfo struct {
 	IPAMType                      string
 	ServiceCidrs                  string
 	IsIPv6Enabled                 bool
+	MTU                           int
 }
 
 // SubnetInfo contains subnet information for a container network.
@@ -41,6 +41,19 @@ const (
 	InfraVnetIPKey = "infraVnetIP"
 )
 
+const (
+	// vlanHeaderLen is the size of the 802.1Q tag carried by frames of vlan tagged networks.
+	vlanHeaderLen = 4
+	// vxlanHeaderLen is the size of the outer Ethernet, IPv4, UDP and VXLAN headers of vxlan encapsulated networks.
+	vxlanHeaderLen = 50
+	// encapTypeVxlan is the EncapTypeKey option of vxlan encapsulated networks, which the CNI sets from the
+	// multitenancy info of the NC.
+	encapTypeVxlan = "Vxlan"
+	// Smallest MTUs supported by IPv4 and IPv6.
+	minIPv4MTU = 68
+	minIPv6MTU = 1280
+)
+
 const (
 	lineDelimiter  = "\n"
 	colonDelimiter = ":"
@@ -66,6 +79,13 @@ func (nm *networkManager) newNetworkImpl(nwInfo *NetworkInfo, extIf *externalInt
 	opt, _ := nwInfo.Options[genericData].(map[string]interface{})
 	log.Printf("opt %+v options %+v", opt, nwInfo.Options)
 
+	// Resolve the MTU before connecting the external interface so that the bridge is created with it.
+	mtu, err := nm.getNetworkMTU(nwInfo, extIf, encapOverhead(nwInfo, opt))
+	if err != nil {
+		return nil, err
+	}
+	nwInfo.MTU = mtu
+
 	switch nwInfo.Mode {
 	case opModeTunnel:
 		fallthrough
@@ -95,7 +115,7 @@ func (nm *networkManager) newNetworkImpl(nwInfo *NetworkInfo, extIf *externalInt
 		return nil, errNetworkModeInvalid
 	}
 
-	err := nm.handleCommonOptions(ifName, nwInfo)
+	err = nm.handleCommonOptions(ifName, nwInfo)
 	if err != nil {
 		log.Printf("handleCommonOptions failed with error %s", err.Error())
 		return nil, err
@@ -110,11 +130,58 @@ func (nm *networkManager) newNetworkImpl(nwInfo *NetworkInfo, extIf *externalInt
 		VlanId:           vlanid,
 		DNS:              nwInfo.DNS,
 		EnableSnatOnHost: nwInfo.EnableSnatOnHost,
+		MTU:              mtu,
 	}
 
 	return nw, nil
 }
 
+// encapOverhead returns the bytes the encapsulation of the network adds to every frame on the master interface.
+func encapOverhead(nwInfo *NetworkInfo, opt map[string]interface{}) int {
+	if opt != nil && opt[EncapTypeKey] == encapTypeVxlan {
+		return vxlanHeaderLen
+	}
+	if nwInfo.Mode == opModeTransparentVlan || (opt != nil && opt[VlanIDKey] != nil) {
+		return vlanHeaderLen
+	}
+	return 0
+}
+
+// getNetworkMTU returns the MTU of the network's bridge and veths. The configured MTU is used when set, otherwise
+// the MTU of the master interface minus the encapsulation overhead, if any. A configured MTU larger than what the
+// master interface can carry is rejected since such frames are dropped on the wire; jumbo frames need the master
+// interface to be configured for them first.
+func (nm *networkManager) getNetworkMTU(nwInfo *NetworkInfo, extIf *externalInterface, overhead int) (int, error) {
+	hostIf, err := nm.netio.GetNetworkInterfaceByName(extIf.Name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get master interface %s: %w", extIf.Name, err)
+	}
+
+	maxMTU := hostIf.MTU - overhead
+
+	if nwInfo.MTU == 0 {
+		log.Printf("[net] Using mtu %d derived from master interface %s", maxMTU, extIf.Name)
+		return maxMTU, nil
+	}
+
+	if nwInfo.MTU > maxMTU {
+		return 0, fmt.Errorf("%w: %d exceeds %d supported by master interface %s", errInvalidMTU, nwInfo.MTU, maxMTU, extIf.Name)
+	}
+
+	if nwInfo.MTU < minMTU(nwInfo.IsIPv6Enabled) {
+		return 0, fmt.Errorf("%w: %d is below the minimum of %d", errInvalidMTU, nwInfo.MTU, minMTU(nwInfo.IsIPv6Enabled))
+	}
+
+	return nwInfo.MTU, nil
+}
+
+func minMTU(ipv6 bool) int {
+	if ipv6 {
+		return minIPv6MTU
+	}
+	return minIPv4MTU
+}
+
 func (nm *networkManager) handleCommonOptions(ifName string, nwInfo *NetworkInfo) error {
 	var err error
 	if routes, exists := nwInfo.Options[RoutesKey]; exists {
@@ -0,0 +1,118 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"net"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetNetworkMTU(t *testing.T) {
+	// the mock master interface has an mtu of 1000
+	tests := []struct {
+		name     string
+		nwInfo   *NetworkInfo
+		overhead int
+		netio    netio.NetIOInterface
+		want     int
+		wantErr  error
+	}{
+		{
+			name:   "derived from master interface",
+			nwInfo: &NetworkInfo{},
+			netio:  netio.NewMockNetIO(false, 0),
+			want:   1000,
+		},
+		{
+			name:     "derived from master interface minus vlan tag",
+			nwInfo:   &NetworkInfo{},
+			overhead: vlanHeaderLen,
+			netio:    netio.NewMockNetIO(false, 0),
+			want:     996,
+		},
+		{
+			name:     "derived from master interface minus vxlan headers",
+			nwInfo:   &NetworkInfo{},
+			overhead: vxlanHeaderLen,
+			netio:    netio.NewMockNetIO(false, 0),
+			want:     950,
+		},
+		{
+			name:   "configured",
+			nwInfo: &NetworkInfo{MTU: 900},
+			netio:  netio.NewMockNetIO(false, 0),
+			want:   900,
+		},
+		{
+			name:    "configured above master interface",
+			nwInfo:  &NetworkInfo{MTU: 9000},
+			netio:   netio.NewMockNetIO(false, 0),
+			wantErr: errInvalidMTU,
+		},
+		{
+			name:     "configured above master interface minus vlan tag",
+			nwInfo:   &NetworkInfo{MTU: 1000},
+			overhead: vlanHeaderLen,
+			netio:    netio.NewMockNetIO(false, 0),
+			wantErr:  errInvalidMTU,
+		},
+		{
+			name:    "configured below ipv6 minimum",
+			nwInfo:  &NetworkInfo{MTU: 600, IsIPv6Enabled: true},
+			netio:   netio.NewMockNetIO(false, 0),
+			wantErr: errInvalidMTU,
+		},
+		{
+			name:    "master interface not found",
+			nwInfo:  &NetworkInfo{},
+			netio:   netio.NewMockNetIO(true, 1),
+			wantErr: netio.ErrMockNetIOFail,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			nm := &networkManager{netio: tt.netio}
+			got, err := nm.getNetworkMTU(tt.nwInfo, &externalInterface{Name: "eth0"}, tt.overhead)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEncapOverhead(t *testing.T) {
+	require.Equal(t, 0, encapOverhead(&NetworkInfo{Mode: opModeBridge}, nil))
+	require.Equal(t, vlanHeaderLen, encapOverhead(&NetworkInfo{Mode: opModeTransparentVlan}, nil))
+	require.Equal(t, vlanHeaderLen, encapOverhead(&NetworkInfo{Mode: opModeBridge}, map[string]interface{}{VlanIDKey: "1", EncapTypeKey: "Vlan"}))
+	require.Equal(t, vxlanHeaderLen, encapOverhead(&NetworkInfo{Mode: opModeBridge}, map[string]interface{}{VlanIDKey: "1", EncapTypeKey: encapTypeVxlan}))
+}
+
+func TestGetEndpointMTU(t *testing.T) {
+	nw := &network{Id: "nw", MTU: 1500}
+
+	mtu, err := nw.getEndpointMTU(&EndpointInfo{})
+	require.NoError(t, err)
+	require.Equal(t, 1500, mtu)
+
+	mtu, err = nw.getEndpointMTU(&EndpointInfo{MTU: 1400})
+	require.NoError(t, err)
+	require.Equal(t, 1400, mtu)
+
+	_, err = nw.getEndpointMTU(&EndpointInfo{MTU: 9000})
+	require.ErrorIs(t, err, errInvalidMTU)
+
+	_, err = nw.getEndpointMTU(&EndpointInfo{
+		MTU:         1000,
+		IPAddresses: []net.IPNet{{IP: net.ParseIP("fd00::5"), Mask: net.CIDRMask(64, 128)}},
+	})
+	require.ErrorIs(t, err, errInvalidMTU)
+}
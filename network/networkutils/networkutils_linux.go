@@ -86,6 +86,22 @@ func (nu NetworkUtils) CreateEndpoint(hostVethName, containerVethName string, ma
 	return nil
 }
 
+// SetVethMTU sets the MTU of both ends of a veth pair. A zero MTU leaves the kernel default in place.
+func (nu NetworkUtils) SetVethMTU(hostVethName, containerVethName string, mtu int) error {
+	if mtu == 0 {
+		return nil
+	}
+
+	for _, name := range []string{hostVethName, containerVethName} {
+		log.Printf("[net] Setting mtu %d on veth interface %s", mtu, name)
+		if err := nu.netlink.SetLinkMTU(name, mtu); err != nil {
+			return errors.Wrapf(err, "failed to set mtu %d on veth interface %s", mtu, name)
+		}
+	}
+
+	return nil
+}
+
 func (nu NetworkUtils) SetupContainerInterface(containerVethName, targetIfName string) error {
 	// Interface needs to be down before renaming.
 	log.Printf("[net] Setting link %v state down.", containerVethName)
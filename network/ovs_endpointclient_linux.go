@@ -83,6 +83,10 @@ func (client *OVSEndpointClient) AddEndpoints(epInfo *EndpointInfo) error {
 		return err
 	}
 
+	if err := epc.SetVethMTU(client.hostVethName, client.containerVethName, epInfo.MTU); err != nil {
+		return err
+	}
+
 	containerIf, err := net.InterfaceByName(client.containerVethName)
 	if err != nil {
 		log.Printf("InterfaceByName returns error for ifname %v with error %v", client.containerVethName, err)
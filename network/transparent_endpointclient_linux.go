@@ -121,13 +121,15 @@ func (client *TransparentEndpointClient) AddEndpoints(epInfo *EndpointInfo) erro
 
 	client.hostVethMac = hostVethIf.HardwareAddr
 
-	log.Printf("Setting mtu %d on veth interface %s", primaryIf.MTU, client.hostVethName)
-	if err := client.netlink.SetLinkMTU(client.hostVethName, primaryIf.MTU); err != nil {
-		log.Errorf("Setting mtu failed for hostveth %s:%v", client.hostVethName, err)
+	// networks created before the mtu was tracked use the mtu of the primary interface
+	mtu := epInfo.MTU
+	if mtu == 0 {
+		mtu = primaryIf.MTU
 	}
 
-	if err := client.netlink.SetLinkMTU(client.containerVethName, primaryIf.MTU); err != nil {
-		log.Errorf("Setting mtu failed for containerveth %s:%v", client.containerVethName, err)
+	// the veths still work at their default mtu, so a failure is not fatal to the endpoint
+	if err = client.netUtilsClient.SetVethMTU(client.hostVethName, client.containerVethName, mtu); err != nil {
+		log.Errorf("Setting mtu failed for veths of %s:%v", client.hostVethName, err)
 	}
 
 	return nil
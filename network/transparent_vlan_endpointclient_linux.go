@@ -81,7 +81,7 @@ func NewTransparentVlanEndpointClient(
 	plc platform.ExecClient,
 ) *TransparentVlanEndpointClient {
 	vlanVethName := fmt.Sprintf("%s_%d", nw.extIf.Name, vlanid)
-	vnetNSName := fmt.Sprintf("az_ns_%d", vlanid)
+	vnetNSName := fmt.Sprintf("%s%d", vnetNSPrefix, vlanid)
 
 	client := &TransparentVlanEndpointClient{
 		primaryHostIfName:        nw.extIf.Name,
@@ -257,6 +257,12 @@ func (client *TransparentVlanEndpointClient) PopulateVM(epInfo *EndpointInfo) er
 	if err = client.netUtilsClient.CreateEndpoint(client.vnetVethName, client.containerVethName, mac); err != nil {
 		return errors.Wrap(err, "failed to create veth pair")
 	}
+	if err = client.netUtilsClient.SetVethMTU(client.vnetVethName, client.containerVethName, epInfo.MTU); err != nil {
+		if delErr := client.netlink.DeleteLink(client.vnetVethName); delErr != nil {
+			log.Errorf("Deleting vnet veth failed on addendpoint failure:%v", delErr)
+		}
+		return errors.Wrap(err, "failed to set mtu on veth pair, deleting")
+	}
 	// Disable RA for veth pair, and delete if any failure
 	if err = client.netUtilsClient.DisableRAForInterface(client.vnetVethName); err != nil {
 		if delErr := client.netlink.DeleteLink(client.vnetVethName); delErr != nil {
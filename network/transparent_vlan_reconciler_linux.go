@@ -0,0 +1,396 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/Azure/azure-container-networking/netns"
+	"github.com/pkg/errors"
+	vishnetlink "github.com/vishvananda/netlink"
+)
+
+const (
+	// vnetNSPrefix is the prefix of the vnet namespaces created by the transparent vlan endpoint client.
+	vnetNSPrefix = "az_ns_"
+	// namedNSDir is where named network namespaces are mounted.
+	namedNSDir = "/var/run/netns"
+)
+
+var errUnsupportedNetworkManager = errors.New("network manager does not expose transparent vlan state")
+
+// NCVlanLister returns the vlan ids of the network containers that CNS has on the node.
+type NCVlanLister interface {
+	NCVlanIDs(ctx context.Context) ([]int, error)
+}
+
+// NCVlanListerFunc is an adapter to use a function as an NCVlanLister.
+type NCVlanListerFunc func(ctx context.Context) ([]int, error)
+
+func (f NCVlanListerFunc) NCVlanIDs(ctx context.Context) ([]int, error) {
+	return f(ctx)
+}
+
+// transparentVlanStateReader exposes the transparent vlan endpoints in the CNI state.
+type transparentVlanStateReader interface {
+	transparentVlanEndpoints() []*endpoint
+	// transparentVlanMasters returns the names of the master interfaces of the transparent vlan networks.
+	transparentVlanMasters() []string
+}
+
+// transparentVlanLister lists the host state created by the transparent vlan endpoint client.
+type transparentVlanLister interface {
+	// NamespaceNames returns the names of the named network namespaces.
+	NamespaceNames() ([]string, error)
+	// LinkNames returns the names of the links in the named network namespace, or in the VM namespace if
+	// nsName is empty.
+	LinkNames(nsName string) ([]string, error)
+	// Rules returns the IPv4 routing rules of the named network namespace.
+	Rules(nsName string) ([]vishnetlink.Rule, error)
+	// DeleteRule deletes a routing rule of the named network namespace.
+	DeleteRule(nsName string, rule *vishnetlink.Rule) error
+}
+
+// TransparentVlanReconcileReport lists the orphaned transparent vlan resources found by a reconcile. On a dry
+// run nothing is removed, otherwise it lists what was removed.
+type TransparentVlanReconcileReport struct {
+	// Namespaces are vnet namespaces whose vlan is not used by any endpoint or network container. Removing
+	// a namespace also removes the vlan link, veths, routes and rules inside it.
+	Namespaces []string
+	// VlanLinks are vlan links left in the VM namespace by an interrupted ADD.
+	VlanLinks []string
+	// Veths are vnet veths, as <namespace>/<link>, of endpoints that are not in the CNI state.
+	Veths []string
+	// Rules are tunneling rules, as <namespace>/<rule>, that duplicate the one rule of a vnet namespace or
+	// point at another routing table.
+	Rules []string
+	// Errors are the failures to remove resources. Resources that failed are not listed above.
+	Errors []error
+}
+
+// TransparentVlanReconciler removes the vnet namespaces, vlan links, veths and routing rules left on the host by the
+// transparent vlan endpoint client when a CNI DEL is lost. A resource is kept if its vlan is used by an endpoint in the CNI state
+// or by a network container in CNS, so the caller must hold the CNI lock while reconciling to keep ADDs that are in
+// flight from being cleaned up.
+type TransparentVlanReconciler struct {
+	primaryIfName string
+	state         transparentVlanStateReader
+	ncs           NCVlanLister
+	lister        transparentVlanLister
+	netnsClient   netnsClient
+	netlink       netlink.NetlinkInterface
+	execInNS      func(nsName string, f func() error) error
+}
+
+// NewTransparentVlanReconciler creates a reconciler for the transparent vlan resources created on top of the
+// primary interface. If primaryIfName is empty, the master interfaces of the transparent vlan networks in the CNI
+// state are used.
+func NewTransparentVlanReconciler(
+	nm NetworkManager,
+	ncs NCVlanLister,
+	primaryIfName string,
+	nl netlink.NetlinkInterface,
+) (*TransparentVlanReconciler, error) {
+	state, ok := nm.(transparentVlanStateReader)
+	if !ok {
+		return nil, errUnsupportedNetworkManager
+	}
+
+	return &TransparentVlanReconciler{
+		primaryIfName: primaryIfName,
+		state:         state,
+		ncs:           ncs,
+		lister:        hostTransparentVlanLister{},
+		netnsClient:   netns.New(),
+		netlink:       nl,
+		execInNS:      ExecuteInNS,
+	}, nil
+}
+
+// Reconcile finds the orphaned transparent vlan resources and removes them unless dryRun is set. Nothing is removed
+// if CNS cannot be reached, since the network containers it knows about could not be protected.
+func (r *TransparentVlanReconciler) Reconcile(ctx context.Context, dryRun bool) (*TransparentVlanReconcileReport, error) {
+	ncVlans, err := r.ncs.NCVlanIDs(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get network container vlans from CNS")
+	}
+
+	inUse := make(map[int]struct{}, len(ncVlans))
+	for _, vlanID := range ncVlans {
+		inUse[vlanID] = struct{}{}
+	}
+
+	vethsInUse := make(map[string]struct{})
+	for _, ep := range r.state.transparentVlanEndpoints() {
+		inUse[ep.VlanID] = struct{}{}
+		vethsInUse[ep.HostIfName] = struct{}{}
+	}
+
+	report := &TransparentVlanReconcileReport{}
+
+	nsNames, err := r.lister.NamespaceNames()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list network namespaces")
+	}
+
+	for _, nsName := range nsNames {
+		vlanID, ok := parseVlanSuffix(nsName, vnetNSPrefix)
+		if !ok {
+			continue
+		}
+
+		if _, ok := inUse[vlanID]; !ok {
+			log.Printf("[transparent vlan] Vnet namespace %s of vlan %d is orphaned", nsName, vlanID)
+			if !dryRun {
+				if err := r.netnsClient.DeleteNamed(nsName); err != nil {
+					report.Errors = append(report.Errors, errors.Wrapf(err, "failed to delete namespace %s", nsName))
+					continue
+				}
+			}
+			report.Namespaces = append(report.Namespaces, nsName)
+			continue
+		}
+
+		r.reconcileVeths(nsName, vethsInUse, dryRun, report)
+		r.reconcileRules(nsName, dryRun, report)
+	}
+
+	masters := []string{r.primaryIfName}
+	if r.primaryIfName == "" {
+		masters = r.state.transparentVlanMasters()
+	}
+	if len(masters) == 0 {
+		return report, nil
+	}
+
+	links, err := r.lister.LinkNames("")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list links")
+	}
+
+	for _, link := range links {
+		vlanID, ok := 0, false
+		for _, master := range masters {
+			if vlanID, ok = parseVlanSuffix(link, master+"_"); ok {
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		// vlan links are moved into the vnet namespace right after creation, so one left in the VM namespace
+		// is from an ADD that failed midway.
+		if _, ok := inUse[vlanID]; ok {
+			continue
+		}
+
+		log.Printf("[transparent vlan] Vlan link %s of vlan %d is orphaned", link, vlanID)
+		if !dryRun {
+			if err := r.netlink.DeleteLink(link); err != nil {
+				report.Errors = append(report.Errors, errors.Wrapf(err, "failed to delete vlan link %s", link))
+				continue
+			}
+		}
+		report.VlanLinks = append(report.VlanLinks, link)
+	}
+
+	return report, nil
+}
+
+// reconcileVeths removes the vnet veths in a vnet namespace that belong to endpoints not in the CNI state.
+func (r *TransparentVlanReconciler) reconcileVeths(nsName string, vethsInUse map[string]struct{}, dryRun bool, report *TransparentVlanReconcileReport) {
+	links, err := r.lister.LinkNames(nsName)
+	if err != nil {
+		report.Errors = append(report.Errors, errors.Wrapf(err, "failed to list links in namespace %s", nsName))
+		return
+	}
+
+	var orphans []string
+	for _, link := range links {
+		if !strings.HasPrefix(link, hostVEthInterfacePrefix) {
+			continue
+		}
+		if _, ok := vethsInUse[link]; !ok {
+			log.Printf("[transparent vlan] Vnet veth %s in namespace %s is orphaned", link, nsName)
+			orphans = append(orphans, link)
+		}
+	}
+
+	if len(orphans) == 0 {
+		return
+	}
+
+	if dryRun {
+		for _, link := range orphans {
+			report.Veths = append(report.Veths, nsName+"/"+link)
+		}
+		return
+	}
+
+	// routes to the pod ips go away with the veth
+	err = r.execInNS(nsName, func() error {
+		for _, link := range orphans {
+			if err := r.netlink.DeleteLink(link); err != nil {
+				report.Errors = append(report.Errors, errors.Wrapf(err, "failed to delete veth %s in namespace %s", link, nsName))
+				continue
+			}
+			report.Veths = append(report.Veths, nsName+"/"+link)
+		}
+		return nil
+	})
+	if err != nil {
+		report.Errors = append(report.Errors, errors.Wrapf(err, "failed to enter namespace %s", nsName))
+	}
+}
+
+// reconcileRules removes the tunneling rules of a vnet namespace other than the one the endpoint client adds.
+func (r *TransparentVlanReconciler) reconcileRules(nsName string, dryRun bool, report *TransparentVlanReconcileReport) {
+	rules, err := r.lister.Rules(nsName)
+	if err != nil {
+		report.Errors = append(report.Errors, errors.Wrapf(err, "failed to list rules in namespace %s", nsName))
+		return
+	}
+
+	found := false
+	for i := range rules {
+		if rules[i].Mark != tunnelingMark {
+			continue
+		}
+		if rules[i].Table == tunnelingTable && !found {
+			found = true
+			continue
+		}
+
+		rule := fmt.Sprintf("%s/fwmark %d lookup %d", nsName, rules[i].Mark, rules[i].Table)
+		log.Printf("[transparent vlan] Rule %s is orphaned", rule)
+		if !dryRun {
+			if err := r.lister.DeleteRule(nsName, &rules[i]); err != nil {
+				report.Errors = append(report.Errors, errors.Wrapf(err, "failed to delete rule %s", rule))
+				continue
+			}
+		}
+		report.Rules = append(report.Rules, rule)
+	}
+}
+
+// parseVlanSuffix returns the vlan id of a name made of the prefix and a vlan id.
+func parseVlanSuffix(name, prefix string) (int, bool) {
+	if !strings.HasPrefix(name, prefix) {
+		return 0, false
+	}
+
+	vlanID, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+	if err != nil || vlanID <= 0 {
+		return 0, false
+	}
+
+	return vlanID, true
+}
+
+// transparentVlanEndpoints returns the endpoints of the transparent vlan networks in the CNI state.
+func (nm *networkManager) transparentVlanEndpoints() []*endpoint {
+	var endpoints []*endpoint
+	for _, extIf := range nm.ExternalInterfaces {
+		for _, nw := range extIf.Networks {
+			if nw.Mode != opModeTransparentVlan {
+				continue
+			}
+			for _, ep := range nw.Endpoints {
+				endpoints = append(endpoints, ep)
+			}
+		}
+	}
+
+	return endpoints
+}
+
+// transparentVlanMasters returns the names of the master interfaces of the transparent vlan networks in the CNI state.
+func (nm *networkManager) transparentVlanMasters() []string {
+	var masters []string
+	for name, extIf := range nm.ExternalInterfaces {
+		for _, nw := range extIf.Networks {
+			if nw.Mode == opModeTransparentVlan {
+				masters = append(masters, name)
+				break
+			}
+		}
+	}
+	sort.Strings(masters)
+
+	return masters
+}
+
+// hostTransparentVlanLister lists the namespaces, links and rules of the host.
+type hostTransparentVlanLister struct{}
+
+func (hostTransparentVlanLister) NamespaceNames() ([]string, error) {
+	entries, err := os.ReadDir(namedNSDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read %s", namedNSDir)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (hostTransparentVlanLister) LinkNames(nsName string) ([]string, error) {
+	var names []string
+	listLinks := func() error {
+		links, err := vishnetlink.LinkList()
+		if err != nil {
+			return errors.Wrap(err, "failed to list links")
+		}
+		for _, link := range links {
+			names = append(names, link.Attrs().Name)
+		}
+		return nil
+	}
+
+	if nsName == "" {
+		if err := listLinks(); err != nil {
+			return nil, err
+		}
+		return names, nil
+	}
+
+	if err := ExecuteInNS(nsName, listLinks); err != nil {
+		return nil, errors.Wrapf(err, "failed to list links in namespace %s", nsName)
+	}
+
+	return names, nil
+}
+
+func (hostTransparentVlanLister) Rules(nsName string) ([]vishnetlink.Rule, error) {
+	var rules []vishnetlink.Rule
+	err := ExecuteInNS(nsName, func() error {
+		var err error
+		rules, err = vishnetlink.RuleList(vishnetlink.FAMILY_V4)
+		return errors.Wrap(err, "failed to list rules")
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list rules in namespace %s", nsName)
+	}
+
+	return rules, nil
+}
+
+func (hostTransparentVlanLister) DeleteRule(nsName string, rule *vishnetlink.Rule) error {
+	err := ExecuteInNS(nsName, func() error {
+		return errors.Wrap(vishnetlink.RuleDel(rule), "failed to delete rule")
+	})
+	return errors.Wrapf(err, "failed to delete rule in namespace %s", nsName)
+}
@@ -0,0 +1,228 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	vishnetlink "github.com/vishvananda/netlink"
+)
+
+var errMockCNS = errors.New("mock cns error")
+
+type mockTransparentVlanState struct {
+	endpoints []*endpoint
+	masters   []string
+}
+
+func (s *mockTransparentVlanState) transparentVlanEndpoints() []*endpoint {
+	return s.endpoints
+}
+
+func (s *mockTransparentVlanState) transparentVlanMasters() []string {
+	return s.masters
+}
+
+type mockTransparentVlanLister struct {
+	namespaces   []string
+	links        map[string][]string
+	rules        map[string][]vishnetlink.Rule
+	deletedRules []string
+}
+
+func (l *mockTransparentVlanLister) NamespaceNames() ([]string, error) {
+	return l.namespaces, nil
+}
+
+func (l *mockTransparentVlanLister) LinkNames(nsName string) ([]string, error) {
+	return l.links[nsName], nil
+}
+
+func (l *mockTransparentVlanLister) Rules(nsName string) ([]vishnetlink.Rule, error) {
+	return l.rules[nsName], nil
+}
+
+func (l *mockTransparentVlanLister) DeleteRule(nsName string, _ *vishnetlink.Rule) error {
+	l.deletedRules = append(l.deletedRules, nsName)
+	return nil
+}
+
+// tunnelingRule returns a tunneling rule that looks up the table.
+func tunnelingRule(table int) vishnetlink.Rule {
+	rule := vishnetlink.NewRule()
+	rule.Mark = tunnelingMark
+	rule.Table = table
+	return *rule
+}
+
+func newTestTransparentVlanReconciler(ncVlans []int, ncErr error, nl netlink.NetlinkInterface, deleted *[]string) *TransparentVlanReconciler {
+	return &TransparentVlanReconciler{
+		primaryIfName: "eth0",
+		state: &mockTransparentVlanState{
+			endpoints: []*endpoint{
+				{Id: "ep1", VlanID: 1, HostIfName: "azv1111"},
+				{Id: "ep2", VlanID: 2, HostIfName: "azv2222"},
+			},
+		},
+		ncs: NCVlanListerFunc(func(context.Context) ([]int, error) {
+			return ncVlans, ncErr
+		}),
+		lister: &mockTransparentVlanLister{
+			namespaces: []string{"az_ns_1", "az_ns_2", "az_ns_3", "az_ns_4", "cni-1234", "az_ns_x"},
+			links: map[string][]string{
+				"":        {"lo", "eth0", "eth0_4", "eth0_5", "azvsnat"},
+				"az_ns_1": {"lo", "eth0_1", "azv1111", "azv9999"},
+				"az_ns_2": {"lo", "eth0_2", "azv2222"},
+			},
+			rules: map[string][]vishnetlink.Rule{
+				"az_ns_1": {tunnelingRule(tunnelingTable)},
+				"az_ns_2": {tunnelingRule(tunnelingTable), tunnelingRule(tunnelingTable), tunnelingRule(3)},
+			},
+		},
+		netnsClient: &mockNetns{
+			deleteNamed: func(name string) error {
+				*deleted = append(*deleted, name)
+				return nil
+			},
+		},
+		netlink: nl,
+		execInNS: func(_ string, f func() error) error {
+			return f()
+		},
+	}
+}
+
+func TestTransparentVlanReconcile(t *testing.T) {
+	tests := []struct {
+		name           string
+		dryRun         bool
+		ncVlans        []int
+		nl             netlink.NetlinkInterface
+		wantNamespaces []string
+		wantVlanLinks  []string
+		wantVeths      []string
+		wantRules      []string
+		wantDeleted    []string
+		wantErrors     int
+	}{
+		{
+			name:           "dry run reports orphans without removing them",
+			dryRun:         true,
+			ncVlans:        []int{4},
+			nl:             netlink.NewMockNetlink(false, ""),
+			wantNamespaces: []string{"az_ns_3"},
+			wantVlanLinks:  []string{"eth0_5"},
+			wantVeths:      []string{"az_ns_1/azv9999"},
+			wantRules:      []string{"az_ns_2/fwmark 333 lookup 2", "az_ns_2/fwmark 333 lookup 3"},
+		},
+		{
+			name:           "orphans are removed",
+			ncVlans:        []int{4},
+			nl:             netlink.NewMockNetlink(false, ""),
+			wantNamespaces: []string{"az_ns_3"},
+			wantVlanLinks:  []string{"eth0_5"},
+			wantVeths:      []string{"az_ns_1/azv9999"},
+			wantRules:      []string{"az_ns_2/fwmark 333 lookup 2", "az_ns_2/fwmark 333 lookup 3"},
+			wantDeleted:    []string{"az_ns_3"},
+		},
+		{
+			name:           "network container without endpoints keeps its namespace",
+			ncVlans:        []int{3, 4, 5},
+			nl:             netlink.NewMockNetlink(false, ""),
+			wantNamespaces: nil,
+			wantVlanLinks:  nil,
+			wantVeths:      []string{"az_ns_1/azv9999"},
+			wantRules:      []string{"az_ns_2/fwmark 333 lookup 2", "az_ns_2/fwmark 333 lookup 3"},
+		},
+		{
+			name:           "link removal failures are reported",
+			ncVlans:        []int{4},
+			nl:             netlink.NewMockNetlink(true, "delete failed"),
+			wantNamespaces: []string{"az_ns_3"},
+			wantRules:      []string{"az_ns_2/fwmark 333 lookup 2", "az_ns_2/fwmark 333 lookup 3"},
+			wantDeleted:    []string{"az_ns_3"},
+			wantErrors:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted []string
+			r := newTestTransparentVlanReconciler(tt.ncVlans, nil, tt.nl, &deleted)
+
+			report, err := r.Reconcile(context.Background(), tt.dryRun)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantNamespaces, report.Namespaces)
+			require.Equal(t, tt.wantVlanLinks, report.VlanLinks)
+			require.Equal(t, tt.wantVeths, report.Veths)
+			require.Equal(t, tt.wantRules, report.Rules)
+			require.Len(t, report.Errors, tt.wantErrors)
+			require.Equal(t, tt.wantDeleted, deleted)
+			if tt.dryRun {
+				require.Empty(t, r.lister.(*mockTransparentVlanLister).deletedRules)
+			} else {
+				require.Len(t, r.lister.(*mockTransparentVlanLister).deletedRules, len(tt.wantRules))
+			}
+		})
+	}
+}
+
+func TestTransparentVlanReconcileMastersFromState(t *testing.T) {
+	var deleted []string
+	r := newTestTransparentVlanReconciler([]int{4}, nil, netlink.NewMockNetlink(false, ""), &deleted)
+	r.primaryIfName = ""
+
+	// without transparent vlan networks in the state no vlan link is known to be ours
+	report, err := r.Reconcile(context.Background(), true)
+	require.NoError(t, err)
+	require.Empty(t, report.VlanLinks)
+
+	r.state.(*mockTransparentVlanState).masters = []string{"eth0"}
+	report, err = r.Reconcile(context.Background(), true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"eth0_5"}, report.VlanLinks)
+}
+
+func TestTransparentVlanReconcileCNSUnavailable(t *testing.T) {
+	var deleted []string
+	r := newTestTransparentVlanReconciler(nil, errMockCNS, netlink.NewMockNetlink(false, ""), &deleted)
+
+	_, err := r.Reconcile(context.Background(), false)
+	require.ErrorIs(t, err, errMockCNS)
+	require.Empty(t, deleted)
+}
+
+func TestTransparentVlanEndpoints(t *testing.T) {
+	nm := &networkManager{
+		ExternalInterfaces: map[string]*externalInterface{
+			"eth0": {
+				Networks: map[string]*network{
+					"vlan": {
+						Mode:      opModeTransparentVlan,
+						Endpoints: map[string]*endpoint{"ep1": {Id: "ep1", VlanID: 1}},
+					},
+					"transparent": {
+						Mode:      opModeTransparent,
+						Endpoints: map[string]*endpoint{"ep2": {Id: "ep2"}},
+					},
+				},
+			},
+		},
+	}
+
+	endpoints := nm.transparentVlanEndpoints()
+	require.Len(t, endpoints, 1)
+	require.Equal(t, "ep1", endpoints[0].Id)
+	require.Equal(t, []string{"eth0"}, nm.transparentVlanMasters())
+
+	_, err := NewTransparentVlanReconciler(nm, nil, "eth0", netlink.NewMockNetlink(false, ""))
+	require.NoError(t, err)
+	_, err = NewTransparentVlanReconciler(NewMockNetworkmanager(), nil, "eth0", netlink.NewMockNetlink(false, ""))
+	require.ErrorIs(t, err, errUnsupportedNetworkManager)
+}
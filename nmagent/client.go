@@ -21,10 +21,32 @@ func NewClient(c Config) (*Client, error) {
 		return nil, errors.Wrap(err, "validating config")
 	}
 
+	threshold := c.CircuitBreakerThreshold
+	switch {
+	case threshold == 0:
+		threshold = DefaultCircuitBreakerThreshold
+	case threshold < 0:
+		threshold = 0
+	}
+	cooldown := c.CircuitBreakerCooldown
+	if cooldown == 0 {
+		cooldown = DefaultCircuitBreakerCooldown
+	}
+
+	breaker := newCircuitBreaker(threshold, cooldown)
+	if c.MetricsRegisterer != nil {
+		if err := c.MetricsRegisterer.Register(newCircuitBreakerStateGauge(breaker)); err != nil {
+			return nil, errors.Wrap(err, "registering circuit breaker metric")
+		}
+	}
+
 	client := &Client{
 		httpClient: &http.Client{
-			Transport: &internal.WireserverTransport{
-				Transport: http.DefaultTransport,
+			Transport: &instrumentedTransport{
+				Transport: &internal.WireserverTransport{
+					Transport: http.DefaultTransport,
+				},
+				Breaker: breaker,
 			},
 		},
 		host:      c.Host,
@@ -290,8 +312,9 @@ func die(code int, headers http.Header, body io.ReadCloser) error {
 		Code: code,
 		// this is a little strange, but the conversion below is to avoid forcing
 		// consumers to depend on an internal type (which they can't anyway)
-		Source: internal.GetErrorSource(headers).String(),
-		Body:   bodyContent,
+		Source:        internal.GetErrorSource(headers).String(),
+		Body:          bodyContent,
+		CorrelationID: headers.Get(internal.HeaderCorrelationID),
 	}
 }
 
@@ -320,8 +343,14 @@ func (c *Client) buildRequest(ctx context.Context, req Request) (*http.Request,
 		return nil, errors.Wrap(err, "retrieving request body")
 	}
 
-	// nolint:wrapcheck // wrapping doesn't provide useful information
-	return http.NewRequestWithContext(ctx, req.Method(), fullURL.String(), body)
+	ctx = context.WithValue(ctx, requestNameKey, requestName(req))
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method(), fullURL.String(), body)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating http request")
+	}
+	httpReq.Header.Set(internal.HeaderCorrelationID, correlationID(ctx))
+
+	return httpReq, nil
 }
 
 func (c *Client) scheme() string {
@@ -2,6 +2,7 @@ package nmagent
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/Azure/azure-container-networking/nmagent/internal"
 )
@@ -11,8 +12,11 @@ import (
 func NewTestClient(transport http.RoundTripper) *Client {
 	return &Client{
 		httpClient: &http.Client{
-			Transport: &internal.WireserverTransport{
-				Transport: transport,
+			Transport: &instrumentedTransport{
+				Transport: &internal.WireserverTransport{
+					Transport: transport,
+				},
+				Breaker: &internal.CircuitBreaker{},
 			},
 		},
 		host: "localhost",
@@ -22,3 +26,11 @@ func NewTestClient(transport http.RoundTripper) *Client {
 		},
 	}
 }
+
+// NewTestClientWithCircuitBreaker is a factory function available in tests only
+// for creating NMAgent clients with a mock transport and a circuit breaker
+func NewTestClientWithCircuitBreaker(transport http.RoundTripper, threshold int, cooldown time.Duration) *Client {
+	client := NewTestClient(transport)
+	client.httpClient.Transport.(*instrumentedTransport).Breaker = newCircuitBreaker(threshold, cooldown)
+	return client
+}
@@ -8,10 +8,12 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-container-networking/nmagent"
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 var _ http.RoundTripper = &TestTripper{}
@@ -779,3 +781,72 @@ func TestGetHomeAz(t *testing.T) {
 		})
 	}
 }
+
+func TestCircuitBreaker(t *testing.T) {
+	calls := 0
+	var gotCorrelationID string
+	client := nmagent.NewTestClientWithCircuitBreaker(&TestTripper{
+		RoundTripF: func(req *http.Request) (*http.Response, error) {
+			calls++
+			gotCorrelationID = req.Header.Get("X-Ms-Correlation-Request-Id")
+			rr := httptest.NewRecorder()
+			rr.WriteHeader(http.StatusOK)
+			_, _ = rr.WriteString(`{"httpStatusCode": "500"}`)
+			return rr.Result(), nil
+		},
+	}, 2, time.Hour)
+
+	ctx, cancel := testContext(t)
+	defer cancel()
+	ctx = nmagent.WithCorrelationID(ctx, "correlation-id")
+
+	for i := 0; i < 2; i++ {
+		_, err := client.GetNCVersionList(ctx)
+		var nmaErr nmagent.Error
+		if !errors.As(err, &nmaErr) {
+			t.Fatal("expected an nmagent error: err:", err)
+		}
+		if nmaErr.CorrelationID != "correlation-id" {
+			t.Error("unexpected correlation id in error: got:", nmaErr.CorrelationID, "exp: correlation-id")
+		}
+	}
+
+	if gotCorrelationID != "correlation-id" {
+		t.Error("unexpected correlation id header: got:", gotCorrelationID, "exp: correlation-id")
+	}
+
+	// the breaker is now open, so the request is not made
+	_, err := client.GetNCVersionList(ctx)
+	if !errors.Is(err, nmagent.ErrCircuitOpen) {
+		t.Fatal("expected open circuit error: err:", err)
+	}
+
+	if calls != 2 {
+		t.Error("unexpected number of requests: got:", calls, "exp:", 2)
+	}
+}
+
+func TestCircuitBreakerMetric(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	_, err := nmagent.NewClient(nmagent.Config{Host: "localhost", Port: 12345, MetricsRegisterer: reg})
+	if err != nil {
+		t.Fatal("unexpected error creating client: err:", err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal("unexpected error gathering metrics: err:", err)
+	}
+	if len(families) != 1 || families[0].GetName() != "nmagent_circuit_breaker_state" {
+		t.Fatal("expected only the circuit breaker metric: got:", families)
+	}
+	if got := families[0].GetMetric()[0].GetGauge().GetValue(); got != 0 {
+		t.Error("unexpected circuit breaker state: got:", got, "exp:", 0)
+	}
+
+	// a second client can not report its state as the same metric
+	_, err = nmagent.NewClient(nmagent.Config{Host: "localhost", Port: 12345, MetricsRegisterer: reg})
+	if err == nil {
+		t.Error("expected an error registering the metric of a second client")
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-container-networking/nmagent/internal"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // Config is a configuration for an NMAgent Client.
@@ -22,8 +24,27 @@ type Config struct {
 	// Optional Config //
 	/////////////////////
 	UseTLS bool // forces all connections to use TLS
+
+	// CircuitBreakerThreshold is the number of consecutive failed requests
+	// after which the client stops sending requests to NMAgent for
+	// CircuitBreakerCooldown. Zero uses DefaultCircuitBreakerThreshold and a
+	// negative value disables the circuit breaker.
+	CircuitBreakerThreshold int
+	// CircuitBreakerCooldown is how long the circuit breaker stays open before
+	// a probe request is let through. Zero uses DefaultCircuitBreakerCooldown.
+	CircuitBreakerCooldown time.Duration
+
+	// MetricsRegisterer, if set, is where the client registers the metric of
+	// the state of its circuit breaker. The request metrics are registered with
+	// the controller-runtime registry.
+	MetricsRegisterer prometheus.Registerer
 }
 
+const (
+	DefaultCircuitBreakerThreshold = 5
+	DefaultCircuitBreakerCooldown  = 30 * time.Second
+)
+
 // Validate reports whether this configuration is a valid configuration for a
 // client.
 func (c Config) Validate() error {
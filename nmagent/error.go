@@ -52,12 +52,18 @@ type Error struct {
 	Code   int    // the HTTP status code received
 	Source string // the component responsible for producing the error
 	Body   []byte // the body of the error returned
+
+	CorrelationID string // the correlation ID of the failed request
 }
 
 // Error constructs a string representation of this error in accordance with
 // the error interface.
 func (e Error) Error() string {
-	return fmt.Sprintf("nmagent: %s: http status %d: %s: body: %s", e.source(), e.Code, e.Message(), string(e.Body))
+	msg := fmt.Sprintf("nmagent: %s: http status %d: %s: body: %s", e.source(), e.Code, e.Message(), string(e.Body))
+	if e.CorrelationID != "" {
+		msg += ": correlation id: " + e.CorrelationID
+	}
+	return msg
 }
 
 func (e Error) source() string {
@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	ErrCircuitOpen = Error("circuit breaker is open")
+)
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets all requests through.
+	BreakerClosed BreakerState = iota
+	// BreakerHalfOpen lets a single probe request through to decide whether
+	// to close or re-open the breaker.
+	BreakerHalfOpen
+	// BreakerOpen rejects all requests until the cooldown has elapsed.
+	BreakerOpen
+)
+
+// String produces the string equivalent for the BreakerState type.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerHalfOpen:
+		return "half-open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return ""
+	}
+}
+
+// CircuitBreaker stops requests to a dependency that is failing. It opens after
+// Threshold consecutive failures and rejects requests for Cooldown, after which
+// it half-opens and lets one probe request through. A successful probe closes
+// the breaker, a failed one re-opens it.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	// OnStateChange, if set, is called with the new state whenever the state
+	// changes. It is called with the breaker's lock held and must not call back
+	// into the breaker.
+	OnStateChange func(BreakerState)
+
+	// now is overridden in tests
+	now func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// Allow reports whether a request may be made. It returns ErrCircuitOpen if the
+// breaker is open, or half-open with a probe already in flight. Every allowed
+// request must be followed by a call to Done.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.clock().Sub(b.openedAt) < b.Cooldown {
+			return ErrCircuitOpen
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Outcome is the result of a request allowed by a CircuitBreaker.
+type Outcome int
+
+const (
+	// OutcomeSuccess is a request that shows the dependency is healthy.
+	OutcomeSuccess Outcome = iota
+	// OutcomeFailure is a request that shows the dependency is unhealthy.
+	OutcomeFailure
+	// OutcomeIgnored is a request that says nothing about the dependency,
+	// such as one canceled by the caller.
+	OutcomeIgnored
+)
+
+// Done records the outcome of a request that was allowed.
+func (b *CircuitBreaker) Done(o Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+		switch o {
+		case OutcomeSuccess:
+			b.failures = 0
+			b.setState(BreakerClosed)
+		case OutcomeFailure:
+			b.open()
+		case OutcomeIgnored:
+			// let the next request probe
+		}
+		return
+	}
+
+	switch o {
+	case OutcomeSuccess:
+		b.failures = 0
+	case OutcomeFailure:
+		b.failures++
+		if b.state == BreakerClosed && b.Threshold > 0 && b.failures >= b.Threshold {
+			b.open()
+		}
+	case OutcomeIgnored:
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *CircuitBreaker) open() {
+	b.failures = 0
+	b.openedAt = b.clock()
+	b.setState(BreakerOpen)
+}
+
+func (b *CircuitBreaker) setState(s BreakerState) {
+	if b.state == s {
+		return
+	}
+	b.state = s
+	if b.OnStateChange != nil {
+		b.OnStateChange(s)
+	}
+}
+
+func (b *CircuitBreaker) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
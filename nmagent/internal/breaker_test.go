@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	var transitions []BreakerState
+
+	b := &CircuitBreaker{
+		Threshold: 3,
+		Cooldown:  10 * time.Second,
+		OnStateChange: func(s BreakerState) {
+			transitions = append(transitions, s)
+		},
+		now: func() time.Time { return now },
+	}
+
+	fail := func() {
+		t.Helper()
+		if err := b.Allow(); err != nil {
+			t.Fatal("unexpected rejection: err:", err)
+		}
+		b.Done(OutcomeFailure)
+	}
+
+	// a success resets the consecutive failure count
+	fail()
+	fail()
+	if err := b.Allow(); err != nil {
+		t.Fatal("unexpected rejection: err:", err)
+	}
+	b.Done(OutcomeSuccess)
+	fail()
+	fail()
+	if got := b.State(); got != BreakerClosed {
+		t.Fatal("unexpected state: got:", got, "exp:", BreakerClosed)
+	}
+
+	fail()
+	if got := b.State(); got != BreakerOpen {
+		t.Fatal("unexpected state: got:", got, "exp:", BreakerOpen)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("expected open circuit: err:", err)
+	}
+
+	// after the cooldown a single probe is let through
+	now = now.Add(10 * time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatal("unexpected rejection of probe: err:", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("expected a second probe to be rejected: err:", err)
+	}
+
+	// a failed probe re-opens the breaker
+	b.Done(OutcomeFailure)
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("expected open circuit: err:", err)
+	}
+
+	// an ignored probe lets the next request probe
+	now = now.Add(10 * time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatal("unexpected rejection of probe: err:", err)
+	}
+	b.Done(OutcomeIgnored)
+	if err := b.Allow(); err != nil {
+		t.Fatal("unexpected rejection of probe: err:", err)
+	}
+
+	// a successful probe closes the breaker
+	b.Done(OutcomeSuccess)
+	if got := b.State(); got != BreakerClosed {
+		t.Fatal("unexpected state: got:", got, "exp:", BreakerClosed)
+	}
+
+	exp := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if len(transitions) != len(exp) {
+		t.Fatal("unexpected transitions: got:", transitions, "exp:", exp)
+	}
+	for i := range exp {
+		if transitions[i] != exp[i] {
+			t.Error("unexpected transition: got:", transitions[i], "exp:", exp[i])
+		}
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := &CircuitBreaker{}
+	for i := 0; i < 100; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatal("unexpected rejection: err:", err)
+		}
+		b.Done(OutcomeFailure)
+	}
+}
@@ -2,6 +2,10 @@ package internal
 
 const (
 	HeaderContentType = "Content-Type"
+
+	// HeaderCorrelationID carries the ID that correlates a request to NMAgent
+	// with the logs of the caller.
+	HeaderCorrelationID = "X-Ms-Correlation-Request-Id"
 )
 
 const (
@@ -0,0 +1,58 @@
+package nmagent
+
+import (
+	"github.com/Azure/azure-container-networking/nmagent/internal"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	requestLabel     = "request"
+	codeLabel        = "code"
+	errorSourceLabel = "error_source"
+
+	// codeError labels requests that failed without a response.
+	codeError = "error"
+	// codeCircuitOpen labels requests rejected by the circuit breaker.
+	codeCircuitOpen = "circuit_open"
+)
+
+var (
+	requestLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "nmagent_request_latency_seconds",
+			Help: "NMAgent request latency in seconds by request type and response code.",
+			//nolint:gomnd // default bucket consts
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 15), // 1 ms to ~16 seconds
+		},
+		[]string{requestLabel, codeLabel},
+	)
+	requestCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nmagent_requests_total",
+			Help: "Count of NMAgent requests by request type, response code and the component that produced an error.",
+		},
+		[]string{requestLabel, codeLabel, errorSourceLabel},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		requestLatency,
+		requestCount,
+	)
+}
+
+// newCircuitBreakerStateGauge returns a gauge that reports the state of the
+// circuit breaker of a client when it is collected.
+func newCircuitBreakerStateGauge(breaker *internal.CircuitBreaker) prometheus.GaugeFunc {
+	return prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "nmagent_circuit_breaker_state",
+			Help: "State of the NMAgent circuit breaker: 0 closed, 1 half-open, 2 open.",
+		},
+		func() float64 {
+			return float64(breaker.State())
+		},
+	)
+}
@@ -0,0 +1,139 @@
+package nmagent
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-container-networking/nmagent/internal"
+	"github.com/Azure/azure-container-networking/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrCircuitOpen is returned when a request is not made because NMAgent has
+// been failing and the client's circuit breaker is open.
+const ErrCircuitOpen = internal.ErrCircuitOpen
+
+// HeaderCorrelationID carries the ID that correlates a request to NMAgent with
+// the logs of the caller.
+const HeaderCorrelationID = internal.HeaderCorrelationID
+
+type contextKey int
+
+const (
+	correlationIDKey contextKey = iota
+	requestNameKey
+)
+
+// WithCorrelationID returns a context that makes the requests to NMAgent made
+// with it carry the correlation ID. Requests made without one carry a random
+// ID. The ID is reported in the Error returned for a failed request.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, id)
+}
+
+func correlationID(ctx context.Context) string {
+	if id, ok := ctx.Value(correlationIDKey).(string); ok && id != "" {
+		return id
+	}
+	return uuid.New().String()
+}
+
+// requestName returns the name used to label the metrics of a request.
+func requestName(req Request) string {
+	switch req.(type) {
+	case JoinNetworkRequest, *JoinNetworkRequest:
+		return "JoinNetwork"
+	case DeleteNetworkRequest, *DeleteNetworkRequest:
+		return "DeleteNetwork"
+	case GetNetworkConfigRequest, *GetNetworkConfigRequest:
+		return "GetNetworkConfig"
+	case *PutNetworkContainerRequest:
+		return "PutNetworkContainer"
+	case DeleteContainerRequest, *DeleteContainerRequest:
+		return "DeleteNetworkContainer"
+	case NCVersionRequest, *NCVersionRequest:
+		return "GetNCVersion"
+	case NCVersionListRequest, *NCVersionListRequest:
+		return "GetNCVersionList"
+	case *SupportedAPIsRequest:
+		return "SupportedAPIs"
+	case *GetHomeAzRequest:
+		return "GetHomeAz"
+	default:
+		return "Unknown"
+	}
+}
+
+// instrumentedTransport is an http.RoundTripper that records metrics for the
+// requests to NMAgent and stops them while the circuit breaker is open. It
+// must wrap the WireserverTransport so that it sees the status codes and error
+// sources of NMAgent.
+type instrumentedTransport struct {
+	Transport http.RoundTripper
+	Breaker   *internal.CircuitBreaker
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	name, _ := req.Context().Value(requestNameKey).(string)
+
+	ctx, span := tracing.Start(req.Context(), "nmagent."+name, trace.WithSpanKind(trace.SpanKindClient))
+	defer func() {
+		if err == nil {
+			span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+		}
+		tracing.End(span, err)
+	}()
+	req = req.Clone(ctx)
+	tracing.Inject(ctx, req.Header)
+
+	if err := t.Breaker.Allow(); err != nil {
+		requestCount.WithLabelValues(name, codeCircuitOpen, "").Inc()
+		return nil, err // nolint:wrapcheck // the sentinel is wrapped by http.Client
+	}
+
+	start := time.Now()
+	resp, err = t.Transport.RoundTrip(req)
+	if err != nil {
+		outcome := internal.OutcomeFailure
+		if req.Context().Err() != nil {
+			// the caller gave up, which says nothing about NMAgent
+			outcome = internal.OutcomeIgnored
+		}
+		t.Breaker.Done(outcome)
+		requestLatency.WithLabelValues(name, codeError).Observe(time.Since(start).Seconds())
+		requestCount.WithLabelValues(name, codeError, "").Inc()
+		return resp, err // nolint:wrapcheck // the error is already wrapped by the WireserverTransport
+	}
+
+	// client errors are the caller's fault and show that NMAgent is up
+	if resp.StatusCode >= http.StatusInternalServerError {
+		t.Breaker.Done(internal.OutcomeFailure)
+	} else {
+		t.Breaker.Done(internal.OutcomeSuccess)
+	}
+
+	code := strconv.Itoa(resp.StatusCode)
+	source := ""
+	if resp.StatusCode != http.StatusOK {
+		source = internal.GetErrorSource(resp.Header).String()
+	}
+	requestLatency.WithLabelValues(name, code).Observe(time.Since(start).Seconds())
+	requestCount.WithLabelValues(name, code, source).Inc()
+
+	// echo the correlation ID so that it can be reported with errors
+	resp.Header.Set(internal.HeaderCorrelationID, req.Header.Get(internal.HeaderCorrelationID))
+
+	return resp, nil
+}
+
+// newCircuitBreaker creates the circuit breaker of a client.
+func newCircuitBreaker(threshold int, cooldown time.Duration) *internal.CircuitBreaker {
+	return &internal.CircuitBreaker{
+		Threshold: threshold,
+		Cooldown:  cooldown,
+	}
+}
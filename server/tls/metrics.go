@@ -0,0 +1,32 @@
+// Copyright 2023 Microsoft. All rights reserved.
+
+package tls
+
+import (
+	"crypto/x509"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const sourceKeyVault = "keyvault"
+
+var certificateExpiry = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "tls_certificate_expiry_timestamp_seconds",
+		Help: "Expiration of the served TLS certificate as a unix timestamp, by certificate source.",
+	},
+	[]string{"source"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		certificateExpiry,
+	)
+}
+
+// SetKeyVaultCertificateExpiry records the expiration of the certificate served from KeyVault, which is
+// refreshed by the keyvault.CertRefresher instead of a retriever of this package.
+func SetKeyVaultCertificateExpiry(leaf *x509.Certificate) {
+	certificateExpiry.WithLabelValues(sourceKeyVault).Set(float64(leaf.NotAfter.Unix()))
+}
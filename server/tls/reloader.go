@@ -0,0 +1,337 @@
+// Copyright 2023 Microsoft. All rights reserved.
+
+package tls
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	sourceFile   = "file"
+	sourceSecret = "secret"
+
+	defaultRefreshInterval = time.Minute
+)
+
+type logger interface {
+	Printf(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+// certStore holds the certificate served by a reloading retriever.
+type certStore struct {
+	source string
+	m      sync.RWMutex
+	cert   *tls.Certificate
+}
+
+// GetCertificate returns the leaf of the current certificate.
+func (s *certStore) GetCertificate() (*x509.Certificate, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.cert.Leaf, nil
+}
+
+// GetPrivateKey returns the private key of the current certificate.
+func (s *certStore) GetPrivateKey() (crypto.PrivateKey, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.cert.PrivateKey, nil
+}
+
+// GetTLSCertificate returns the current certificate. It is meant to be used as tls.Config.GetCertificate, so
+// that new connections are served the reloaded certificate.
+func (s *certStore) GetTLSCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.cert, nil
+}
+
+// set replaces the current certificate and reports whether it changed.
+func (s *certStore) set(cert *tls.Certificate) bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+	certificateExpiry.WithLabelValues(s.source).Set(float64(cert.Leaf.NotAfter.Unix()))
+	if s.cert != nil && s.cert.Leaf.Equal(cert.Leaf) {
+		return false
+	}
+	s.cert = cert
+	return true
+}
+
+// expiry returns the expiration of the current certificate.
+func (s *certStore) expiry() time.Time {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.cert.Leaf.NotAfter
+}
+
+// FileTlsCertificateWatcher is a TlsCertificateRetriever that reloads the certificate when the PEM file at
+// TLSCertificatePath changes, so that a rotated certificate is served without restarting.
+type FileTlsCertificateWatcher struct {
+	certStore
+	settings TlsSettings
+	logger   logger
+	checksum [sha256.Size]byte
+}
+
+// NewFileTlsCertificateWatcher returns a FileTlsCertificateWatcher with the certificate read from the file.
+func NewFileTlsCertificateWatcher(settings TlsSettings, l logger) (*FileTlsCertificateWatcher, error) {
+	w := &FileTlsCertificateWatcher{
+		certStore: certStore{source: sourceFile},
+		settings:  settings,
+		logger:    l,
+	}
+	if _, err := w.reload(); err != nil {
+		return nil, err
+	}
+	l.Printf("[tls] loaded certificate from %s, expiration: %s", settings.TLSCertificatePath, w.expiry())
+	return w, nil
+}
+
+// reload reads the file and replaces the certificate if the file changed.
+func (w *FileTlsCertificateWatcher) reload() (bool, error) {
+	content, err := os.ReadFile(w.settings.TLSCertificatePath)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to read %s", w.settings.TLSCertificatePath)
+	}
+	checksum := sha256.Sum256(content)
+	if checksum == w.checksum {
+		return false, nil
+	}
+
+	// the content that was hashed is parsed by the retriever of the OS, which decrypts it on Windows
+	retriever, err := newTlsCertificateRetriever(w.settings, content)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to parse %s", w.settings.TLSCertificatePath)
+	}
+	cert, err := tlsCertificate(retriever)
+	if err != nil {
+		return false, err
+	}
+	w.checksum = checksum
+	return w.set(cert), nil
+}
+
+// Watch reloads the certificate at the interval until the context is done. The current certificate is kept
+// if the file can not be read or parsed, such as while it is being replaced.
+func (w *FileTlsCertificateWatcher) Watch(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "watch canceled")
+		case <-ticker.C:
+			changed, err := w.reload()
+			if err != nil {
+				w.logger.Errorf("[tls] failed to reload certificate, certificate expiring on %s is kept: %v", w.expiry(), err)
+				continue
+			}
+			if changed {
+				w.logger.Printf("[tls] reloaded certificate from %s, expiration: %s", w.settings.TLSCertificatePath, w.expiry())
+			}
+		}
+	}
+}
+
+// chainRetriever is implemented by the retrievers that read the intermediate certificates along with the leaf.
+type chainRetriever interface {
+	certificateChain() ([][]byte, error)
+}
+
+// pemCertificateChain returns the DER certificates of the PEM blocks with the leaf, the first certificate that
+// is not a CA, first and the intermediates after it in the order of the file.
+func pemCertificateChain(blocks []*pem.Block) ([][]byte, error) {
+	var leaf []byte
+	var intermediates [][]byte
+	for _, block := range blocks {
+		if block.Type != CertLabel {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse certificate")
+		}
+		if leaf == nil && !cert.IsCA {
+			leaf = block.Bytes
+			continue
+		}
+		intermediates = append(intermediates, block.Bytes)
+	}
+	if leaf == nil {
+		return nil, errors.New("no leaf certificate found")
+	}
+	return append([][]byte{leaf}, intermediates...), nil
+}
+
+// tlsCertificate builds a tls.Certificate from the certificate and key of the retriever. The intermediate
+// certificates of the retriever are served after the leaf.
+func tlsCertificate(retriever TlsCertificateRetriever) (*tls.Certificate, error) {
+	leaf, err := retriever.GetCertificate()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get certificate")
+	}
+	if leaf == nil {
+		return nil, errors.New("certificate retrieval returned empty")
+	}
+	key, err := retriever.GetPrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get certificate private key")
+	}
+	chain := [][]byte{leaf.Raw}
+	if cr, ok := retriever.(chainRetriever); ok {
+		if chain, err = cr.certificateChain(); err != nil {
+			return nil, errors.Wrap(err, "failed to get certificate chain")
+		}
+	}
+	return &tls.Certificate{
+		Certificate: chain,
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, nil
+}
+
+// secretClient is the subset of the Secrets client used by SecretTlsCertificateRetriever.
+type secretClient interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.Secret, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+}
+
+// SecretTlsCertificateRetriever is a TlsCertificateRetriever that reads the certificate from a
+// kubernetes.io/tls Secret and reloads it when the Secret is updated.
+type SecretTlsCertificateRetriever struct {
+	certStore
+	secrets secretClient
+	name    string
+	logger  logger
+}
+
+// NewSecretTlsCertificateRetriever returns a SecretTlsCertificateRetriever with the certificate read from the
+// Secret. The secrets client is scoped to the namespace of the Secret.
+func NewSecretTlsCertificateRetriever(ctx context.Context, secrets secretClient, name string, l logger) (*SecretTlsCertificateRetriever, error) {
+	r := &SecretTlsCertificateRetriever{
+		certStore: certStore{source: sourceSecret},
+		secrets:   secrets,
+		name:      name,
+		logger:    l,
+	}
+	if _, _, err := r.reload(ctx); err != nil {
+		return nil, err
+	}
+	l.Printf("[tls] loaded certificate from secret %s, expiration: %s", name, r.expiry())
+	return r, nil
+}
+
+// reload gets the Secret and replaces the certificate if it changed. It returns the resource version of the
+// Secret, from which the Secret can be watched.
+func (r *SecretTlsCertificateRetriever) reload(ctx context.Context) (string, bool, error) {
+	secret, err := r.secrets.Get(ctx, r.name, metav1.GetOptions{})
+	if err != nil {
+		return "", false, errors.Wrapf(err, "failed to get secret %s", r.name)
+	}
+	changed, err := r.load(secret)
+	return secret.ResourceVersion, changed, err
+}
+
+// load replaces the certificate with the one of the Secret if it changed.
+func (r *SecretTlsCertificateRetriever) load(secret *corev1.Secret) (bool, error) {
+	cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to parse certificate of secret %s", r.name)
+	}
+	cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to parse leaf certificate of secret %s", r.name)
+	}
+	return r.set(&cert), nil
+}
+
+// Watch reloads the certificate when the Secret is updated until the context is done. The watch is
+// re-established from a fresh read of the Secret when it closes or fails, waiting for the retry interval after
+// a failure. The current certificate is kept if the Secret can not be read or parsed.
+func (r *SecretTlsCertificateRetriever) Watch(ctx context.Context, retryInterval time.Duration) error {
+	if retryInterval <= 0 {
+		retryInterval = defaultRefreshInterval
+	}
+
+	for {
+		if err := r.watch(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Errorf("[tls] failed to watch secret %s, certificate expiring on %s is kept: %v", r.name, r.expiry(), err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryInterval):
+			}
+		}
+		if ctx.Err() != nil {
+			return errors.Wrap(ctx.Err(), "watch canceled")
+		}
+	}
+}
+
+// watch reloads the Secret and then applies its updates until the watch closes.
+func (r *SecretTlsCertificateRetriever) watch(ctx context.Context) error {
+	resourceVersion, changed, err := r.reload(ctx)
+	if err != nil {
+		return err
+	}
+	if changed {
+		r.logger.Printf("[tls] reloaded certificate from secret %s, expiration: %s", r.name, r.expiry())
+	}
+
+	w, err := r.secrets.Watch(ctx, metav1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", r.name).String(),
+		ResourceVersion: resourceVersion,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to watch secret %s", r.name)
+	}
+	defer w.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			switch event.Type { //nolint:exhaustive // bookmarks and deletions do not change the served certificate
+			case watch.Error:
+				return errors.Wrapf(apierrors.FromObject(event.Object), "watch of secret %s failed", r.name)
+			case watch.Added, watch.Modified:
+				secret, ok := event.Object.(*corev1.Secret)
+				if !ok {
+					continue
+				}
+				changed, err := r.load(secret)
+				if err != nil {
+					r.logger.Errorf("[tls] failed to reload certificate, certificate expiring on %s is kept: %v", r.expiry(), err)
+					continue
+				}
+				if changed {
+					r.logger.Printf("[tls] reloaded certificate from secret %s, expiration: %s", r.name, r.expiry())
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2023 Microsoft. All rights reserved.
+
+package tls
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+type testLogger struct {
+	t *testing.T
+}
+
+func (l testLogger) Printf(format string, args ...any) {
+	l.t.Logf(format, args...)
+}
+
+func (l testLogger) Errorf(format string, args ...any) {
+	l.t.Logf(format, args...)
+}
+
+func TestFileTlsCertificateWatcherReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	require.NoError(t, os.WriteFile(path, createPemCertificate(t), 0o600))
+
+	w, err := NewFileTlsCertificateWatcher(TlsSettings{TLSCertificatePath: path}, testLogger{t})
+	require.NoError(t, err)
+	first, err := w.GetTLSCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, commonName, first.Leaf.Subject.CommonName)
+
+	// an unchanged file is not reloaded
+	changed, err := w.reload()
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	// a rotated file is reloaded
+	require.NoError(t, os.WriteFile(path, createPemCertificate(t), 0o600))
+	changed, err = w.reload()
+	require.NoError(t, err)
+	require.True(t, changed)
+	second, err := w.GetTLSCertificate(nil)
+	require.NoError(t, err)
+	require.False(t, first.Leaf.Equal(second.Leaf))
+
+	leaf, err := w.GetCertificate()
+	require.NoError(t, err)
+	require.True(t, second.Leaf.Equal(leaf))
+	require.Equal(t, float64(leaf.NotAfter.Unix()), testutil.ToFloat64(certificateExpiry.WithLabelValues(sourceFile)))
+
+	// a partially written file keeps the current certificate
+	require.NoError(t, os.WriteFile(path, []byte("-----BEGIN CERTIFICATE-----"), 0o600))
+	_, err = w.reload()
+	require.Error(t, err)
+	current, err := w.GetTLSCertificate(nil)
+	require.NoError(t, err)
+	require.Same(t, second, current)
+}
+
+func TestFileTlsCertificateWatcherMissingFile(t *testing.T) {
+	_, err := NewFileTlsCertificateWatcher(TlsSettings{TLSCertificatePath: filepath.Join(t.TempDir(), "missing.pem")}, testLogger{t})
+	require.Error(t, err)
+}
+
+func TestSetKeyVaultCertificateExpiry(t *testing.T) {
+	block, _ := pem.Decode(createPemCertificate(t))
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+
+	SetKeyVaultCertificateExpiry(leaf)
+	require.Equal(t, float64(leaf.NotAfter.Unix()), testutil.ToFloat64(certificateExpiry.WithLabelValues(sourceKeyVault)))
+}
+
+// createCACertificate returns the PEM of a self-signed CA certificate.
+func createCACertificate(t *testing.T) []byte {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, rsaBits)
+	require.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "intermediate"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: CertLabel, Bytes: der})
+}
+
+func TestFileTlsCertificateWatcherChain(t *testing.T) {
+	ca := createCACertificate(t)
+	caBlock, _ := pem.Decode(ca)
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	// the intermediate is listed before the leaf, which is still served first
+	require.NoError(t, os.WriteFile(path, append(ca, createPemCertificate(t)...), 0o600))
+
+	w, err := NewFileTlsCertificateWatcher(TlsSettings{TLSCertificatePath: path}, testLogger{t})
+	require.NoError(t, err)
+	cert, err := w.GetTLSCertificate(nil)
+	require.NoError(t, err)
+	require.Len(t, cert.Certificate, 2)
+	require.Equal(t, cert.Leaf.Raw, cert.Certificate[0])
+	require.Equal(t, caBlock.Bytes, cert.Certificate[1])
+}
+
+type fakeSecrets struct {
+	secret  *corev1.Secret
+	err     error
+	watcher *watch.FakeWatcher
+	watches int
+}
+
+func (f *fakeSecrets) Get(_ context.Context, name string, _ metav1.GetOptions) (*corev1.Secret, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.secret.Name != name {
+		return nil, errors.Errorf("secret %s not found", name)
+	}
+	return f.secret, nil
+}
+
+func (f *fakeSecrets) Watch(context.Context, metav1.ListOptions) (watch.Interface, error) {
+	f.watches++
+	return f.watcher, nil
+}
+
+// tlsSecret builds a kubernetes.io/tls Secret from the PEM bundle of createPemCertificate.
+func tlsSecret(t *testing.T, name string) *corev1.Secret {
+	t.Helper()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{},
+	}
+	rest := createPemCertificate(t)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case CertLabel:
+			secret.Data[corev1.TLSCertKey] = pem.EncodeToMemory(block)
+		case PrivateKeyLabel:
+			secret.Data[corev1.TLSPrivateKeyKey] = pem.EncodeToMemory(block)
+		}
+	}
+	return secret
+}
+
+func TestSecretTlsCertificateRetrieverRefresh(t *testing.T) {
+	ctx := context.Background()
+	secrets := &fakeSecrets{secret: tlsSecret(t, "cns-tls")}
+
+	r, err := NewSecretTlsCertificateRetriever(ctx, secrets, "cns-tls", testLogger{t})
+	require.NoError(t, err)
+	first, err := r.GetTLSCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, commonName, first.Leaf.Subject.CommonName)
+
+	key, err := r.GetPrivateKey()
+	require.NoError(t, err)
+	require.NotNil(t, key)
+
+	// an updated Secret is reloaded
+	secrets.secret = tlsSecret(t, "cns-tls")
+	_, changed, err := r.reload(ctx)
+	require.NoError(t, err)
+	require.True(t, changed)
+	second, err := r.GetTLSCertificate(nil)
+	require.NoError(t, err)
+	require.False(t, first.Leaf.Equal(second.Leaf))
+
+	// a failed read keeps the current certificate
+	secrets.err = errors.New("apiserver unavailable")
+	_, _, err = r.reload(ctx)
+	require.Error(t, err)
+	current, err := r.GetTLSCertificate(nil)
+	require.NoError(t, err)
+	require.Same(t, second, current)
+}
+
+func TestSecretTlsCertificateRetrieverWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	secrets := &fakeSecrets{secret: tlsSecret(t, "cns-tls"), watcher: watch.NewFake()}
+
+	r, err := NewSecretTlsCertificateRetriever(ctx, secrets, "cns-tls", testLogger{t})
+	require.NoError(t, err)
+	first, err := r.GetTLSCertificate(nil)
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		done <- r.Watch(ctx, time.Millisecond)
+	}()
+
+	// an update of the Secret is applied without reading it again
+	updated := tlsSecret(t, "cns-tls")
+	secrets.watcher.Modify(updated)
+	require.Eventually(t, func() bool {
+		cert, _ := r.GetTLSCertificate(nil)
+		return !cert.Leaf.Equal(first.Leaf)
+	}, 5*time.Second, time.Millisecond)
+	second, err := r.GetTLSCertificate(nil)
+	require.NoError(t, err)
+
+	// an invalid update keeps the current certificate. The watcher is unbuffered, so the invalid update has been
+	// handled once the next event is received.
+	invalid := tlsSecret(t, "cns-tls")
+	delete(invalid.Data, corev1.TLSPrivateKeyKey)
+	secrets.watcher.Modify(invalid)
+	secrets.watcher.Modify(updated)
+	current, err := r.GetTLSCertificate(nil)
+	require.NoError(t, err)
+	require.Same(t, second, current)
+	require.Equal(t, 1, secrets.watches)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestSecretTlsCertificateRetrieverInvalidSecret(t *testing.T) {
+	secret := tlsSecret(t, "cns-tls")
+	delete(secret.Data, corev1.TLSPrivateKeyKey)
+
+	_, err := NewSecretTlsCertificateRetriever(context.Background(), &fakeSecrets{secret: secret}, "cns-tls", testLogger{t})
+	require.Error(t, err)
+}
@@ -9,6 +9,10 @@ type TlsSettings struct {
 	TLSSubjectName                     string
 	TLSCertificatePath                 string
 	TLSPort                            string
+	TLSSecretNamespace                 string
+	TLSSecretName                      string
+	TLSRefreshInterval                 time.Duration
+	TLSClientCACertificatePath         string
 	KeyVaultURL                        string
 	KeyVaultCertificateName            string
 	MSIResourceID                      string
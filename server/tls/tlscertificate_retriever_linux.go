@@ -36,6 +36,11 @@ func (fcert *linuxTlsCertificateRetriever) GetCertificate() (*x509.Certificate,
 	return nil, fmt.Errorf("No Certificate block found")
 }
 
+// certificateChain returns the leaf certificate of the pem followed by the intermediate certificates
+func (fcert *linuxTlsCertificateRetriever) certificateChain() ([][]byte, error) {
+	return pemCertificateChain(fcert.pemBlock)
+}
+
 // GetPrivateKey Returns the private key associated with the pem
 func (fcert *linuxTlsCertificateRetriever) GetPrivateKey() (crypto.PrivateKey, error) {
 	for _, block := range fcert.pemBlock {
@@ -95,6 +100,14 @@ func NewTlsCertificateRetriever(settings TlsSettings) (TlsCertificateRetriever,
 		return nil, fmt.Errorf("Failed to read file with error %+v", err)
 	}
 
+	return newTlsCertificateRetriever(settings, content)
+}
+
+// newTlsCertificateRetriever creates a TlsCertificateRetriever from the content of the file at TLSCertificatePath.
+func newTlsCertificateRetriever(settings TlsSettings, content []byte) (TlsCertificateRetriever, error) {
+	linuxCertStoreRetriever := &linuxTlsCertificateRetriever{
+		settings: settings,
+	}
 	if err := linuxCertStoreRetriever.parsePEMFile(content); err != nil {
 		return nil, fmt.Errorf("Failed to parse PEM file with error %+v", err)
 	}
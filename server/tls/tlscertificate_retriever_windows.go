@@ -39,6 +39,11 @@ func (wtls *windowsTlsCertificateRetriever) GetCertificate() (*x509.Certificate,
 	return nil, fmt.Errorf("No Certificate block found")
 }
 
+// certificateChain returns the leaf certificate of the pem followed by the intermediate certificates
+func (wtls *windowsTlsCertificateRetriever) certificateChain() ([][]byte, error) {
+	return pemCertificateChain(wtls.pemBlock)
+}
+
 // GetPrivateKey Returns the private key associated with the pem
 func (wtls *windowsTlsCertificateRetriever) GetPrivateKey() (crypto.PrivateKey, error) {
 	for _, block := range wtls.pemBlock {
@@ -124,6 +129,16 @@ func NewTlsCertificateRetriever(settings TlsSettings) (TlsCertificateRetriever,
 		return nil, fmt.Errorf("Failed to read file with error %+v", err)
 	}
 
+	return newTlsCertificateRetriever(settings, content)
+}
+
+// newTlsCertificateRetriever creates a TlsCertificateRetriever from the encrypted content of the file at
+// TLSCertificatePath.
+func newTlsCertificateRetriever(settings TlsSettings, content []byte) (TlsCertificateRetriever, error) {
+	windowsCertStoreRetriever := &windowsTlsCertificateRetriever{
+		settings: settings,
+	}
+
 	decrypted, err := windowsCertStoreRetriever.decrypt(content)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to decrypt file with error %+v", err)
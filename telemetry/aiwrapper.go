@@ -39,6 +39,26 @@ func CreateAITelemetryHandle(aiConfig aitelemetry.AIConfig, disableAll, disableM
 	return nil
 }
 
+// CreateOTLPTelemetryHandle creates the telemetry handle that exports to the OTLP/HTTP endpoint of an
+// OpenTelemetry collector instead of Application Insights.
+func CreateOTLPTelemetryHandle(endpoint string, aiConfig aitelemetry.AIConfig, disableAll, disableMetric, disableTrace bool) error {
+	var err error
+
+	if disableAll {
+		log.Printf("Telemetry is disabled")
+		return ErrTelemetryDisabled
+	}
+
+	th, err = aitelemetry.NewOTLPTelemetry(endpoint, aiConfig)
+	if err != nil {
+		return err
+	}
+
+	gDisableMetric = disableMetric
+	gDisableTrace = disableTrace
+	return nil
+}
+
 func SendAITelemetry(cnireport CNIReport) {
 	if th == nil || gDisableTrace {
 		return
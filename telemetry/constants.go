@@ -9,6 +9,8 @@ const (
 	CNIDelTimeMetricStr    = "CNIDelTimeMs"
 	CNIUpdateTimeMetricStr = "CNIUpdateTimeMs"
 	CNILockTimeoutStr      = "CNILockTimeoutError"
+	// CNITelemetryReportsDroppedStr is the number of reports dropped from the telemetry spool
+	CNITelemetryReportsDroppedStr = "CNITelemetryReportsDropped"
 
 	// Dimension Names
 	ContextStr        = "Context"
@@ -0,0 +1,276 @@
+// Copyright 2018 Microsoft. All rights reserved.
+// MIT License
+
+package telemetry
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-container-networking/internal/lockedfile"
+	"github.com/pkg/errors"
+)
+
+// DefaultSpoolSegmentSize - size in bytes at which the spool starts a new segment file
+// DefaultSpoolSize - total size in bytes of the spool segments, beyond which reports are dropped
+const (
+	DefaultSpoolSegmentSize = 256 * 1024
+	DefaultSpoolSize        = 4 * 1024 * 1024
+
+	spoolSegmentExt  = ".seg"
+	spoolDrainingExt = ".draining"
+	spoolDroppedFile = "dropped"
+	spoolLockFile    = "spool.lock"
+	spoolHeaderSize  = 8
+)
+
+var ErrSpoolFull = errors.New("telemetry spool is full")
+
+// Spool is a durable on-disk queue for telemetry reports that could not be written to the telemetry socket.
+// Reports are appended to segment files as frames of a little endian payload length, a CRC32 of the payload
+// and the payload, each written with a single append so that a crash can at most leave a truncated frame at
+// the end of a segment. Reports that don't fit in the spool are dropped and counted, so that the telemetry
+// service can report how many were lost. The spool may be appended to by several processes at once: appends
+// and the claiming of segments by Drain are serialized by a lock file in the spool dir, so no segment is
+// claimed while a writer has it open.
+type Spool struct {
+	dir         string
+	segmentSize int64
+	maxSize     int64
+}
+
+// NewSpool creates a spool in dir. Non-positive sizes are set to the defaults.
+func NewSpool(dir string, segmentSize, maxSize int64) *Spool {
+	if segmentSize <= 0 {
+		segmentSize = DefaultSpoolSegmentSize
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultSpoolSize
+	}
+	return &Spool{dir: dir, segmentSize: segmentSize, maxSize: maxSize}
+}
+
+// NewDefaultSpool creates a spool in the platform spool directory with the default sizes.
+func NewDefaultSpool() *Spool {
+	return NewSpool(SpoolDir, DefaultSpoolSegmentSize, DefaultSpoolSize)
+}
+
+// Append adds the report to the spool. If the spool is full the report is dropped and ErrSpoolFull is returned.
+func (s *Spool) Append(report []byte) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return errors.Wrap(err, "failed to create spool dir")
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	frame := make([]byte, spoolHeaderSize+len(report))
+	binary.LittleEndian.PutUint32(frame[0:4], uint32(len(report)))
+	binary.LittleEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(report))
+	copy(frame[spoolHeaderSize:], report)
+
+	segments, total, err := s.segments(spoolSegmentExt)
+	if err != nil {
+		return err
+	}
+
+	if total+int64(len(frame)) > s.maxSize {
+		s.drop()
+		return ErrSpoolFull
+	}
+
+	// append to the newest segment until it reaches the segment size
+	var name string
+	if n := len(segments); n > 0 && segments[n-1].size+int64(len(frame)) <= s.segmentSize {
+		name = segments[n-1].name
+	} else {
+		name = fmt.Sprintf("%020d%s", time.Now().UnixNano(), spoolSegmentExt)
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		s.drop()
+		return errors.Wrap(err, "failed to open spool segment")
+	}
+	defer f.Close()
+
+	if _, err := f.Write(frame); err != nil {
+		s.drop()
+		return errors.Wrap(err, "failed to write to spool segment")
+	}
+
+	return nil
+}
+
+// Drain calls handle with each spooled report, oldest first, and removes the drained segments. A truncated or
+// corrupt frame is skipped and counted as dropped, and the frames after it in the segment are still drained.
+// Drain returns the number of reports drained and the number dropped since the last drain. Reports are
+// delivered at least once: a segment whose drain is interrupted is drained again in full.
+func (s *Spool) Drain(handle func([]byte)) (drained, dropped int, err error) {
+	dropped, err = s.claim()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	claimed, _, err := s.segments(spoolDrainingExt)
+	if err != nil {
+		return 0, dropped, err
+	}
+
+	for _, seg := range claimed {
+		path := filepath.Join(s.dir, seg.name)
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return drained, dropped, errors.Wrap(err, "failed to read spool segment")
+		}
+
+		n, corrupt := readFrames(b, handle)
+		drained += n
+		dropped += corrupt
+
+		if err := os.Remove(path); err != nil {
+			return drained, dropped, errors.Wrap(err, "failed to remove spool segment")
+		}
+	}
+
+	return drained, dropped, nil
+}
+
+// claim renames the segments to be drained, so that reports appended while draining go to new segments, and
+// resets the dropped count, returning it. It holds the spool lock, so no writer has a claimed segment open.
+func (s *Spool) claim() (int, error) {
+	if _, err := os.Stat(s.dir); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	current, _, err := s.segments(spoolSegmentExt)
+	if err != nil {
+		return 0, err
+	}
+	for _, seg := range current {
+		path := filepath.Join(s.dir, seg.name)
+		if err := os.Rename(path, strings.TrimSuffix(path, spoolSegmentExt)+spoolDrainingExt); err != nil {
+			return 0, errors.Wrap(err, "failed to claim spool segment")
+		}
+	}
+
+	// the dropped file grows by a byte for each dropped report
+	droppedPath := filepath.Join(s.dir, spoolDroppedFile)
+	fi, err := os.Stat(droppedPath)
+	if err != nil {
+		return 0, nil //nolint:nilerr // nothing was dropped
+	}
+	if err := os.Remove(droppedPath); err != nil {
+		return 0, errors.Wrap(err, "failed to reset spool dropped count")
+	}
+	return int(fi.Size()), nil
+}
+
+// lock takes the spool lock and returns the func that releases it.
+func (s *Spool) lock() (func(), error) {
+	unlock, err := lockedfile.MutexAt(filepath.Join(s.dir, spoolLockFile)).Lock()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to lock spool")
+	}
+	return unlock, nil
+}
+
+// readFrames calls handle with the payload of each valid frame in b. It returns the number of frames read and
+// the number of truncated or corrupt frames. After a corrupt frame it resumes at the next offset that holds a
+// valid frame, so a corrupt frame only loses its own report.
+func readFrames(b []byte, handle func([]byte)) (read, corrupt int) {
+	for len(b) > 0 {
+		if size, ok := validFrame(b); ok {
+			handle(b[spoolHeaderSize : spoolHeaderSize+size])
+			b = b[spoolHeaderSize+size:]
+			read++
+			continue
+		}
+
+		corrupt++
+		next := 1
+		for ; next < len(b); next++ {
+			if _, ok := validFrame(b[next:]); ok {
+				break
+			}
+		}
+		b = b[next:]
+	}
+	return read, corrupt
+}
+
+// validFrame returns the payload size of the frame at the start of b, and whether it is a whole frame with a
+// non-empty payload that matches its checksum.
+func validFrame(b []byte) (int, bool) {
+	if len(b) < spoolHeaderSize {
+		return 0, false
+	}
+	size := int(binary.LittleEndian.Uint32(b[0:4]))
+	sum := binary.LittleEndian.Uint32(b[4:8])
+	if size == 0 || size > len(b)-spoolHeaderSize {
+		return 0, false
+	}
+	return size, crc32.ChecksumIEEE(b[spoolHeaderSize:spoolHeaderSize+size]) == sum
+}
+
+// drop counts a dropped report by appending a byte to the dropped file, which is safe across processes.
+func (s *Spool) drop() {
+	f, err := os.OpenFile(filepath.Join(s.dir, spoolDroppedFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = f.Write([]byte{0})
+}
+
+type spoolSegment struct {
+	name string
+	size int64
+}
+
+// segments returns the spool files with the extension, oldest first, and their total size.
+func (s *Spool) segments(ext string) ([]spoolSegment, int64, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, errors.Wrap(err, "failed to read spool dir")
+	}
+
+	var segments []spoolSegment
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ext {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				// drained or claimed since the read
+				continue
+			}
+			return nil, 0, errors.Wrap(err, "failed to stat spool segment")
+		}
+		segments = append(segments, spoolSegment{name: entry.Name(), size: fi.Size()})
+		total += fi.Size()
+	}
+
+	sort.Slice(segments, func(i, j int) bool { return segments[i].name < segments[j].name })
+	return segments, total, nil
+}
@@ -0,0 +1,196 @@
+// Copyright 2018 Microsoft. All rights reserved.
+// MIT License
+
+package telemetry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func drainAll(t *testing.T, s *Spool) (reports []string, dropped int) {
+	t.Helper()
+	n, dropped, err := s.Drain(func(b []byte) {
+		reports = append(reports, string(b))
+	})
+	require.NoError(t, err)
+	require.Len(t, reports, n)
+	return reports, dropped
+}
+
+func TestSpoolAppendDrain(t *testing.T) {
+	s := NewSpool(t.TempDir(), 64, 1024)
+
+	want := []string{"report-1", "report-2", "report-3", "report-4", "report-5", "report-6"}
+	for _, r := range want {
+		require.NoError(t, s.Append([]byte(r)))
+	}
+
+	// the segment size forces several segments, which must be drained in order
+	segments, _, err := s.segments(spoolSegmentExt)
+	require.NoError(t, err)
+	require.Greater(t, len(segments), 1)
+
+	got, dropped := drainAll(t, s)
+	require.Equal(t, want, got)
+	require.Zero(t, dropped)
+
+	// the drained segments are removed
+	got, dropped = drainAll(t, s)
+	require.Empty(t, got)
+	require.Zero(t, dropped)
+}
+
+func TestSpoolFull(t *testing.T) {
+	s := NewSpool(t.TempDir(), 32, 32)
+
+	require.NoError(t, s.Append([]byte("0123456789")))
+	require.ErrorIs(t, s.Append([]byte("0123456789abcdefghij")), ErrSpoolFull)
+	require.ErrorIs(t, s.Append([]byte("0123456789abcdefghij")), ErrSpoolFull)
+
+	got, dropped := drainAll(t, s)
+	require.Equal(t, []string{"0123456789"}, got)
+	require.Equal(t, 2, dropped)
+
+	// the dropped count is reset by the drain
+	_, dropped = drainAll(t, s)
+	require.Zero(t, dropped)
+}
+
+func TestSpoolCorruptFrames(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(b []byte) []byte
+	}{
+		{
+			name: "truncated header",
+			corrupt: func(b []byte) []byte {
+				return append(b, 1, 2, 3)
+			},
+		},
+		{
+			name: "truncated payload",
+			corrupt: func(b []byte) []byte {
+				return b[:len(b)-2]
+			},
+		},
+		{
+			name: "bad checksum",
+			corrupt: func(b []byte) []byte {
+				b[len(b)-1] ^= 0xff
+				return b
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := NewSpool(dir, 1024, 1024)
+			require.NoError(t, s.Append([]byte("first")))
+			require.NoError(t, s.Append([]byte("second")))
+
+			segments, _, err := s.segments(spoolSegmentExt)
+			require.NoError(t, err)
+			require.Len(t, segments, 1)
+
+			path := filepath.Join(dir, segments[0].name)
+			b, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, tt.corrupt(b), 0o600))
+
+			got, dropped := drainAll(t, s)
+			require.Contains(t, got, "first")
+			require.Equal(t, 1, dropped)
+		})
+	}
+}
+
+func TestSpoolCorruptFrameMidSegment(t *testing.T) {
+	dir := t.TempDir()
+	s := NewSpool(dir, 1024, 1024)
+	want := []string{"first", "second", "third", "fourth"}
+	for _, r := range want {
+		require.NoError(t, s.Append([]byte(r)))
+	}
+
+	segments, _, err := s.segments(spoolSegmentExt)
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	// corrupt the checksum and the length of the second frame
+	path := filepath.Join(dir, segments[0].name)
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	second := spoolHeaderSize + len("first")
+	b[second] = 0xff
+	b[second+4] ^= 0xff
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+
+	// only the corrupt frame is lost, the frames after it are drained
+	got, dropped := drainAll(t, s)
+	require.Equal(t, []string{"first", "third", "fourth"}, got)
+	require.Equal(t, 1, dropped)
+}
+
+func TestSpoolConcurrentAppendDrain(t *testing.T) {
+	s := NewSpool(t.TempDir(), 64, 1<<20)
+
+	const writers, reports = 4, 50
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < reports; i++ {
+				require.NoError(t, s.Append([]byte(fmt.Sprintf("report-%d-%d", w, i))))
+			}
+		}(w)
+	}
+
+	var got []string
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for drained := false; !drained; {
+		select {
+		case <-done:
+			drained = true
+		default:
+		}
+		reports, dropped := drainAll(t, s)
+		require.Zero(t, dropped)
+		got = append(got, reports...)
+	}
+
+	// no report appended while a segment was claimed is lost
+	require.Len(t, got, writers*reports)
+}
+
+func TestSendReportSpoolsWhenDisconnected(t *testing.T) {
+	s := NewSpool(t.TempDir(), 0, 0)
+	tb := NewTelemetryBuffer()
+	tb.SetSpool(s)
+
+	reportManager := &ReportManager{Report: &CNIReport{Name: "test", CniSucceeded: true}}
+	require.NoError(t, reportManager.SendReport(tb))
+	require.NoError(t, SendCNIMetric(&AIMetric{}, tb))
+
+	tb.DrainSpool(s)
+	require.Len(t, tb.data, 2)
+
+	report, ok := (<-tb.data).(CNIReport)
+	require.True(t, ok)
+	require.Equal(t, "test", report.Name)
+
+	_, ok = (<-tb.data).(AIMetric)
+	require.True(t, ok)
+}
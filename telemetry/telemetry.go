@@ -108,10 +108,10 @@ func (reportMgr *ReportManager) SendReport(tb *TelemetryBuffer) error {
 	var err error
 	var report []byte
 
-	if tb != nil && tb.Connected {
+	if tb != nil && (tb.Connected || tb.spool != nil) {
 		report, err = reportMgr.ReportToBytes()
 		if err == nil {
-			if _, err = tb.Write(report); err != nil {
+			if err = tb.send(report); err != nil {
 				log.Printf("telemetry write failed:%v", err)
 			}
 		}
@@ -138,11 +138,11 @@ func SendCNIMetric(cniMetric *AIMetric, tb *TelemetryBuffer) error {
 	var err error
 	var report []byte
 
-	if tb != nil && tb.Connected {
+	if tb != nil && (tb.Connected || tb.spool != nil) {
 		reportMgr := &ReportManager{Report: cniMetric}
 		report, err = reportMgr.ReportToBytes()
 		if err == nil {
-			if _, err = tb.Write(report); err != nil {
+			if err = tb.send(report); err != nil {
 				log.Printf("Error writing to telemetry socket:%v", err)
 			}
 		}
@@ -152,11 +152,11 @@ func SendCNIMetric(cniMetric *AIMetric, tb *TelemetryBuffer) error {
 }
 
 func SendCNIEvent(tb *TelemetryBuffer, report *CNIReport) {
-	if tb != nil && tb.Connected {
+	if tb != nil && (tb.Connected || tb.spool != nil) {
 		reportMgr := &ReportManager{Report: report}
 		reportBytes, err := reportMgr.ReportToBytes()
 		if err == nil {
-			if _, err = tb.Write(reportBytes); err != nil {
+			if err = tb.send(reportBytes); err != nil {
 				log.Printf("Error writing to telemetry socket:%v", err)
 			}
 		}
@@ -14,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Azure/azure-container-networking/aitelemetry"
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/log"
 	"github.com/Azure/azure-container-networking/platform"
@@ -33,6 +34,9 @@ type TelemetryConfig struct {
 	BatchSizeInBytes              int
 	GetEnvRetryCount              int
 	GetEnvRetryWaitTimeInSecs     int
+	// OTLPEndpoint is the OTLP/HTTP endpoint of an OpenTelemetry collector. If set, telemetry is exported
+	// there instead of to Application Insights.
+	OTLPEndpoint string
 }
 
 // FdName - file descriptor name
@@ -55,6 +59,7 @@ type TelemetryBuffer struct {
 	data        chan interface{}
 	cancel      chan bool
 	mutex       sync.Mutex
+	spool       *Spool
 }
 
 // Buffer object holds the different types of reports
@@ -106,23 +111,10 @@ func (tb *TelemetryBuffer) StartServer() error {
 					for {
 						reportStr, err := read(conn)
 						if err == nil {
-							var tmp map[string]interface{}
-							err = json.Unmarshal(reportStr, &tmp)
-							if err != nil {
+							if err = tb.enqueue(reportStr); err != nil {
 								log.Logf("StartServer: unmarshal error:%v", err)
 								return
 							}
-							if _, ok := tmp["CniSucceeded"]; ok {
-								var cniReport CNIReport
-								json.Unmarshal([]byte(reportStr), &cniReport)
-								tb.data <- cniReport
-							} else if _, ok := tmp["Metric"]; ok {
-								var aiMetric AIMetric
-								json.Unmarshal([]byte(reportStr), &aiMetric)
-								tb.data <- aiMetric
-							} else {
-								log.Logf("StartServer: default case:%+v...", tmp)
-							}
 						} else {
 							var index int
 							var value net.Conn
@@ -157,6 +149,76 @@ func (tb *TelemetryBuffer) StartServer() error {
 	return nil
 }
 
+// enqueue decodes a report written to the telemetry socket and queues it to be pushed
+func (tb *TelemetryBuffer) enqueue(reportStr []byte) error {
+	var tmp map[string]interface{}
+	if err := json.Unmarshal(reportStr, &tmp); err != nil {
+		return err
+	}
+
+	if _, ok := tmp["CniSucceeded"]; ok {
+		var cniReport CNIReport
+		json.Unmarshal(reportStr, &cniReport)
+		tb.data <- cniReport
+	} else if _, ok := tmp["Metric"]; ok {
+		var aiMetric AIMetric
+		json.Unmarshal(reportStr, &aiMetric)
+		tb.data <- aiMetric
+	} else {
+		log.Logf("StartServer: default case:%+v...", tmp)
+	}
+
+	return nil
+}
+
+// SetSpool sets the spool that reports are appended to when they can't be written to the telemetry socket.
+func (tb *TelemetryBuffer) SetSpool(s *Spool) {
+	tb.spool = s
+}
+
+// DrainSpool queues the reports spooled while the telemetry service was unavailable, and a metric of the
+// number of reports the spool dropped. It blocks until the reports are queued, so run it before or
+// alongside PushData.
+func (tb *TelemetryBuffer) DrainSpool(s *Spool) {
+	drained, dropped, err := s.Drain(func(report []byte) {
+		if err := tb.enqueue(report); err != nil {
+			log.Logf("[Telemetry] Skipping spooled report: %v", err)
+		}
+	})
+	if err != nil {
+		log.Logf("[Telemetry] Failed to drain spool: %v", err)
+	}
+
+	log.Logf("[Telemetry] Drained %d spooled reports, %d dropped", drained, dropped)
+
+	if dropped > 0 {
+		tb.data <- AIMetric{
+			Metric: aitelemetry.Metric{
+				Name:             CNITelemetryReportsDroppedStr,
+				Value:            float64(dropped),
+				CustomDimensions: make(map[string]string),
+			},
+		}
+	}
+}
+
+// send writes the report to the telemetry socket, or appends it to the spool if the buffer isn't connected
+// or the write fails.
+func (tb *TelemetryBuffer) send(report []byte) error {
+	var err error
+	if tb.Connected {
+		if _, err = tb.Write(report); err == nil || tb.spool == nil {
+			return err
+		}
+		log.Printf("telemetry write failed, spooling report:%v", err)
+	}
+
+	if tb.spool == nil {
+		return nil
+	}
+	return tb.spool.Append(report)
+}
+
 func (tb *TelemetryBuffer) Connect() error {
 	err := tb.Dial(FdName)
 	if err == nil {
@@ -15,6 +15,7 @@ const (
 	TelemetryServiceProcessName = "azure-vnet-telemetry.exe"
 	CniInstallDir               = "c:\\k\\azurecni\\bin"
 	metadataFile                = "azuremetadata.json"
+	SpoolDir                    = "c:\\k\\azurecni\\telemetry-spool"
 )
 
 // Dial - try to connect to a named pipe with 'name'
@@ -0,0 +1,114 @@
+package nmagentemulator
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// faultRequest is the body of an admin request to inject a fault. The delay is
+// a duration string such as "2s".
+type faultRequest struct {
+	StatusCode int    `json:"statusCode"`
+	Wireserver bool   `json:"wireserver"`
+	Count      int    `json:"count"`
+	Delay      string `json:"delay"`
+}
+
+// State is the state of the emulator returned by the admin API.
+type State struct {
+	JoinedNetworks    []string            `json:"joinedNetworks"`
+	NetworkContainers []ncState           `json:"networkContainers"`
+	SupportedAPIs     []string            `json:"supportedApis"`
+	HomeAz            uint                `json:"homeAz"`
+	Calls             map[Operation]int   `json:"calls"`
+	Faults            map[Operation]Fault `json:"faults"`
+}
+
+type ncState struct {
+	ID                string `json:"id"`
+	PrimaryAddress    string `json:"primaryAddress"`
+	VNetID            string `json:"vnetId"`
+	Version           uint64 `json:"version"`
+	ProgrammedVersion uint64 `json:"programmedVersion"`
+}
+
+// serveAdmin serves the admin API:
+//
+//	GET    /emulator/state          returns the State
+//	PUT    /emulator/faults/{op}    injects a fault into the operation
+//	DELETE /emulator/faults         clears all faults
+func (e *Emulator) serveAdmin(w http.ResponseWriter, req *http.Request) {
+	path := strings.TrimPrefix(req.URL.Path, AdminPath)
+
+	switch {
+	case path == "state" && req.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(e.state())
+
+	case path == "faults" && req.Method == http.MethodDelete:
+		e.ClearFaults()
+
+	case strings.HasPrefix(path, "faults/") && req.Method == http.MethodPut:
+		var fr faultRequest
+		if err := json.NewDecoder(req.Body).Decode(&fr); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		f := Fault{StatusCode: fr.StatusCode, Wireserver: fr.Wireserver, Count: fr.Count}
+		if fr.Delay != "" {
+			delay, err := time.ParseDuration(fr.Delay)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			f.Delay = delay
+		}
+
+		if err := e.InjectFault(Operation(strings.TrimPrefix(path, "faults/")), f); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (e *Emulator) state() State {
+	joined := e.JoinedNetworks()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s := State{
+		JoinedNetworks:    joined,
+		NetworkContainers: []ncState{},
+		SupportedAPIs:     e.supportedAPIs,
+		HomeAz:            e.homeAz,
+		Calls:             map[Operation]int{},
+		Faults:            map[Operation]Fault{},
+	}
+	for id, nc := range e.ncs {
+		s.NetworkContainers = append(s.NetworkContainers, ncState{
+			ID:                id,
+			PrimaryAddress:    nc.PrimaryAddress,
+			VNetID:            nc.VNetID,
+			Version:           nc.Version,
+			ProgrammedVersion: nc.ProgrammedVersion,
+		})
+	}
+	sort.Slice(s.NetworkContainers, func(i, j int) bool {
+		return s.NetworkContainers[i].ID < s.NetworkContainers[j].ID
+	})
+	for op, n := range e.calls {
+		s.Calls[op] = n
+	}
+	for op, f := range e.faults {
+		s.Faults[op] = *f
+	}
+	return s
+}
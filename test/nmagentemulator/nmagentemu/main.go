@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/cns/wireserver"
+	"github.com/Azure/azure-container-networking/test/nmagentemulator"
+)
+
+func main() {
+	addr := flag.String("addr", ":9080", "address to serve the emulated wireserver on")
+	homeAz := flag.Uint("home-az", 1, "home AZ reported by NMAgent, 0 to report none")
+	apis := flag.String("supported-apis", "NetworkManagementDNCRegistrationProtocolExtensions,NetworkManagementAuthenticationTokenSupport",
+		"comma separated APIs reported as supported by NMAgent")
+	mac := flag.String("mac", "000D3A6E2F01", "mac address of the primary interface")
+	prefix := flag.String("subnet", "10.240.0.0/16", "subnet prefix of the primary interface")
+	primaryIP := flag.String("primary-ip", "10.240.0.4", "primary ip address of the primary interface")
+	flag.Parse()
+
+	emulator := nmagentemulator.New(nmagentemulator.Config{
+		SupportedAPIs: strings.Split(*apis, ","),
+		HomeAz:        *homeAz,
+		Interfaces: wireserver.GetInterfacesResult{
+			Interface: []wireserver.Interface{
+				{
+					MacAddress: *mac,
+					IsPrimary:  true,
+					IPSubnet: []wireserver.Subnet{
+						{
+							Prefix:    *prefix,
+							IPAddress: []wireserver.Address{{Address: *primaryIP, IsPrimary: true}},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	fmt.Printf("starting nmagent emulator on %s ....\n", *addr)
+	if err := http.ListenAndServe(*addr, emulator); err != nil { //nolint:gosec // test server, timeouts are not needed
+		fmt.Fprintf(os.Stderr, "nmagent emulator: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,525 @@
+// Package nmagentemulator provides a stateful, in-memory emulator of the
+// NMAgent REST API as it is exposed through the wireserver plugin path, for use
+// in local integration testing of CNS and CNI.
+package nmagentemulator
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns/wireserver"
+	"github.com/Azure/azure-container-networking/nmagent"
+	"github.com/pkg/errors"
+)
+
+const (
+	wirePluginPath = "/machine/plugins"
+	nmagentComp    = "nmagent"
+
+	// AdminPath is the prefix of the endpoints used to control the emulator at runtime.
+	AdminPath = "/emulator/"
+)
+
+// Operation names an NMAgent or wireserver API served by the emulator.
+type Operation string
+
+const (
+	OpJoinNetwork            Operation = "JoinNetwork"
+	OpDeleteNetwork          Operation = "DeleteNetwork"
+	OpGetNetworkConfig       Operation = "GetNetworkConfig"
+	OpPutNetworkContainer    Operation = "PutNetworkContainer"
+	OpDeleteNetworkContainer Operation = "DeleteNetworkContainer"
+	OpGetNCVersion           Operation = "GetNCVersion"
+	OpGetNCVersionList       Operation = "GetNCVersionList"
+	OpSupportedAPIs          Operation = "SupportedAPIs"
+	OpGetHomeAz              Operation = "GetHomeAz"
+	OpGetInterfaces          Operation = "GetInterfaces"
+)
+
+var (
+	errUnknownOperation  = errors.New("unknown operation")
+	errInvalidStatusCode = errors.New("fault status code must be a non-2xx http status code")
+)
+
+// Fault describes a failure injected into an operation.
+type Fault struct {
+	// StatusCode is the status code returned instead of the emulated response.
+	// It is zero for faults that only add a delay.
+	StatusCode int `json:"statusCode"`
+	// Wireserver returns the status code from wireserver itself instead of
+	// embedding it in the NMAgent response, as wireserver does when it cannot
+	// reach NMAgent.
+	Wireserver bool `json:"wireserver"`
+	// Count is the number of requests the fault applies to. Zero applies it
+	// until the fault is cleared.
+	Count int `json:"count"`
+	// Delay is added before the response is written.
+	Delay time.Duration `json:"delay"`
+}
+
+// Config is the initial state of an Emulator.
+type Config struct {
+	// SupportedAPIs are returned by the supported APIs query.
+	SupportedAPIs []string
+	// HomeAz is returned by the home AZ query. Zero emulates an NMAgent that
+	// does not know its home AZ.
+	HomeAz uint
+	// Interfaces are returned by the wireserver interface query.
+	Interfaces wireserver.GetInterfacesResult
+}
+
+// NetworkContainer is a network container published to the emulator.
+type NetworkContainer struct {
+	nmagent.PutNetworkContainerRequest
+	// ProgrammedVersion is the version reported by NMAgent. It follows Version
+	// unless it was pinned with SetProgrammedVersion.
+	ProgrammedVersion uint64
+}
+
+// Emulator is an http.Handler that emulates NMAgent behind wireserver. It keeps
+// the joined networks and published network containers in memory so that the
+// calls made through it are reflected in later queries.
+type Emulator struct {
+	mu            sync.Mutex
+	supportedAPIs []string
+	homeAz        uint
+	interfaces    wireserver.GetInterfacesResult
+	networks      map[string]nmagent.VirtualNetwork
+	ncs           map[string]*NetworkContainer
+	pinned        map[string]uint64
+	faults        map[Operation]*Fault
+	calls         map[Operation]int
+}
+
+// New creates an Emulator with the provided initial state.
+func New(c Config) *Emulator {
+	return &Emulator{
+		supportedAPIs: c.SupportedAPIs,
+		homeAz:        c.HomeAz,
+		interfaces:    c.Interfaces,
+		networks:      map[string]nmagent.VirtualNetwork{},
+		ncs:           map[string]*NetworkContainer{},
+		pinned:        map[string]uint64{},
+		faults:        map[Operation]*Fault{},
+		calls:         map[Operation]int{},
+	}
+}
+
+// InjectFault makes the following requests for the operation fail as described
+// by the fault, replacing any fault already injected for it.
+func (e *Emulator) InjectFault(op Operation, f Fault) error {
+	if _, ok := operations[op]; !ok {
+		return errors.Wrapf(errUnknownOperation, "%q", op)
+	}
+	if f.StatusCode != 0 && (f.StatusCode < 100 || f.StatusCode > 599 || f.StatusCode/100 == 2) {
+		return errors.Wrapf(errInvalidStatusCode, "%d", f.StatusCode)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.faults[op] = &f
+	return nil
+}
+
+// ClearFaults removes all injected faults.
+func (e *Emulator) ClearFaults() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.faults = map[Operation]*Fault{}
+}
+
+// Calls returns the number of requests received for the operation, including
+// the ones that failed.
+func (e *Emulator) Calls(op Operation) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls[op]
+}
+
+// SetHomeAz sets the home AZ returned by NMAgent.
+func (e *Emulator) SetHomeAz(az uint) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.homeAz = az
+}
+
+// SetSupportedAPIs sets the APIs NMAgent reports as supported.
+func (e *Emulator) SetSupportedAPIs(apis []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.supportedAPIs = apis
+}
+
+// SetProgrammedVersion pins the version NMAgent reports for a network
+// container, to emulate NMAgent lagging behind or running ahead of CNS.
+func (e *Emulator) SetProgrammedVersion(ncID string, version uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pinned[ncID] = version
+	if nc, ok := e.ncs[ncID]; ok {
+		nc.ProgrammedVersion = version
+	}
+}
+
+// NetworkContainer returns the network container published with the ID.
+func (e *Emulator) NetworkContainer(ncID string) (NetworkContainer, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	nc, ok := e.ncs[ncID]
+	if !ok {
+		return NetworkContainer{}, false
+	}
+	return *nc, true
+}
+
+// JoinedNetworks returns the IDs of the joined virtual networks, sorted.
+func (e *Emulator) JoinedNetworks() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ids := make([]string, 0, len(e.networks))
+	for id := range e.networks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// response is an emulated NMAgent response. A body that is a string is written
+// unmodified, anything else is encoded as JSON with the status code embedded
+// the way wireserver does.
+type response struct {
+	code int
+	body any
+}
+
+type route struct {
+	op      Operation
+	method  string
+	pattern []string
+	handle  func(e *Emulator, params []string, body []byte) response
+}
+
+// routes are matched against the NMAgent path carried in the type parameter of
+// the wireserver plugin query. The "*" segments are passed to the handler.
+var routes = []route{
+	{OpJoinNetwork, http.MethodPost, split("NetworkManagement/joinedVirtualNetworks/*/api-version/1"), (*Emulator).joinNetwork},
+	{OpDeleteNetwork, http.MethodPost, split("NetworkManagement/joinedVirtualNetworks/*/api-version/1/method/DELETE"), (*Emulator).deleteNetwork},
+	{OpGetNetworkConfig, http.MethodGet, split("NetworkManagement/joinedVirtualNetworks/*/api-version/1"), (*Emulator).getNetworkConfig},
+	{OpPutNetworkContainer, http.MethodPost, split("NetworkManagement/interfaces/*/networkContainers/*/authenticationToken/*/api-version/1"), (*Emulator).putNetworkContainer},
+	{OpDeleteNetworkContainer, http.MethodPost, split("NetworkManagement/interfaces/*/networkContainers/*/authenticationToken/*/api-version/1/method/DELETE"), (*Emulator).deleteNetworkContainer},
+	{OpGetNCVersion, http.MethodGet, split("NetworkManagement/interfaces/*/networkContainers/*/version/authenticationToken/*/api-version/*"), (*Emulator).getNCVersion},
+	{OpGetNCVersionList, http.MethodGet, split("NetworkManagement/interfaces/api-version/*"), (*Emulator).getNCVersionList},
+	{OpSupportedAPIs, http.MethodGet, split("GetSupportedApis"), (*Emulator).getSupportedAPIs},
+	{OpGetHomeAz, http.MethodGet, split("GetHomeAz/api-version/1"), (*Emulator).getHomeAz},
+	{OpGetInterfaces, http.MethodGet, split("getinterfaceinfov1"), (*Emulator).getInterfaces},
+}
+
+var operations = func() map[Operation]struct{} {
+	ops := map[Operation]struct{}{}
+	for _, r := range routes {
+		ops[r.op] = struct{}{}
+	}
+	return ops
+}()
+
+func split(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// match returns the wildcard segments of path if it matches the route.
+func (r route) match(method string, path []string) ([]string, bool) {
+	if method != r.method || len(path) != len(r.pattern) {
+		return nil, false
+	}
+
+	var params []string
+	for i, seg := range r.pattern {
+		if seg == "*" {
+			params = append(params, path[i])
+			continue
+		}
+		if !strings.EqualFold(seg, path[i]) {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// ServeHTTP serves the wireserver plugin path for NMAgent. Requests to AdminPath
+// are handled by the admin API.
+func (e *Emulator) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if strings.HasPrefix(req.URL.Path, AdminPath) {
+		e.serveAdmin(w, req)
+		return
+	}
+
+	if strings.TrimSuffix(req.URL.Path, "/") != wirePluginPath || req.URL.Query().Get("comp") != nmagentComp {
+		http.NotFound(w, req)
+		return
+	}
+
+	// wireserver rejects PUTs, so clients always send them as POSTs
+	if req.Method == http.MethodPost && req.ContentLength <= 0 {
+		http.Error(w, "length required", http.StatusLengthRequired)
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	path := split(req.URL.Query().Get("type"))
+	for _, r := range routes {
+		params, ok := r.match(req.Method, path)
+		if !ok {
+			continue
+		}
+
+		if f := e.takeFault(r.op); f != nil {
+			if f.Delay > 0 {
+				select {
+				case <-time.After(f.Delay):
+				case <-req.Context().Done():
+					return
+				}
+			}
+			if f.StatusCode != 0 {
+				if f.Wireserver {
+					http.Error(w, http.StatusText(f.StatusCode), f.StatusCode)
+					return
+				}
+				writeResponse(w, response{code: f.StatusCode})
+				return
+			}
+		}
+
+		writeResponse(w, r.handle(e, params, body))
+		return
+	}
+
+	// wireserver passes unknown paths to NMAgent, which answers them with a 404
+	writeResponse(w, response{code: http.StatusNotFound})
+}
+
+// takeFault counts a call to the operation and returns the fault to apply to it, if any.
+func (e *Emulator) takeFault(op Operation) *Fault {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.calls[op]++
+	f, ok := e.faults[op]
+	if !ok {
+		return nil
+	}
+
+	if f.Count > 0 {
+		f.Count--
+		if f.Count == 0 {
+			delete(e.faults, op)
+		}
+	}
+	fault := *f
+	return &fault
+}
+
+func writeResponse(w http.ResponseWriter, resp response) {
+	if s, ok := resp.body.(string); ok {
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		w.WriteHeader(resp.code)
+		_, _ = io.WriteString(w, s)
+		return
+	}
+
+	out := map[string]json.RawMessage{}
+	if resp.body != nil {
+		b, err := json.Marshal(resp.body)
+		if err == nil {
+			err = json.Unmarshal(b, &out)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// wireserver always answers with a 200 and the status code from NMAgent as a string in the body
+	code, _ := json.Marshal(strconv.Itoa(resp.code))
+	out["httpStatusCode"] = code
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+func (e *Emulator) joinNetwork(params []string, _ []byte) response {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	vnetID := params[0]
+	if _, ok := e.networks[vnetID]; !ok {
+		e.networks[vnetID] = nmagent.VirtualNetwork{VNetVersion: "0"}
+	}
+	return response{code: http.StatusOK}
+}
+
+func (e *Emulator) deleteNetwork(params []string, _ []byte) response {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	vnetID := params[0]
+	if _, ok := e.networks[vnetID]; !ok {
+		return response{code: http.StatusNotFound}
+	}
+	delete(e.networks, vnetID)
+	return response{code: http.StatusOK}
+}
+
+func (e *Emulator) getNetworkConfig(params []string, _ []byte) response {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	vnet, ok := e.networks[params[0]]
+	if !ok {
+		return response{code: http.StatusNotFound}
+	}
+	return response{code: http.StatusOK, body: vnet}
+}
+
+func (e *Emulator) putNetworkContainer(params []string, body []byte) response {
+	primaryIP, ncID, token := params[0], params[1], params[2]
+
+	var req nmagent.PutNetworkContainerRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return response{code: http.StatusBadRequest}
+	}
+	req.PrimaryAddress = primaryIP
+	req.ID = ncID
+	req.AuthenticationToken = token
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.networks[req.VNetID]; !ok {
+		// NMAgent only programs network containers in joined networks
+		return response{code: http.StatusPreconditionFailed}
+	}
+
+	nc := &NetworkContainer{PutNetworkContainerRequest: req, ProgrammedVersion: req.Version}
+	if version, ok := e.pinned[ncID]; ok {
+		nc.ProgrammedVersion = version
+	}
+	e.ncs[ncID] = nc
+	return response{code: http.StatusOK}
+}
+
+func (e *Emulator) deleteNetworkContainer(params []string, _ []byte) response {
+	primaryIP, ncID, token := params[0], params[1], params[2]
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if code := e.authorize(primaryIP, ncID, token); code != http.StatusOK {
+		return response{code: code}
+	}
+	delete(e.ncs, ncID)
+	delete(e.pinned, ncID)
+	return response{code: http.StatusOK}
+}
+
+func (e *Emulator) getNCVersion(params []string, _ []byte) response {
+	primaryIP, ncID, token := params[0], params[1], params[2]
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if code := e.authorize(primaryIP, ncID, token); code != http.StatusOK {
+		return response{code: code}
+	}
+	return response{
+		code: http.StatusOK,
+		body: nmagent.NCVersion{
+			NetworkContainerID: ncID,
+			Version:            strconv.FormatUint(e.ncs[ncID].ProgrammedVersion, 10),
+		},
+	}
+}
+
+// authorize checks that the network container exists on the interface and was
+// published with the token. It must be called with the lock held.
+func (e *Emulator) authorize(primaryIP, ncID, token string) int {
+	nc, ok := e.ncs[ncID]
+	if !ok || nc.PrimaryAddress != primaryIP {
+		return http.StatusNotFound
+	}
+	if nc.AuthenticationToken != token {
+		return http.StatusUnauthorized
+	}
+	return http.StatusOK
+}
+
+func (e *Emulator) getNCVersionList(_ []string, _ []byte) response {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	list := nmagent.NCVersionList{Containers: []nmagent.NCVersion{}}
+	for id, nc := range e.ncs {
+		list.Containers = append(list.Containers, nmagent.NCVersion{
+			NetworkContainerID: id,
+			Version:            strconv.FormatUint(nc.ProgrammedVersion, 10),
+		})
+	}
+	sort.Slice(list.Containers, func(i, j int) bool {
+		return list.Containers[i].NetworkContainerID < list.Containers[j].NetworkContainerID
+	})
+	return response{code: http.StatusOK, body: list}
+}
+
+func (e *Emulator) getSupportedAPIs(_ []string, _ []byte) response {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	b, err := xml.Marshal(supportedAPIsXML{Types: e.supportedAPIs})
+	if err != nil {
+		return response{code: http.StatusInternalServerError}
+	}
+	return response{code: http.StatusOK, body: string(b)}
+}
+
+type supportedAPIsXML struct {
+	XMLName xml.Name `xml:"SupportedApis"`
+	Types   []string `xml:"type"`
+}
+
+func (e *Emulator) getHomeAz(_ []string, _ []byte) response {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.homeAz == 0 {
+		return response{code: http.StatusNotFound}
+	}
+	return response{code: http.StatusOK, body: nmagent.AzResponse{HomeAz: e.homeAz}}
+}
+
+func (e *Emulator) getInterfaces(_ []string, _ []byte) response {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	b, err := xml.Marshal(interfacesXML{Interface: e.interfaces.Interface})
+	if err != nil {
+		return response{code: http.StatusInternalServerError}
+	}
+	return response{code: http.StatusOK, body: string(b)}
+}
+
+type interfacesXML struct {
+	XMLName   xml.Name `xml:"Interfaces"`
+	Interface []wireserver.Interface
+}
@@ -0,0 +1,270 @@
+package nmagentemulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/wireserver"
+	"github.com/Azure/azure-container-networking/nmagent"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEmulator(t *testing.T) (*Emulator, *httptest.Server, *nmagent.Client) {
+	t.Helper()
+
+	e := New(Config{
+		SupportedAPIs: []string{"NetworkManagementDNCRegistrationProtocolExtensions"},
+		HomeAz:        2,
+		Interfaces: wireserver.GetInterfacesResult{
+			Interface: []wireserver.Interface{
+				{
+					MacAddress: "000D3A6E2F01",
+					IsPrimary:  true,
+					IPSubnet: []wireserver.Subnet{
+						{Prefix: "10.240.0.0/16", IPAddress: []wireserver.Address{{Address: "10.240.0.4", IsPrimary: true}}},
+					},
+				},
+			},
+		},
+	})
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+
+	host, portStr, err := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+	require.NoError(t, err)
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	require.NoError(t, err)
+
+	client, err := nmagent.NewClient(nmagent.Config{Host: host, Port: uint16(port)})
+	require.NoError(t, err)
+
+	return e, srv, client
+}
+
+func putNCRequest(version uint64) *nmagent.PutNetworkContainerRequest {
+	return &nmagent.PutNetworkContainerRequest{
+		ID:                  "nc1",
+		VNetID:              "vnet1",
+		Version:             version,
+		SubnetName:          "subnet1",
+		IPv4Addrs:           []string{"10.0.0.5"},
+		Policies:            []nmagent.Policy{},
+		VlanID:              0,
+		AuthenticationToken: "token",
+		PrimaryAddress:      "10.240.0.4",
+	}
+}
+
+func TestNMAgentClient(t *testing.T) {
+	e, _, client := newTestEmulator(t)
+	ctx := context.Background()
+
+	// network containers can only be put in joined networks
+	err := client.PutNetworkContainer(ctx, putNCRequest(1))
+	var nmaErr nmagent.Error
+	require.ErrorAs(t, err, &nmaErr)
+	require.Equal(t, http.StatusPreconditionFailed, nmaErr.StatusCode())
+
+	require.NoError(t, client.JoinNetwork(ctx, nmagent.JoinNetworkRequest{NetworkID: "vnet1"}))
+	require.Equal(t, []string{"vnet1"}, e.JoinedNetworks())
+
+	vnet, err := client.GetNetworkConfiguration(ctx, nmagent.GetNetworkConfigRequest{VNetID: "vnet1"})
+	require.NoError(t, err)
+	require.Equal(t, "0", vnet.VNetVersion)
+
+	require.NoError(t, client.PutNetworkContainer(ctx, putNCRequest(3)))
+	nc, ok := e.NetworkContainer("nc1")
+	require.True(t, ok)
+	require.Equal(t, uint64(3), nc.Version)
+	require.Equal(t, []string{"10.0.0.5"}, nc.IPv4Addrs)
+
+	version, err := client.GetNCVersion(ctx, nmagent.NCVersionRequest{
+		AuthToken: "token", NetworkContainerID: "nc1", PrimaryAddress: "10.240.0.4",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "3", version.Version)
+
+	_, err = client.GetNCVersion(ctx, nmagent.NCVersionRequest{
+		AuthToken: "other", NetworkContainerID: "nc1", PrimaryAddress: "10.240.0.4",
+	})
+	require.ErrorAs(t, err, &nmaErr)
+	require.Equal(t, http.StatusUnauthorized, nmaErr.StatusCode())
+
+	// emulate NMAgent lagging behind
+	e.SetProgrammedVersion("nc1", 2)
+	list, err := client.GetNCVersionList(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []nmagent.NCVersion{{NetworkContainerID: "nc1", Version: "2"}}, list.Containers)
+
+	apis, err := client.SupportedAPIs(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"NetworkManagementDNCRegistrationProtocolExtensions"}, apis)
+
+	az, err := client.GetHomeAz(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint(2), az.HomeAz)
+
+	require.NoError(t, client.DeleteNetworkContainer(ctx, nmagent.DeleteContainerRequest{
+		NCID: "nc1", PrimaryAddress: "10.240.0.4", AuthenticationToken: "token",
+	}))
+	list, err = client.GetNCVersionList(ctx)
+	require.NoError(t, err)
+	require.Empty(t, list.Containers)
+
+	require.NoError(t, client.DeleteNetwork(ctx, nmagent.DeleteNetworkRequest{NetworkID: "vnet1"}))
+	require.Empty(t, e.JoinedNetworks())
+}
+
+func TestFaults(t *testing.T) {
+	e, _, client := newTestEmulator(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		fault      Fault
+		wantSource string
+	}{
+		{
+			name:       "nmagent",
+			fault:      Fault{StatusCode: http.StatusInternalServerError, Count: 1},
+			wantSource: "nmagent",
+		},
+		{
+			name:       "wireserver",
+			fault:      Fault{StatusCode: http.StatusServiceUnavailable, Wireserver: true, Count: 1},
+			wantSource: "wireserver",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, e.InjectFault(OpGetNCVersionList, tt.fault))
+
+			_, err := client.GetNCVersionList(ctx)
+			var nmaErr nmagent.Error
+			require.ErrorAs(t, err, &nmaErr)
+			require.Equal(t, tt.fault.StatusCode, nmaErr.StatusCode())
+			require.Equal(t, tt.wantSource, nmaErr.Source)
+
+			// the fault only applied to one request
+			_, err = client.GetNCVersionList(ctx)
+			require.NoError(t, err)
+		})
+	}
+
+	require.Equal(t, 4, e.Calls(OpGetNCVersionList))
+
+	require.NoError(t, e.InjectFault(OpGetHomeAz, Fault{StatusCode: http.StatusInternalServerError}))
+	for i := 0; i < 3; i++ {
+		_, err := client.GetHomeAz(ctx)
+		require.Error(t, err)
+	}
+	e.ClearFaults()
+	_, err := client.GetHomeAz(ctx)
+	require.NoError(t, err)
+
+	require.Error(t, e.InjectFault("Unknown", Fault{StatusCode: http.StatusInternalServerError}))
+	require.Error(t, e.InjectFault(OpGetHomeAz, Fault{StatusCode: http.StatusOK}))
+}
+
+func TestWireserverProxyAndClient(t *testing.T) {
+	e, srv, _ := newTestEmulator(t)
+	ctx := context.Background()
+
+	proxy := &wireserver.Proxy{Host: strings.TrimPrefix(srv.URL, "http://"), HTTPClient: http.DefaultClient}
+
+	resp, err := proxy.JoinNetwork(ctx, "vnet1")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, decodeStatus(t, resp))
+
+	params := cns.NetworkContainerParameters{NCID: "nc1", AuthToken: "token", AssociatedInterfaceID: "10.240.0.4"}
+	payload, err := json.Marshal(putNCRequest(5))
+	require.NoError(t, err)
+
+	resp, err = proxy.PublishNC(ctx, params, payload)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, decodeStatus(t, resp))
+
+	nc, ok := e.NetworkContainer("nc1")
+	require.True(t, ok)
+	require.Equal(t, uint64(5), nc.Version)
+
+	resp, err = proxy.UnpublishNC(ctx, params, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, decodeStatus(t, resp))
+	_, ok = e.NetworkContainer("nc1")
+	require.False(t, ok)
+
+	// the interface query is decoded the way wireserver.Client decodes it
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/machine/plugins?comp=nmagent&type=getinterfaceinfov1", http.NoBody)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var interfaces wireserver.GetInterfacesResult
+	require.NoError(t, xml.NewDecoder(resp.Body).Decode(&interfaces))
+	require.Len(t, interfaces.Interface, 1)
+	require.Equal(t, "10.240.0.4", interfaces.Interface[0].IPSubnet[0].IPAddress[0].Address)
+}
+
+func TestAdmin(t *testing.T) {
+	e, srv, client := newTestEmulator(t)
+	ctx := context.Background()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, srv.URL+AdminPath+"faults/"+string(OpJoinNetwork),
+		bytes.NewBufferString(`{"statusCode":403,"count":1,"delay":"10ms"}`))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	err = client.JoinNetwork(ctx, nmagent.JoinNetworkRequest{NetworkID: "vnet1"})
+	var nmaErr nmagent.Error
+	require.ErrorAs(t, err, &nmaErr)
+	require.Equal(t, http.StatusForbidden, nmaErr.StatusCode())
+
+	require.NoError(t, client.JoinNetwork(ctx, nmagent.JoinNetworkRequest{NetworkID: "vnet1"}))
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+AdminPath+"state", http.NoBody)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var state State
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&state))
+	require.Equal(t, []string{"vnet1"}, state.JoinedNetworks)
+	require.Equal(t, 2, state.Calls[OpJoinNetwork])
+	require.Empty(t, state.Faults)
+	require.Equal(t, e.JoinedNetworks(), state.JoinedNetworks)
+}
+
+func decodeStatus(t *testing.T, resp *http.Response) int {
+	t.Helper()
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		HTTPStatusCode string `json:"httpStatusCode"`
+	}
+	require.NoError(t, json.Unmarshal(b, &body))
+	code, err := strconv.Atoi(body.HTTPStatusCode)
+	require.NoError(t, err)
+	return code
+}
@@ -24,6 +24,7 @@ const (
 	FlagBinDirectory      = "bin-directory"
 	FlagConflistDirectory = "conflist-directory"
 	FlagVersion           = "version"
+	FlagScenario          = "scenario"
 
 	// CNI Log Flags
 	FlagFollow      = "follow"
@@ -69,6 +70,7 @@ const (
 	DefaultConflistDirLinux = "/etc/cni/net.d/"
 	DefaultLogFile          = "/var/log/azure-vnet.log"
 	Transparent             = "transparent"
+	TransparentVlan         = "transparent-vlan"
 	Bridge                  = "bridge"
 	Azure0                  = "azure0"
 
@@ -92,6 +94,7 @@ var (
 	EnvCNICNSUrl                     = EnvPrefix + "_" + strings.ToUpper(FlagCNSUrl)
 	EnvCNIEnableExactMatchForPodName = EnvPrefix + "_" + strings.ToUpper(FlagEnableExactMatchForPodName)
 	EnvNetworkname                   = EnvPrefix + "_" + strings.ToUpper(FlagNetworkName)
+	EnvCNIScenario                   = EnvPrefix + "_" + strings.ToUpper(FlagScenario)
 
 	Defaults = map[string]string{
 		FlagOS:                         Linux,
@@ -42,7 +42,7 @@ func InstallCNICmd() *cobra.Command {
 				return err
 			}
 
-			// only allow bridge and transparent modes
+			// only allow bridge, transparent and transparent-vlan modes
 			if err := envs.SetCNIDatapathMode(viper.GetString(c.FlagMode)); err != nil {
 				return err
 			}
@@ -56,12 +56,13 @@ func InstallCNICmd() *cobra.Command {
 			envs.CNSURL = viper.GetString(c.FlagCNSUrl)
 			envs.EnableExactMatchForPodName = viper.GetBool(c.FlagEnableExactMatchForPodName)
 			envs.NetworkName = viper.GetString(c.FlagNetworkName)
+			envs.ConflistScenario = viper.GetString(c.FlagScenario)
 
 			return i.InstallLocal(envs)
 		},
 	}
 
-	cmd.Flags().String(c.FlagMode, c.Defaults[c.FlagMode], fmt.Sprintf("Datapath mode for Azure CNI, options are %s and %s", c.Transparent, c.Bridge))
+	cmd.Flags().String(c.FlagMode, c.Defaults[c.FlagMode], fmt.Sprintf("Datapath mode for Azure CNI, options are %s, %s and %s", c.Transparent, c.TransparentVlan, c.Bridge))
 	cmd.Flags().String(c.FlagTarget, c.Defaults[c.FlagTarget], fmt.Sprintf("Location to install Azure CNI, options are %s and %s", c.Local, c.Cluster))
 	cmd.Flags().String(c.FlagIPAM, c.Defaults[c.FlagIPAM], fmt.Sprintf("Specify which IPAM source to use, options are %s and %s", c.AzureVNETIPAM, c.AzureCNSIPAM))
 	cmd.Flags().String(c.FlagOS, c.Defaults[c.FlagOS], fmt.Sprintf("Specify which operating system to install, options are %s and %s", c.Linux, c.Windows))
@@ -72,6 +73,7 @@ func InstallCNICmd() *cobra.Command {
 	cmd.Flags().String(c.FlagCNSUrl, c.Defaults[c.FlagCNSUrl], "CNS URL if multitenancy")
 	cmd.Flags().String(c.FlagEnableExactMatchForPodName, c.Defaults[c.FlagEnableExactMatchForPodName], "Enable exact match for pod name if multitenancy")
 	cmd.Flags().String(c.FlagNetworkName, c.Defaults[c.FlagNetworkName], "Network name to create pods in")
+	cmd.Flags().String(c.FlagScenario, "", "Conflist scenario to render, such as swift or overlay. Picked from the tenancy and IPAM when empty")
 
 	return cmd
 }
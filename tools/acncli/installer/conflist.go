@@ -6,108 +6,54 @@ package installer
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
-	ccn "github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cni/conflist"
 	c "github.com/Azure/azure-container-networking/tools/acncli/api"
 )
 
-type rawPlugin struct {
-	Type string `json:"type"`
-}
-
-type rawConflist struct {
-	Name       string        `json:"name"`
-	CniVersion string        `json:"cniVersion"`
-	Plugins    []interface{} `json:"plugins"`
-}
-
-func LoadConfList(conflistpath string) (rawConflist, error) {
-	var conflist rawConflist
-
-	jsonFile, err := os.Open(conflistpath)
-	if err != nil {
-		return conflist, err
+// Scenario returns the conflist scenario of the installer config. An explicit scenario takes precedence,
+// otherwise it is picked from the tenancy, IPAM type and datapath mode.
+func (i *InstallerConfig) Scenario() conflist.Scenario {
+	switch {
+	case i.ConflistScenario != "":
+		return conflist.Scenario(i.ConflistScenario)
+	case i.CNITenancy != c.CNI && i.CNIMode == c.TransparentVlan:
+		return conflist.MultitenancyTransparentVlan
+	case i.CNITenancy != c.CNI:
+		return conflist.Multitenancy
+	case i.IPAMType == c.AzureCNSIPAM:
+		return conflist.Swift
+	default:
+		return conflist.Vnet
 	}
-	defer jsonFile.Close()
-
-	byteValue, err := io.ReadAll(jsonFile)
-	if err != nil {
-		return conflist, err
-	}
-
-	err = json.Unmarshal(byteValue, &conflist)
-
-	return conflist, nil
 }
 
-func LoadConf(conflistpath string) (ccn.NetworkConfig, rawConflist, int, error) {
-	var (
-		netconfig   ccn.NetworkConfig
-		conflist    rawConflist
-		pluginIndex int
-		err         error
-		plugin      rawPlugin
-	)
-
-	conflist, err = LoadConfList(conflistpath)
-	if err != nil {
-		return netconfig, conflist, pluginIndex, err
+// RenderConflist writes the conflist rendered from the installer config in place of the packaged conflist.
+func RenderConflist(conflistpath string, installerConf InstallerConfig, perm os.FileMode) error {
+	goos, _, _ := strings.Cut(strings.ToLower(installerConf.OSType), "_")
+	opts := &conflist.Options{
+		Scenario:                   installerConf.Scenario(),
+		OS:                         goos,
+		Name:                       installerConf.NetworkName,
+		CNSURL:                     installerConf.CNSURL,
+		EnableExactMatchForPodName: &installerConf.EnableExactMatchForPodName,
 	}
-
-	// find the conflist that matches the AzureCNIBin type
-	for pluginIndex = range conflist.Plugins {
-		mapbytes, err := json.Marshal(conflist.Plugins[pluginIndex])
-		if err != nil {
-			return netconfig, conflist, pluginIndex, err
-		}
-		err = json.Unmarshal(mapbytes, &plugin)
-
-		if plugin.Type == c.AzureCNIBin {
-			err = json.Unmarshal(mapbytes, &netconfig)
-			return netconfig, conflist, pluginIndex, err
-		}
+	// transparent-vlan mode is set by the multitenancy transparent-vlan scenario
+	if installerConf.CNIMode != c.TransparentVlan {
+		opts.Mode = installerConf.CNIMode
 	}
 
-	return netconfig, conflist, pluginIndex, fmt.Errorf("Conf in conflist not found matching %s type", c.AzureCNIBin)
-}
-
-func ModifyConflists(conflistpath string, installerConf InstallerConfig, perm os.FileMode) error {
-	netconfig, conflist, confindex, err := LoadConf(conflistpath)
-
-	// Set the newtork name (default is 'azure')
-	conflist.Name = installerConf.NetworkName
-
-	// change the netconfig from passed installerConf
-	netconfig.IPAM.Type = installerConf.IPAMType
-	netconfig.Mode = installerConf.CNIMode
-
-	// no bridge in transparent mode
-	if netconfig.Mode == c.Transparent {
-		netconfig.Bridge = ""
-	} else if netconfig.Mode == c.Bridge {
-		netconfig.Bridge = c.Azure0
-	}
-
-	// if multitenant, update multitenant configs
-	if netconfig.MultiTenancy {
-		netconfig.CNSUrl = installerConf.CNSURL
-		netconfig.EnableExactMatchForPodName = installerConf.EnableExactMatchForPodName
-	}
-
-	// set conf back in conflist
-	conflist.Plugins[confindex] = netconfig
-
-	// get target path
-	dstFile := installerConf.DstConflistDir + filepath.Base(conflistpath)
-	filebytes, err := json.MarshalIndent(conflist, "", "\t")
+	filebytes, err := conflist.Marshal(opts)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("🚛 - Installing %v...\n", dstFile)
+	// get target path
+	dstFile := installerConf.DstConflistDir + filepath.Base(conflistpath)
+	fmt.Printf("🚛 - Installing %v for scenario %s...\n", dstFile, opts.Scenario)
 	return os.WriteFile(dstFile, filebytes, perm)
 }
 
@@ -23,6 +23,7 @@ type InstallerConfig struct {
 	CNSURL                     string
 	EnableExactMatchForPodName bool
 	NetworkName                string
+	ConflistScenario           string
 }
 
 func (i *InstallerConfig) SetExempt(exempt []string) {
@@ -56,14 +57,14 @@ func (i *InstallerConfig) SetCNIType(cniType string) error {
 }
 
 func (i *InstallerConfig) SetCNIDatapathMode(cniMode string) error {
-	// check transparent or bridge mode only
+	// check transparent, transparent-vlan or bridge mode only
 	if cniMode != "" {
-		if strings.EqualFold(cniMode, c.Transparent) || strings.EqualFold(cniMode, c.Bridge) {
-			i.CNIMode = cniMode
+		if strings.EqualFold(cniMode, c.Transparent) || strings.EqualFold(cniMode, c.TransparentVlan) || strings.EqualFold(cniMode, c.Bridge) {
+			i.CNIMode = strings.ToLower(cniMode)
 			return nil
 		}
 
-		return fmt.Errorf("No CNI datapath mode supplied, please use %q or %q and try again", c.Transparent, c.Bridge)
+		return fmt.Errorf("No CNI datapath mode supplied, please use %q, %q or %q and try again", c.Transparent, c.TransparentVlan, c.Bridge)
 	}
 	return nil
 }
@@ -100,9 +101,9 @@ func InstallLocal(installerConf InstallerConfig) error {
 		return fmt.Errorf("Failed to copy CNI binaries with err: %v", err)
 	}
 
-	fmt.Printf("🚛 - Copying conflists...\n")
+	fmt.Printf("🚛 - Rendering conflists...\n")
 	for _, conf := range conflists {
-		err = ModifyConflists(conf, installerConf, c.ConflistPerm)
+		err = RenderConflist(conf, installerConf, c.ConflistPerm)
 		if err != nil {
 			return err
 		}
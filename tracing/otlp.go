@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+)
+
+const (
+	tracesPath     = "/v1/traces"
+	defaultTimeout = 10 * time.Second
+)
+
+var ErrInvalidEndpoint = errors.New("invalid OTLP endpoint")
+
+// NewOTLPExporter creates an exporter for the OTLP/HTTP endpoint of a collector, such as http://localhost:4318.
+// Spans that fail to export are dropped rather than retried, so that exporting never holds up a CNI command.
+func NewOTLPExporter(endpoint string) (*otlptrace.Exporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, errors.Wrapf(ErrInvalidEndpoint, "%q", endpoint)
+	}
+
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithURLPath(strings.TrimSuffix(u.Path, "/") + tracesPath),
+		otlptracehttp.WithTimeout(defaultTimeout),
+		otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: false}),
+	}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create OTLP trace exporter")
+	}
+	return exporter, nil
+}
@@ -0,0 +1,108 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// receiver is an in-process OTLP/HTTP traces receiver that records the spans it is sent.
+type receiver struct {
+	mu    sync.Mutex
+	spans []*tracepb.Span
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil || req.URL.Path != tracesPath || req.Header.Get("Content-Type") != "application/x-protobuf" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var data tracepb.TracesData
+	if err := proto.Unmarshal(body, &data); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rs := range data.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			r.spans = append(r.spans, ss.Spans...)
+		}
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	collector := &receiver{}
+	collectorSrv := httptest.NewServer(collector)
+	defer collectorSrv.Close()
+
+	exporter, err := NewOTLPExporter(collectorSrv.URL)
+	require.NoError(t, err)
+	shutdown := Init("test", exporter)
+
+	// the server continues the trace of the client
+	var serverTraceID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx, span := Start(Extract(req.Context(), req.Header), "server", trace.WithSpanKind(trace.SpanKindServer))
+		serverTraceID = TraceID(ctx)
+		End(span, errors.New("server failed"))
+	}))
+	defer srv.Close()
+
+	ctx, span := Start(context.Background(), "client")
+	traceID := TraceID(ctx)
+	require.NotEmpty(t, traceID)
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/path", http.NoBody)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	End(span, nil)
+
+	require.Equal(t, traceID, serverTraceID)
+	// the request of the caller is not modified
+	require.Empty(t, req.Header.Get("traceparent"))
+
+	require.NoError(t, shutdown(context.Background()))
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	require.Len(t, collector.spans, 3)
+
+	byName := map[string]*tracepb.Span{}
+	for _, s := range collector.spans {
+		byName[s.Name] = s
+	}
+	require.Contains(t, byName, "client")
+	require.Contains(t, byName, "GET /path")
+	require.Contains(t, byName, "server")
+
+	// client -> GET /path -> server
+	require.Equal(t, byName["client"].SpanId, byName["GET /path"].ParentSpanId)
+	require.Equal(t, byName["GET /path"].SpanId, byName["server"].ParentSpanId)
+	require.Equal(t, tracepb.Span_SPAN_KIND_CLIENT, byName["GET /path"].Kind)
+	require.Equal(t, tracepb.Status_STATUS_CODE_ERROR, byName["server"].Status.Code)
+}
+
+func TestTraceIDWithoutSpan(t *testing.T) {
+	require.Empty(t, TraceID(context.Background()))
+}
+
+func TestNewOTLPExporterInvalidEndpoint(t *testing.T) {
+	_, err := NewOTLPExporter("localhost:4318")
+	require.ErrorIs(t, err, ErrInvalidEndpoint)
+}
@@ -0,0 +1,171 @@
+package zapotlp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"go.uber.org/zap/zapcore"
+)
+
+var levelToSev = map[zapcore.Level]logspb.SeverityNumber{
+	zapcore.DebugLevel:  logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG,
+	zapcore.InfoLevel:   logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+	zapcore.WarnLevel:   logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+	zapcore.ErrorLevel:  logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+	zapcore.DPanicLevel: logspb.SeverityNumber_SEVERITY_NUMBER_FATAL,
+	zapcore.PanicLevel:  logspb.SeverityNumber_SEVERITY_NUMBER_FATAL,
+	zapcore.FatalLevel:  logspb.SeverityNumber_SEVERITY_NUMBER_FATAL,
+}
+
+var _ zapcore.Core = (*Core)(nil)
+
+// Core implements zapcore.Core for OpenTelemetry.
+// Core converts each entry and its fields to an OTLP log record and queues it on an Exporter, which sends
+// the records to the collector in batches. Fields become log record attributes; object fields are flattened
+// with dotted keys.
+type Core struct {
+	zapcore.LevelEnabler
+	exporter *Exporter
+	fields   []zapcore.Field
+}
+
+// NewCore creates a new OpenTelemetry zap core that queues log records on the Exporter.
+func NewCore(le zapcore.LevelEnabler, exporter *Exporter) *Core {
+	return &Core{
+		LevelEnabler: le,
+		exporter:     exporter,
+	}
+}
+
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	clone := c.clone()
+	clone.fields = append(clone.fields, fields...)
+	return clone
+}
+
+// Check implements zapcore.Core
+//
+//nolint:gocritic // ignore hugeparam in interface impl
+func (c *Core) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write implements zapcore.Core
+//
+//nolint:gocritic // ignore hugeparam in interface impl
+func (c *Core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for i := range c.fields {
+		c.fields[i].AddTo(enc)
+	}
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+
+	attrs := attributes("", enc.Fields)
+	if entry.LoggerName != "" {
+		attrs = append(attrs, stringAttr("logger", entry.LoggerName))
+	}
+	if entry.Caller.Defined {
+		attrs = append(attrs, stringAttr("caller", entry.Caller.String()))
+	}
+
+	c.exporter.Export(&logspb.LogRecord{
+		TimeUnixNano:         uint64(entry.Time.UnixNano()),
+		ObservedTimeUnixNano: uint64(time.Now().UnixNano()),
+		SeverityNumber:       levelToSev[entry.Level],
+		SeverityText:         entry.Level.CapitalString(),
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: entry.Message}},
+		Attributes:           attrs,
+	})
+	return nil
+}
+
+// Sync exports the queued log records.
+func (c *Core) Sync() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	return errors.Wrap(c.exporter.Flush(ctx), "core failed to sync")
+}
+
+// clone derives a new Core from this Core, sharing the exporter and duplicating the fields so that children and
+// parent cores may add fields independently after cloning.
+func (c *Core) clone() *Core {
+	fields := make([]zapcore.Field, len(c.fields))
+	copy(fields, c.fields)
+	return &Core{
+		LevelEnabler: c.LevelEnabler,
+		exporter:     c.exporter,
+		fields:       fields,
+	}
+}
+
+// attributes converts the fields encoded by a MapObjectEncoder to attributes, sorted by key.
+func attributes(prefix string, m map[string]interface{}) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var attrs []*commonpb.KeyValue
+	for _, k := range keys {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		switch v := m[k].(type) {
+		case map[string]interface{}:
+			attrs = append(attrs, attributes(key, v)...)
+		case string:
+			attrs = append(attrs, stringAttr(key, v))
+		case bool:
+			attrs = append(attrs, &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}})
+		case int, int8, int16, int32, int64, uint8, uint16, uint32:
+			attrs = append(attrs, &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: toInt64(v)}}})
+		case float32:
+			attrs = append(attrs, &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: float64(v)}}})
+		case float64:
+			attrs = append(attrs, &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v}}})
+		default:
+			attrs = append(attrs, stringAttr(key, fmt.Sprintf("%v", v)))
+		}
+	}
+	return attrs
+}
+
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int8:
+		return int64(n)
+	case int16:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	case uint8:
+		return int64(n)
+	case uint16:
+		return int64(n)
+	case uint32:
+		return int64(n)
+	default:
+		return 0
+	}
+}
+
+func stringAttr(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
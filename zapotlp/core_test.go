@@ -0,0 +1,210 @@
+package zapotlp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/proto"
+)
+
+// receiver is an in-process OTLP/HTTP logs receiver that records what it is sent.
+type receiver struct {
+	mu       sync.Mutex
+	resource []*commonpb.KeyValue
+	records  []*logspb.LogRecord
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil || req.URL.Path != logsPath || req.Header.Get("Content-Type") != contentType {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var data logspb.LogsData
+	if err := proto.Unmarshal(body, &data); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rl := range data.ResourceLogs {
+		r.resource = rl.Resource.Attributes
+		for _, sl := range rl.ScopeLogs {
+			r.records = append(r.records, sl.LogRecords...)
+		}
+	}
+}
+
+func attr(attrs []*commonpb.KeyValue, key string) *commonpb.AnyValue {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return nil
+}
+
+type sub struct {
+	subnet string
+	num    int
+}
+
+func (s sub) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("subnet", s.subnet)
+	enc.AddInt("num", s.num)
+	return nil
+}
+
+func TestCore(t *testing.T) {
+	r := &receiver{}
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	exporter, err := NewExporter(ExporterConfig{
+		Endpoint:      srv.URL,
+		Resource:      map[string]string{"service.name": "test"},
+		BatchInterval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	log := zap.New(NewCore(zapcore.InfoLevel, exporter)).With(zap.String("component", "test"))
+	log.Debug("dropped")
+	log.Info("hello", zap.Int("count", 3), zap.Bool("ok", true), zap.Object("sub", sub{subnet: "podnet", num: 2}))
+	log.Error("failed", zap.Error(io.EOF))
+
+	require.NoError(t, log.Sync())
+
+	r.mu.Lock()
+	require.Equal(t, "test", attr(r.resource, "service.name").GetStringValue())
+	require.Len(t, r.records, 2)
+
+	info := r.records[0]
+	require.Equal(t, "hello", info.Body.GetStringValue())
+	require.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_INFO, info.SeverityNumber)
+	require.Equal(t, "test", attr(info.Attributes, "component").GetStringValue())
+	require.Equal(t, int64(3), attr(info.Attributes, "count").GetIntValue())
+	require.True(t, attr(info.Attributes, "ok").GetBoolValue())
+	require.Equal(t, "podnet", attr(info.Attributes, "sub.subnet").GetStringValue())
+	require.Equal(t, int64(2), attr(info.Attributes, "sub.num").GetIntValue())
+
+	errRecord := r.records[1]
+	require.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, errRecord.SeverityNumber)
+	require.Equal(t, "EOF", attr(errRecord.Attributes, "error").GetStringValue())
+	r.mu.Unlock()
+
+	// records queued before close are exported
+	log.Warn("closing")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, exporter.Close(ctx))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	require.Len(t, r.records, 3)
+}
+
+func TestExporterBatchSize(t *testing.T) {
+	r := &receiver{}
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	exporter, err := NewExporter(ExporterConfig{Endpoint: srv.URL, BatchInterval: time.Hour, MaxBatchSize: 2})
+	require.NoError(t, err)
+	defer exporter.Close(context.Background()) //nolint:errcheck // test cleanup
+
+	log := zap.New(NewCore(zapcore.InfoLevel, exporter))
+	log.Info("one")
+	log.Info("two")
+
+	// the full batch is exported in the background, long before the batch interval
+	require.Eventually(t, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return len(r.records) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestExporterDoesNotBlockOnCollector(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+
+	exporter, err := NewExporter(ExporterConfig{Endpoint: srv.URL, BatchInterval: time.Hour, MaxBatchSize: 1, MaxQueueSize: 2})
+	require.NoError(t, err)
+	defer exporter.Close(context.Background()) //nolint:errcheck // test cleanup
+	defer close(release)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			exporter.Export(&logspb.LogRecord{})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("export waited on the collector")
+	}
+
+	// telemetry beyond the queue size is dropped while the collector is slow
+	exporter.mu.Lock()
+	require.LessOrEqual(t, len(exporter.records), 2)
+	exporter.mu.Unlock()
+}
+
+func TestExporterMetrics(t *testing.T) {
+	var mu sync.Mutex
+	var metrics []*metricspb.Metric
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil || req.URL.Path != metricsPath {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var data metricspb.MetricsData
+		if err := proto.Unmarshal(body, &data); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rm := range data.ResourceMetrics {
+			for _, sm := range rm.ScopeMetrics {
+				metrics = append(metrics, sm.Metrics...)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	exporter, err := NewExporter(ExporterConfig{Endpoint: srv.URL, BatchInterval: time.Hour})
+	require.NoError(t, err)
+	exporter.ExportMetric(&metricspb.Metric{Name: "testmetric"})
+	require.NoError(t, exporter.Close(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, metrics, 1)
+	require.Equal(t, "testmetric", metrics[0].Name)
+}
+
+func TestNewExporterInvalidEndpoint(t *testing.T) {
+	_, err := NewExporter(ExporterConfig{Endpoint: "localhost:4318"})
+	require.ErrorIs(t, err, ErrInvalidEndpoint)
+}
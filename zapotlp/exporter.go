@@ -0,0 +1,273 @@
+package zapotlp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	logsPath    = "/v1/logs"
+	metricsPath = "/v1/metrics"
+	contentType = "application/x-protobuf"
+	scopeName   = "github.com/Azure/azure-container-networking/zapotlp"
+
+	defaultBatchInterval = 15 * time.Second
+	defaultMaxBatchSize  = 512
+	defaultMaxQueueSize  = 8192
+	defaultTimeout       = 10 * time.Second
+)
+
+var ErrInvalidEndpoint = errors.New("invalid OTLP endpoint")
+
+// ExporterConfig is the configuration of an Exporter.
+type ExporterConfig struct {
+	// Endpoint is the OTLP/HTTP endpoint of the collector, such as http://localhost:4318.
+	Endpoint string
+	// Resource are the attributes of the resource producing the telemetry, such as service.name.
+	Resource map[string]string
+	// ScopeName and ScopeVersion are the instrumentation scope of the telemetry. ScopeName defaults to this package.
+	ScopeName    string
+	ScopeVersion string
+	// BatchInterval is how often queued telemetry is exported.
+	BatchInterval time.Duration
+	// MaxBatchSize is the number of queued log records or metrics that triggers an export before the interval.
+	MaxBatchSize int
+	// MaxQueueSize is the number of log records or metrics that may be queued while an export is in progress.
+	// Telemetry queued beyond it is dropped.
+	MaxQueueSize int
+}
+
+// Exporter batches log records and metrics and exports them to an OpenTelemetry collector over OTLP/HTTP.
+// Exports run in the background, so queueing never waits on the collector. Telemetry that fails to export, or
+// that does not fit in the queue, is dropped. Exporter is safe for concurrent use.
+type Exporter struct {
+	cfg        ExporterConfig
+	logsURL    string
+	metricsURL string
+	resource   *resourcepb.Resource
+	scope      *commonpb.InstrumentationScope
+	client     *http.Client
+
+	mu      sync.Mutex
+	records []*logspb.LogRecord
+	metrics []*metricspb.Metric
+
+	// exportMu serializes exports so that telemetry is sent in order.
+	exportMu sync.Mutex
+
+	full    chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// NewExporter creates an Exporter and starts its export loop. Close must be called to stop it.
+func NewExporter(cfg ExporterConfig) (*Exporter, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, errors.Wrapf(ErrInvalidEndpoint, "%q", cfg.Endpoint)
+	}
+	if cfg.BatchInterval <= 0 {
+		cfg.BatchInterval = defaultBatchInterval
+	}
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = defaultMaxBatchSize
+	}
+	if cfg.MaxQueueSize < cfg.MaxBatchSize {
+		cfg.MaxQueueSize = defaultMaxQueueSize
+		if cfg.MaxQueueSize < cfg.MaxBatchSize {
+			cfg.MaxQueueSize = cfg.MaxBatchSize
+		}
+	}
+	if cfg.ScopeName == "" {
+		cfg.ScopeName = scopeName
+	}
+
+	base := strings.TrimSuffix(u.String(), "/")
+	e := &Exporter{
+		cfg:        cfg,
+		logsURL:    base + logsPath,
+		metricsURL: base + metricsPath,
+		resource:   &resourcepb.Resource{Attributes: stringAttributes(cfg.Resource)},
+		scope:      &commonpb.InstrumentationScope{Name: cfg.ScopeName, Version: cfg.ScopeVersion},
+		client:     &http.Client{Timeout: defaultTimeout},
+		full:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	go e.run()
+	return e, nil
+}
+
+// Export queues a log record. A full batch is exported by the export loop.
+func (e *Exporter) Export(record *logspb.LogRecord) {
+	e.mu.Lock()
+	if len(e.records) < e.cfg.MaxQueueSize {
+		e.records = append(e.records, record)
+	}
+	full := len(e.records) >= e.cfg.MaxBatchSize
+	e.mu.Unlock()
+
+	if full {
+		e.signalFull()
+	}
+}
+
+// ExportMetric queues a metric. A full batch is exported by the export loop.
+func (e *Exporter) ExportMetric(metric *metricspb.Metric) {
+	e.mu.Lock()
+	if len(e.metrics) < e.cfg.MaxQueueSize {
+		e.metrics = append(e.metrics, metric)
+	}
+	full := len(e.metrics) >= e.cfg.MaxBatchSize
+	e.mu.Unlock()
+
+	if full {
+		e.signalFull()
+	}
+}
+
+// signalFull wakes the export loop without waiting for it.
+func (e *Exporter) signalFull() {
+	select {
+	case e.full <- struct{}{}:
+	default:
+	}
+}
+
+// Flush exports the queued log records and metrics.
+func (e *Exporter) Flush(ctx context.Context) error {
+	e.exportMu.Lock()
+	defer e.exportMu.Unlock()
+
+	e.mu.Lock()
+	records, metrics := e.records, e.metrics
+	e.records, e.metrics = nil, nil
+	e.mu.Unlock()
+
+	var errs []string
+	if len(records) > 0 {
+		// LogsData is wire compatible with the ExportLogsServiceRequest of the collector
+		data := &logspb.LogsData{
+			ResourceLogs: []*logspb.ResourceLogs{
+				{
+					Resource:  e.resource,
+					ScopeLogs: []*logspb.ScopeLogs{{Scope: e.scope, LogRecords: records}},
+				},
+			},
+		}
+		if err := e.post(ctx, e.logsURL, data); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to export %d log records", len(records)).Error())
+		}
+	}
+
+	if len(metrics) > 0 {
+		// MetricsData is wire compatible with the ExportMetricsServiceRequest of the collector
+		data := &metricspb.MetricsData{
+			ResourceMetrics: []*metricspb.ResourceMetrics{
+				{
+					Resource:     e.resource,
+					ScopeMetrics: []*metricspb.ScopeMetrics{{Scope: e.scope, Metrics: metrics}},
+				},
+			},
+		}
+		if err := e.post(ctx, e.metricsURL, data); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to export %d metrics", len(metrics)).Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (e *Exporter) post(ctx context.Context, endpoint string, msg proto.Message) error {
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal request")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close stops the export loop and exports the queued telemetry, waiting until the context is done.
+func (e *Exporter) Close(ctx context.Context) error {
+	e.once.Do(func() {
+		close(e.stop)
+	})
+
+	select {
+	case <-e.stopped:
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "exporter did not stop")
+	}
+	return e.Flush(ctx)
+}
+
+func (e *Exporter) run() {
+	defer close(e.stopped)
+
+	ticker := time.NewTicker(e.cfg.BatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.full:
+		case <-e.stop:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		_ = e.Flush(ctx)
+		cancel()
+	}
+}
+
+// stringAttributes converts the map to attributes, sorted by key.
+func stringAttributes(m map[string]string) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, &commonpb.KeyValue{
+			Key:   k,
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: m[k]}},
+		})
+	}
+	return attrs
+}
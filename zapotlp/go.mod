@@ -0,0 +1,19 @@
+module github.com/Azure/azure-container-networking/zapotlp
+
+go 1.20
+
+require (
+	github.com/pkg/errors v0.9.1
+	github.com/stretchr/testify v1.8.2
+	go.opentelemetry.io/proto/otlp v0.19.0
+	go.uber.org/zap v1.24.0
+	google.golang.org/protobuf v1.28.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.uber.org/atomic v1.7.0 // indirect
+	go.uber.org/multierr v1.6.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)